@@ -99,6 +99,8 @@ func GetAllHandlers(hoverfly *Hoverfly) []handlers.AdminHandler {
 	list = append(list, &v2.HoverflyDestinationHandler{Hoverfly: hoverfly})
 	list = append(list, &v2.HoverflyModeHandler{Hoverfly: hoverfly})
 	list = append(list, &v2.HoverflyMiddlewareHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.HoverflyMiddlewareChainHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.HoverflyMiddlewareTesterHandler{Hoverfly: hoverfly})
 	list = append(list, &v2.HoverflyUsageHandler{Hoverfly: hoverfly})
 	list = append(list, &v2.HoverflyVersionHandler{Hoverfly: hoverfly})
 	list = append(list, &v2.HoverflyUpstreamProxyHandler{Hoverfly: hoverfly})
@@ -106,6 +108,14 @@ func GetAllHandlers(hoverfly *Hoverfly) []handlers.AdminHandler {
 	list = append(list, &v2.CacheHandler{Hoverfly: hoverfly})
 	list = append(list, &v2.LogsHandler{Hoverfly: hoverfly.StoreLogsHook})
 	list = append(list, &v2.JournalHandler{Hoverfly: hoverfly.Journal})
+	list = append(list, &v2.CertificatesHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.GrpcHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.HarHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.PactHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.OpenApiHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.ImportHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.ContractHandler{Hoverfly: hoverfly})
+	list = append(list, &v2.MetricsHandler{Hoverfly: hoverfly})
 	list = append(list, &v2.ShutdownHandler{})
 
 	return list
@@ -0,0 +1,380 @@
+package certs
+
+import (
+	"bytes"
+	"container/list"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported key types for leaf certificates
+const (
+	KeyTypeRSA   = "rsa"
+	KeyTypeECDSA = "ecdsa"
+)
+
+// DefaultCacheSize - default amount of leaf certificates kept in memory
+const DefaultCacheSize = 1000
+
+// DefaultLeafValidity - default validity of generated leaf certificates
+const DefaultLeafValidity = 365 * 24 * time.Hour
+
+// IssuedCertificate - leaf certificate issued for a single host
+type IssuedCertificate struct {
+	Host         string
+	SerialNumber string
+	KeyType      string
+	NotBefore    time.Time
+	NotAfter     time.Time
+	Certificate  *tls.Certificate
+}
+
+// CertificateManager - issues leaf certificates signed by the MITM CA and keeps the
+// most recently used ones in memory so repeated handshakes to the same host are cheap
+type CertificateManager struct {
+	keyType      string
+	leafValidity time.Duration
+	cacheSize    int
+
+	ca      tls.Certificate
+	x509ca  *x509.Certificate
+	leafKey crypto.Signer
+
+	entries map[string]*list.Element
+	order   *list.List
+
+	mu sync.Mutex
+}
+
+// NewCertificateManager - returns new CertificateManager using given CA to sign leaf certificates
+func NewCertificateManager(ca tls.Certificate) *CertificateManager {
+	manager := &CertificateManager{
+		keyType:      KeyTypeRSA,
+		leafValidity: DefaultLeafValidity,
+		cacheSize:    DefaultCacheSize,
+		entries:      make(map[string]*list.Element),
+		order:        list.New(),
+	}
+
+	manager.SetCA(ca)
+
+	return manager
+}
+
+// SetCA - replaces the CA used to sign leaf certificates, previously issued
+// certificates are discarded
+func (this *CertificateManager) SetCA(ca tls.Certificate) error {
+	if len(ca.Certificate) == 0 {
+		return fmt.Errorf("CA certificate is empty")
+	}
+
+	x509ca, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		return err
+	}
+
+	if !x509ca.IsCA {
+		return fmt.Errorf("Certificate is not a CA certificate")
+	}
+
+	if _, ok := ca.PrivateKey.(crypto.Signer); !ok {
+		return fmt.Errorf("CA private key cannot be used for signing")
+	}
+
+	ca.Leaf = x509ca
+
+	this.mu.Lock()
+	this.ca = ca
+	this.x509ca = x509ca
+	this.flush()
+	this.mu.Unlock()
+
+	return nil
+}
+
+// SetCAFromPEM - parses PEM encoded certificate and key and uses them as the new CA
+func (this *CertificateManager) SetCAFromPEM(certificate, key []byte) error {
+	ca, err := tls.X509KeyPair(certificate, key)
+	if err != nil {
+		return err
+	}
+
+	return this.SetCA(ca)
+}
+
+// GetCA - returns the CA currently used to sign leaf certificates
+func (this *CertificateManager) GetCA() tls.Certificate {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	return this.ca
+}
+
+// GetCAPEM - returns PEM encoded CA certificate, ready to be distributed to clients
+func (this *CertificateManager) GetCAPEM() []byte {
+	ca := this.GetCA()
+	if len(ca.Certificate) == 0 {
+		return nil
+	}
+
+	var buffer bytes.Buffer
+	pem.Encode(&buffer, &pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate[0]})
+
+	return buffer.Bytes()
+}
+
+// SetKeyType - sets key type used for leaf certificates, either "rsa" or "ecdsa"
+func (this *CertificateManager) SetKeyType(keyType string) error {
+	keyType = strings.ToLower(keyType)
+	if keyType != KeyTypeRSA && keyType != KeyTypeECDSA {
+		return fmt.Errorf("Unknown key type `%v`, only `%v` or `%v` are supported", keyType, KeyTypeRSA, KeyTypeECDSA)
+	}
+
+	this.mu.Lock()
+	if this.keyType != keyType {
+		this.keyType = keyType
+		this.leafKey = nil
+		this.flush()
+	}
+	this.mu.Unlock()
+
+	return nil
+}
+
+// GetKeyType - returns key type used for leaf certificates
+func (this *CertificateManager) GetKeyType() string {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	return this.keyType
+}
+
+// SetLeafValidity - sets how long newly issued leaf certificates are valid for
+func (this *CertificateManager) SetLeafValidity(validity time.Duration) error {
+	if validity <= 0 {
+		return fmt.Errorf("Leaf certificate validity must be positive")
+	}
+
+	this.mu.Lock()
+	this.leafValidity = validity
+	this.flush()
+	this.mu.Unlock()
+
+	return nil
+}
+
+// SetCacheSize - sets how many leaf certificates are kept in memory, zero disables caching
+func (this *CertificateManager) SetCacheSize(size int) error {
+	if size < 0 {
+		return fmt.Errorf("Certificate cache size cannot be negative")
+	}
+
+	this.mu.Lock()
+	this.cacheSize = size
+	for this.order.Len() > size {
+		this.evictOldest()
+	}
+	this.mu.Unlock()
+
+	return nil
+}
+
+// GetCertificate - returns leaf certificate for given host, issuing a new one if
+// it is not in the cache or the cached one has expired
+func (this *CertificateManager) GetCertificate(host string) (*tls.Certificate, error) {
+	host = stripPort(host)
+
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if element, ok := this.entries[host]; ok {
+		issued := element.Value.(*IssuedCertificate)
+		if time.Now().Before(issued.NotAfter) {
+			this.order.MoveToFront(element)
+			return issued.Certificate, nil
+		}
+		this.order.Remove(element)
+		delete(this.entries, host)
+	}
+
+	issued, err := this.issue(host)
+	if err != nil {
+		return nil, err
+	}
+
+	if this.cacheSize > 0 {
+		if this.order.Len() >= this.cacheSize {
+			this.evictOldest()
+		}
+		this.entries[host] = this.order.PushFront(issued)
+	}
+
+	return issued.Certificate, nil
+}
+
+// TLSConfigForHost - returns TLS configuration which can be used to terminate TLS for given host
+func (this *CertificateManager) TLSConfigForHost(host string) (*tls.Config, error) {
+	cert, err := this.GetCertificate(host)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		Certificates:       []tls.Certificate{*cert},
+	}, nil
+}
+
+// TLSConfig - returns TLS configuration which issues certificates for the server name sent by
+// the client, falling back to given host for clients which do not send one
+func (this *CertificateManager) TLSConfig(defaultHost string) *tls.Config {
+	return &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if hello.ServerName == "" {
+				return this.GetCertificate(defaultHost)
+			}
+			return this.GetCertificate(hello.ServerName)
+		},
+	}
+}
+
+// GetIssuedCertificates - returns cached leaf certificates, most recently used first
+func (this *CertificateManager) GetIssuedCertificates() []IssuedCertificate {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	issued := []IssuedCertificate{}
+	for element := this.order.Front(); element != nil; element = element.Next() {
+		issued = append(issued, *element.Value.(*IssuedCertificate))
+	}
+
+	return issued
+}
+
+// Flush - removes all cached leaf certificates
+func (this *CertificateManager) Flush() {
+	this.mu.Lock()
+	this.flush()
+	this.mu.Unlock()
+}
+
+func (this *CertificateManager) flush() {
+	this.entries = make(map[string]*list.Element)
+	this.order.Init()
+}
+
+func (this *CertificateManager) evictOldest() {
+	oldest := this.order.Back()
+	if oldest == nil {
+		return
+	}
+	this.order.Remove(oldest)
+	delete(this.entries, oldest.Value.(*IssuedCertificate).Host)
+}
+
+// issue - generates and signs a new leaf certificate, the leaf private key is generated
+// once per key type and shared between hosts as key generation dominates the cost
+func (this *CertificateManager) issue(host string) (*IssuedCertificate, error) {
+	if this.x509ca == nil {
+		return nil, fmt.Errorf("CA certificate has not been set")
+	}
+
+	if this.leafKey == nil {
+		key, err := generateKey(this.keyType)
+		if err != nil {
+			return nil, err
+		}
+		this.leafKey = key
+	}
+
+	pkixpub, err := x509.MarshalPKIXPublicKey(this.leafKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	h := sha1.New()
+	h.Write(pkixpub)
+	keyID := h.Sum(nil)
+
+	serial, err := rand.Int(rand.Reader, MaxSerialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   host,
+			Organization: this.x509ca.Subject.Organization,
+		},
+		SubjectKeyId:          keyID,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		// backdated to allow for clock skew between Hoverfly and its clients
+		NotBefore: time.Now().Add(-1 * time.Hour),
+		NotAfter:  time.Now().Add(this.leafValidity),
+	}
+
+	if this.keyType == KeyTypeRSA {
+		tmpl.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		tmpl.IPAddresses = []net.IP{ip}
+	} else {
+		tmpl.DNSNames = []string{host}
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, tmpl, this.x509ca, this.leafKey.Public(), this.ca.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	x509c, err := x509.ParseCertificate(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IssuedCertificate{
+		Host:         host,
+		SerialNumber: fmt.Sprintf("%x", x509c.SerialNumber),
+		KeyType:      this.keyType,
+		NotBefore:    x509c.NotBefore,
+		NotAfter:     x509c.NotAfter,
+		Certificate: &tls.Certificate{
+			Certificate: [][]byte{raw, this.ca.Certificate[0]},
+			PrivateKey:  this.leafKey,
+			Leaf:        x509c,
+		},
+	}, nil
+}
+
+func generateKey(keyType string) (crypto.Signer, error) {
+	if keyType == KeyTypeECDSA {
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+
+	return rsa.GenerateKey(rand.Reader, 2048)
+}
+
+func stripPort(host string) string {
+	hostname, _, err := net.SplitHostPort(host)
+	if err != nil {
+		return host
+	}
+
+	return hostname
+}
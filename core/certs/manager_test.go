@@ -0,0 +1,213 @@
+package certs_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/certs"
+)
+
+func newTestCA(t *testing.T) tls.Certificate {
+	x509c, priv, err := certs.NewCertificatePair("hoverfly.proxy", "Hoverfly Authority", 365*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to generate certificate and key pair, got error: %s", err.Error())
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{x509c.Raw},
+		PrivateKey:  priv,
+		Leaf:        x509c,
+	}
+}
+
+func TestCertificateManager_GetCertificate_IssuesCertificateSignedByCA(t *testing.T) {
+	ca := newTestCA(t)
+	unit := certs.NewCertificateManager(ca)
+
+	tlsc, err := unit.GetCertificate("hoverfly.io:443")
+	if err != nil {
+		t.Fatalf("Failed to get certificate, got error: %s", err.Error())
+	}
+
+	if err := tlsc.Leaf.VerifyHostname("hoverfly.io"); err != nil {
+		t.Errorf("tlsc.Leaf.VerifyHostname(%q): got %v, want no error", "hoverfly.io", err)
+	}
+
+	if err := tlsc.Leaf.CheckSignatureFrom(ca.Leaf); err != nil {
+		t.Errorf("tlsc.Leaf.CheckSignatureFrom(ca): got %v, want no error", err)
+	}
+
+	if _, ok := tlsc.PrivateKey.(*rsa.PrivateKey); !ok {
+		t.Errorf("tlsc.PrivateKey: got %T, want *rsa.PrivateKey", tlsc.PrivateKey)
+	}
+}
+
+func TestCertificateManager_GetCertificate_IssuesCertificateForIPAddress(t *testing.T) {
+	unit := certs.NewCertificateManager(newTestCA(t))
+
+	tlsc, err := unit.GetCertificate("127.0.0.1:8500")
+	if err != nil {
+		t.Fatalf("Failed to get certificate, got error: %s", err.Error())
+	}
+
+	if got := len(tlsc.Leaf.IPAddresses); got != 1 {
+		t.Fatalf("len(tlsc.Leaf.IPAddresses): got %d, want 1", got)
+	}
+
+	if got := tlsc.Leaf.IPAddresses[0].String(); got != "127.0.0.1" {
+		t.Errorf("tlsc.Leaf.IPAddresses[0]: got %v, want 127.0.0.1", got)
+	}
+}
+
+func TestCertificateManager_GetCertificate_ReturnsCachedCertificate(t *testing.T) {
+	unit := certs.NewCertificateManager(newTestCA(t))
+
+	first, _ := unit.GetCertificate("hoverfly.io")
+	second, _ := unit.GetCertificate("hoverfly.io:443")
+
+	if first != second {
+		t.Error("expected second certificate for the same host to come from the cache")
+	}
+
+	if got := len(unit.GetIssuedCertificates()); got != 1 {
+		t.Errorf("len(GetIssuedCertificates()): got %d, want 1", got)
+	}
+}
+
+func TestCertificateManager_GetCertificate_EvictsLeastRecentlyUsed(t *testing.T) {
+	unit := certs.NewCertificateManager(newTestCA(t))
+	unit.SetCacheSize(2)
+
+	unit.GetCertificate("one.io")
+	unit.GetCertificate("two.io")
+	unit.GetCertificate("one.io")
+	unit.GetCertificate("three.io")
+
+	issued := unit.GetIssuedCertificates()
+	if got := len(issued); got != 2 {
+		t.Fatalf("len(GetIssuedCertificates()): got %d, want 2", got)
+	}
+
+	if issued[0].Host != "three.io" || issued[1].Host != "one.io" {
+		t.Errorf("GetIssuedCertificates(): got [%v, %v], want [three.io, one.io]", issued[0].Host, issued[1].Host)
+	}
+}
+
+func TestCertificateManager_SetCacheSize_ZeroDisablesCaching(t *testing.T) {
+	unit := certs.NewCertificateManager(newTestCA(t))
+	unit.SetCacheSize(0)
+
+	unit.GetCertificate("hoverfly.io")
+
+	if got := len(unit.GetIssuedCertificates()); got != 0 {
+		t.Errorf("len(GetIssuedCertificates()): got %d, want 0", got)
+	}
+}
+
+func TestCertificateManager_SetKeyType_IssuesECDSACertificates(t *testing.T) {
+	unit := certs.NewCertificateManager(newTestCA(t))
+
+	err := unit.SetKeyType("ECDSA")
+	if err != nil {
+		t.Fatalf("Failed to set key type, got error: %s", err.Error())
+	}
+
+	tlsc, err := unit.GetCertificate("hoverfly.io")
+	if err != nil {
+		t.Fatalf("Failed to get certificate, got error: %s", err.Error())
+	}
+
+	if _, ok := tlsc.PrivateKey.(*ecdsa.PrivateKey); !ok {
+		t.Errorf("tlsc.PrivateKey: got %T, want *ecdsa.PrivateKey", tlsc.PrivateKey)
+	}
+
+	if got := unit.GetIssuedCertificates()[0].KeyType; got != "ecdsa" {
+		t.Errorf("KeyType: got %v, want ecdsa", got)
+	}
+}
+
+func TestCertificateManager_SetKeyType_RejectsUnknownKeyType(t *testing.T) {
+	unit := certs.NewCertificateManager(newTestCA(t))
+
+	if err := unit.SetKeyType("dsa"); err == nil {
+		t.Error("SetKeyType(dsa): got no error, want error")
+	}
+
+	if got := unit.GetKeyType(); got != "rsa" {
+		t.Errorf("GetKeyType(): got %v, want rsa", got)
+	}
+}
+
+func TestCertificateManager_SetLeafValidity_IsUsedForNewCertificates(t *testing.T) {
+	unit := certs.NewCertificateManager(newTestCA(t))
+	unit.SetLeafValidity(48 * time.Hour)
+
+	tlsc, _ := unit.GetCertificate("hoverfly.io")
+
+	validUntil := time.Now().Add(48 * time.Hour)
+	if tlsc.Leaf.NotAfter.After(validUntil) || tlsc.Leaf.NotAfter.Before(validUntil.Add(-time.Minute)) {
+		t.Errorf("tlsc.Leaf.NotAfter: got %v, want around %v", tlsc.Leaf.NotAfter, validUntil)
+	}
+}
+
+func TestCertificateManager_SetCA_RotatesCAAndFlushesCache(t *testing.T) {
+	unit := certs.NewCertificateManager(newTestCA(t))
+	unit.GetCertificate("hoverfly.io")
+
+	newCA := newTestCA(t)
+	err := unit.SetCA(newCA)
+	if err != nil {
+		t.Fatalf("Failed to set CA, got error: %s", err.Error())
+	}
+
+	if got := len(unit.GetIssuedCertificates()); got != 0 {
+		t.Errorf("len(GetIssuedCertificates()): got %d, want 0", got)
+	}
+
+	tlsc, _ := unit.GetCertificate("hoverfly.io")
+	if err := tlsc.Leaf.CheckSignatureFrom(newCA.Leaf); err != nil {
+		t.Errorf("tlsc.Leaf.CheckSignatureFrom(newCA): got %v, want no error", err)
+	}
+}
+
+func TestCertificateManager_SetCA_RejectsNonCACertificate(t *testing.T) {
+	unit := certs.NewCertificateManager(newTestCA(t))
+
+	leaf, _ := unit.GetCertificate("hoverfly.io")
+
+	if err := unit.SetCA(*leaf); err == nil {
+		t.Error("SetCA(leaf): got no error, want error")
+	}
+}
+
+func TestCertificateManager_GetCAPEM_ReturnsEncodedCA(t *testing.T) {
+	ca := newTestCA(t)
+	unit := certs.NewCertificateManager(ca)
+
+	block, _ := pem.Decode(unit.GetCAPEM())
+	if block == nil {
+		t.Fatal("pem.Decode: got nil, want certificate block")
+	}
+
+	x509c, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate, got error: %s", err.Error())
+	}
+
+	if !x509c.Equal(ca.Leaf) {
+		t.Error("expected PEM to contain the CA certificate")
+	}
+}
+
+func TestCertificateManager_SetCAFromPEM_RejectsInvalidPEM(t *testing.T) {
+	unit := certs.NewCertificateManager(newTestCA(t))
+
+	if err := unit.SetCAFromPEM([]byte("not a cert"), []byte("not a key")); err == nil {
+		t.Error("SetCAFromPEM: got no error, want error")
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	hv "github.com/SpectoLabs/hoverfly/core"
+	"github.com/fsnotify/fsnotify"
+)
+
+// configFileSettleTime - how long to wait after the configuration file changes before reloading
+// it, as saving a file can change it more than once
+const configFileSettleTime = 100 * time.Millisecond
+
+// givenFlags - the names of the flags given on the command line
+func givenFlags() map[string]bool {
+	given := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	return given
+}
+
+// withoutFlagSettings - the configuration file without the settings flags were given for, as
+// they take precedence over it
+func withoutFlagSettings(configFile hv.ConfigFile) hv.ConfigFile {
+	given := givenFlags()
+
+	if given["pp"] {
+		configFile.Listeners.Proxy.Port = ""
+	}
+	if given["ap"] {
+		configFile.Listeners.Admin.Port = ""
+	}
+	if given["https-only"] {
+		configFile.Listeners.Proxy.HttpsOnly = nil
+	}
+	if given["proxy-auth"] {
+		configFile.Listeners.Proxy.Authorization = ""
+	}
+	if given["capture"] || given["synthesize"] || given["modify"] || given["webserver"] {
+		configFile.Mode = ""
+	}
+	if given["webserver"] {
+		configFile.Webserver = nil
+	}
+	if given["dest"] || given["destination"] {
+		configFile.Destinations = nil
+	}
+	if given["upstream-proxy"] {
+		configFile.UpstreamProxy = ""
+	}
+	if given["tls-verification"] {
+		configFile.TLSVerification = nil
+	}
+	if given["v"] {
+		configFile.Verbose = nil
+	}
+	if given["db-path"] {
+		configFile.DatabasePath = ""
+	}
+	if given["disable-cache"] {
+		configFile.DisableCache = nil
+	}
+	if given["simulation-dir"] {
+		configFile.SimulationDirectory = ""
+	}
+	if given["capture-body-file-size"] {
+		configFile.BodyFileCaptureSize = nil
+	}
+	if given["auth"] {
+		configFile.Auth.Enabled = nil
+	}
+	if given["middleware"] {
+		configFile.Middleware = nil
+	}
+	if given["import"] {
+		configFile.Imports = nil
+	}
+
+	return configFile
+}
+
+// watchConfigFile - reloads the configuration file when it changes or Hoverfly is sent SIGHUP,
+// applying the settings which can be changed while Hoverfly is running
+func watchConfigFile(hoverfly *hv.Hoverfly, path string, loaded *hv.ConfigFile) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	var events chan fsnotify.Event
+	var errors chan error
+
+	// editors often replace the file rather than writing to it, so its directory is watched
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		err = watcher.Add(filepath.Dir(path))
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Warn("Failed to watch the configuration file, send Hoverfly SIGHUP to reload it")
+	} else {
+		defer watcher.Close()
+		events, errors = watcher.Events, watcher.Errors
+	}
+
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-hangup:
+		case <-settled:
+			settled = nil
+		case event := <-events:
+			if filepath.Clean(event.Name) == filepath.Clean(path) && event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+				settled = time.After(configFileSettleTime)
+			}
+			continue
+		case err := <-errors:
+			log.WithFields(log.Fields{
+				"error": err.Error(),
+			}).Warn("Failed to watch the configuration file")
+			continue
+		}
+
+		configFile, err := hv.LoadConfigFile(path)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err.Error(),
+			}).Error("Failed to reload configuration file, keeping the current settings")
+			continue
+		}
+		*configFile = withoutFlagSettings(*configFile)
+
+		log.WithFields(log.Fields{
+			"config": path,
+		}).Info("Configuration file has been reloaded")
+
+		hoverfly.ReloadConfigFile(loaded, configFile)
+		loaded = configFile
+	}
+}
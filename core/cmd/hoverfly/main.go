@@ -24,20 +24,25 @@ import (
 	"crypto/tls"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
-	"github.com/SpectoLabs/goproxy"
 	hv "github.com/SpectoLabs/hoverfly/core"
 	"github.com/SpectoLabs/hoverfly/core/authentication/backends"
 	"github.com/SpectoLabs/hoverfly/core/cache"
 	hvc "github.com/SpectoLabs/hoverfly/core/certs"
 	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/journal"
 	"github.com/SpectoLabs/hoverfly/core/matching"
+	hvm "github.com/SpectoLabs/hoverfly/core/metrics"
 	mw "github.com/SpectoLabs/hoverfly/core/middleware"
 	"github.com/SpectoLabs/hoverfly/core/modes"
+	"github.com/SpectoLabs/hoverfly/core/tracing"
+	"github.com/boltdb/bolt"
 )
 
 type arrayFlags []string
@@ -53,17 +58,19 @@ func (i *arrayFlags) Set(value string) error {
 
 var importFlags arrayFlags
 var destinationFlags arrayFlags
+var middlewareHeaderFlags arrayFlags
 
 const boltBackend = "boltdb"
 const inmemoryBackend = "memory"
 
 var (
 	version     = flag.Bool("version", false, "get the version of hoverfly")
+	configPath  = flag.String("config", "", "YAML or JSON configuration file to read settings from, which environment variables and flags take precedence over - it is reloaded when it changes or Hoverfly is sent SIGHUP")
 	verbose     = flag.Bool("v", false, "should every proxy request be logged to stdout")
 	capture     = flag.Bool("capture", false, "start Hoverfly in capture mode - transparently intercepts and saves requests/response")
 	synthesize  = flag.Bool("synthesize", false, "start Hoverfly in synthesize mode (middleware is required)")
 	modify      = flag.Bool("modify", false, "start Hoverfly in modify mode - applies middleware (required) to both outgoing and incomming HTTP traffic")
-	middleware  = flag.String("middleware", "", "should proxy use middleware")
+	middleware  = flag.String("middleware", "", "should proxy use middleware - a binary, a binary and script (i.e. \"python middleware.py\"), a .lua script to run inside Hoverfly, or a URL to remote middleware, served over gRPC when its scheme is grpc or grpcs")
 	proxyPort   = flag.String("pp", "", "proxy port - run proxy on another port (i.e. '-pp 9999' to run proxy on port 9999)")
 	adminPort   = flag.String("ap", "", "admin port - run admin interface on another port (i.e. '-ap 1234' to run admin UI on port 1234)")
 	metrics     = flag.Bool("metrics", false, "supply -metrics flag to enable metrics logging to stdout")
@@ -86,6 +93,10 @@ var (
 	cert       = flag.String("cert", "", "CA certificate used to sign MITM certificates")
 	key        = flag.String("key", "", "private key of the CA used to sign MITM certificates")
 
+	certCacheSize = flag.Int("cert-cache-size", hvc.DefaultCacheSize, "Set the amount of MITM certificates to be kept in memory (default \"1000\")")
+	certKeyType   = flag.String("cert-key-type", hvc.KeyTypeRSA, "Key type used for MITM certificates, options are \"rsa\" and \"ecdsa\" (default \"rsa\")")
+	certValidity  = flag.Duration("cert-validity", hvc.DefaultLeafValidity, "Validity of MITM certificates (default \"8760h\")")
+
 	tlsVerification = flag.Bool("tls-verification", true, "turn on/off tls verification for outgoing requests (will not try to verify certificates) - defaults to true")
 
 	upstreamProxy = flag.String("upstream-proxy", "", "specify an upstream proxy for hoverfly to route traffic through")
@@ -95,10 +106,26 @@ var (
 	database     = flag.String("db", inmemoryBackend, "Persistance storage to use - 'boltdb' or 'memory' which will not write anything to disk")
 	disableCache = flag.Bool("disable-cache", false, "Disable the cache that sits infront of matching")
 
+	simulationDir       = flag.String("simulation-dir", "", "Directory the bodyFile of each response is read from, and captured bodies are written to (defaults to the working directory)")
+	captureBodyFileSize = flag.Int("capture-body-file-size", 0, "Write captured response bodies larger than this many bytes to files in the bodies folder of -simulation-dir, referenced by bodyFile, 0 keeps every body in the simulation")
+
 	logsFormat = flag.String("logs", "plaintext", "Specify format for logs, options are \"plaintext\" and \"json\" (default \"plaintext\")")
 	logsSize   = flag.Int("logs-size", 1000, "Set the amount of logs to be stored in memory (default \"1000\")")
 
-	journalSize = flag.Int("journal-size", 1000, "Set the size of request/response journal (default \"1000\")")
+	journalSize      = flag.Int("journal-size", 1000, "Set the size of request/response journal, 0 disables it and -1 removes the limit (default \"1000\")")
+	journalStore     = flag.String("journal-store", inmemoryBackend, "Where to keep the journal - 'boltdb' to keep it in the database at -db-path across restarts, or 'memory'")
+	metricsLabels    = flag.String("metrics-labels", "", "Comma separated labels to add to the metrics at /metrics - 'destination' and 'pair' are left out by default as they add a series for each destination or request response pair")
+	metricsMaxSeries = flag.Int("metrics-max-series", hvm.DefaultMaxSeries, "Limit the number of series of each metric at /metrics, counting further destinations and pairs as 'other', 0 removes the limit")
+	tracingEndpoint  = flag.String("tracing-endpoint", "", "Export a trace span for each request to this OTLP/HTTP collector endpoint (i.e. '-tracing-endpoint http://localhost:4318/v1/traces')")
+	tracingService   = flag.String("tracing-service-name", "hoverfly", "Service name to export trace spans under")
+	persistState     = flag.Bool("persist-state", false, "Save the simulation, mode, destination and middleware to the database at -db-path whenever they change, and restore them on startup")
+	journalRetention = flag.Duration("journal-retention", 0, "Remove journal entries older than this (i.e. '-journal-retention 24h'), by default entries are kept until the journal is full")
+
+	middlewarePersistent = flag.Bool("middleware-persistent", false, "Start the middleware once and keep it running, streaming newline delimited JSON to it rather than starting it for each request, or stream pairs to remote middleware served over gRPC")
+	middlewareTimeout    = flag.Duration("middleware-timeout", mw.DefaultTimeout, "How long middleware has to respond before the request fails (i.e. '-middleware-timeout 500ms')")
+	middlewareCACert     = flag.String("middleware-ca-cert", "", "PEM encoded CA certificate to verify remote middleware served over TLS with")
+	middlewareClientCert = flag.String("middleware-client-cert", "", "PEM encoded client certificate to present to remote middleware served over TLS")
+	middlewareClientKey  = flag.String("middleware-client-key", "", "PEM encoded key of the -middleware-client-cert")
 )
 
 var CA_CERT = []byte(`-----BEGIN CERTIFICATE-----
@@ -151,6 +178,8 @@ AGCtSd0PcMk6IUmaG5mGWHJRb2mvi92Rhx1JUFfdc07FbHnNQBZfCj/SP26XROqp
 nIoRtbUjdBkzPrwfSh22POoCdDUKlRUcwR0Wq7lrpQchSU1Xtz6Jkw==
 -----END RSA PRIVATE KEY-----`)
 
+var defaultCA tls.Certificate
+
 func init() {
 	// overriding default goproxy certificate
 	tlsc, err := tls.X509KeyPair(CA_CERT, CA_KEY)
@@ -159,15 +188,16 @@ func init() {
 			"error": err.Error(),
 		}).Fatal("Failed to load certifiate and key pair")
 	}
-	goproxy.GoproxyCa = tlsc
+	defaultCA = tlsc
 }
 
 func main() {
 	hoverfly := hv.NewHoverfly()
 
 	// log.SetFormatter(&log.JSONFormatter{})
-	flag.Var(&importFlags, "import", "import from file or from URL (i.e. '-import my_service.json' or '-import http://mypage.com/service_x.json'")
+	flag.Var(&importFlags, "import", "import from file or from URL (i.e. '-import my_service.json' or '-import http://mypage.com/service_x.json'), simulations, OpenAPI documents, Postman collections or WireMock mappings")
 	flag.Var(&destinationFlags, "dest", "specify which hosts to process (i.e. '-dest fooservice.org -dest barservice.org -dest catservice.org') - other hosts will be ignored will passthrough'")
+	flag.Var(&middlewareHeaderFlags, "middleware-header", "header to send to remote middleware with every request, as gRPC metadata when it is served over gRPC (i.e. '-middleware-header \"Authorization: Bearer token\"')")
 	flag.Parse()
 	if *logsFormat == "json" {
 		log.SetFormatter(&log.JSONFormatter{})
@@ -187,20 +217,47 @@ func main() {
 	hoverfly.StoreLogsHook.LogsLimit = *logsSize
 	hoverfly.Journal.EntryLimit = *journalSize
 
-	// getting settings
-	cfg := hv.InitSettings()
+	// getting settings, from the defaults, the configuration file and then environment variables
+	configFile := &hv.ConfigFile{}
+	if *configPath != "" {
+		var err error
+		configFile, err = hv.LoadConfigFile(*configPath)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err.Error(),
+			}).Fatal("Failed to load configuration file")
+		}
+
+		log.WithFields(log.Fields{
+			"config": *configPath,
+		}).Info("Configuration file has been loaded")
+	}
+	*configFile = withoutFlagSettings(*configFile)
+
+	cfg, err := hv.InitSettingsWithConfigFile(configFile)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Fatal("Failed to apply configuration file")
+	}
+
+	given := givenFlags()
 
-	if *verbose {
+	if given["v"] {
+		cfg.Verbose = *verbose
+	}
+	if cfg.Verbose {
 		// Only log the warning severity or above.
 		log.SetLevel(log.DebugLevel)
 		log.Info("Log level set to verbose")
 	}
-	cfg.Verbose = *verbose
 
 	if *dev {
 		handlers.EnableCors = true
 	}
 
+	ca := defaultCA
+
 	if *generateCA {
 		tlsc, err := hvc.GenerateAndSave(*certName, *certOrg, 5*365*24*time.Hour)
 		if err != nil {
@@ -208,7 +265,7 @@ func main() {
 				"error": err.Error(),
 			}).Fatal("Failed to generate certificate")
 		}
-		goproxy.GoproxyCa = *tlsc
+		ca = *tlsc
 
 	} else if *cert != "" && *key != "" {
 		tlsc, err := tls.LoadX509KeyPair(*cert, *key)
@@ -218,7 +275,7 @@ func main() {
 			}).Fatal("Failed to load certifiate and key pair")
 		}
 
-		goproxy.GoproxyCa = tlsc
+		ca = tlsc
 
 		log.WithFields(log.Fields{
 			"certificate": *cert,
@@ -226,6 +283,30 @@ func main() {
 		}).Info("Default keys have been overwritten")
 	}
 
+	if err := hoverfly.CertificateManager.SetCA(ca); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Fatal("Failed to set CA certificate")
+	}
+
+	if err := hoverfly.CertificateManager.SetKeyType(*certKeyType); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Fatal("Failed to set certificate key type")
+	}
+
+	if err := hoverfly.CertificateManager.SetLeafValidity(*certValidity); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Fatal("Failed to set certificate validity")
+	}
+
+	if err := hoverfly.CertificateManager.SetCacheSize(*certCacheSize); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Fatal("Failed to set certificate cache size")
+	}
+
 	// overriding environment variables (proxy and admin ports)
 	if *proxyPort != "" {
 		cfg.ProxyPort = *proxyPort
@@ -251,14 +332,35 @@ func main() {
 		}).Info("Upstream proxy has been set")
 	}
 
-	cfg.HttpsOnly = *httpsOnly
+	if given["https-only"] {
+		cfg.HttpsOnly = *httpsOnly
+	}
 
 	// overriding default middleware setting
-	newMiddleware, err := mw.ConvertToNewMiddleware(*middleware)
-	if err != nil {
-		log.Error(err.Error())
+	if given["middleware"] {
+		remoteOptions, err := getMiddlewareRemoteOptions()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err.Error(),
+			}).Fatal("Failed to read the remote middleware options")
+		}
+
+		newMiddleware, err := mw.ConvertToNewMiddlewareWithRemoteOptions(*middleware, remoteOptions)
+		if err != nil {
+			log.Error(err.Error())
+		}
+		cfg.Middleware = *newMiddleware
+	}
+	if given["middleware-timeout"] {
+		cfg.Middleware.Timeout = *middlewareTimeout
+	}
+	if *middlewarePersistent {
+		cfg.Middleware.SetPersistent(true, cfg.Middleware.Timeout)
+	}
+
+	if given["webserver"] {
+		cfg.Webserver = *webserver
 	}
-	cfg.Middleware = *newMiddleware
 
 	mode := getInitialMode(cfg)
 
@@ -270,17 +372,18 @@ func main() {
 		cfg.AuthEnabled = true
 	}
 
-	// disabling tls verification if flag or env variable is set to 'false' (defaults to true)
-	if !cfg.TLSVerification || !*tlsVerification {
-		cfg.TLSVerification = false
-
+	// disabling tls verification if flag, env variable or configuration file is set to 'false' (defaults to true)
+	if given["tls-verification"] {
+		cfg.TLSVerification = *tlsVerification
+	}
+	if !cfg.TLSVerification {
 		log.Info("TLS certificate verification has been disabled")
 	}
 
 	if len(destinationFlags) > 0 {
 		cfg.Destination = strings.Join(destinationFlags[:], "|")
 
-	} else {
+	} else if given["destination"] || cfg.Destination == "" {
 		//  setting destination regexp
 		cfg.Destination = *destination
 	}
@@ -294,9 +397,14 @@ func main() {
 		cfg.DatabasePath = *databasePath
 	}
 
-	if *database == boltBackend {
-		db := cache.GetDB(cfg.DatabasePath)
+	// the journal can be kept in the database even when the cache is kept in memory
+	var db *bolt.DB
+	if *database == boltBackend || *journalStore == boltBackend || *persistState {
+		db = cache.GetDB(cfg.DatabasePath)
 		defer db.Close()
+	}
+
+	if *database == boltBackend {
 		requestCache = cache.NewBoltDBCache(db, []byte("requestsBucket"))
 		metadataCache = cache.NewBoltDBCache(db, []byte("metadataBucket"))
 		tokenCache = cache.NewBoltDBCache(db, []byte(backends.TokenBucketName))
@@ -315,14 +423,35 @@ func main() {
 			"database": *database,
 		}).Fatalf("Unknown database type")
 	}
-	cfg.DisableCache = *disableCache
+
+	if *journalStore == boltBackend {
+		hoverfly.Journal.Store = journal.NewBoltDBJournalStore(db, []byte(journal.JournalBucketName))
+
+		log.Info("Keeping the journal in boltdb")
+	} else if *journalStore != inmemoryBackend {
+		log.WithFields(log.Fields{
+			"journalStore": *journalStore,
+		}).Fatalf("Unknown journal store")
+	}
+	hoverfly.Journal.Retention = *journalRetention
+
+	if given["simulation-dir"] {
+		cfg.SimulationDirectory = *simulationDir
+	}
+	if given["capture-body-file-size"] {
+		cfg.BodyFileCaptureSize = *captureBodyFileSize
+	}
+
+	if given["disable-cache"] {
+		cfg.DisableCache = *disableCache
+	}
 	if cfg.DisableCache {
 		requestCache = nil
 
 		log.Info("Request cache has been disabled")
 	}
 
-	if *proxyAuthorizationHeader == "header-auth" {
+	if *proxyAuthorizationHeader == hv.HeaderAuthorization || configFile.Listeners.Proxy.Authorization == hv.HeaderAuthorization {
 		log.Warnf("Proxy authentication will use `X-HOVERFLY-AUTHORIZATION` instead of `Proxy-Authorization`")
 		cfg.ProxyAuthorizationHeader = "X-HOVERFLY-AUTHORIZATION"
 		log.Warnf("Setting Hoverfly to only proxy HTTPS requests")
@@ -360,6 +489,11 @@ func main() {
 		}
 		cfg.AuthEnabled = true
 	}
+	if err := hoverfly.AddUsers(configFile.Users); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Fatal("Failed to add users from the configuration file")
+	}
 	if cfg.AuthEnabled {
 		if os.Getenv(hv.HoverflyAdminUsernameEV) != "" && os.Getenv(hv.HoverflyAdminPasswordEV) != "" {
 			hoverfly.Authentication.AddUser(
@@ -380,9 +514,36 @@ func main() {
 		}
 	}
 
+	if len(configFile.MiddlewareChain) > 0 {
+		if err := hoverfly.SetMiddlewareChain(v2.MiddlewareChainView{Middlewares: configFile.MiddlewareChain}); err != nil {
+			log.WithFields(log.Fields{
+				"error": err.Error(),
+			}).Fatal("Failed to set the middleware chain from the configuration file")
+		}
+	}
+
+	// a restored state already holds whatever was imported when it was first saved
+	restored := false
+	if *persistState {
+		hoverfly.StateCache = cache.NewBoltDBCache(db, []byte(hv.StateBucketName))
+
+		flagsDestination, flagsMiddleware, flagsMode := cfg.Destination, cfg.Middleware, cfg.GetMode()
+		restored, err = hoverfly.RestoreState()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err.Error(),
+			}).Fatal("Failed to restore state")
+		}
+
+		if restored {
+			log.Info("State has been restored, skipping imports")
+			overrideRestoredState(hoverfly, flagsDestination, flagsMiddleware, flagsMode)
+		}
+	}
+
 	// importing records if environment variable is set
 	ev := os.Getenv(hv.HoverflyImportRecordsEV)
-	if ev != "" {
+	if ev != "" && !restored {
 		err := hoverfly.Import(ev)
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -394,9 +555,10 @@ func main() {
 		}
 	}
 
-	// importing stuff
-	if len(importFlags) > 0 {
-		for i, v := range importFlags {
+	// importing stuff, from the flags or otherwise the configuration file
+	imports := append(configFile.Imports, importFlags...)
+	if len(imports) > 0 && !restored {
+		for i, v := range imports {
 			if v != "" {
 				log.WithFields(log.Fields{
 					"import": v,
@@ -414,13 +576,34 @@ func main() {
 		}
 	}
 
+	var labels []string
+	if *metricsLabels != "" {
+		labels = strings.Split(*metricsLabels, ",")
+	}
+
+	for i, label := range labels {
+		labels[i] = strings.TrimSpace(label)
+		if labels[i] != "destination" && labels[i] != "pair" {
+			log.WithFields(log.Fields{
+				"label": label,
+			}).Fatal("Unknown metrics label, only 'destination' and 'pair' can be added")
+		}
+	}
+	hoverfly.Metrics = hvm.NewRequestMetrics(labels, *metricsMaxSeries)
+
+	if *tracingEndpoint != "" {
+		hoverfly.Tracer = tracing.NewTracer(tracing.NewOTLPExporter(*tracingEndpoint, *tracingService))
+
+		log.WithFields(log.Fields{
+			"endpoint": *tracingEndpoint,
+		}).Info("Exporting trace spans")
+	}
+
 	// start metrics registry flush
 	if *metrics {
 		hoverfly.Counter.Init()
 	}
 
-	cfg.Webserver = *webserver
-
 	err = hoverfly.StartProxy()
 	if err != nil {
 		log.WithFields(log.Fields{
@@ -428,6 +611,10 @@ func main() {
 		}).Fatal("Failed to start proxy")
 	}
 
+	if *configPath != "" {
+		go watchConfigFile(hoverfly, *configPath, configFile)
+	}
+
 	// starting admin interface, this is blocking
 	adminApi := hv.AdminApi{}
 	adminApi.StartAdminInterface(hoverfly)
@@ -472,8 +659,71 @@ func createSuperUser(h *hv.Hoverfly) {
 	}
 }
 
+// getMiddlewareRemoteOptions - the options to connect to remote middleware with, from the
+// -middleware-header flags and the certificate files
+func getMiddlewareRemoteOptions() (mw.RemoteOptions, error) {
+	options := mw.RemoteOptions{}
+
+	for _, header := range middlewareHeaderFlags {
+		nameAndValue := strings.SplitN(header, ":", 2)
+		if len(nameAndValue) != 2 {
+			return options, fmt.Errorf("Middleware header %s is not in the form 'Name: value'", header)
+		}
+
+		if options.Headers == nil {
+			options.Headers = map[string]string{}
+		}
+		options.Headers[strings.TrimSpace(nameAndValue[0])] = strings.TrimSpace(nameAndValue[1])
+	}
+
+	for _, file := range []struct {
+		path  string
+		value *string
+	}{
+		{*middlewareCACert, &options.CACert},
+		{*middlewareClientCert, &options.ClientCert},
+		{*middlewareClientKey, &options.ClientKey},
+	} {
+		if file.path == "" {
+			continue
+		}
+
+		contents, err := ioutil.ReadFile(file.path)
+		if err != nil {
+			return options, err
+		}
+		*file.value = string(contents)
+	}
+
+	return options, nil
+}
+
+// overrideRestoredState - flags given on the command line take precedence over the restored state
+func overrideRestoredState(hoverfly *hv.Hoverfly, destination string, middleware mw.Middleware, mode string) {
+	given := givenFlags()
+
+	if given["dest"] || given["destination"] {
+		hoverfly.Cfg.Destination = destination
+	}
+
+	if given["middleware"] {
+		hoverfly.Cfg.Middleware.Stop()
+		hoverfly.Cfg.Middleware = middleware
+	}
+
+	if given["capture"] || given["synthesize"] || given["modify"] || given["webserver"] {
+		if err := hoverfly.SetMode(mode); err != nil {
+			log.WithFields(log.Fields{
+				"error": err.Error(),
+			}).Fatal("Failed to set mode")
+		}
+	}
+
+	hoverfly.SaveState()
+}
+
 func getInitialMode(cfg *hv.Configuration) string {
-	if *webserver {
+	if cfg.Webserver {
 		return modes.Simulate
 	}
 
@@ -509,5 +759,5 @@ func getInitialMode(cfg *hv.Configuration) string {
 		return modes.Modify
 	}
 
-	return modes.Simulate
+	return cfg.Mode
 }
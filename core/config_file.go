@@ -0,0 +1,468 @@
+package hoverfly
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/modes"
+	"gopkg.in/yaml.v2"
+)
+
+// Proxy authorization settings, which are where clients give their credentials to the proxy
+const (
+	ProxyAuthorization  = "proxy-auth"
+	HeaderAuthorization = "header-auth"
+)
+
+// ConfigFile - the settings in a YAML or JSON configuration file. Settings in the file take
+// precedence over the defaults, and environment variables and flags given on the command line
+// take precedence over the file. Settings which are not in the file are left as they are
+type ConfigFile struct {
+	Mode         string   `json:"mode,omitempty"`
+	Webserver    *bool    `json:"webserver,omitempty"`
+	Destinations []string `json:"destinations,omitempty"`
+
+	UpstreamProxy   string `json:"upstreamProxy,omitempty"`
+	TLSVerification *bool  `json:"tlsVerification,omitempty"`
+
+	Verbose      *bool  `json:"verbose,omitempty"`
+	DatabasePath string `json:"databasePath,omitempty"`
+	DisableCache *bool  `json:"disableCache,omitempty"`
+
+	SimulationDirectory string `json:"simulationDirectory,omitempty"`
+	BodyFileCaptureSize *int   `json:"bodyFileCaptureSize,omitempty"`
+
+	Listeners ConfigFileListeners `json:"listeners,omitempty"`
+	Auth      ConfigFileAuth      `json:"auth,omitempty"`
+	Users     []ConfigFileUser    `json:"users,omitempty"`
+
+	Middleware      *v2.MiddlewareView         `json:"middleware,omitempty"`
+	MiddlewareChain []v2.ChainedMiddlewareView `json:"middlewareChain,omitempty"`
+
+	Imports []string `json:"imports,omitempty"`
+}
+
+// ConfigFileListeners - the ports Hoverfly listens on, and how the proxy is reached
+type ConfigFileListeners struct {
+	Proxy ConfigFileProxyListener `json:"proxy,omitempty"`
+	Admin ConfigFileAdminListener `json:"admin,omitempty"`
+}
+
+// ConfigFileProxyListener - the port of the proxy, whether it only proxies HTTPS requests and
+// whether clients authorize with the proxy-auth Proxy-Authorization header or the header-auth
+// X-HOVERFLY-AUTHORIZATION header, which only proxies HTTPS requests as well
+type ConfigFileProxyListener struct {
+	Port          ConfigFilePort `json:"port,omitempty"`
+	HttpsOnly     *bool          `json:"httpsOnly,omitempty"`
+	Authorization string         `json:"authorization,omitempty"`
+}
+
+type ConfigFileAdminListener struct {
+	Port ConfigFilePort `json:"port,omitempty"`
+}
+
+// ConfigFilePort - a port, which can be written as a number or a string
+type ConfigFilePort string
+
+func (this *ConfigFilePort) UnmarshalJSON(data []byte) error {
+	var port interface{}
+	if err := json.Unmarshal(data, &port); err != nil {
+		return err
+	}
+
+	switch value := port.(type) {
+	case string:
+		*this = ConfigFilePort(value)
+	case float64:
+		*this = ConfigFilePort(strconv.Itoa(int(value)))
+	default:
+		return fmt.Errorf("Port %s is not a number", string(data))
+	}
+
+	return nil
+}
+
+// ConfigFileAuth - whether the admin API and proxy need authentication, the secret tokens are
+// signed with and how many seconds they expire after
+type ConfigFileAuth struct {
+	Enabled         *bool  `json:"enabled,omitempty"`
+	Secret          string `json:"secret,omitempty"`
+	TokenExpiration int    `json:"tokenExpiration,omitempty"`
+}
+
+// ConfigFileUser - a user added when Hoverfly starts, with a password or the hash of one. Users
+// are admins unless admin is false
+type ConfigFileUser struct {
+	Username     string `json:"username"`
+	Password     string `json:"password,omitempty"`
+	PasswordHash string `json:"passwordHash,omitempty"`
+	Admin        *bool  `json:"admin,omitempty"`
+}
+
+// LoadConfigFile - reads a configuration file, which is JSON or YAML. Settings which are not
+// known are an error, so that a misspelt setting is not silently ignored
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	configFile, err := parseConfigFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("Configuration file %s is not valid: %s", path, err.Error())
+	}
+
+	return configFile, nil
+}
+
+func parseConfigFile(data []byte) (*ConfigFile, error) {
+	if !json.Valid(data) {
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+
+		var err error
+		if data, err = json.Marshal(fromYAML(document)); err != nil {
+			return nil, err
+		}
+	}
+
+	configFile := &ConfigFile{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(configFile); err != nil {
+		return nil, err
+	}
+
+	if err := configFile.validate(); err != nil {
+		return nil, err
+	}
+
+	return configFile, nil
+}
+
+func (this *ConfigFile) validate() error {
+	if this.Mode != "" && this.Mode != modes.Simulate && this.Mode != modes.Capture && this.Mode != modes.Modify && this.Mode != modes.Synthesize {
+		return fmt.Errorf("Mode %s is not one of simulate, capture, modify or synthesize", this.Mode)
+	}
+
+	authorization := this.Listeners.Proxy.Authorization
+	if authorization != "" && authorization != ProxyAuthorization && authorization != HeaderAuthorization {
+		return fmt.Errorf("Proxy authorization %s is not one of %s or %s", authorization, ProxyAuthorization, HeaderAuthorization)
+	}
+
+	for i, user := range this.Users {
+		if user.Username == "" {
+			return fmt.Errorf("User %d has no username", i)
+		}
+
+		if (user.Password == "") == (user.PasswordHash == "") {
+			return fmt.Errorf("User %s needs either a password or a passwordHash", user.Username)
+		}
+	}
+
+	return nil
+}
+
+// Destination - the destinations in the file as a single regular expression, or "" when there
+// are none
+func (this *ConfigFile) Destination() string {
+	return strings.Join(this.Destinations, "|")
+}
+
+// apply - sets the configuration from the settings in the file
+func (this *ConfigFile) apply(cfg *Configuration) error {
+	if this.Listeners.Proxy.Port != "" {
+		cfg.ProxyPort = string(this.Listeners.Proxy.Port)
+	}
+	if this.Listeners.Admin.Port != "" {
+		cfg.AdminPort = string(this.Listeners.Admin.Port)
+	}
+	if this.Listeners.Proxy.HttpsOnly != nil {
+		cfg.HttpsOnly = *this.Listeners.Proxy.HttpsOnly
+	}
+	if this.Listeners.Proxy.Authorization == HeaderAuthorization {
+		cfg.ProxyAuthorizationHeader = "X-HOVERFLY-AUTHORIZATION"
+		cfg.HttpsOnly = true
+	}
+
+	if this.Mode != "" {
+		cfg.Mode = this.Mode
+	}
+	if this.Webserver != nil {
+		cfg.Webserver = *this.Webserver
+	}
+	if len(this.Destinations) > 0 {
+		cfg.Destination = this.Destination()
+	}
+
+	if this.UpstreamProxy != "" {
+		cfg.SetUpstreamProxy(this.UpstreamProxy)
+	}
+	if this.TLSVerification != nil {
+		cfg.TLSVerification = *this.TLSVerification
+	}
+
+	if this.Verbose != nil {
+		cfg.Verbose = *this.Verbose
+	}
+	if this.DatabasePath != "" {
+		cfg.DatabasePath = this.DatabasePath
+	}
+	if this.DisableCache != nil {
+		cfg.DisableCache = *this.DisableCache
+	}
+
+	if this.SimulationDirectory != "" {
+		cfg.SimulationDirectory = this.SimulationDirectory
+	}
+	if this.BodyFileCaptureSize != nil {
+		cfg.BodyFileCaptureSize = *this.BodyFileCaptureSize
+	}
+
+	if this.Auth.Enabled != nil {
+		cfg.AuthEnabled = *this.Auth.Enabled
+	}
+	if this.Auth.Secret != "" {
+		cfg.SecretKey = []byte(this.Auth.Secret)
+	}
+	if this.Auth.TokenExpiration != 0 {
+		cfg.JWTExpirationDelta = this.Auth.TokenExpiration
+	}
+
+	if this.Middleware != nil {
+		newMiddleware, err := newMiddlewareFromView(*this.Middleware)
+		if err != nil {
+			return fmt.Errorf("Middleware is not valid: %s", err.Error())
+		}
+		cfg.Middleware = *newMiddleware
+	}
+
+	return nil
+}
+
+// withoutEnvironmentSettings - the file without the settings which environment variables are
+// set for, as they take precedence over it
+func (this ConfigFile) withoutEnvironmentSettings() ConfigFile {
+	if os.Getenv(HoverflyProxyPortEV) != "" {
+		this.Listeners.Proxy.Port = ""
+	}
+	if os.Getenv(HoverflyAdminPortEV) != "" {
+		this.Listeners.Admin.Port = ""
+	}
+	if os.Getenv(HoverflyUpstreamProxyPortEV) != "" {
+		this.UpstreamProxy = ""
+	}
+	if os.Getenv(HoverflyTLSVerification) != "" {
+		this.TLSVerification = nil
+	}
+	if os.Getenv(HoverflyDBEV) != "" {
+		this.DatabasePath = ""
+	}
+	if os.Getenv(HoverflyAuthEnabledEV) != "" {
+		this.Auth.Enabled = nil
+	}
+	if os.Getenv(HoverflySecretEV) != "" {
+		this.Auth.Secret = ""
+	}
+	if os.Getenv(HoverflyTokenExpirationEV) != "" {
+		this.Auth.TokenExpiration = 0
+	}
+	if os.Getenv(HoverflyMiddlewareEV) != "" {
+		this.Middleware = nil
+	}
+
+	return this
+}
+
+// AddUsers - adds the users from a configuration file, replacing any with the same username
+func (hf *Hoverfly) AddUsers(users []ConfigFileUser) error {
+	for _, user := range users {
+		admin := user.Admin == nil || *user.Admin
+
+		var err error
+		if user.PasswordHash != "" {
+			err = hf.Authentication.AddUserHashedPassword(user.Username, user.PasswordHash, admin)
+		} else {
+			err = hf.Authentication.AddUser(user.Username, user.Password, admin)
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to add user %s: %s", user.Username, err.Error())
+		}
+
+		log.WithFields(log.Fields{
+			"username": user.Username,
+		}).Info("User added successfully")
+	}
+
+	return nil
+}
+
+// ReloadConfigFile - applies the settings which have changed since the configuration file was
+// last loaded, and which can be changed while Hoverfly is running. These are the mode,
+// destinations, middleware, upstream proxy, TLS verification, verbose logging, body file
+// settings and new users. Changes to the other settings are logged as needing a restart.
+// Settings environment variables are set for are not applied, as they take precedence
+func (hf *Hoverfly) ReloadConfigFile(previous, current *ConfigFile) {
+	before, after := previous.withoutEnvironmentSettings(), current.withoutEnvironmentSettings()
+
+	changed := func(setting string, before, after interface{}) bool {
+		if reflect.DeepEqual(before, after) {
+			return false
+		}
+
+		log.WithFields(log.Fields{
+			"setting": setting,
+		}).Info("Applying changed setting from the configuration file")
+		return true
+	}
+
+	fail := func(setting string, err error) {
+		log.WithFields(log.Fields{
+			"setting": setting,
+			"error":   err.Error(),
+		}).Error("Failed to apply changed setting from the configuration file")
+	}
+
+	if changed("destinations", before.Destinations, after.Destinations) {
+		destination := after.Destination()
+		if destination == "" {
+			destination = "."
+		}
+		if err := hf.SetDestination(destination); err != nil {
+			fail("destinations", err)
+		}
+	}
+
+	if changed("middleware", before.Middleware, after.Middleware) {
+		middlewareView := v2.MiddlewareView{}
+		if after.Middleware != nil {
+			middlewareView = *after.Middleware
+		}
+		if err := hf.SetMiddlewareWithOptions(middlewareView); err != nil {
+			fail("middleware", err)
+		}
+	}
+
+	if changed("middlewareChain", before.MiddlewareChain, after.MiddlewareChain) {
+		if err := hf.SetMiddlewareChain(v2.MiddlewareChainView{Middlewares: after.MiddlewareChain}); err != nil {
+			fail("middlewareChain", err)
+		}
+	}
+
+	// the mode is changed after the middleware, which modify and synthesize mode need
+	if changed("mode", before.Mode, after.Mode) {
+		mode := after.Mode
+		if mode == "" {
+			mode = modes.Simulate
+		}
+		if err := hf.SetMode(mode); err != nil {
+			fail("mode", err)
+		}
+	}
+
+	upstreamProxyChanged := changed("upstreamProxy", before.UpstreamProxy, after.UpstreamProxy)
+	if upstreamProxyChanged {
+		hf.Cfg.SetUpstreamProxy(after.UpstreamProxy)
+	}
+
+	tlsVerificationChanged := changed("tlsVerification", before.TLSVerification, after.TLSVerification)
+	if tlsVerificationChanged {
+		hf.Cfg.SetTLSVerification(after.TLSVerification == nil || *after.TLSVerification)
+	}
+
+	if upstreamProxyChanged || tlsVerificationChanged {
+		hf.setHTTPClient(GetDefaultHoverflyHTTPClient(hf.Cfg.GetTLSVerification(), hf.Cfg.GetUpstreamProxy()))
+	}
+
+	if changed("verbose", before.Verbose, after.Verbose) {
+		hf.Cfg.SetVerbose(after.Verbose != nil && *after.Verbose)
+		if hf.Cfg.GetVerbose() {
+			log.SetLevel(log.DebugLevel)
+		} else {
+			log.SetLevel(log.InfoLevel)
+		}
+	}
+
+	if changed("simulationDirectory", before.SimulationDirectory, after.SimulationDirectory) {
+		hf.Cfg.SetSimulationDirectory(after.SimulationDirectory)
+	}
+
+	if changed("bodyFileCaptureSize", before.BodyFileCaptureSize, after.BodyFileCaptureSize) {
+		bodyFileCaptureSize := 0
+		if after.BodyFileCaptureSize != nil {
+			bodyFileCaptureSize = *after.BodyFileCaptureSize
+		}
+		hf.Cfg.SetBodyFileCaptureSize(bodyFileCaptureSize)
+	}
+
+	if changed("users", before.Users, after.Users) {
+		added := []ConfigFileUser{}
+		for _, user := range after.Users {
+			if !containsUser(before.Users, user) {
+				added = append(added, user)
+			}
+		}
+		if err := hf.AddUsers(added); err != nil {
+			fail("users", err)
+		}
+	}
+
+	for _, setting := range []struct {
+		name          string
+		before, after interface{}
+	}{
+		{"listeners", before.Listeners, after.Listeners},
+		{"webserver", before.Webserver, after.Webserver},
+		{"databasePath", before.DatabasePath, after.DatabasePath},
+		{"disableCache", before.DisableCache, after.DisableCache},
+		{"auth", before.Auth, after.Auth},
+		{"imports", before.Imports, after.Imports},
+	} {
+		if !reflect.DeepEqual(setting.before, setting.after) {
+			log.WithFields(log.Fields{
+				"setting": setting.name,
+			}).Warn("Setting changed in the configuration file, restart Hoverfly to apply it")
+		}
+	}
+}
+
+func containsUser(users []ConfigFileUser, user ConfigFileUser) bool {
+	for _, existing := range users {
+		if reflect.DeepEqual(existing, user) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fromYAML - converts the maps YAML is unmarshalled into, which can have keys of any type, into
+// maps with string keys which can be marshalled to JSON
+func fromYAML(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		object := map[string]interface{}{}
+		for key, item := range typed {
+			object[fmt.Sprint(key)] = fromYAML(item)
+		}
+		return object
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = fromYAML(item)
+		}
+		return typed
+	}
+
+	return value
+}
@@ -0,0 +1,338 @@
+package hoverfly
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/modes"
+	. "github.com/onsi/gomega"
+)
+
+const yamlConfigFile = `
+mode: capture
+destinations:
+  - api.example.com
+  - auth.example.com
+upstreamProxy: corporate-proxy:3128
+tlsVerification: false
+databasePath: /var/lib/hoverfly/requests.db
+simulationDirectory: simulations
+bodyFileCaptureSize: 1024
+listeners:
+  proxy:
+    port: 9500
+    httpsOnly: true
+  admin:
+    port: "9888"
+auth:
+  enabled: true
+  secret: secret
+  tokenExpiration: 3600
+users:
+  - username: admin
+    password: password
+  - username: reader
+    passwordHash: hash
+    admin: false
+imports:
+  - simulation.json
+`
+
+func Test_LoadConfigFile_ReadsYAML(t *testing.T) {
+	RegisterTestingT(t)
+
+	directory, _ := ioutil.TempDir("", "hoverfly-config")
+	defer os.RemoveAll(directory)
+
+	path := filepath.Join(directory, "hoverfly.yml")
+	ioutil.WriteFile(path, []byte(yamlConfigFile), 0644)
+
+	configFile, err := LoadConfigFile(path)
+	Expect(err).To(BeNil())
+
+	Expect(configFile.Mode).To(Equal("capture"))
+	Expect(configFile.Destination()).To(Equal("api.example.com|auth.example.com"))
+	Expect(configFile.UpstreamProxy).To(Equal("corporate-proxy:3128"))
+	Expect(*configFile.TLSVerification).To(BeFalse())
+	Expect(configFile.DatabasePath).To(Equal("/var/lib/hoverfly/requests.db"))
+	Expect(configFile.SimulationDirectory).To(Equal("simulations"))
+	Expect(*configFile.BodyFileCaptureSize).To(Equal(1024))
+
+	Expect(configFile.Listeners.Proxy.Port).To(Equal(ConfigFilePort("9500")))
+	Expect(*configFile.Listeners.Proxy.HttpsOnly).To(BeTrue())
+	Expect(configFile.Listeners.Admin.Port).To(Equal(ConfigFilePort("9888")))
+
+	Expect(*configFile.Auth.Enabled).To(BeTrue())
+	Expect(configFile.Auth.Secret).To(Equal("secret"))
+	Expect(configFile.Auth.TokenExpiration).To(Equal(3600))
+
+	Expect(configFile.Users).To(HaveLen(2))
+	Expect(configFile.Users[0].Username).To(Equal("admin"))
+	Expect(configFile.Users[0].Admin).To(BeNil())
+	Expect(configFile.Users[1].PasswordHash).To(Equal("hash"))
+	Expect(*configFile.Users[1].Admin).To(BeFalse())
+
+	Expect(configFile.Imports).To(ConsistOf("simulation.json"))
+}
+
+func Test_LoadConfigFile_ErrorsWhenFileDoesNotExist(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := LoadConfigFile("does-not-exist.yml")
+	Expect(err).ToNot(BeNil())
+}
+
+func Test_parseConfigFile_ReadsJSON(t *testing.T) {
+	RegisterTestingT(t)
+
+	configFile, err := parseConfigFile([]byte(`{
+	"mode": "simulate",
+	"webserver": true,
+	"listeners": {"proxy": {"port": 9500, "authorization": "header-auth"}},
+	"middleware": {"remote": "http://localhost:8080/process", "timeout": 500},
+	"middlewareChain": [{"name": "first", "phase": "request", "remote": "http://localhost:8081/process"}]
+}`))
+	Expect(err).To(BeNil())
+
+	Expect(configFile.Mode).To(Equal("simulate"))
+	Expect(*configFile.Webserver).To(BeTrue())
+	Expect(configFile.Listeners.Proxy.Port).To(Equal(ConfigFilePort("9500")))
+	Expect(configFile.Listeners.Proxy.Authorization).To(Equal(HeaderAuthorization))
+	Expect(configFile.Middleware.Remote).To(Equal("http://localhost:8080/process"))
+	Expect(configFile.Middleware.Timeout).To(Equal(500))
+	Expect(configFile.MiddlewareChain).To(HaveLen(1))
+	Expect(configFile.MiddlewareChain[0].Name).To(Equal("first"))
+	Expect(configFile.MiddlewareChain[0].Remote).To(Equal("http://localhost:8081/process"))
+}
+
+func Test_parseConfigFile_ReadsEmptyFile(t *testing.T) {
+	RegisterTestingT(t)
+
+	configFile, err := parseConfigFile([]byte(""))
+	Expect(err).To(BeNil())
+	Expect(*configFile).To(Equal(ConfigFile{}))
+}
+
+func Test_parseConfigFile_ErrorsOnUnknownSettings(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := parseConfigFile([]byte("destination: api.example.com"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(ContainSubstring("destination"))
+}
+
+func Test_parseConfigFile_ErrorsOnUnknownMode(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := parseConfigFile([]byte("mode: replay"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Mode replay is not one of simulate, capture, modify or synthesize"))
+}
+
+func Test_parseConfigFile_ErrorsOnUnknownProxyAuthorization(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := parseConfigFile([]byte("listeners: {proxy: {authorization: basic}}"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Proxy authorization basic is not one of proxy-auth or header-auth"))
+}
+
+func Test_parseConfigFile_ErrorsWhenUserHasNoPassword(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := parseConfigFile([]byte("users: [{username: admin}]"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("User admin needs either a password or a passwordHash"))
+}
+
+func Test_InitSettingsWithConfigFile_FileOverridesDefaults(t *testing.T) {
+	RegisterTestingT(t)
+
+	configFile, err := parseConfigFile([]byte(yamlConfigFile))
+	Expect(err).To(BeNil())
+
+	cfg, err := InitSettingsWithConfigFile(configFile)
+	Expect(err).To(BeNil())
+
+	Expect(cfg.Mode).To(Equal("capture"))
+	Expect(cfg.Destination).To(Equal("api.example.com|auth.example.com"))
+	Expect(cfg.UpstreamProxy).To(Equal("http://corporate-proxy:3128"))
+	Expect(cfg.TLSVerification).To(BeFalse())
+	Expect(cfg.DatabasePath).To(Equal("/var/lib/hoverfly/requests.db"))
+	Expect(cfg.SimulationDirectory).To(Equal("simulations"))
+	Expect(cfg.BodyFileCaptureSize).To(Equal(1024))
+	Expect(cfg.ProxyPort).To(Equal("9500"))
+	Expect(cfg.AdminPort).To(Equal("9888"))
+	Expect(cfg.HttpsOnly).To(BeTrue())
+	Expect(cfg.AuthEnabled).To(BeTrue())
+	Expect(cfg.SecretKey).To(Equal([]byte("secret")))
+	Expect(cfg.JWTExpirationDelta).To(Equal(3600))
+}
+
+func Test_InitSettingsWithConfigFile_KeepsDefaultsForSettingsNotInFile(t *testing.T) {
+	RegisterTestingT(t)
+
+	cfg, err := InitSettingsWithConfigFile(&ConfigFile{})
+	Expect(err).To(BeNil())
+
+	Expect(cfg.Mode).To(Equal("simulate"))
+	Expect(cfg.ProxyPort).To(Equal(DefaultPort))
+	Expect(cfg.AdminPort).To(Equal(DefaultAdminPort))
+	Expect(cfg.DatabasePath).To(Equal(DefaultDatabasePath))
+	Expect(cfg.JWTExpirationDelta).To(Equal(DefaultJWTExpirationDelta))
+	Expect(cfg.TLSVerification).To(BeTrue())
+	Expect(cfg.ProxyAuthorizationHeader).To(Equal("Proxy-Authorization"))
+	Expect(cfg.SecretKey).ToNot(BeEmpty())
+}
+
+func Test_InitSettingsWithConfigFile_HeaderAuthorizationOnlyProxiesHttps(t *testing.T) {
+	RegisterTestingT(t)
+
+	cfg, err := InitSettingsWithConfigFile(&ConfigFile{
+		Listeners: ConfigFileListeners{Proxy: ConfigFileProxyListener{Authorization: HeaderAuthorization}},
+	})
+	Expect(err).To(BeNil())
+
+	Expect(cfg.ProxyAuthorizationHeader).To(Equal("X-HOVERFLY-AUTHORIZATION"))
+	Expect(cfg.HttpsOnly).To(BeTrue())
+}
+
+func Test_InitSettingsWithConfigFile_EnvironmentVariablesOverrideFile(t *testing.T) {
+	RegisterTestingT(t)
+
+	defer os.Setenv(HoverflyProxyPortEV, "")
+	defer os.Setenv(HoverflyTLSVerification, "")
+
+	os.Setenv(HoverflyProxyPortEV, "6666")
+	os.Setenv(HoverflyTLSVerification, "true")
+
+	configFile, err := parseConfigFile([]byte(yamlConfigFile))
+	Expect(err).To(BeNil())
+
+	cfg, err := InitSettingsWithConfigFile(configFile)
+	Expect(err).To(BeNil())
+
+	Expect(cfg.ProxyPort).To(Equal("6666"))
+	Expect(cfg.TLSVerification).To(BeTrue())
+	Expect(cfg.AdminPort).To(Equal("9888"))
+}
+
+func Test_InitSettingsWithConfigFile_ErrorsWhenMiddlewareIsNotValid(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := InitSettingsWithConfigFile(&ConfigFile{
+		Middleware: &v2.MiddlewareView{Script: "script"},
+	})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Middleware is not valid: Cannot run script with no binary"))
+}
+
+func Test_Hoverfly_ReloadConfigFile_AppliesChangedSettings(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{ProxyPort: "8500", TLSVerification: true})
+
+	captureSize := 2048
+	unit.ReloadConfigFile(&ConfigFile{}, &ConfigFile{
+		Mode:                modes.Capture,
+		UpstreamProxy:       "corporate-proxy:3128",
+		SimulationDirectory: "simulations",
+		BodyFileCaptureSize: &captureSize,
+	})
+
+	Expect(unit.Cfg.GetMode()).To(Equal(modes.Capture))
+	Expect(unit.Cfg.UpstreamProxy).To(Equal("http://corporate-proxy:3128"))
+	Expect(unit.Cfg.SimulationDirectory).To(Equal("simulations"))
+	Expect(unit.Cfg.BodyFileCaptureSize).To(Equal(2048))
+}
+
+func Test_Hoverfly_ReloadConfigFile_RevertsSettingsRemovedFromFile(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{Mode: modes.Capture, TLSVerification: false, UpstreamProxy: "http://corporate-proxy:3128"})
+
+	tlsVerification := false
+	unit.ReloadConfigFile(&ConfigFile{
+		Mode:            modes.Capture,
+		UpstreamProxy:   "corporate-proxy:3128",
+		TLSVerification: &tlsVerification,
+	}, &ConfigFile{})
+
+	Expect(unit.Cfg.GetMode()).To(Equal(modes.Simulate))
+	Expect(unit.Cfg.UpstreamProxy).To(Equal(""))
+	Expect(unit.Cfg.TLSVerification).To(BeTrue())
+}
+
+func Test_Hoverfly_ReloadConfigFile_DoesNotApplySettingsWhichNeedRestart(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{ProxyPort: "8500", DatabasePath: "requests.db"})
+
+	unit.ReloadConfigFile(&ConfigFile{}, &ConfigFile{
+		Listeners:    ConfigFileListeners{Proxy: ConfigFileProxyListener{Port: "9500"}},
+		DatabasePath: "other.db",
+	})
+
+	Expect(unit.Cfg.ProxyPort).To(Equal("8500"))
+	Expect(unit.Cfg.DatabasePath).To(Equal("requests.db"))
+}
+
+func Test_Hoverfly_ReloadConfigFile_DoesNotApplySettingsEnvironmentVariablesAreSetFor(t *testing.T) {
+	RegisterTestingT(t)
+
+	defer os.Setenv(HoverflyUpstreamProxyPortEV, "")
+	os.Setenv(HoverflyUpstreamProxyPortEV, "http://environment-proxy:3128")
+
+	unit := NewHoverflyWithConfiguration(&Configuration{UpstreamProxy: "http://environment-proxy:3128"})
+
+	unit.ReloadConfigFile(&ConfigFile{}, &ConfigFile{UpstreamProxy: "corporate-proxy:3128"})
+
+	Expect(unit.Cfg.UpstreamProxy).To(Equal("http://environment-proxy:3128"))
+}
+
+// run with -race, reloading changes settings which requests being handled at the same time read
+func Test_Hoverfly_ReloadConfigFile_CanBeReloadedWhileHandlingRequests(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	unit.Cfg.SetMode(modes.Capture)
+
+	captureSize := 1024 * 1024
+	tlsVerification := false
+	verbose := true
+	files := []*ConfigFile{
+		{Mode: modes.Capture},
+		{
+			Mode:                modes.Capture,
+			UpstreamProxy:       server.URL,
+			TLSVerification:     &tlsVerification,
+			Verbose:             &verbose,
+			SimulationDirectory: "simulations",
+			BodyFileCaptureSize: &captureSize,
+		},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			unit.ReloadConfigFile(files[i%2], files[(i+1)%2])
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		r, err := http.NewRequest("GET", "http://somehost.com/path", nil)
+		Expect(err).To(BeNil())
+
+		unit.processRequest(r)
+	}
+
+	<-done
+	Expect(unit.Cfg.GetMode()).To(Equal(modes.Capture))
+}
@@ -0,0 +1,216 @@
+package hoverfly
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/goproxy"
+	"github.com/SpectoLabs/hoverfly/core/grpc"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/util"
+)
+
+var grpcStatusHeaders = []string{"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"}
+
+// getGrpcMethod - returns the method a gRPC request is calling, or nil if the request
+// isn't gRPC or the method isn't described by any uploaded descriptor set
+func (hf *Hoverfly) getGrpcMethod(req *http.Request) *grpc.Method {
+	if hf.Grpc == nil || !grpc.IsGrpcContentType(req.Header.Get("Content-Type")) {
+		return nil
+	}
+
+	return hf.Grpc.GetMethod(req.URL.Path)
+}
+
+// decodeGrpcRequest - replaces the length-prefixed protobuf body of a gRPC request with its
+// JSON rendering, so it can be matched, recorded and passed to middleware like any other body
+func (hf *Hoverfly) decodeGrpcRequest(req *http.Request) *http.Response {
+	method := hf.getGrpcMethod(req)
+	if method == nil || req.Body == nil {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return goproxy.NewResponse(req, goproxy.ContentTypeText, http.StatusBadRequest, err.Error())
+	}
+
+	decoded, err := method.DecodeRequest(body, req.Header.Get("Grpc-Encoding"))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":  err.Error(),
+			"method": method.Path(),
+		}).Warn("Could not decode gRPC request")
+
+		return goproxy.NewResponse(req, goproxy.ContentTypeText, http.StatusBadRequest, err.Error())
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewBufferString(decoded))
+	req.ContentLength = int64(len(decoded))
+
+	return nil
+}
+
+// encodeGrpcRequest - encodes the JSON rendered body of a gRPC request back into
+// length-prefixed protobuf messages before it is sent upstream
+func (hf *Hoverfly) encodeGrpcRequest(req *http.Request, body []byte) ([]byte, error) {
+	method := hf.getGrpcMethod(req)
+	if method == nil {
+		return body, nil
+	}
+
+	encoded, err := method.EncodeRequest(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("Could not encode gRPC request: %s", err.Error())
+	}
+
+	req.ContentLength = int64(len(encoded))
+	req.Header.Del("Content-Length")
+
+	return encoded, nil
+}
+
+// decodeGrpcResponse - renders the messages of an upstream gRPC response as JSON and moves the
+// status trailers into the headers, so they are recorded with the rest of the response
+func (hf *Hoverfly) decodeGrpcResponse(req *http.Request, resp *http.Response) error {
+	if !grpc.IsGrpcContentType(req.Header.Get("Content-Type")) || !grpc.IsGrpcContentType(resp.Header.Get("Content-Type")) {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	// trailers are only populated once the body has been read
+	for _, name := range grpcStatusHeaders {
+		if value := resp.Trailer.Get(name); value != "" {
+			if name == "Grpc-Message" {
+				value = grpc.DecodeStatusMessage(value)
+			}
+			resp.Header.Set(name, value)
+		}
+	}
+	resp.Trailer = nil
+
+	if method := hf.getGrpcMethod(req); method != nil {
+		decoded, err := method.DecodeResponse(body, resp.Header.Get("Grpc-Encoding"))
+		if err != nil {
+			return fmt.Errorf("Could not decode gRPC response: %s", err.Error())
+		}
+		body = []byte(decoded)
+		resp.Header.Del("Grpc-Encoding")
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	resp.ContentLength = int64(len(body))
+	resp.TransferEncoding = nil
+	resp.Header.Del("Content-Length")
+
+	return nil
+}
+
+// encodeGrpcResponse - turns the response to a gRPC request into length-prefixed protobuf messages,
+// sending grpc-status and grpc-message as trailers. Responses which aren't successful are
+// mapped to a gRPC status, as gRPC clients expect every response to have a 200 status code
+func (hf *Hoverfly) encodeGrpcResponse(req *http.Request, resp *http.Response) {
+	if resp == nil || !grpc.IsGrpcContentType(req.Header.Get("Content-Type")) {
+		return
+	}
+
+	body, _ := util.GetResponseBody(resp)
+
+	// the header map may be shared with the journal or simulation, so it is copied before being changed
+	header := make(http.Header)
+	for name, values := range resp.Header {
+		header[name] = values
+	}
+	resp.Header = header
+
+	trailers := map[string]string{}
+	for _, name := range grpcStatusHeaders {
+		trailers[name] = popHeader(resp.Header, name)
+	}
+
+	var encoded []byte
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		encoded = []byte(body)
+
+		if method := hf.getGrpcMethod(req); method != nil {
+			var err error
+			if encoded, err = method.EncodeResponse(body); err != nil {
+				log.WithFields(log.Fields{
+					"error":  err.Error(),
+					"method": method.Path(),
+				}).Warn("Could not encode gRPC response")
+
+				encoded = nil
+				trailers["Grpc-Status"] = strconv.Itoa(grpc.StatusInternal)
+				trailers["Grpc-Message"] = "Could not encode gRPC response: " + err.Error()
+			}
+		}
+
+		if trailers["Grpc-Status"] == "" {
+			trailers["Grpc-Status"] = strconv.Itoa(grpc.StatusOK)
+		}
+	} else {
+		trailers["Grpc-Status"] = strconv.Itoa(grpc.StatusFromHTTP(resp.StatusCode))
+		trailers["Grpc-Message"] = strings.TrimSpace(body)
+		trailers["Grpc-Status-Details-Bin"] = ""
+	}
+
+	if !grpc.IsGrpcContentType(resp.Header.Get("Content-Type")) {
+		popHeader(resp.Header, "Content-Type")
+		resp.Header.Set("Content-Type", "application/grpc")
+	}
+	popHeader(resp.Header, "Content-Length")
+
+	for _, name := range grpcStatusHeaders {
+		if value := trailers[name]; value != "" {
+			if name == "Grpc-Message" {
+				value = grpc.EncodeStatusMessage(value)
+			}
+			resp.Header[http.TrailerPrefix+name] = []string{value}
+		}
+	}
+
+	resp.StatusCode = http.StatusOK
+	resp.Body = ioutil.NopCloser(bytes.NewBuffer(encoded))
+	resp.ContentLength = int64(len(encoded))
+}
+
+// popHeader - removes a header regardless of the case it was stored with, returning its first value
+func popHeader(header http.Header, name string) string {
+	value := ""
+	for key, values := range header {
+		if strings.EqualFold(key, name) {
+			if value == "" && len(values) > 0 {
+				value = values[0]
+			}
+			delete(header, key)
+		}
+	}
+
+	return value
+}
+
+// isDecodedGrpcRequest - returns true when the body of a gRPC request was rendered as JSON
+func (hf *Hoverfly) isDecodedGrpcRequest(request *models.RequestDetails) bool {
+	if hf.Grpc == nil || request.Body == "" {
+		return false
+	}
+
+	for _, contentType := range request.Headers["Content-Type"] {
+		if grpc.IsGrpcContentType(contentType) {
+			return hf.Grpc.GetMethod(request.Path) != nil
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,661 @@
+package grpc
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Wire types as defined by the protobuf encoding
+const (
+	wireVarint     = 0
+	wireFixed64    = 1
+	wireBytes      = 2
+	wireStartGroup = 3
+	wireEndGroup   = 4
+	wireFixed32    = 5
+)
+
+// DecodeRequest - renders the length-prefixed request messages as JSON, client streaming
+// methods are rendered as an array of messages
+func (this Method) DecodeRequest(body []byte, encoding string) (string, error) {
+	return this.registry.decode(this.InputType, this.ClientStreaming, body, encoding)
+}
+
+// EncodeRequest - encodes JSON rendered request messages into length-prefixed protobuf messages
+func (this Method) EncodeRequest(body string) ([]byte, error) {
+	return this.registry.encode(this.InputType, this.ClientStreaming, body)
+}
+
+// DecodeResponse - renders the length-prefixed response messages as JSON, server streaming
+// methods are rendered as an array of messages
+func (this Method) DecodeResponse(body []byte, encoding string) (string, error) {
+	return this.registry.decode(this.OutputType, this.ServerStreaming, body, encoding)
+}
+
+// EncodeResponse - encodes JSON rendered response messages into length-prefixed protobuf messages
+func (this Method) EncodeResponse(body string) ([]byte, error) {
+	return this.registry.encode(this.OutputType, this.ServerStreaming, body)
+}
+
+func (this *Registry) decode(typeName string, streaming bool, body []byte, encoding string) (string, error) {
+	msg, err := this.getMessage(typeName)
+	if err != nil {
+		return "", err
+	}
+
+	frames, err := ReadFrames(body, encoding)
+	if err != nil {
+		return "", err
+	}
+
+	if len(frames) == 0 {
+		return "", nil
+	}
+
+	if !streaming && len(frames) > 1 {
+		return "", fmt.Errorf("Expected a single %s message, got %d", typeName, len(frames))
+	}
+
+	messages := []interface{}{}
+	for _, frame := range frames {
+		decoded, err := this.decodeMessage(msg, frame)
+		if err != nil {
+			return "", err
+		}
+		messages = append(messages, decoded)
+	}
+
+	var rendered []byte
+	if streaming {
+		rendered, err = json.Marshal(messages)
+	} else {
+		rendered, err = json.Marshal(messages[0])
+	}
+
+	return string(rendered), err
+}
+
+func (this *Registry) encode(typeName string, streaming bool, body string) ([]byte, error) {
+	msg, err := this.getMessage(typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(body) == "" {
+		return []byte{}, nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("Invalid JSON for %s: %s", typeName, err.Error())
+	}
+
+	messages, isArray := value.([]interface{})
+	if !isArray {
+		messages = []interface{}{value}
+	} else if !streaming {
+		return nil, fmt.Errorf("Expected a single %s message, got an array", typeName)
+	}
+
+	var framed []byte
+	for _, message := range messages {
+		object, ok := message.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Expected %s to be a JSON object", typeName)
+		}
+
+		encoded, err := this.encodeMessage(msg, object)
+		if err != nil {
+			return nil, err
+		}
+
+		framed = AppendFrame(framed, encoded)
+	}
+
+	return framed, nil
+}
+
+func (this *Registry) decodeMessage(msg *message, data []byte) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+
+	for len(data) > 0 {
+		key, n := proto.DecodeVarint(data)
+		if n == 0 {
+			return nil, fmt.Errorf("Malformed %s message", msg.name)
+		}
+		data = data[n:]
+
+		number := int32(key >> 3)
+		wireType := int(key & 7)
+
+		value, rest, err := readValue(wireType, data)
+		if err != nil {
+			return nil, fmt.Errorf("Malformed %s message: %s", msg.name, err.Error())
+		}
+		data = rest
+
+		f := msg.fieldByNumber(number)
+		if f == nil {
+			// unknown fields are skipped
+			continue
+		}
+
+		if f.label != labelRepeated {
+			decoded, err := this.decodeValue(f, wireType, value)
+			if err != nil {
+				return nil, err
+			}
+			result[f.jsonName] = decoded
+			continue
+		}
+
+		if entry, _ := this.getMessage(f.typeName); entry != nil && entry.mapEntry && f.kind == typeMessage {
+			if err := this.decodeMapEntry(result, f, entry, value.bytes); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		list, _ := result[f.jsonName].([]interface{})
+
+		if wireType == wireBytes && isPackable(f.kind) {
+			packed := value.bytes
+			packedWireType := wireTypeOf(f.kind)
+			for len(packed) > 0 {
+				element, rest, err := readValue(packedWireType, packed)
+				if err != nil {
+					return nil, fmt.Errorf("Malformed %s message: %s", msg.name, err.Error())
+				}
+				packed = rest
+
+				decoded, err := this.decodeValue(f, packedWireType, element)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, decoded)
+			}
+		} else {
+			decoded, err := this.decodeValue(f, wireType, value)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, decoded)
+		}
+
+		result[f.jsonName] = list
+	}
+
+	return result, nil
+}
+
+func (this *Registry) decodeMapEntry(result map[string]interface{}, f *field, entry *message, data []byte) error {
+	decoded, err := this.decodeMessage(entry, data)
+	if err != nil {
+		return err
+	}
+
+	entries, ok := result[f.jsonName].(map[string]interface{})
+	if !ok {
+		entries = make(map[string]interface{})
+		result[f.jsonName] = entries
+	}
+
+	key := ""
+	if decodedKey, ok := decoded["key"]; ok {
+		key = fmt.Sprint(decodedKey)
+	}
+
+	value, ok := decoded["value"]
+	if !ok {
+		value = defaultValue(entry.fieldByNumber(2))
+	}
+
+	entries[key] = value
+
+	return nil
+}
+
+func (this *Registry) decodeValue(f *field, wireType int, value wireValue) (interface{}, error) {
+	if wireType != wireTypeOf(f.kind) {
+		return nil, fmt.Errorf("Field %s has wire type %d, expected %d", f.name, wireType, wireTypeOf(f.kind))
+	}
+
+	switch f.kind {
+	case typeDouble:
+		return floatValue(math.Float64frombits(value.number), 64), nil
+	case typeFloat:
+		return floatValue(float64(math.Float32frombits(uint32(value.number))), 32), nil
+	case typeInt64, typeSfixed64:
+		return strconv.FormatInt(int64(value.number), 10), nil
+	case typeUint64, typeFixed64:
+		return strconv.FormatUint(value.number, 10), nil
+	case typeSint64:
+		return strconv.FormatInt(int64(value.number>>1)^-int64(value.number&1), 10), nil
+	case typeInt32, typeSfixed32:
+		return int64(int32(value.number)), nil
+	case typeUint32, typeFixed32:
+		return int64(uint32(value.number)), nil
+	case typeSint32:
+		return int64(int32(uint32(value.number)>>1) ^ -int32(value.number&1)), nil
+	case typeBool:
+		return value.number != 0, nil
+	case typeString:
+		return string(value.bytes), nil
+	case typeBytes:
+		return base64.StdEncoding.EncodeToString(value.bytes), nil
+	case typeEnum:
+		if e := this.getEnum(f.typeName); e != nil {
+			if name, ok := e.names[int32(value.number)]; ok {
+				return name, nil
+			}
+		}
+		return int64(int32(value.number)), nil
+	case typeMessage:
+		nested, err := this.getMessage(f.typeName)
+		if err != nil {
+			return nil, err
+		}
+		return this.decodeMessage(nested, value.bytes)
+	}
+
+	return nil, fmt.Errorf("Field %s has unsupported type %d", f.name, f.kind)
+}
+
+func (this *Registry) encodeMessage(msg *message, object map[string]interface{}) ([]byte, error) {
+	for key := range object {
+		if msg.byName[key] == nil {
+			return nil, fmt.Errorf("Unknown field %s in %s", key, msg.name)
+		}
+	}
+
+	var buffer []byte
+	for _, f := range msg.fields {
+		value, ok := object[f.jsonName]
+		if !ok {
+			value, ok = object[f.name]
+		}
+		if !ok || value == nil {
+			continue
+		}
+
+		var err error
+		if f.label != labelRepeated {
+			buffer, err = this.appendField(buffer, f, value)
+		} else if entry, _ := this.getMessage(f.typeName); entry != nil && entry.mapEntry && f.kind == typeMessage {
+			buffer, err = this.appendMap(buffer, f, entry, value)
+		} else {
+			buffer, err = this.appendList(buffer, f, value)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buffer, nil
+}
+
+func (this *Registry) appendList(buffer []byte, f *field, value interface{}) ([]byte, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Field %s must be an array", f.name)
+	}
+
+	if !f.packed || !isPackable(f.kind) {
+		for _, element := range list {
+			var err error
+			buffer, err = this.appendField(buffer, f, element)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buffer, nil
+	}
+
+	var packed []byte
+	for _, element := range list {
+		var err error
+		packed, err = this.appendValue(packed, f, element)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buffer = appendTag(buffer, f.number, wireBytes)
+	buffer = append(buffer, proto.EncodeVarint(uint64(len(packed)))...)
+	return append(buffer, packed...), nil
+}
+
+func (this *Registry) appendMap(buffer []byte, f *field, entry *message, value interface{}) ([]byte, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Field %s must be an object", f.name)
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	keyField := entry.fieldByNumber(1)
+	for _, key := range keys {
+		var entryValue interface{} = key
+		if keyField != nil && keyField.kind == typeBool {
+			entryValue = key == "true"
+		}
+
+		encoded, err := this.encodeMessage(entry, map[string]interface{}{
+			"key":   entryValue,
+			"value": object[key],
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		buffer = appendTag(buffer, f.number, wireBytes)
+		buffer = append(buffer, proto.EncodeVarint(uint64(len(encoded)))...)
+		buffer = append(buffer, encoded...)
+	}
+
+	return buffer, nil
+}
+
+func (this *Registry) appendField(buffer []byte, f *field, value interface{}) ([]byte, error) {
+	buffer = appendTag(buffer, f.number, wireTypeOf(f.kind))
+	return this.appendValue(buffer, f, value)
+}
+
+func (this *Registry) appendValue(buffer []byte, f *field, value interface{}) ([]byte, error) {
+	switch f.kind {
+	case typeDouble:
+		number, err := toFloat(value)
+		if err != nil {
+			return nil, fieldError(f, err)
+		}
+		return appendFixed64(buffer, math.Float64bits(number)), nil
+	case typeFloat:
+		number, err := toFloat(value)
+		if err != nil {
+			return nil, fieldError(f, err)
+		}
+		return appendFixed32(buffer, math.Float32bits(float32(number))), nil
+	case typeInt64, typeInt32:
+		number, err := toInt(value)
+		if err != nil {
+			return nil, fieldError(f, err)
+		}
+		return append(buffer, proto.EncodeVarint(uint64(number))...), nil
+	case typeUint64, typeUint32:
+		number, err := toUint(value)
+		if err != nil {
+			return nil, fieldError(f, err)
+		}
+		return append(buffer, proto.EncodeVarint(number)...), nil
+	case typeSint64, typeSint32:
+		number, err := toInt(value)
+		if err != nil {
+			return nil, fieldError(f, err)
+		}
+		return append(buffer, proto.EncodeVarint(uint64(number<<1)^uint64(number>>63))...), nil
+	case typeFixed64:
+		number, err := toUint(value)
+		if err != nil {
+			return nil, fieldError(f, err)
+		}
+		return appendFixed64(buffer, number), nil
+	case typeSfixed64:
+		number, err := toInt(value)
+		if err != nil {
+			return nil, fieldError(f, err)
+		}
+		return appendFixed64(buffer, uint64(number)), nil
+	case typeFixed32:
+		number, err := toUint(value)
+		if err != nil {
+			return nil, fieldError(f, err)
+		}
+		return appendFixed32(buffer, uint32(number)), nil
+	case typeSfixed32:
+		number, err := toInt(value)
+		if err != nil {
+			return nil, fieldError(f, err)
+		}
+		return appendFixed32(buffer, uint32(int32(number))), nil
+	case typeBool:
+		boolean, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("Field %s must be a boolean", f.name)
+		}
+		if boolean {
+			return append(buffer, 1), nil
+		}
+		return append(buffer, 0), nil
+	case typeString:
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("Field %s must be a string", f.name)
+		}
+		return appendBytes(buffer, []byte(str)), nil
+	case typeBytes:
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("Field %s must be a base64 encoded string", f.name)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(str)
+		if err != nil {
+			return nil, fieldError(f, err)
+		}
+		return appendBytes(buffer, decoded), nil
+	case typeEnum:
+		if name, ok := value.(string); ok {
+			e := this.getEnum(f.typeName)
+			if e == nil {
+				return nil, fmt.Errorf("Unknown enum type %s", f.typeName)
+			}
+			number, ok := e.numbers[name]
+			if !ok {
+				return nil, fmt.Errorf("Unknown value %s for enum %s", name, f.typeName)
+			}
+			return append(buffer, proto.EncodeVarint(uint64(number))...), nil
+		}
+		number, err := toInt(value)
+		if err != nil {
+			return nil, fieldError(f, err)
+		}
+		return append(buffer, proto.EncodeVarint(uint64(number))...), nil
+	case typeMessage:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Field %s must be an object", f.name)
+		}
+		nested, err := this.getMessage(f.typeName)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := this.encodeMessage(nested, object)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(buffer, encoded), nil
+	}
+
+	return nil, fmt.Errorf("Field %s has unsupported type %d", f.name, f.kind)
+}
+
+func (this *message) fieldByNumber(number int32) *field {
+	for _, f := range this.fields {
+		if f.number == number {
+			return f
+		}
+	}
+	return nil
+}
+
+// wireValue - a single value read from the wire, either a number or bytes depending on the wire type
+type wireValue struct {
+	number uint64
+	bytes  []byte
+}
+
+func readValue(wireType int, data []byte) (wireValue, []byte, error) {
+	switch wireType {
+	case wireVarint:
+		number, n := proto.DecodeVarint(data)
+		if n == 0 {
+			return wireValue{}, nil, fmt.Errorf("truncated varint")
+		}
+		return wireValue{number: number}, data[n:], nil
+	case wireFixed64:
+		if len(data) < 8 {
+			return wireValue{}, nil, fmt.Errorf("truncated fixed64")
+		}
+		return wireValue{number: binary.LittleEndian.Uint64(data)}, data[8:], nil
+	case wireFixed32:
+		if len(data) < 4 {
+			return wireValue{}, nil, fmt.Errorf("truncated fixed32")
+		}
+		return wireValue{number: uint64(binary.LittleEndian.Uint32(data))}, data[4:], nil
+	case wireBytes:
+		length, n := proto.DecodeVarint(data)
+		if n == 0 || uint64(len(data)-n) < length {
+			return wireValue{}, nil, fmt.Errorf("truncated length delimited field")
+		}
+		end := n + int(length)
+		return wireValue{bytes: data[n:end]}, data[end:], nil
+	}
+
+	return wireValue{}, nil, fmt.Errorf("unsupported wire type %d", wireType)
+}
+
+func wireTypeOf(kind int32) int {
+	switch kind {
+	case typeDouble, typeFixed64, typeSfixed64:
+		return wireFixed64
+	case typeFloat, typeFixed32, typeSfixed32:
+		return wireFixed32
+	case typeString, typeBytes, typeMessage:
+		return wireBytes
+	case typeGroup:
+		return wireStartGroup
+	}
+	return wireVarint
+}
+
+func isPackable(kind int32) bool {
+	return wireTypeOf(kind) != wireBytes && kind != typeGroup
+}
+
+func defaultValue(f *field) interface{} {
+	if f == nil {
+		return nil
+	}
+
+	switch f.kind {
+	case typeString, typeBytes:
+		return ""
+	case typeBool:
+		return false
+	case typeInt64, typeUint64, typeSint64, typeFixed64, typeSfixed64:
+		return "0"
+	case typeMessage:
+		return map[string]interface{}{}
+	}
+	return int64(0)
+}
+
+func floatValue(number float64, bitSize int) interface{} {
+	switch {
+	case math.IsNaN(number):
+		return "NaN"
+	case math.IsInf(number, 1):
+		return "Infinity"
+	case math.IsInf(number, -1):
+		return "-Infinity"
+	}
+	return json.Number(strconv.FormatFloat(number, 'g', -1, bitSize))
+}
+
+func appendTag(buffer []byte, number int32, wireType int) []byte {
+	return append(buffer, proto.EncodeVarint(uint64(number)<<3|uint64(wireType))...)
+}
+
+func appendBytes(buffer []byte, data []byte) []byte {
+	buffer = append(buffer, proto.EncodeVarint(uint64(len(data)))...)
+	return append(buffer, data...)
+}
+
+func appendFixed64(buffer []byte, number uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], number)
+	return append(buffer, b[:]...)
+}
+
+func appendFixed32(buffer []byte, number uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], number)
+	return append(buffer, b[:]...)
+}
+
+func toInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return strconv.ParseInt(v.String(), 10, 64)
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case float64:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	}
+	return 0, fmt.Errorf("expected a number, got %v", value)
+}
+
+func toUint(value interface{}) (uint64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return strconv.ParseUint(v.String(), 10, 64)
+	case string:
+		return strconv.ParseUint(v, 10, 64)
+	case float64:
+		return uint64(v), nil
+	case int64:
+		return uint64(v), nil
+	}
+	return 0, fmt.Errorf("expected a number, got %v", value)
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.Float64()
+	case string:
+		switch v {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		return strconv.ParseFloat(v, 64)
+	case float64:
+		return v, nil
+	}
+	return 0, fmt.Errorf("expected a number, got %v", value)
+}
+
+func fieldError(f *field, err error) error {
+	return fmt.Errorf("Invalid value for field %s: %s", f.name, err.Error())
+}
@@ -0,0 +1,175 @@
+package grpc
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func sayHello() *Method {
+	registry := NewRegistry()
+	if err := registry.AddDescriptorSet(helloworldDescriptorSet()); err != nil {
+		panic(err)
+	}
+
+	return registry.GetMethod("/helloworld.Greeter/SayHello")
+}
+
+func Test_Method_DecodeRequest_RendersMessageAsJSON(t *testing.T) {
+	RegisterTestingT(t)
+
+	// name: "Ben", times: 3
+	body := AppendFrame(nil, []byte{0x0a, 0x03, 'B', 'e', 'n', 0x10, 0x03})
+
+	decoded, err := sayHello().DecodeRequest(body, "")
+	Expect(err).To(BeNil())
+	Expect(decoded).To(MatchJSON(`{"name": "Ben", "times": 3}`))
+}
+
+func Test_Method_DecodeRequest_SkipsUnknownFields(t *testing.T) {
+	RegisterTestingT(t)
+
+	// name: "Ben", field 99: 1
+	body := AppendFrame(nil, []byte{0x0a, 0x03, 'B', 'e', 'n', 0x98, 0x06, 0x01})
+
+	decoded, err := sayHello().DecodeRequest(body, "")
+	Expect(err).To(BeNil())
+	Expect(decoded).To(MatchJSON(`{"name": "Ben"}`))
+}
+
+func Test_Method_DecodeRequest_ReturnsEmptyStringForEmptyBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	decoded, err := sayHello().DecodeRequest([]byte{}, "")
+	Expect(err).To(BeNil())
+	Expect(decoded).To(Equal(""))
+}
+
+func Test_Method_DecodeRequest_ErrorsOnMultipleMessagesForUnaryMethod(t *testing.T) {
+	RegisterTestingT(t)
+
+	body := AppendFrame(AppendFrame(nil, []byte{0x10, 0x01}), []byte{0x10, 0x02})
+
+	_, err := sayHello().DecodeRequest(body, "")
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Expected a single helloworld.HelloRequest message, got 2"))
+}
+
+func Test_Method_DecodeRequest_ErrorsOnMalformedMessage(t *testing.T) {
+	RegisterTestingT(t)
+
+	body := AppendFrame(nil, []byte{0x0a, 0x10, 'B'})
+
+	_, err := sayHello().DecodeRequest(body, "")
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(ContainSubstring("Malformed helloworld.HelloRequest message"))
+}
+
+func Test_Method_EncodeRequest_CanRoundTripAllFieldTypes(t *testing.T) {
+	RegisterTestingT(t)
+
+	request := `{
+		"name": "Ben",
+		"times": -3,
+		"tags": ["a", "b"],
+		"mood": "HAPPY",
+		"labels": {"env": "test", "team": "core"},
+		"requestId": "9007199254740993",
+		"ratio": 0.5,
+		"sender": {"name": "Tommy"},
+		"payload": "aGVsbG8=",
+		"scores": [1, -2, 300],
+		"urgent": true
+	}`
+
+	unit := sayHello()
+
+	encoded, err := unit.EncodeRequest(request)
+	Expect(err).To(BeNil())
+
+	decoded, err := unit.DecodeRequest(encoded, "")
+	Expect(err).To(BeNil())
+	Expect(decoded).To(MatchJSON(request))
+}
+
+func Test_Method_EncodeRequest_AcceptsOriginalFieldNames(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := sayHello()
+
+	encoded, err := unit.EncodeRequest(`{"request_id": 12}`)
+	Expect(err).To(BeNil())
+
+	decoded, err := unit.DecodeRequest(encoded, "")
+	Expect(err).To(BeNil())
+	Expect(decoded).To(MatchJSON(`{"requestId": "12"}`))
+}
+
+func Test_Method_EncodeRequest_PacksRepeatedScalarsInProto3(t *testing.T) {
+	RegisterTestingT(t)
+
+	encoded, err := sayHello().EncodeRequest(`{"scores": [1, -1]}`)
+	Expect(err).To(BeNil())
+
+	// field 10 length delimited, zig zag encoded 1 and -1
+	Expect(encoded).To(Equal(AppendFrame(nil, []byte{0x52, 0x02, 0x02, 0x01})))
+}
+
+func Test_Method_EncodeRequest_ErrorsOnUnknownField(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := sayHello().EncodeRequest(`{"surname": "Ben"}`)
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Unknown field surname in helloworld.HelloRequest"))
+}
+
+func Test_Method_EncodeRequest_ErrorsOnUnknownEnumValue(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := sayHello().EncodeRequest(`{"mood": "GRUMPY"}`)
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Unknown value GRUMPY for enum helloworld.Mood"))
+}
+
+func Test_Method_EncodeRequest_ErrorsOnInvalidJSON(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := sayHello().EncodeRequest(`{"name": `)
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(ContainSubstring("Invalid JSON for helloworld.HelloRequest"))
+}
+
+func Test_Method_EncodeResponse_EncodesArrayForServerStreamingMethod(t *testing.T) {
+	RegisterTestingT(t)
+
+	registry := NewRegistry()
+	Expect(registry.AddDescriptorSet(helloworldDescriptorSet())).To(Succeed())
+	unit := registry.GetMethod("/helloworld.Greeter/StreamHellos")
+
+	encoded, err := unit.EncodeResponse(`[{"message": "one"}, {"message": "two"}]`)
+	Expect(err).To(BeNil())
+
+	frames, err := ReadFrames(encoded, "")
+	Expect(err).To(BeNil())
+	Expect(frames).To(HaveLen(2))
+
+	decoded, err := unit.DecodeResponse(encoded, "")
+	Expect(err).To(BeNil())
+	Expect(decoded).To(MatchJSON(`[{"message": "one"}, {"message": "two"}]`))
+}
+
+func Test_Method_EncodeResponse_ErrorsOnArrayForUnaryMethod(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := sayHello().EncodeResponse(`[{"message": "one"}]`)
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Expected a single helloworld.HelloReply message, got an array"))
+}
+
+func Test_Method_EncodeResponse_ReturnsNoMessagesForEmptyBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	encoded, err := sayHello().EncodeResponse("")
+	Expect(err).To(BeNil())
+	Expect(encoded).To(BeEmpty())
+}
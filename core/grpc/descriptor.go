@@ -0,0 +1,273 @@
+package grpc
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// The types below are a minimal subset of google/protobuf/descriptor.proto, only holding
+// what is needed to encode and decode messages dynamically. Fields which are not declared
+// here are skipped when unmarshalling a descriptor set.
+
+// Field types as defined in FieldDescriptorProto.Type
+const (
+	typeDouble   = 1
+	typeFloat    = 2
+	typeInt64    = 3
+	typeUint64   = 4
+	typeInt32    = 5
+	typeFixed64  = 6
+	typeFixed32  = 7
+	typeBool     = 8
+	typeString   = 9
+	typeGroup    = 10
+	typeMessage  = 11
+	typeBytes    = 12
+	typeUint32   = 13
+	typeEnum     = 14
+	typeSfixed32 = 15
+	typeSfixed64 = 16
+	typeSint32   = 17
+	typeSint64   = 18
+)
+
+// Field labels as defined in FieldDescriptorProto.Label
+const (
+	labelOptional = 1
+	labelRequired = 2
+	labelRepeated = 3
+)
+
+type FileDescriptorSet struct {
+	File []*FileDescriptorProto `protobuf:"bytes,1,rep,name=file"`
+}
+
+func (m *FileDescriptorSet) Reset()         { *m = FileDescriptorSet{} }
+func (m *FileDescriptorSet) String() string { return proto.CompactTextString(m) }
+func (*FileDescriptorSet) ProtoMessage()    {}
+
+type FileDescriptorProto struct {
+	Name        *string                   `protobuf:"bytes,1,opt,name=name"`
+	Package     *string                   `protobuf:"bytes,2,opt,name=package"`
+	MessageType []*DescriptorProto        `protobuf:"bytes,4,rep,name=message_type"`
+	EnumType    []*EnumDescriptorProto    `protobuf:"bytes,5,rep,name=enum_type"`
+	Service     []*ServiceDescriptorProto `protobuf:"bytes,6,rep,name=service"`
+	Syntax      *string                   `protobuf:"bytes,12,opt,name=syntax"`
+}
+
+func (m *FileDescriptorProto) Reset()         { *m = FileDescriptorProto{} }
+func (m *FileDescriptorProto) String() string { return proto.CompactTextString(m) }
+func (*FileDescriptorProto) ProtoMessage()    {}
+
+func (m *FileDescriptorProto) GetPackage() string {
+	if m != nil && m.Package != nil {
+		return *m.Package
+	}
+	return ""
+}
+
+func (m *FileDescriptorProto) GetSyntax() string {
+	if m != nil && m.Syntax != nil {
+		return *m.Syntax
+	}
+	return ""
+}
+
+type DescriptorProto struct {
+	Name       *string                 `protobuf:"bytes,1,opt,name=name"`
+	Field      []*FieldDescriptorProto `protobuf:"bytes,2,rep,name=field"`
+	NestedType []*DescriptorProto      `protobuf:"bytes,3,rep,name=nested_type"`
+	EnumType   []*EnumDescriptorProto  `protobuf:"bytes,4,rep,name=enum_type"`
+	Options    *MessageOptions         `protobuf:"bytes,7,opt,name=options"`
+}
+
+func (m *DescriptorProto) Reset()         { *m = DescriptorProto{} }
+func (m *DescriptorProto) String() string { return proto.CompactTextString(m) }
+func (*DescriptorProto) ProtoMessage()    {}
+
+func (m *DescriptorProto) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+type MessageOptions struct {
+	MapEntry *bool `protobuf:"varint,7,opt,name=map_entry"`
+}
+
+func (m *MessageOptions) Reset()         { *m = MessageOptions{} }
+func (m *MessageOptions) String() string { return proto.CompactTextString(m) }
+func (*MessageOptions) ProtoMessage()    {}
+
+func (m *MessageOptions) GetMapEntry() bool {
+	if m != nil && m.MapEntry != nil {
+		return *m.MapEntry
+	}
+	return false
+}
+
+type FieldDescriptorProto struct {
+	Name     *string       `protobuf:"bytes,1,opt,name=name"`
+	Number   *int32        `protobuf:"varint,3,opt,name=number"`
+	Label    *int32        `protobuf:"varint,4,opt,name=label"`
+	Type     *int32        `protobuf:"varint,5,opt,name=type"`
+	TypeName *string       `protobuf:"bytes,6,opt,name=type_name"`
+	Options  *FieldOptions `protobuf:"bytes,8,opt,name=options"`
+	JsonName *string       `protobuf:"bytes,10,opt,name=json_name"`
+}
+
+func (m *FieldDescriptorProto) Reset()         { *m = FieldDescriptorProto{} }
+func (m *FieldDescriptorProto) String() string { return proto.CompactTextString(m) }
+func (*FieldDescriptorProto) ProtoMessage()    {}
+
+func (m *FieldDescriptorProto) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *FieldDescriptorProto) GetNumber() int32 {
+	if m != nil && m.Number != nil {
+		return *m.Number
+	}
+	return 0
+}
+
+func (m *FieldDescriptorProto) GetLabel() int32 {
+	if m != nil && m.Label != nil {
+		return *m.Label
+	}
+	return 0
+}
+
+func (m *FieldDescriptorProto) GetType() int32 {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return 0
+}
+
+func (m *FieldDescriptorProto) GetTypeName() string {
+	if m != nil && m.TypeName != nil {
+		return *m.TypeName
+	}
+	return ""
+}
+
+func (m *FieldDescriptorProto) GetJsonName() string {
+	if m != nil && m.JsonName != nil {
+		return *m.JsonName
+	}
+	return ""
+}
+
+type FieldOptions struct {
+	Packed *bool `protobuf:"varint,2,opt,name=packed"`
+}
+
+func (m *FieldOptions) Reset()         { *m = FieldOptions{} }
+func (m *FieldOptions) String() string { return proto.CompactTextString(m) }
+func (*FieldOptions) ProtoMessage()    {}
+
+type EnumDescriptorProto struct {
+	Name  *string                     `protobuf:"bytes,1,opt,name=name"`
+	Value []*EnumValueDescriptorProto `protobuf:"bytes,2,rep,name=value"`
+}
+
+func (m *EnumDescriptorProto) Reset()         { *m = EnumDescriptorProto{} }
+func (m *EnumDescriptorProto) String() string { return proto.CompactTextString(m) }
+func (*EnumDescriptorProto) ProtoMessage()    {}
+
+func (m *EnumDescriptorProto) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+type EnumValueDescriptorProto struct {
+	Name   *string `protobuf:"bytes,1,opt,name=name"`
+	Number *int32  `protobuf:"varint,2,opt,name=number"`
+}
+
+func (m *EnumValueDescriptorProto) Reset()         { *m = EnumValueDescriptorProto{} }
+func (m *EnumValueDescriptorProto) String() string { return proto.CompactTextString(m) }
+func (*EnumValueDescriptorProto) ProtoMessage()    {}
+
+func (m *EnumValueDescriptorProto) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *EnumValueDescriptorProto) GetNumber() int32 {
+	if m != nil && m.Number != nil {
+		return *m.Number
+	}
+	return 0
+}
+
+type ServiceDescriptorProto struct {
+	Name   *string                  `protobuf:"bytes,1,opt,name=name"`
+	Method []*MethodDescriptorProto `protobuf:"bytes,2,rep,name=method"`
+}
+
+func (m *ServiceDescriptorProto) Reset()         { *m = ServiceDescriptorProto{} }
+func (m *ServiceDescriptorProto) String() string { return proto.CompactTextString(m) }
+func (*ServiceDescriptorProto) ProtoMessage()    {}
+
+func (m *ServiceDescriptorProto) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+type MethodDescriptorProto struct {
+	Name            *string `protobuf:"bytes,1,opt,name=name"`
+	InputType       *string `protobuf:"bytes,2,opt,name=input_type"`
+	OutputType      *string `protobuf:"bytes,3,opt,name=output_type"`
+	ClientStreaming *bool   `protobuf:"varint,5,opt,name=client_streaming"`
+	ServerStreaming *bool   `protobuf:"varint,6,opt,name=server_streaming"`
+}
+
+func (m *MethodDescriptorProto) Reset()         { *m = MethodDescriptorProto{} }
+func (m *MethodDescriptorProto) String() string { return proto.CompactTextString(m) }
+func (*MethodDescriptorProto) ProtoMessage()    {}
+
+func (m *MethodDescriptorProto) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *MethodDescriptorProto) GetInputType() string {
+	if m != nil && m.InputType != nil {
+		return *m.InputType
+	}
+	return ""
+}
+
+func (m *MethodDescriptorProto) GetOutputType() string {
+	if m != nil && m.OutputType != nil {
+		return *m.OutputType
+	}
+	return ""
+}
+
+func (m *MethodDescriptorProto) GetClientStreaming() bool {
+	if m != nil && m.ClientStreaming != nil {
+		return *m.ClientStreaming
+	}
+	return false
+}
+
+func (m *MethodDescriptorProto) GetServerStreaming() bool {
+	if m != nil && m.ServerStreaming != nil {
+		return *m.ServerStreaming
+	}
+	return false
+}
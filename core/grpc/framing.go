@@ -0,0 +1,165 @@
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+)
+
+// gRPC status codes, see https://github.com/grpc/grpc/blob/master/doc/statuscodes.md
+const (
+	StatusOK               = 0
+	StatusUnknown          = 2
+	StatusDeadlineExceeded = 4
+	StatusPermissionDenied = 7
+	StatusUnimplemented    = 12
+	StatusInternal         = 13
+	StatusUnavailable      = 14
+	StatusUnauthenticated  = 16
+)
+
+const (
+	frameHeaderLength      = 5
+	compressedFlag    byte = 1
+)
+
+// IsGrpcContentType - returns true for application/grpc and its +proto variants
+func IsGrpcContentType(contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	if !strings.HasPrefix(contentType, "application/grpc") {
+		return false
+	}
+
+	rest := strings.TrimPrefix(contentType, "application/grpc")
+	return rest == "" || strings.HasPrefix(rest, ";") || strings.HasPrefix(rest, "+proto")
+}
+
+// ReadFrames - splits a body into length-prefixed messages, decompressing them when
+// the compressed flag is set and the body was sent with gzip grpc-encoding
+func ReadFrames(body []byte, encoding string) ([][]byte, error) {
+	frames := [][]byte{}
+
+	for len(body) > 0 {
+		if len(body) < frameHeaderLength {
+			return nil, fmt.Errorf("Malformed gRPC message: truncated message header")
+		}
+
+		length := binary.BigEndian.Uint32(body[1:frameHeaderLength])
+		if uint64(len(body)-frameHeaderLength) < uint64(length) {
+			return nil, fmt.Errorf("Malformed gRPC message: expected %d bytes, got %d", length, len(body)-frameHeaderLength)
+		}
+
+		frame := body[frameHeaderLength : frameHeaderLength+int(length)]
+		if body[0] == compressedFlag {
+			var err error
+			if frame, err = decompress(frame, encoding); err != nil {
+				return nil, err
+			}
+		}
+
+		frames = append(frames, frame)
+		body = body[frameHeaderLength+int(length):]
+	}
+
+	return frames, nil
+}
+
+// ReadFrame - reads the next length-prefixed message from a stream, returning io.EOF once
+// the stream ends between messages
+func ReadFrame(reader io.Reader, encoding string) ([]byte, error) {
+	var header [frameHeaderLength]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("Malformed gRPC message: truncated message header")
+		}
+		return nil, err
+	}
+
+	frame := make([]byte, binary.BigEndian.Uint32(header[1:]))
+	if _, err := io.ReadFull(reader, frame); err != nil {
+		return nil, fmt.Errorf("Malformed gRPC message: expected %d bytes", len(frame))
+	}
+
+	if header[0] == compressedFlag {
+		return decompress(frame, encoding)
+	}
+
+	return frame, nil
+}
+
+// AppendFrame - appends an uncompressed length-prefixed message to the buffer
+func AppendFrame(buffer []byte, message []byte) []byte {
+	var header [frameHeaderLength]byte
+	binary.BigEndian.PutUint32(header[1:], uint32(len(message)))
+
+	buffer = append(buffer, header[:]...)
+	return append(buffer, message...)
+}
+
+// StatusFromHTTP - maps HTTP status codes to gRPC status codes for responses which aren't gRPC,
+// as described in https://github.com/grpc/grpc/blob/master/doc/http-grpc-status-mapping.md
+func StatusFromHTTP(status int) int {
+	switch status {
+	case 400:
+		return StatusInternal
+	case 401:
+		return StatusUnauthenticated
+	case 403:
+		return StatusPermissionDenied
+	case 404:
+		return StatusUnimplemented
+	case 429, 502, 503, 504:
+		return StatusUnavailable
+	}
+
+	return StatusUnknown
+}
+
+// EncodeStatusMessage - percent encodes a grpc-message trailer value
+func EncodeStatusMessage(message string) string {
+	var buffer bytes.Buffer
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			buffer.WriteByte(c)
+		} else {
+			fmt.Fprintf(&buffer, "%%%02X", c)
+		}
+	}
+
+	return buffer.String()
+}
+
+// DecodeStatusMessage - decodes a percent encoded grpc-message trailer value,
+// returning it unchanged if it isn't valid percent encoding
+func DecodeStatusMessage(message string) string {
+	if !strings.Contains(message, "%") {
+		return message
+	}
+
+	decoded, err := url.PathUnescape(message)
+	if err != nil {
+		return message
+	}
+
+	return decoded
+}
+
+func decompress(frame []byte, encoding string) ([]byte, error) {
+	if encoding != "gzip" {
+		return nil, fmt.Errorf("Unsupported gRPC message encoding: %s", encoding)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(frame))
+	if err != nil {
+		return nil, fmt.Errorf("Malformed gRPC message: %s", err.Error())
+	}
+	defer reader.Close()
+
+	return ioutil.ReadAll(reader)
+}
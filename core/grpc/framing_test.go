@@ -0,0 +1,116 @@
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_IsGrpcContentType(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(IsGrpcContentType("application/grpc")).To(BeTrue())
+	Expect(IsGrpcContentType("application/grpc+proto")).To(BeTrue())
+	Expect(IsGrpcContentType("application/grpc; charset=utf-8")).To(BeTrue())
+	Expect(IsGrpcContentType("Application/GRPC")).To(BeTrue())
+
+	Expect(IsGrpcContentType("application/grpc-web")).To(BeFalse())
+	Expect(IsGrpcContentType("application/grpc+json")).To(BeFalse())
+	Expect(IsGrpcContentType("application/json")).To(BeFalse())
+	Expect(IsGrpcContentType("")).To(BeFalse())
+}
+
+func Test_ReadFrames_SplitsLengthPrefixedMessages(t *testing.T) {
+	RegisterTestingT(t)
+
+	body := AppendFrame(AppendFrame(nil, []byte("one")), []byte("three"))
+	Expect(body).To(Equal([]byte{0, 0, 0, 0, 3, 'o', 'n', 'e', 0, 0, 0, 0, 5, 't', 'h', 'r', 'e', 'e'}))
+
+	frames, err := ReadFrames(body, "")
+	Expect(err).To(BeNil())
+	Expect(frames).To(Equal([][]byte{[]byte("one"), []byte("three")}))
+}
+
+func Test_ReadFrames_DecompressesGzipMessages(t *testing.T) {
+	RegisterTestingT(t)
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte("hello"))
+	writer.Close()
+
+	body := AppendFrame(nil, compressed.Bytes())
+	body[0] = 1
+
+	frames, err := ReadFrames(body, "gzip")
+	Expect(err).To(BeNil())
+	Expect(frames).To(Equal([][]byte{[]byte("hello")}))
+}
+
+func Test_ReadFrames_ErrorsOnUnsupportedCompression(t *testing.T) {
+	RegisterTestingT(t)
+
+	body := AppendFrame(nil, []byte("hello"))
+	body[0] = 1
+
+	_, err := ReadFrames(body, "snappy")
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Unsupported gRPC message encoding: snappy"))
+}
+
+func Test_ReadFrames_ErrorsOnTruncatedMessage(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := ReadFrames([]byte{0, 0, 0, 0, 5, 'o', 'n', 'e'}, "")
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Malformed gRPC message: expected 5 bytes, got 3"))
+
+	_, err = ReadFrames([]byte{0, 0}, "")
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Malformed gRPC message: truncated message header"))
+}
+
+func Test_ReadFrame_ReadsMessagesFromStreamUntilEOF(t *testing.T) {
+	RegisterTestingT(t)
+
+	reader := bytes.NewReader(AppendFrame(AppendFrame(nil, []byte("one")), []byte("three")))
+
+	frame, err := ReadFrame(reader, "")
+	Expect(err).To(BeNil())
+	Expect(frame).To(Equal([]byte("one")))
+
+	frame, err = ReadFrame(reader, "")
+	Expect(err).To(BeNil())
+	Expect(frame).To(Equal([]byte("three")))
+
+	_, err = ReadFrame(reader, "")
+	Expect(err).To(Equal(io.EOF))
+
+	_, err = ReadFrame(bytes.NewReader([]byte{0, 0, 0, 0, 5, 'o', 'n', 'e'}), "")
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Malformed gRPC message: expected 5 bytes"))
+}
+
+func Test_StatusFromHTTP(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(StatusFromHTTP(400)).To(Equal(StatusInternal))
+	Expect(StatusFromHTTP(401)).To(Equal(StatusUnauthenticated))
+	Expect(StatusFromHTTP(403)).To(Equal(StatusPermissionDenied))
+	Expect(StatusFromHTTP(404)).To(Equal(StatusUnimplemented))
+	Expect(StatusFromHTTP(502)).To(Equal(StatusUnavailable))
+	Expect(StatusFromHTTP(500)).To(Equal(StatusUnknown))
+}
+
+func Test_StatusMessage_IsPercentEncoded(t *testing.T) {
+	RegisterTestingT(t)
+
+	encoded := EncodeStatusMessage("100% café\n")
+	Expect(encoded).To(Equal("100%25 caf%C3%A9%0A"))
+	Expect(DecodeStatusMessage(encoded)).To(Equal("100% café\n"))
+
+	Expect(DecodeStatusMessage("not %zz encoded")).To(Equal("not %zz encoded"))
+}
@@ -0,0 +1,289 @@
+package grpc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Registry - holds the services, messages and enums of uploaded descriptor sets
+type Registry struct {
+	messages map[string]*message
+	enums    map[string]*enum
+	methods  map[string]*Method
+	mu       sync.RWMutex
+}
+
+// Method - gRPC method which can be encoded and decoded using the registry
+type Method struct {
+	Service         string
+	Name            string
+	InputType       string
+	OutputType      string
+	ClientStreaming bool
+	ServerStreaming bool
+
+	registry *Registry
+}
+
+type message struct {
+	name     string
+	fields   []*field
+	byName   map[string]*field
+	mapEntry bool
+	proto3   bool
+}
+
+type field struct {
+	name     string
+	jsonName string
+	number   int32
+	label    int32
+	kind     int32
+	typeName string
+	packed   bool
+}
+
+type enum struct {
+	names   map[int32]string
+	numbers map[string]int32
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		messages: make(map[string]*message),
+		enums:    make(map[string]*enum),
+		methods:  make(map[string]*Method),
+	}
+}
+
+// AddDescriptorSet - parses a serialized FileDescriptorSet, as produced by
+// `protoc --include_imports --descriptor_set_out`, and adds its services to the registry
+func (this *Registry) AddDescriptorSet(data []byte) error {
+	var set FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("Invalid descriptor set: %s", err.Error())
+	}
+
+	if len(set.File) == 0 {
+		return fmt.Errorf("Invalid descriptor set: no files found")
+	}
+
+	messages := make(map[string]*message)
+	enums := make(map[string]*enum)
+	methods := make(map[string]*Method)
+
+	for _, file := range set.File {
+		prefix := file.GetPackage()
+		proto3 := file.GetSyntax() == "proto3"
+
+		for _, descriptor := range file.MessageType {
+			addMessage(messages, enums, prefix, descriptor, proto3)
+		}
+
+		for _, descriptor := range file.EnumType {
+			addEnum(enums, prefix, descriptor)
+		}
+
+		for _, service := range file.Service {
+			serviceName := qualify(prefix, service.GetName())
+			for _, method := range service.Method {
+				methods["/"+serviceName+"/"+method.GetName()] = &Method{
+					Service:         serviceName,
+					Name:            method.GetName(),
+					InputType:       strings.TrimPrefix(method.GetInputType(), "."),
+					OutputType:      strings.TrimPrefix(method.GetOutputType(), "."),
+					ClientStreaming: method.GetClientStreaming(),
+					ServerStreaming: method.GetServerStreaming(),
+					registry:        this,
+				}
+			}
+		}
+	}
+
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	for path, method := range methods {
+		if !this.hasMessage(messages, method.InputType) || !this.hasMessage(messages, method.OutputType) {
+			return fmt.Errorf("Invalid descriptor set: types of %s are missing, make sure imports are included", path)
+		}
+	}
+
+	for name, msg := range messages {
+		this.messages[name] = msg
+	}
+	for name, e := range enums {
+		this.enums[name] = e
+	}
+	for path, method := range methods {
+		this.methods[path] = method
+	}
+
+	return nil
+}
+
+// GetMethod - returns method for given request path, eg. /helloworld.Greeter/SayHello,
+// or nil if the method is not known
+func (this *Registry) GetMethod(path string) *Method {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+
+	return this.methods[path]
+}
+
+// GetMethods - returns all known methods sorted by path
+func (this *Registry) GetMethods() []*Method {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+
+	paths := make([]string, 0, len(this.methods))
+	for path := range this.methods {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	methods := make([]*Method, 0, len(paths))
+	for _, path := range paths {
+		methods = append(methods, this.methods[path])
+	}
+
+	return methods
+}
+
+// Reset - removes all descriptor sets from the registry
+func (this *Registry) Reset() {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	this.messages = make(map[string]*message)
+	this.enums = make(map[string]*enum)
+	this.methods = make(map[string]*Method)
+}
+
+// Path - returns the HTTP/2 path the method is called on
+func (this Method) Path() string {
+	return "/" + this.Service + "/" + this.Name
+}
+
+func (this *Registry) getMessage(name string) (*message, error) {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+
+	msg, ok := this.messages[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown message type %s", name)
+	}
+
+	return msg, nil
+}
+
+func (this *Registry) hasMessage(added map[string]*message, name string) bool {
+	return added[name] != nil || this.messages[name] != nil
+}
+
+func (this *Registry) getEnum(name string) *enum {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+
+	return this.enums[name]
+}
+
+func addMessage(messages map[string]*message, enums map[string]*enum, prefix string, descriptor *DescriptorProto, proto3 bool) {
+	name := qualify(prefix, descriptor.GetName())
+
+	msg := &message{
+		name:     name,
+		byName:   make(map[string]*field),
+		mapEntry: descriptor.Options.GetMapEntry(),
+		proto3:   proto3,
+	}
+
+	for _, fieldDescriptor := range descriptor.Field {
+		f := &field{
+			name:     fieldDescriptor.GetName(),
+			jsonName: fieldDescriptor.GetJsonName(),
+			number:   fieldDescriptor.GetNumber(),
+			label:    fieldDescriptor.GetLabel(),
+			kind:     fieldDescriptor.GetType(),
+			typeName: strings.TrimPrefix(fieldDescriptor.GetTypeName(), "."),
+		}
+
+		if f.jsonName == "" {
+			f.jsonName = toJsonName(f.name)
+		}
+
+		// repeated scalars are packed by default in proto3
+		if fieldDescriptor.Options != nil && fieldDescriptor.Options.Packed != nil {
+			f.packed = *fieldDescriptor.Options.Packed
+		} else {
+			f.packed = proto3
+		}
+
+		msg.fields = append(msg.fields, f)
+		msg.byName[f.name] = f
+		msg.byName[f.jsonName] = f
+	}
+
+	sort.Slice(msg.fields, func(i, j int) bool {
+		return msg.fields[i].number < msg.fields[j].number
+	})
+
+	messages[name] = msg
+
+	for _, nested := range descriptor.NestedType {
+		addMessage(messages, enums, name, nested, proto3)
+	}
+
+	for _, nested := range descriptor.EnumType {
+		addEnum(enums, name, nested)
+	}
+}
+
+func addEnum(enums map[string]*enum, prefix string, descriptor *EnumDescriptorProto) {
+	e := &enum{
+		names:   make(map[int32]string),
+		numbers: make(map[string]int32),
+	}
+
+	for _, value := range descriptor.Value {
+		// the first name wins when enum values are aliased
+		if _, ok := e.names[value.GetNumber()]; !ok {
+			e.names[value.GetNumber()] = value.GetName()
+		}
+		e.numbers[value.GetName()] = value.GetNumber()
+	}
+
+	enums[qualify(prefix, descriptor.GetName())] = e
+}
+
+func qualify(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "." + name
+}
+
+// toJsonName - converts field name to lowerCamelCase the same way protoc does
+func toJsonName(name string) string {
+	var result []byte
+	upper := false
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c == '_' {
+			upper = true
+		} else if upper && 'a' <= c && c <= 'z' {
+			result = append(result, c-'a'+'A')
+			upper = false
+		} else {
+			result = append(result, c)
+			upper = false
+		}
+	}
+
+	return string(result)
+}
@@ -0,0 +1,189 @@
+package grpc
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	. "github.com/onsi/gomega"
+)
+
+func fieldDescriptor(name string, number int32, label int32, kind int32, typeName string) *FieldDescriptorProto {
+	descriptor := &FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Label:  proto.Int32(label),
+		Type:   proto.Int32(kind),
+	}
+
+	if typeName != "" {
+		descriptor.TypeName = proto.String(typeName)
+	}
+
+	return descriptor
+}
+
+func helloworldDescriptorSet() []byte {
+	file := &FileDescriptorProto{
+		Name:    proto.String("helloworld.proto"),
+		Package: proto.String("helloworld"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*DescriptorProto{
+			&DescriptorProto{
+				Name: proto.String("HelloRequest"),
+				Field: []*FieldDescriptorProto{
+					fieldDescriptor("name", 1, labelOptional, typeString, ""),
+					fieldDescriptor("times", 2, labelOptional, typeInt32, ""),
+					fieldDescriptor("tags", 3, labelRepeated, typeString, ""),
+					fieldDescriptor("mood", 4, labelOptional, typeEnum, ".helloworld.Mood"),
+					fieldDescriptor("labels", 5, labelRepeated, typeMessage, ".helloworld.HelloRequest.LabelsEntry"),
+					fieldDescriptor("request_id", 6, labelOptional, typeInt64, ""),
+					fieldDescriptor("ratio", 7, labelOptional, typeFloat, ""),
+					fieldDescriptor("sender", 8, labelOptional, typeMessage, ".helloworld.Person"),
+					fieldDescriptor("payload", 9, labelOptional, typeBytes, ""),
+					fieldDescriptor("scores", 10, labelRepeated, typeSint32, ""),
+					fieldDescriptor("urgent", 11, labelOptional, typeBool, ""),
+				},
+				NestedType: []*DescriptorProto{
+					&DescriptorProto{
+						Name: proto.String("LabelsEntry"),
+						Field: []*FieldDescriptorProto{
+							fieldDescriptor("key", 1, labelOptional, typeString, ""),
+							fieldDescriptor("value", 2, labelOptional, typeString, ""),
+						},
+						Options: &MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+			&DescriptorProto{
+				Name: proto.String("Person"),
+				Field: []*FieldDescriptorProto{
+					fieldDescriptor("name", 1, labelOptional, typeString, ""),
+				},
+			},
+			&DescriptorProto{
+				Name: proto.String("HelloReply"),
+				Field: []*FieldDescriptorProto{
+					fieldDescriptor("message", 1, labelOptional, typeString, ""),
+				},
+			},
+		},
+		EnumType: []*EnumDescriptorProto{
+			&EnumDescriptorProto{
+				Name: proto.String("Mood"),
+				Value: []*EnumValueDescriptorProto{
+					&EnumValueDescriptorProto{Name: proto.String("NEUTRAL"), Number: proto.Int32(0)},
+					&EnumValueDescriptorProto{Name: proto.String("HAPPY"), Number: proto.Int32(1)},
+				},
+			},
+		},
+		Service: []*ServiceDescriptorProto{
+			&ServiceDescriptorProto{
+				Name: proto.String("Greeter"),
+				Method: []*MethodDescriptorProto{
+					&MethodDescriptorProto{
+						Name:       proto.String("SayHello"),
+						InputType:  proto.String(".helloworld.HelloRequest"),
+						OutputType: proto.String(".helloworld.HelloReply"),
+					},
+					&MethodDescriptorProto{
+						Name:            proto.String("StreamHellos"),
+						InputType:       proto.String(".helloworld.HelloRequest"),
+						OutputType:      proto.String(".helloworld.HelloReply"),
+						ServerStreaming: proto.Bool(true),
+					},
+				},
+			},
+		},
+	}
+
+	data, err := proto.Marshal(&FileDescriptorSet{File: []*FileDescriptorProto{file}})
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
+
+func Test_Registry_AddDescriptorSet_AddsMethods(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewRegistry()
+
+	Expect(unit.AddDescriptorSet(helloworldDescriptorSet())).To(Succeed())
+
+	methods := unit.GetMethods()
+	Expect(methods).To(HaveLen(2))
+
+	Expect(methods[0].Path()).To(Equal("/helloworld.Greeter/SayHello"))
+	Expect(methods[0].Service).To(Equal("helloworld.Greeter"))
+	Expect(methods[0].InputType).To(Equal("helloworld.HelloRequest"))
+	Expect(methods[0].OutputType).To(Equal("helloworld.HelloReply"))
+	Expect(methods[0].ServerStreaming).To(BeFalse())
+
+	Expect(methods[1].Path()).To(Equal("/helloworld.Greeter/StreamHellos"))
+	Expect(methods[1].ServerStreaming).To(BeTrue())
+}
+
+func Test_Registry_GetMethod_ReturnsNilForUnknownMethod(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewRegistry()
+	Expect(unit.AddDescriptorSet(helloworldDescriptorSet())).To(Succeed())
+
+	Expect(unit.GetMethod("/helloworld.Greeter/SayHello")).ToNot(BeNil())
+	Expect(unit.GetMethod("/helloworld.Greeter/SayGoodbye")).To(BeNil())
+}
+
+func Test_Registry_AddDescriptorSet_ErrorsOnInvalidDescriptorSet(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewRegistry()
+
+	err := unit.AddDescriptorSet([]byte{0xff, 0xff})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(ContainSubstring("Invalid descriptor set"))
+}
+
+func Test_Registry_AddDescriptorSet_ErrorsWhenMethodTypesAreMissing(t *testing.T) {
+	RegisterTestingT(t)
+
+	data, _ := proto.Marshal(&FileDescriptorSet{
+		File: []*FileDescriptorProto{
+			&FileDescriptorProto{
+				Name:    proto.String("service.proto"),
+				Package: proto.String("helloworld"),
+				Service: []*ServiceDescriptorProto{
+					&ServiceDescriptorProto{
+						Name: proto.String("Greeter"),
+						Method: []*MethodDescriptorProto{
+							&MethodDescriptorProto{
+								Name:       proto.String("SayHello"),
+								InputType:  proto.String(".helloworld.HelloRequest"),
+								OutputType: proto.String(".helloworld.HelloReply"),
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	unit := NewRegistry()
+
+	err := unit.AddDescriptorSet(data)
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Invalid descriptor set: types of /helloworld.Greeter/SayHello are missing, make sure imports are included"))
+	Expect(unit.GetMethods()).To(HaveLen(0))
+}
+
+func Test_Registry_Reset_RemovesMethods(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewRegistry()
+	Expect(unit.AddDescriptorSet(helloworldDescriptorSet())).To(Succeed())
+
+	unit.Reset()
+
+	Expect(unit.GetMethods()).To(HaveLen(0))
+	Expect(unit.GetMethod("/helloworld.Greeter/SayHello")).To(BeNil())
+}
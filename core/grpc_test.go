@@ -0,0 +1,179 @@
+package hoverfly
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/grpc"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/util"
+	"github.com/golang/protobuf/proto"
+	. "github.com/onsi/gomega"
+)
+
+func greeterDescriptorSet() []byte {
+	stringField := func(name string) []*grpc.FieldDescriptorProto {
+		return []*grpc.FieldDescriptorProto{
+			&grpc.FieldDescriptorProto{
+				Name:   proto.String(name),
+				Number: proto.Int32(1),
+				Label:  proto.Int32(1),
+				Type:   proto.Int32(9),
+			},
+		}
+	}
+
+	data, _ := proto.Marshal(&grpc.FileDescriptorSet{
+		File: []*grpc.FileDescriptorProto{
+			&grpc.FileDescriptorProto{
+				Name:    proto.String("helloworld.proto"),
+				Package: proto.String("helloworld"),
+				Syntax:  proto.String("proto3"),
+				MessageType: []*grpc.DescriptorProto{
+					&grpc.DescriptorProto{Name: proto.String("HelloRequest"), Field: stringField("name")},
+					&grpc.DescriptorProto{Name: proto.String("HelloReply"), Field: stringField("message")},
+				},
+				Service: []*grpc.ServiceDescriptorProto{
+					&grpc.ServiceDescriptorProto{
+						Name: proto.String("Greeter"),
+						Method: []*grpc.MethodDescriptorProto{
+							&grpc.MethodDescriptorProto{
+								Name:       proto.String("SayHello"),
+								InputType:  proto.String(".helloworld.HelloRequest"),
+								OutputType: proto.String(".helloworld.HelloReply"),
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	return data
+}
+
+func newGrpcRequest(name string) *http.Request {
+	body := grpc.AppendFrame(nil, append([]byte{0x0a, byte(len(name))}, name...))
+
+	request, _ := http.NewRequest("POST", "http://greeter.com/helloworld.Greeter/SayHello", bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/grpc")
+	request.Header.Set("Te", "trailers")
+
+	return request
+}
+
+func Test_Hoverfly_processRequest_SimulatesGrpcMethod(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(200, "")
+	defer server.Close()
+
+	Expect(unit.Grpc.AddDescriptorSet(greeterDescriptorSet())).To(Succeed())
+
+	unit.Simulation.AddRequestMatcherResponsePair(&models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Path: &models.RequestFieldMatchers{
+				ExactMatch: util.StringToPointer("/helloworld.Greeter/SayHello"),
+			},
+			Body: &models.RequestFieldMatchers{
+				JsonMatch: util.StringToPointer(`{"name": "Ben"}`),
+			},
+		},
+		Response: models.ResponseDetails{
+			Status: 200,
+			Body:   `{"message": "Hello Ben"}`,
+			Headers: map[string][]string{
+				"Content-Type": []string{"application/grpc"},
+			},
+		},
+	})
+
+	unit.Cfg.SetMode("simulate")
+
+	request := newGrpcRequest("Ben")
+	response := unit.processRequest(request)
+	unit.encodeGrpcResponse(request, response)
+
+	Expect(response.StatusCode).To(Equal(http.StatusOK))
+	Expect(response.Header.Get("Content-Type")).To(Equal("application/grpc"))
+	Expect(response.Header[http.TrailerPrefix+"Grpc-Status"]).To(Equal([]string{"0"}))
+
+	body, _ := ioutil.ReadAll(response.Body)
+	Expect(body).To(Equal(grpc.AppendFrame(nil, []byte{0x0a, 0x09, 'H', 'e', 'l', 'l', 'o', ' ', 'B', 'e', 'n'})))
+}
+
+func Test_Hoverfly_processRequest_MapsGrpcSimulationMissToUnavailableStatus(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(200, "")
+	defer server.Close()
+
+	Expect(unit.Grpc.AddDescriptorSet(greeterDescriptorSet())).To(Succeed())
+
+	unit.Cfg.SetMode("simulate")
+
+	request := newGrpcRequest("Ben")
+	response := unit.processRequest(request)
+	unit.encodeGrpcResponse(request, response)
+
+	Expect(response.StatusCode).To(Equal(http.StatusOK))
+	Expect(response.Header[http.TrailerPrefix+"Grpc-Status"]).To(Equal([]string{"14"}))
+	Expect(response.Header[http.TrailerPrefix+"Grpc-Message"][0]).To(ContainSubstring("Could not find a match for request"))
+
+	body, _ := ioutil.ReadAll(response.Body)
+	Expect(body).To(BeEmpty())
+}
+
+func Test_Hoverfly_processRequest_CapturesGrpcMethodAsJSON(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(200, "")
+	defer server.Close()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		Expect(body).To(Equal(grpc.AppendFrame(nil, []byte{0x0a, 0x03, 'B', 'e', 'n'})))
+
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		w.Write(grpc.AppendFrame(nil, []byte{0x0a, 0x02, 'H', 'i'}))
+		w.Header().Set("Grpc-Status", "0")
+		w.Header().Set("Grpc-Message", "all%20good")
+	}))
+	defer upstream.Close()
+
+	unit.HTTP = &http.Client{Transport: &http.Transport{
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			return url.Parse(upstream.URL)
+		},
+	}}
+
+	Expect(unit.Grpc.AddDescriptorSet(greeterDescriptorSet())).To(Succeed())
+
+	unit.Cfg.SetMode("capture")
+
+	request := newGrpcRequest("Ben")
+	response := unit.processRequest(request)
+	Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+	Expect(unit.Simulation.MatchingPairs).To(HaveLen(1))
+
+	pair := unit.Simulation.MatchingPairs[0]
+	Expect(*pair.RequestMatcher.Body.JsonMatch).To(Equal(`{"name":"Ben"}`))
+	Expect(pair.Response.Body).To(Equal(`{"message":"Hi"}`))
+	Expect(pair.Response.Headers["Grpc-Status"]).To(Equal([]string{"0"}))
+	Expect(pair.Response.Headers["Grpc-Message"]).To(Equal([]string{"all good"}))
+
+	unit.encodeGrpcResponse(request, response)
+
+	Expect(response.Header[http.TrailerPrefix+"Grpc-Status"]).To(Equal([]string{"0"}))
+	Expect(response.Header[http.TrailerPrefix+"Grpc-Message"]).To(Equal([]string{"all good"}))
+	Expect(pair.Response.Headers).ToNot(HaveKey(http.TrailerPrefix + "Grpc-Status"))
+
+	body, _ := ioutil.ReadAll(response.Body)
+	Expect(body).To(Equal(grpc.AppendFrame(nil, []byte{0x0a, 0x02, 'H', 'i'})))
+}
@@ -0,0 +1,98 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+)
+
+type HoverflyCertificates interface {
+	GetCACertificate() []byte
+	SetCACertificate(certificate, key string) error
+	GetIssuedCertificates() CertificatesView
+	FlushCertificates()
+}
+
+type CertificatesHandler struct {
+	Hoverfly HoverflyCertificates
+}
+
+func (this *CertificatesHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Get("/api/v2/certs", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Get),
+	))
+	mux.Delete("/api/v2/certs", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Delete),
+	))
+	mux.Options("/api/v2/certs", negroni.New(
+		negroni.HandlerFunc(this.Options),
+	))
+
+	mux.Get("/api/v2/certs/ca", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.GetCA),
+	))
+	mux.Put("/api/v2/certs/ca", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.PutCA),
+	))
+	mux.Options("/api/v2/certs/ca", negroni.New(
+		negroni.HandlerFunc(this.OptionsCA),
+	))
+}
+
+func (this *CertificatesHandler) Get(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	bytes, _ := json.Marshal(this.Hoverfly.GetIssuedCertificates())
+
+	handlers.WriteResponse(w, bytes)
+}
+
+func (this *CertificatesHandler) Delete(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	this.Hoverfly.FlushCertificates()
+
+	this.Get(w, req, next)
+}
+
+func (this *CertificatesHandler) Options(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add("Allow", "OPTIONS, GET, DELETE")
+	handlers.WriteResponse(w, []byte(""))
+}
+
+func (this *CertificatesHandler) GetCA(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	certificate := this.Hoverfly.GetCACertificate()
+	if certificate == nil {
+		handlers.WriteErrorResponse(w, "CA certificate has not been set", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"hoverfly-ca.pem\"")
+	w.Write(certificate)
+}
+
+func (this *CertificatesHandler) PutCA(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	var caView CertificateAuthorityView
+	err := handlers.ReadFromRequest(req, &caView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = this.Hoverfly.SetCACertificate(caView.Certificate, caView.Key)
+	if err != nil {
+		handlers.WriteErrorResponse(w, "Invalid CA: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	this.GetCA(w, req, next)
+}
+
+func (this *CertificatesHandler) OptionsCA(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add("Allow", "OPTIONS, GET, PUT")
+	handlers.WriteResponse(w, []byte(""))
+}
@@ -0,0 +1,182 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type HoverflyCertificatesStub struct {
+	certificate  string
+	certificates []CertificateView
+	flushed      bool
+}
+
+func (this HoverflyCertificatesStub) GetCACertificate() []byte {
+	if this.certificate == "" {
+		return nil
+	}
+	return []byte(this.certificate)
+}
+
+func (this *HoverflyCertificatesStub) SetCACertificate(certificate, key string) error {
+	if key == "" {
+		return fmt.Errorf("missing key")
+	}
+
+	this.certificate = certificate
+	return nil
+}
+
+func (this HoverflyCertificatesStub) GetIssuedCertificates() CertificatesView {
+	if this.flushed {
+		return CertificatesView{Certificates: []CertificateView{}}
+	}
+	return CertificatesView{Certificates: this.certificates}
+}
+
+func (this *HoverflyCertificatesStub) FlushCertificates() {
+	this.flushed = true
+}
+
+func Test_CertificatesHandler_Get_ReturnsIssuedCertificates(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyCertificatesStub{
+		certificates: []CertificateView{
+			CertificateView{Host: "hoverfly.io", KeyType: "rsa"},
+		},
+	}
+	unit := CertificatesHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "/api/v2/certs", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Get, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	certificatesView, err := unmarshalCertificatesView(response.Body)
+	Expect(err).To(BeNil())
+
+	Expect(certificatesView.Certificates).To(HaveLen(1))
+	Expect(certificatesView.Certificates[0].Host).To(Equal("hoverfly.io"))
+	Expect(certificatesView.Certificates[0].KeyType).To(Equal("rsa"))
+}
+
+func Test_CertificatesHandler_Delete_FlushesCertificates(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyCertificatesStub{
+		certificates: []CertificateView{
+			CertificateView{Host: "hoverfly.io"},
+		},
+	}
+	unit := CertificatesHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("DELETE", "/api/v2/certs", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Delete, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(stubHoverfly.flushed).To(BeTrue())
+
+	certificatesView, err := unmarshalCertificatesView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(certificatesView.Certificates).To(HaveLen(0))
+}
+
+func Test_CertificatesHandler_GetCA_ReturnsPEM(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyCertificatesStub{certificate: "-----BEGIN CERTIFICATE-----"}
+	unit := CertificatesHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "/api/v2/certs/ca", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.GetCA, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(response.Header().Get("Content-Type")).To(Equal("application/x-pem-file"))
+	Expect(response.Body.String()).To(Equal("-----BEGIN CERTIFICATE-----"))
+}
+
+func Test_CertificatesHandler_GetCA_ReturnsNotFoundWhenCAIsNotSet(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := CertificatesHandler{Hoverfly: &HoverflyCertificatesStub{}}
+
+	request, err := http.NewRequest("GET", "/api/v2/certs/ca", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.GetCA, request)
+	Expect(response.Code).To(Equal(http.StatusNotFound))
+}
+
+func Test_CertificatesHandler_PutCA_SetsCA(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyCertificatesStub{certificate: "old"}
+	unit := CertificatesHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, _ := json.Marshal(CertificateAuthorityView{Certificate: "new", Key: "key"})
+
+	request, err := http.NewRequest("PUT", "/api/v2/certs/ca", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PutCA, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(response.Body.String()).To(Equal("new"))
+}
+
+func Test_CertificatesHandler_PutCA_ReturnsUnprocessableEntityOnError(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyCertificatesStub{certificate: "old"}
+	unit := CertificatesHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, _ := json.Marshal(CertificateAuthorityView{Certificate: "new"})
+
+	request, err := http.NewRequest("PUT", "/api/v2/certs/ca", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PutCA, request)
+	Expect(response.Code).To(Equal(http.StatusUnprocessableEntity))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("Invalid CA: missing key"))
+	Expect(stubHoverfly.certificate).To(Equal("old"))
+}
+
+func Test_CertificatesHandler_PutCA_ReturnsBadRequestOnMalformedJSON(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := CertificatesHandler{Hoverfly: &HoverflyCertificatesStub{}}
+
+	request, err := http.NewRequest("PUT", "/api/v2/certs/ca", ioutil.NopCloser(bytes.NewBufferString("{{")))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PutCA, request)
+	Expect(response.Code).To(Equal(http.StatusBadRequest))
+}
+
+func unmarshalCertificatesView(buffer *bytes.Buffer) (CertificatesView, error) {
+	body, err := ioutil.ReadAll(buffer)
+	if err != nil {
+		return CertificatesView{}, err
+	}
+
+	var certificatesView CertificatesView
+
+	err = json.Unmarshal(body, &certificatesView)
+	if err != nil {
+		return CertificatesView{}, err
+	}
+
+	return certificatesView, nil
+}
@@ -0,0 +1,106 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+)
+
+type HoverflyContract interface {
+	GetContract() ContractView
+	PutContract(ContractView) error
+	DeleteContract()
+	GetContractViolations(JournalEntryFilterView) (ContractViolationsView, error)
+}
+
+type ContractHandler struct {
+	Hoverfly HoverflyContract
+}
+
+func (this *ContractHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Get("/api/v2/contract", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Get),
+	))
+	mux.Put("/api/v2/contract", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Put),
+	))
+	mux.Delete("/api/v2/contract", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Delete),
+	))
+	mux.Options("/api/v2/contract", negroni.New(
+		negroni.HandlerFunc(this.Options),
+	))
+
+	mux.Get("/api/v2/contract/violations", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.GetViolations),
+	))
+	mux.Options("/api/v2/contract/violations", negroni.New(
+		negroni.HandlerFunc(this.OptionsViolations),
+	))
+}
+
+func (this *ContractHandler) Get(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	bytes, _ := json.Marshal(this.Hoverfly.GetContract())
+
+	handlers.WriteResponse(w, bytes)
+}
+
+func (this *ContractHandler) Put(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	var contractView ContractView
+	err := handlers.ReadFromRequest(req, &contractView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = this.Hoverfly.PutContract(contractView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	this.Get(w, req, next)
+}
+
+func (this *ContractHandler) Delete(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	this.Hoverfly.DeleteContract()
+
+	this.Get(w, req, next)
+}
+
+// GetViolations - validates the journal against the contract, taking
+// the same query parameters as the journal to choose which entries
+func (this *ContractHandler) GetViolations(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	filter, err := newJournalEntryFilterFromQuery(req.URL.Query())
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	violationsView, err := this.Hoverfly.GetContractViolations(filter)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bytes, _ := json.Marshal(violationsView)
+
+	handlers.WriteResponse(w, bytes)
+}
+
+func (this *ContractHandler) Options(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add("Allow", "OPTIONS, GET, PUT, DELETE")
+	handlers.WriteResponse(w, []byte(""))
+}
+
+func (this *ContractHandler) OptionsViolations(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add("Allow", "OPTIONS, GET")
+	handlers.WriteResponse(w, []byte(""))
+}
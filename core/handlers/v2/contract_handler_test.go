@@ -0,0 +1,186 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type HoverflyContractStub struct {
+	contract   *ContractView
+	filter     JournalEntryFilterView
+	violations []ContractViolationView
+}
+
+func (this HoverflyContractStub) GetContract() ContractView {
+	if this.contract == nil {
+		return ContractView{}
+	}
+
+	return *this.contract
+}
+
+func (this *HoverflyContractStub) PutContract(contractView ContractView) error {
+	if contractView.Document == "invalid" {
+		return fmt.Errorf("Invalid OpenAPI document: only Swagger 2.0 and OpenAPI 3 documents are supported")
+	}
+
+	this.contract = &contractView
+	return nil
+}
+
+func (this *HoverflyContractStub) DeleteContract() {
+	this.contract = nil
+}
+
+func (this *HoverflyContractStub) GetContractViolations(filter JournalEntryFilterView) (ContractViolationsView, error) {
+	if this.contract == nil {
+		return ContractViolationsView{}, fmt.Errorf("No contract has been set")
+	}
+
+	this.filter = filter
+	return ContractViolationsView{Violations: this.violations}, nil
+}
+
+func Test_ContractHandler_Put_SetsContract(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyContractStub{}
+	unit := ContractHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, _ := json.Marshal(ContractView{
+		Document:              "openapi: 3.0.0",
+		RejectInvalidRequests: true,
+	})
+
+	request, err := http.NewRequest("PUT", "/api/v2/contract", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(stubHoverfly.contract.Document).To(Equal("openapi: 3.0.0"))
+	Expect(stubHoverfly.contract.RejectInvalidRequests).To(BeTrue())
+
+	var contractView ContractView
+	Expect(json.Unmarshal(response.Body.Bytes(), &contractView)).To(Succeed())
+	Expect(contractView.Document).To(Equal("openapi: 3.0.0"))
+	Expect(contractView.RejectInvalidRequests).To(BeTrue())
+}
+
+func Test_ContractHandler_Put_ReturnsUnprocessableEntityOnInvalidDocument(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyContractStub{}
+	unit := ContractHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, _ := json.Marshal(ContractView{Document: "invalid"})
+
+	request, err := http.NewRequest("PUT", "/api/v2/contract", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusUnprocessableEntity))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("Invalid OpenAPI document: only Swagger 2.0 and OpenAPI 3 documents are supported"))
+	Expect(stubHoverfly.contract).To(BeNil())
+}
+
+func Test_ContractHandler_Put_ReturnsBadRequestOnMalformedJson(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := ContractHandler{Hoverfly: &HoverflyContractStub{}}
+
+	request, err := http.NewRequest("PUT", "/api/v2/contract", ioutil.NopCloser(bytes.NewBufferString("{{}")))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("Malformed JSON"))
+}
+
+func Test_ContractHandler_Delete_RemovesContract(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyContractStub{contract: &ContractView{Document: "openapi: 3.0.0"}}
+	unit := ContractHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("DELETE", "/api/v2/contract", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Delete, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(stubHoverfly.contract).To(BeNil())
+	Expect(response.Body.String()).To(Equal(`{"document":"","rejectInvalidRequests":false}`))
+}
+
+func Test_ContractHandler_GetViolations_ReturnsViolationsOfFilteredJournal(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyContractStub{
+		contract: &ContractView{Document: "openapi: 3.0.0"},
+		violations: []ContractViolationView{
+			ContractViolationView{
+				Entry:  JournalEntryView{Mode: "simulate"},
+				Errors: []string{"path /owners is not in the contract"},
+			},
+		},
+	}
+	unit := ContractHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "/api/v2/contract/violations?mode=simulate", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.GetViolations, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(stubHoverfly.filter.Mode).To(Equal("simulate"))
+
+	var violationsView ContractViolationsView
+	Expect(json.Unmarshal(response.Body.Bytes(), &violationsView)).To(Succeed())
+	Expect(violationsView.Violations).To(HaveLen(1))
+	Expect(violationsView.Violations[0].Errors).To(Equal([]string{"path /owners is not in the contract"}))
+}
+
+func Test_ContractHandler_GetViolations_ReturnsBadRequestWithoutContract(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := ContractHandler{Hoverfly: &HoverflyContractStub{}}
+
+	request, err := http.NewRequest("GET", "/api/v2/contract/violations", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.GetViolations, request)
+	Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("No contract has been set"))
+}
+
+func Test_ContractHandler_GetViolations_ReturnsBadRequestOnInvalidFilter(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := ContractHandler{Hoverfly: &HoverflyContractStub{contract: &ContractView{}}}
+
+	request, err := http.NewRequest("GET", "/api/v2/contract/violations?order=up", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.GetViolations, request)
+	Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("order must be asc or desc"))
+}
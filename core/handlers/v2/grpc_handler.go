@@ -0,0 +1,79 @@
+package v2
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+)
+
+type HoverflyGrpc interface {
+	GetGrpcMethods() GrpcMethodsView
+	AddGrpcDescriptorSet([]byte) error
+	DeleteGrpcDescriptorSets()
+}
+
+type GrpcHandler struct {
+	Hoverfly HoverflyGrpc
+}
+
+func (this *GrpcHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Get("/api/v2/grpc/descriptors", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Get),
+	))
+	mux.Put("/api/v2/grpc/descriptors", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Put),
+	))
+	mux.Delete("/api/v2/grpc/descriptors", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Delete),
+	))
+	mux.Options("/api/v2/grpc/descriptors", negroni.New(
+		negroni.HandlerFunc(this.Options),
+	))
+}
+
+func (this *GrpcHandler) Get(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	bytes, _ := json.Marshal(this.Hoverfly.GetGrpcMethods())
+
+	handlers.WriteResponse(w, bytes)
+}
+
+func (this *GrpcHandler) Put(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	var descriptorSetView GrpcDescriptorSetView
+	err := handlers.ReadFromRequest(req, &descriptorSetView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	descriptorSet, err := base64.StdEncoding.DecodeString(descriptorSetView.DescriptorSet)
+	if err != nil {
+		handlers.WriteErrorResponse(w, "Descriptor set must be base64 encoded", http.StatusBadRequest)
+		return
+	}
+
+	err = this.Hoverfly.AddGrpcDescriptorSet(descriptorSet)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	this.Get(w, req, next)
+}
+
+func (this *GrpcHandler) Delete(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	this.Hoverfly.DeleteGrpcDescriptorSets()
+
+	this.Get(w, req, next)
+}
+
+func (this *GrpcHandler) Options(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add("Allow", "OPTIONS, GET, PUT, DELETE")
+	handlers.WriteResponse(w, []byte(""))
+}
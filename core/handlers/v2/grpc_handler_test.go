@@ -0,0 +1,165 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type HoverflyGrpcStub struct {
+	descriptorSets [][]byte
+	methods        []GrpcMethodView
+}
+
+func (this HoverflyGrpcStub) GetGrpcMethods() GrpcMethodsView {
+	return GrpcMethodsView{Methods: this.methods}
+}
+
+func (this *HoverflyGrpcStub) AddGrpcDescriptorSet(descriptorSet []byte) error {
+	if string(descriptorSet) == "invalid" {
+		return fmt.Errorf("Invalid descriptor set: no files found")
+	}
+
+	this.descriptorSets = append(this.descriptorSets, descriptorSet)
+	this.methods = append(this.methods, GrpcMethodView{Path: "/helloworld.Greeter/SayHello"})
+	return nil
+}
+
+func (this *HoverflyGrpcStub) DeleteGrpcDescriptorSets() {
+	this.descriptorSets = nil
+	this.methods = []GrpcMethodView{}
+}
+
+func Test_GrpcHandler_Get_ReturnsMethods(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyGrpcStub{
+		methods: []GrpcMethodView{
+			GrpcMethodView{Path: "/helloworld.Greeter/SayHello", ServerStreaming: true},
+		},
+	}
+	unit := GrpcHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "/api/v2/grpc/descriptors", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Get, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	methodsView, err := unmarshalGrpcMethodsView(response.Body)
+	Expect(err).To(BeNil())
+
+	Expect(methodsView.Methods).To(HaveLen(1))
+	Expect(methodsView.Methods[0].Path).To(Equal("/helloworld.Greeter/SayHello"))
+	Expect(methodsView.Methods[0].ServerStreaming).To(BeTrue())
+}
+
+func Test_GrpcHandler_Put_AddsDecodedDescriptorSet(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyGrpcStub{}
+	unit := GrpcHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, _ := json.Marshal(GrpcDescriptorSetView{
+		DescriptorSet: base64.StdEncoding.EncodeToString([]byte("descriptor-set")),
+	})
+
+	request, err := http.NewRequest("PUT", "/api/v2/grpc/descriptors", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(stubHoverfly.descriptorSets).To(HaveLen(1))
+	Expect(string(stubHoverfly.descriptorSets[0])).To(Equal("descriptor-set"))
+
+	methodsView, err := unmarshalGrpcMethodsView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(methodsView.Methods).To(HaveLen(1))
+}
+
+func Test_GrpcHandler_Put_ReturnsBadRequestWhenNotBase64(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyGrpcStub{}
+	unit := GrpcHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, _ := json.Marshal(GrpcDescriptorSetView{DescriptorSet: "not base64!"})
+
+	request, err := http.NewRequest("PUT", "/api/v2/grpc/descriptors", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("Descriptor set must be base64 encoded"))
+	Expect(stubHoverfly.descriptorSets).To(HaveLen(0))
+}
+
+func Test_GrpcHandler_Put_ReturnsUnprocessableEntityOnInvalidDescriptorSet(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyGrpcStub{}
+	unit := GrpcHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, _ := json.Marshal(GrpcDescriptorSetView{
+		DescriptorSet: base64.StdEncoding.EncodeToString([]byte("invalid")),
+	})
+
+	request, err := http.NewRequest("PUT", "/api/v2/grpc/descriptors", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusUnprocessableEntity))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("Invalid descriptor set: no files found"))
+}
+
+func Test_GrpcHandler_Delete_RemovesDescriptorSets(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyGrpcStub{
+		descriptorSets: [][]byte{[]byte("descriptor-set")},
+		methods: []GrpcMethodView{
+			GrpcMethodView{Path: "/helloworld.Greeter/SayHello"},
+		},
+	}
+	unit := GrpcHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("DELETE", "/api/v2/grpc/descriptors", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Delete, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(stubHoverfly.descriptorSets).To(BeNil())
+
+	methodsView, err := unmarshalGrpcMethodsView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(methodsView.Methods).To(HaveLen(0))
+}
+
+func unmarshalGrpcMethodsView(buffer *bytes.Buffer) (GrpcMethodsView, error) {
+	body, err := ioutil.ReadAll(buffer)
+	if err != nil {
+		return GrpcMethodsView{}, err
+	}
+
+	var methodsView GrpcMethodsView
+
+	err = json.Unmarshal(body, &methodsView)
+	if err != nil {
+		return GrpcMethodsView{}, err
+	}
+
+	return methodsView, nil
+}
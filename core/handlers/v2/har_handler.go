@@ -0,0 +1,97 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+)
+
+type HoverflyHar interface {
+	GetJournalHar(JournalEntryFilterView) (HarView, error)
+	GetSimulationHar() (HarView, error)
+	PutSimulationHar(HarView) error
+}
+
+type HarHandler struct {
+	Hoverfly HoverflyHar
+}
+
+func (this *HarHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Get("/api/v2/journal/har", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.GetJournal),
+	))
+	mux.Options("/api/v2/journal/har", negroni.New(
+		negroni.HandlerFunc(this.OptionsJournal),
+	))
+
+	mux.Get("/api/v2/simulation/har", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.GetSimulation),
+	))
+	mux.Put("/api/v2/simulation/har", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.PutSimulation),
+	))
+	mux.Options("/api/v2/simulation/har", negroni.New(
+		negroni.HandlerFunc(this.OptionsSimulation),
+	))
+}
+
+func (this *HarHandler) GetJournal(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	filter, err := newJournalEntryFilterFromQuery(req.URL.Query())
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	harView, err := this.Hoverfly.GetJournalHar(filter)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, _ := json.Marshal(harView)
+	handlers.WriteResponse(w, bytes)
+}
+
+func (this *HarHandler) GetSimulation(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	harView, err := this.Hoverfly.GetSimulationHar()
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, _ := json.Marshal(harView)
+	handlers.WriteResponse(w, bytes)
+}
+
+func (this *HarHandler) PutSimulation(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	var harView HarView
+	err := handlers.ReadFromRequest(req, &harView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = this.Hoverfly.PutSimulationHar(harView)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	this.GetSimulation(w, req, next)
+}
+
+func (this *HarHandler) OptionsJournal(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add("Allow", "OPTIONS, GET")
+	handlers.WriteResponse(w, []byte(""))
+}
+
+func (this *HarHandler) OptionsSimulation(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add("Allow", "OPTIONS, GET, PUT")
+	handlers.WriteResponse(w, []byte(""))
+}
@@ -0,0 +1,207 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type HoverflyHarStub struct {
+	filter  JournalEntryFilterView
+	entries []HarEntryView
+	err     error
+}
+
+func (this *HoverflyHarStub) GetJournalHar(filter JournalEntryFilterView) (HarView, error) {
+	this.filter = filter
+	if this.err != nil {
+		return HarView{}, this.err
+	}
+
+	return HarView{Log: HarLogView{Version: "1.2", Entries: this.entries}}, nil
+}
+
+func (this HoverflyHarStub) GetSimulationHar() (HarView, error) {
+	return HarView{Log: HarLogView{Version: "1.2", Entries: this.entries}}, nil
+}
+
+func (this *HoverflyHarStub) PutSimulationHar(harView HarView) error {
+	for _, entry := range harView.Log.Entries {
+		if entry.Request.URL == "invalid" {
+			return fmt.Errorf("Invalid request URL invalid")
+		}
+	}
+
+	this.entries = harView.Log.Entries
+	return nil
+}
+
+func Test_HarHandler_GetJournal_ReturnsHarOfFilteredJournal(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyHarStub{
+		entries: []HarEntryView{
+			HarEntryView{Request: HarRequestView{Method: "GET", URL: "http://test.com/path"}},
+		},
+	}
+	unit := HarHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "/api/v2/journal/har?status=200&limit=1", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.GetJournal, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	harView, err := unmarshalHarView(response.Body)
+	Expect(err).To(BeNil())
+
+	Expect(harView.Log.Version).To(Equal("1.2"))
+	Expect(harView.Log.Entries).To(HaveLen(1))
+	Expect(harView.Log.Entries[0].Request.URL).To(Equal("http://test.com/path"))
+
+	Expect(stubHoverfly.filter.Status).To(Equal(200))
+	Expect(*stubHoverfly.filter.Limit).To(Equal(1))
+}
+
+func Test_HarHandler_GetJournal_ReturnsBadRequestOnInvalidFilter(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := HarHandler{Hoverfly: &HoverflyHarStub{}}
+
+	request, err := http.NewRequest("GET", "/api/v2/journal/har?sort=size", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.GetJournal, request)
+	Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("sort must be timeStarted or latency"))
+}
+
+func Test_HarHandler_GetJournal_ReturnsErrorWhenJournalDisabled(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := HarHandler{Hoverfly: &HoverflyHarStub{err: fmt.Errorf("Journal disabled")}}
+
+	request, err := http.NewRequest("GET", "/api/v2/journal/har", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.GetJournal, request)
+	Expect(response.Code).To(Equal(http.StatusInternalServerError))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("Journal disabled"))
+}
+
+func Test_HarHandler_PutSimulation_ReplacesSimulationAndReturnsHar(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyHarStub{}
+	unit := HarHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, _ := json.Marshal(HarView{
+		Log: HarLogView{
+			Entries: []HarEntryView{
+				HarEntryView{Request: HarRequestView{Method: "GET", URL: "http://test.com/path"}},
+			},
+		},
+	})
+
+	request, err := http.NewRequest("PUT", "/api/v2/simulation/har", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PutSimulation, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(stubHoverfly.entries).To(HaveLen(1))
+
+	harView, err := unmarshalHarView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(harView.Log.Entries).To(HaveLen(1))
+	Expect(harView.Log.Entries[0].Request.URL).To(Equal("http://test.com/path"))
+}
+
+func Test_HarHandler_PutSimulation_ReturnsBadRequestOnMalformedJson(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := HarHandler{Hoverfly: &HoverflyHarStub{}}
+
+	request, err := http.NewRequest("PUT", "/api/v2/simulation/har", ioutil.NopCloser(bytes.NewBufferString("{{}")))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PutSimulation, request)
+	Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("Malformed JSON"))
+}
+
+func Test_HarHandler_PutSimulation_ReturnsUnprocessableEntityOnInvalidEntry(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyHarStub{}
+	unit := HarHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes, _ := json.Marshal(HarView{
+		Log: HarLogView{
+			Entries: []HarEntryView{
+				HarEntryView{Request: HarRequestView{Method: "GET", URL: "invalid"}},
+			},
+		},
+	})
+
+	request, err := http.NewRequest("PUT", "/api/v2/simulation/har", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PutSimulation, request)
+	Expect(response.Code).To(Equal(http.StatusUnprocessableEntity))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("Invalid request URL invalid"))
+	Expect(stubHoverfly.entries).To(BeNil())
+}
+
+func Test_HarHandler_Options_SetsAllowHeaders(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := HarHandler{Hoverfly: &HoverflyHarStub{}}
+
+	request, err := http.NewRequest("OPTIONS", "/api/v2/journal/har", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.OptionsJournal, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(response.Header().Get("Allow")).To(Equal("OPTIONS, GET"))
+
+	request, err = http.NewRequest("OPTIONS", "/api/v2/simulation/har", nil)
+	Expect(err).To(BeNil())
+
+	response = makeRequestOnHandler(unit.OptionsSimulation, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(response.Header().Get("Allow")).To(Equal("OPTIONS, GET, PUT"))
+}
+
+func unmarshalHarView(buffer *bytes.Buffer) (HarView, error) {
+	body, err := ioutil.ReadAll(buffer)
+	if err != nil {
+		return HarView{}, err
+	}
+
+	var harView HarView
+
+	err = json.Unmarshal(body, &harView)
+	if err != nil {
+		return HarView{}, err
+	}
+
+	return harView, nil
+}
@@ -0,0 +1,81 @@
+package v2
+
+// HarView is an HTTP Archive, as described by the HAR 1.2 spec
+// http://www.softwareishard.com/blog/har-12-spec/
+type HarView struct {
+	Log HarLogView `json:"log"`
+}
+
+type HarLogView struct {
+	Version string         `json:"version"`
+	Creator HarCreatorView `json:"creator"`
+	Entries []HarEntryView `json:"entries"`
+}
+
+type HarCreatorView struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HarEntryView struct {
+	StartedDateTime string          `json:"startedDateTime"`
+	Time            float64         `json:"time"`
+	Request         HarRequestView  `json:"request"`
+	Response        HarResponseView `json:"response"`
+	Cache           struct{}        `json:"cache"`
+	Timings         HarTimingsView  `json:"timings"`
+	Comment         string          `json:"comment,omitempty"`
+}
+
+type HarRequestView struct {
+	Method      string             `json:"method"`
+	URL         string             `json:"url"`
+	HTTPVersion string             `json:"httpVersion"`
+	Cookies     []HarCookieView    `json:"cookies"`
+	Headers     []HarNameValueView `json:"headers"`
+	QueryString []HarNameValueView `json:"queryString"`
+	PostData    *HarPostDataView   `json:"postData,omitempty"`
+	HeadersSize int                `json:"headersSize"`
+	BodySize    int                `json:"bodySize"`
+}
+
+type HarResponseView struct {
+	Status      int                `json:"status"`
+	StatusText  string             `json:"statusText"`
+	HTTPVersion string             `json:"httpVersion"`
+	Cookies     []HarCookieView    `json:"cookies"`
+	Headers     []HarNameValueView `json:"headers"`
+	Content     HarContentView     `json:"content"`
+	RedirectURL string             `json:"redirectURL"`
+	HeadersSize int                `json:"headersSize"`
+	BodySize    int                `json:"bodySize"`
+}
+
+type HarCookieView struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HarNameValueView struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HarPostDataView struct {
+	MimeType string             `json:"mimeType"`
+	Text     string             `json:"text"`
+	Params   []HarNameValueView `json:"params,omitempty"`
+}
+
+type HarContentView struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type HarTimingsView struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
@@ -0,0 +1,112 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type HoverflyMiddlewareChainStub struct {
+	Chain MiddlewareChainView
+}
+
+func (this HoverflyMiddlewareChainStub) GetMiddlewareChain() MiddlewareChainView {
+	return this.Chain
+}
+
+func (this *HoverflyMiddlewareChainStub) SetMiddlewareChain(chain MiddlewareChainView) error {
+	for _, chained := range chain.Middlewares {
+		if chained.Phase == "error" {
+			return fmt.Errorf("error")
+		}
+	}
+
+	this.Chain = chain
+	return nil
+}
+
+func Test_HoverflyMiddlewareChainHandler_Get_ReturnsTheChain(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyMiddlewareChainStub{
+		Chain: MiddlewareChainView{
+			Middlewares: []ChainedMiddlewareView{
+				{Name: "first", Phase: "request", MiddlewareView: MiddlewareView{Binary: "python", Script: "middleware"}},
+			},
+		},
+	}
+	unit := HoverflyMiddlewareChainHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Get, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	body, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+	Expect(body).To(MatchJSON(`{"middlewares": [{"name": "first", "phase": "request", "binary": "python", "script": "middleware", "remote": ""}]}`))
+}
+
+func Test_HoverflyMiddlewareChainHandler_Put_SetsTheChain(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyMiddlewareChainStub{}
+	unit := HoverflyMiddlewareChainHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes := []byte(`{"middlewares": [{"name": "lua", "phase": "response", "requestMatcher": {"destination": {"exactMatch": "test.com"}}, "engine": "lua", "script": "pair.response.status = 201"}]}`)
+
+	request, err := http.NewRequest("PUT", "", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(stubHoverfly.Chain.Middlewares).To(HaveLen(1))
+	Expect(stubHoverfly.Chain.Middlewares[0].Name).To(Equal("lua"))
+	Expect(stubHoverfly.Chain.Middlewares[0].Phase).To(Equal("response"))
+	Expect(*stubHoverfly.Chain.Middlewares[0].RequestMatcher.Destination.ExactMatch).To(Equal("test.com"))
+	Expect(stubHoverfly.Chain.Middlewares[0].Engine).To(Equal("lua"))
+
+	var chainView MiddlewareChainView
+	Expect(json.Unmarshal(response.Body.Bytes(), &chainView)).To(BeNil())
+	Expect(chainView).To(Equal(stubHoverfly.Chain))
+}
+
+func Test_HoverflyMiddlewareChainHandler_Put_Will422ErrorIfHoverflyErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := HoverflyMiddlewareChainHandler{Hoverfly: &HoverflyMiddlewareChainStub{}}
+
+	bodyBytes := []byte(`{"middlewares": [{"phase": "error", "binary": "python"}]}`)
+
+	request, err := http.NewRequest("PUT", "", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusUnprocessableEntity))
+
+	errorViewResponse, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+
+	Expect(errorViewResponse.Error).To(Equal("Invalid middleware chain: error"))
+}
+
+func Test_HoverflyMiddlewareChainHandler_Options_GetsOptions(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := HoverflyMiddlewareChainHandler{Hoverfly: &HoverflyMiddlewareChainStub{}}
+
+	request, err := http.NewRequest("OPTIONS", "/api/v2/hoverfly/middleware/chain", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Options, request)
+
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(response.Header().Get("Allow")).To(Equal("OPTIONS, GET, PUT"))
+}
@@ -12,20 +12,30 @@ import (
 )
 
 type HoverflyMiddlewareStub struct {
-	Binary string
-	Script string
-	Remote string
+	Binary     string
+	Script     string
+	Remote     string
+	Persistent bool
+	Timeout    int
 }
 
-func (this HoverflyMiddlewareStub) GetMiddleware() (string, string, string) {
-	return this.Binary, this.Script, this.Remote
+func (this HoverflyMiddlewareStub) GetMiddlewareView() MiddlewareView {
+	return MiddlewareView{
+		Binary:     this.Binary,
+		Script:     this.Script,
+		Remote:     this.Remote,
+		Persistent: this.Persistent,
+		Timeout:    this.Timeout,
+	}
 }
 
-func (this *HoverflyMiddlewareStub) SetMiddleware(binary, script, remote string) error {
-	this.Binary = binary
-	this.Script = script
-	this.Remote = remote
-	if script == "error" {
+func (this *HoverflyMiddlewareStub) SetMiddlewareWithOptions(middlewareView MiddlewareView) error {
+	this.Binary = middlewareView.Binary
+	this.Script = middlewareView.Script
+	this.Remote = middlewareView.Remote
+	this.Persistent = middlewareView.Persistent
+	this.Timeout = middlewareView.Timeout
+	if middlewareView.Script == "error" {
 		return fmt.Errorf("error")
 	}
 
@@ -83,6 +93,29 @@ func TestHoverflyMiddlewareHandlerPutSetsTheNewMiddlewarendReplacesTheTestMiddle
 	Expect(middlewareViewResponse.Script).To(Equal("new-middleware"))
 }
 
+func Test_HoverflyMiddlewareHandler_Put_SetsPersistentMiddlewareWithTimeout(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyMiddlewareStub{}
+	unit := HoverflyMiddlewareHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes := []byte(`{"binary": "python", "script": "middleware", "persistent": true, "timeout": 250}`)
+
+	request, err := http.NewRequest("PUT", "", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Put, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(stubHoverfly.Persistent).To(BeTrue())
+	Expect(stubHoverfly.Timeout).To(Equal(250))
+
+	middlewareViewResponse, err := unmarshalMiddlewareView(response.Body)
+	Expect(err).To(BeNil())
+
+	Expect(middlewareViewResponse.Persistent).To(BeTrue())
+	Expect(middlewareViewResponse.Timeout).To(Equal(250))
+}
+
 func TestHoverflyMiddlewareHandlerPutWill422ErrorIfHoverflyErrors(t *testing.T) {
 	RegisterTestingT(t)
 
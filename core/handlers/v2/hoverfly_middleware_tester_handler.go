@@ -0,0 +1,54 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+)
+
+type HoverflyMiddlewareTester interface {
+	TestMiddleware(MiddlewareTestView) (MiddlewareTestResultView, error)
+}
+
+// HoverflyMiddlewareTesterHandler - runs middleware on a sample pair, so that it can be debugged
+// without sending requests through Hoverfly
+type HoverflyMiddlewareTesterHandler struct {
+	Hoverfly HoverflyMiddlewareTester
+}
+
+func (this *HoverflyMiddlewareTesterHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Post("/api/v2/hoverfly/middleware/test", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Post),
+	))
+	mux.Options("/api/v2/hoverfly/middleware/test", negroni.New(
+		negroni.HandlerFunc(this.Options),
+	))
+}
+
+func (this *HoverflyMiddlewareTesterHandler) Post(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	var testReq MiddlewareTestView
+	err := handlers.ReadFromRequest(req, &testReq)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), 400)
+		return
+	}
+
+	result, err := this.Hoverfly.TestMiddleware(testReq)
+	if err != nil {
+		handlers.WriteErrorResponse(w, "Could not test middleware: "+err.Error(), 422)
+		return
+	}
+
+	resultBytes, _ := json.Marshal(result)
+
+	handlers.WriteResponse(w, resultBytes)
+}
+
+func (this *HoverflyMiddlewareTesterHandler) Options(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add("Allow", "OPTIONS, POST")
+	handlers.WriteResponse(w, []byte(""))
+}
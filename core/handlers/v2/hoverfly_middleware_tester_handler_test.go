@@ -0,0 +1,87 @@
+package v2
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+)
+
+type HoverflyMiddlewareTesterStub struct {
+	Tested MiddlewareTestView
+}
+
+func (this *HoverflyMiddlewareTesterStub) TestMiddleware(testView MiddlewareTestView) (MiddlewareTestResultView, error) {
+	this.Tested = testView
+	if testView.Name == "error" {
+		return MiddlewareTestResultView{}, fmt.Errorf("error")
+	}
+
+	return MiddlewareTestResultView{
+		Pair: RequestResponsePairViewV1{
+			Response: ResponseDetailsView{Status: 201, Body: "tested"},
+		},
+		Diagnostics: MiddlewareDiagnosticsView{
+			Stderr:  "debugging",
+			Latency: 3,
+		},
+	}, nil
+}
+
+func Test_HoverflyMiddlewareTesterHandler_Post_TestsTheMiddlewareAndReturnsTheResult(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyMiddlewareTesterStub{}
+	unit := HoverflyMiddlewareTesterHandler{Hoverfly: stubHoverfly}
+
+	bodyBytes := []byte(`{"middleware": {"engine": "lua", "script": "pair.response.status = 201"}, "pair": {"request": {"path": "/test"}, "response": {"status": 200}}}`)
+
+	request, err := http.NewRequest("POST", "", ioutil.NopCloser(bytes.NewBuffer(bodyBytes)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Post, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(stubHoverfly.Tested.Middleware.Engine).To(Equal("lua"))
+	Expect(stubHoverfly.Tested.Pair.Request.Path).To(Equal(util.StringToPointer("/test")))
+
+	body, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+	Expect(string(body)).To(ContainSubstring(`"body":"tested"`))
+	Expect(string(body)).To(ContainSubstring(`"diagnostics":{"stderr":"debugging","latency":3}`))
+}
+
+func Test_HoverflyMiddlewareTesterHandler_Post_Will422ErrorIfHoverflyErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := HoverflyMiddlewareTesterHandler{Hoverfly: &HoverflyMiddlewareTesterStub{}}
+
+	request, err := http.NewRequest("POST", "", ioutil.NopCloser(bytes.NewBuffer([]byte(`{"name": "error"}`))))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Post, request)
+	Expect(response.Code).To(Equal(http.StatusUnprocessableEntity))
+
+	errorViewResponse, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+
+	Expect(errorViewResponse.Error).To(Equal("Could not test middleware: error"))
+}
+
+func Test_HoverflyMiddlewareTesterHandler_Options_GetsOptions(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := HoverflyMiddlewareTesterHandler{Hoverfly: &HoverflyMiddlewareTesterStub{}}
+
+	request, err := http.NewRequest("OPTIONS", "/api/v2/hoverfly/middleware/test", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Options, request)
+
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(response.Header().Get("Allow")).To(Equal("OPTIONS, POST"))
+}
@@ -0,0 +1,79 @@
+package v2
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+)
+
+type HoverflyImport interface {
+	GetSimulation() (SimulationViewV2, error)
+	PutSimulationPostman([]byte) ([]string, error)
+	PutSimulationWireMock([]byte) ([]string, error)
+}
+
+type ImportHandler struct {
+	Hoverfly HoverflyImport
+}
+
+func (this *ImportHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Put("/api/v2/simulation/postman", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.PutPostman),
+	))
+	mux.Options("/api/v2/simulation/postman", negroni.New(
+		negroni.HandlerFunc(this.Options),
+	))
+
+	mux.Put("/api/v2/simulation/wiremock", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.PutWireMock),
+	))
+	mux.Options("/api/v2/simulation/wiremock", negroni.New(
+		negroni.HandlerFunc(this.Options),
+	))
+}
+
+// PutPostman - replaces the simulation with one converted from the Postman
+// collection in the request body
+func (this *ImportHandler) PutPostman(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	this.put(w, req, this.Hoverfly.PutSimulationPostman)
+}
+
+// PutWireMock - replaces the simulation with one converted from the WireMock
+// stub mapping, or file of mappings, in the request body
+func (this *ImportHandler) PutWireMock(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	this.put(w, req, this.Hoverfly.PutSimulationWireMock)
+}
+
+func (this *ImportHandler) put(w http.ResponseWriter, req *http.Request, convert func([]byte) ([]string, error)) {
+	body, _ := ioutil.ReadAll(req.Body)
+
+	untranslated, err := convert(body)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	simulationView, err := this.Hoverfly.GetSimulation()
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, _ := json.Marshal(SimulationImportView{
+		Simulation:   simulationView,
+		Untranslated: untranslated,
+	})
+
+	handlers.WriteResponse(w, bytes)
+}
+
+func (this *ImportHandler) Options(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add("Allow", "OPTIONS, PUT")
+	handlers.WriteResponse(w, []byte(""))
+}
@@ -0,0 +1,102 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type HoverflyImportStub struct {
+	format     string
+	data       []byte
+	simulation SimulationViewV2
+}
+
+func (this HoverflyImportStub) GetSimulation() (SimulationViewV2, error) {
+	return this.simulation, nil
+}
+
+func (this *HoverflyImportStub) PutSimulationPostman(data []byte) ([]string, error) {
+	return this.put("postman", data)
+}
+
+func (this *HoverflyImportStub) PutSimulationWireMock(data []byte) ([]string, error) {
+	return this.put("wiremock", data)
+}
+
+func (this *HoverflyImportStub) put(format string, data []byte) ([]string, error) {
+	if string(data) == "invalid" {
+		return nil, fmt.Errorf("Invalid %s document", format)
+	}
+
+	this.format = format
+	this.data = data
+	this.simulation = SimulationViewV2{
+		DataViewV2: DataViewV2{
+			RequestResponsePairs: []RequestMatcherResponsePairViewV2{
+				RequestMatcherResponsePairViewV2{
+					Response: ResponseDetailsView{Status: 200},
+				},
+			},
+		},
+	}
+	return []string{"mapping 1: response.fault is not translated"}, nil
+}
+
+func Test_ImportHandler_PutPostman_ImportsCollectionAndReturnsReport(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyImportStub{}
+	unit := ImportHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("PUT", "/api/v2/simulation/postman", ioutil.NopCloser(bytes.NewBufferString(`{"info": {}}`)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PutPostman, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(stubHoverfly.format).To(Equal("postman"))
+	Expect(string(stubHoverfly.data)).To(Equal(`{"info": {}}`))
+
+	var importView SimulationImportView
+	Expect(json.Unmarshal(response.Body.Bytes(), &importView)).To(Succeed())
+	Expect(importView.Simulation.RequestResponsePairs).To(HaveLen(1))
+	Expect(importView.Untranslated).To(Equal([]string{"mapping 1: response.fault is not translated"}))
+}
+
+func Test_ImportHandler_PutWireMock_ImportsMappings(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyImportStub{}
+	unit := ImportHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("PUT", "/api/v2/simulation/wiremock", ioutil.NopCloser(bytes.NewBufferString(`{"mappings": []}`)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PutWireMock, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(stubHoverfly.format).To(Equal("wiremock"))
+	Expect(string(stubHoverfly.data)).To(Equal(`{"mappings": []}`))
+}
+
+func Test_ImportHandler_PutWireMock_ReturnsBadRequestOnInvalidMappings(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := ImportHandler{Hoverfly: &HoverflyImportStub{}}
+
+	request, err := http.NewRequest("PUT", "/api/v2/simulation/wiremock", ioutil.NopCloser(bytes.NewBufferString("invalid")))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PutWireMock, request)
+	Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("Invalid wiremock document"))
+}
@@ -2,7 +2,11 @@ package v2
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/SpectoLabs/hoverfly/core/handlers"
 	"github.com/codegangsta/negroni"
@@ -10,8 +14,10 @@ import (
 )
 
 type HoverflyJournal interface {
-	GetEntries() ([]JournalEntryView, error)
+	GetFilteredEntries(JournalEntryFilterView) (JournalView, error)
 	DeleteEntries() error
+	SearchEntries(RequestMatcherViewV2) (JournalSearchResultView, error)
+	VerifyEntries(JournalVerificationView) (JournalVerificationResultView, error)
 }
 
 type JournalHandler struct {
@@ -30,17 +36,36 @@ func (this *JournalHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandl
 	mux.Options("/api/v2/journal", negroni.New(
 		negroni.HandlerFunc(this.Options),
 	))
+
+	mux.Post("/api/v2/journal/count", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.PostCount),
+	))
+	mux.Options("/api/v2/journal/count", negroni.New(
+		negroni.HandlerFunc(this.OptionsPostOnly),
+	))
+
+	mux.Post("/api/v2/journal/verify", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.PostVerify),
+	))
+	mux.Options("/api/v2/journal/verify", negroni.New(
+		negroni.HandlerFunc(this.OptionsPostOnly),
+	))
 }
 
 func (this *JournalHandler) Get(response http.ResponseWriter, request *http.Request, next http.HandlerFunc) {
-	var journalView JournalView
-	entries, err := this.Hoverfly.GetEntries()
+	filter, err := newJournalEntryFilterFromQuery(request.URL.Query())
 	if err != nil {
-		handlers.WriteErrorResponse(response, err.Error(), http.StatusInternalServerError)
+		handlers.WriteErrorResponse(response, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	journalView.Journal = entries
+	journalView, err := this.Hoverfly.GetFilteredEntries(filter)
+	if err != nil {
+		handlers.WriteErrorResponse(response, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	bytes, _ := json.Marshal(journalView)
 	handlers.WriteResponse(response, bytes)
@@ -56,7 +81,111 @@ func (this *JournalHandler) Delete(response http.ResponseWriter, request *http.R
 	this.Get(response, request, next)
 }
 
+func (this *JournalHandler) PostCount(response http.ResponseWriter, request *http.Request, next http.HandlerFunc) {
+	var requestMatcher RequestMatcherViewV2
+	err := handlers.ReadFromRequest(request, &requestMatcher)
+	if err != nil {
+		handlers.WriteErrorResponse(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := this.Hoverfly.SearchEntries(requestMatcher)
+	if err != nil {
+		handlers.WriteErrorResponse(response, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, _ := json.Marshal(result)
+	handlers.WriteResponse(response, bytes)
+}
+
+func (this *JournalHandler) PostVerify(response http.ResponseWriter, request *http.Request, next http.HandlerFunc) {
+	var verification JournalVerificationView
+	err := handlers.ReadFromRequest(request, &verification)
+	if err != nil {
+		handlers.WriteErrorResponse(response, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(verification.Requests) == 0 {
+		handlers.WriteErrorResponse(response, "No requests to verify", http.StatusBadRequest)
+		return
+	}
+
+	if times := verification.Times; times != nil && times.Exactly != nil && (times.AtLeast != nil || times.AtMost != nil) {
+		handlers.WriteErrorResponse(response, "exactly cannot be combined with atLeast or atMost", http.StatusBadRequest)
+		return
+	}
+
+	result, err := this.Hoverfly.VerifyEntries(verification)
+	if err != nil {
+		handlers.WriteErrorResponse(response, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, _ := json.Marshal(result)
+	handlers.WriteResponse(response, bytes)
+}
+
 func (this *JournalHandler) Options(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	w.Header().Add("Allow", "OPTIONS, GET, DELETE")
 	handlers.WriteResponse(w, []byte(""))
 }
+
+func (this *JournalHandler) OptionsPostOnly(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add("Allow", "OPTIONS, POST")
+	handlers.WriteResponse(w, []byte(""))
+}
+
+func newJournalEntryFilterFromQuery(query url.Values) (JournalEntryFilterView, error) {
+	filter := JournalEntryFilterView{
+		Mode:        query.Get("mode"),
+		Destination: query.Get("destination"),
+		Path:        query.Get("path"),
+		Search:      query.Get("search"),
+		Sort:        query.Get("sort"),
+		Order:       query.Get("order"),
+	}
+
+	for name, value := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if query.Get(name) == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, query.Get(name))
+		if err != nil {
+			return filter, fmt.Errorf("%s must be a RFC3339 timestamp", name)
+		}
+		*value = &parsed
+	}
+
+	for name, value := range map[string]*int{"status": &filter.Status, "offset": &filter.Offset} {
+		if query.Get(name) == "" {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(query.Get(name))
+		if err != nil || parsed < 0 {
+			return filter, fmt.Errorf("%s must be a positive number", name)
+		}
+		*value = parsed
+	}
+
+	if query.Get("limit") != "" {
+		limit, err := strconv.Atoi(query.Get("limit"))
+		if err != nil || limit < 0 {
+			return filter, fmt.Errorf("limit must be a positive number")
+		}
+		filter.Limit = &limit
+	}
+
+	if filter.Sort != "" && filter.Sort != "timeStarted" && filter.Sort != "latency" {
+		return filter, fmt.Errorf("sort must be timeStarted or latency")
+	}
+
+	if filter.Order != "" && filter.Order != "asc" && filter.Order != "desc" {
+		return filter, fmt.Errorf("order must be asc or desc")
+	}
+
+	return filter, nil
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"testing"
+	"time"
 
 	"fmt"
 
@@ -13,9 +14,12 @@ import (
 )
 
 type HoverflyJournalStub struct {
-	limit   int
-	deleted bool
-	error   bool
+	limit          int
+	deleted        bool
+	error          bool
+	requestMatcher RequestMatcherViewV2
+	verification   JournalVerificationView
+	filter         JournalEntryFilterView
 }
 
 func (this *HoverflyJournalStub) GetEntries() ([]JournalEntryView, error) {
@@ -34,6 +38,22 @@ func (this *HoverflyJournalStub) GetEntries() ([]JournalEntryView, error) {
 	}
 }
 
+func (this *HoverflyJournalStub) GetFilteredEntries(filter JournalEntryFilterView) (JournalView, error) {
+	this.filter = filter
+
+	entries, err := this.GetEntries()
+	if err != nil {
+		return JournalView{}, err
+	}
+
+	return JournalView{
+		Journal: entries,
+		Offset:  filter.Offset,
+		Limit:   len(entries),
+		Total:   len(entries),
+	}, nil
+}
+
 func (this *HoverflyJournalStub) DeleteEntries() error {
 	if this.error {
 		return fmt.Errorf("delete error")
@@ -43,6 +63,36 @@ func (this *HoverflyJournalStub) DeleteEntries() error {
 	return nil
 }
 
+func (this *HoverflyJournalStub) SearchEntries(requestMatcher RequestMatcherViewV2) (JournalSearchResultView, error) {
+	if this.error {
+		return JournalSearchResultView{}, fmt.Errorf("search error")
+	}
+
+	this.requestMatcher = requestMatcher
+
+	return JournalSearchResultView{
+		Count: 1,
+		Journal: []JournalEntryView{
+			JournalEntryView{
+				Mode: "test",
+			},
+		},
+	}, nil
+}
+
+func (this *HoverflyJournalStub) VerifyEntries(verification JournalVerificationView) (JournalVerificationResultView, error) {
+	if this.error {
+		return JournalVerificationResultView{}, fmt.Errorf("verify error")
+	}
+
+	this.verification = verification
+
+	return JournalVerificationResultView{
+		Verified: false,
+		Message:  "requests were not made in the order given",
+	}, nil
+}
+
 func Test_JournalHandler_Get_ReturnsJournal(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -156,3 +206,191 @@ func unmarshalJournalView(buffer *bytes.Buffer) (JournalView, error) {
 
 	return journalView, nil
 }
+
+func Test_JournalHandler_Get_PassesQueryToFilter(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyJournalStub{}
+	unit := JournalHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "/api/v2/journal?from=2017-03-13T12:00:00Z&to=2017-03-13T13:00:00Z&mode=capture&status=404&destination=*.io&path=/api/*&search=hello&offset=10&limit=5&sort=latency&order=desc", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Get, request)
+
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	filter := stubHoverfly.filter
+	Expect(filter.From.Format(time.RFC3339)).To(Equal("2017-03-13T12:00:00Z"))
+	Expect(filter.To.Format(time.RFC3339)).To(Equal("2017-03-13T13:00:00Z"))
+	Expect(filter.Mode).To(Equal("capture"))
+	Expect(filter.Status).To(Equal(404))
+	Expect(filter.Destination).To(Equal("*.io"))
+	Expect(filter.Path).To(Equal("/api/*"))
+	Expect(filter.Search).To(Equal("hello"))
+	Expect(filter.Offset).To(Equal(10))
+	Expect(*filter.Limit).To(Equal(5))
+	Expect(filter.Sort).To(Equal("latency"))
+	Expect(filter.Order).To(Equal("desc"))
+
+	journalView, err := unmarshalJournalView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(journalView.Offset).To(Equal(10))
+	Expect(journalView.Total).To(Equal(1))
+}
+
+func Test_JournalHandler_Get_ErrorsOnInvalidQuery(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := JournalHandler{Hoverfly: &HoverflyJournalStub{}}
+
+	for query, message := range map[string]string{
+		"from=yesterday": "from must be a RFC3339 timestamp",
+		"status=ok":      "status must be a positive number",
+		"offset=-1":      "offset must be a positive number",
+		"limit=all":      "limit must be a positive number",
+		"sort=mode":      "sort must be timeStarted or latency",
+		"order=up":       "order must be asc or desc",
+	} {
+		request, err := http.NewRequest("GET", "/api/v2/journal?"+query, nil)
+		Expect(err).To(BeNil())
+
+		response := makeRequestOnHandler(unit.Get, request)
+
+		Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+		errorView, err := unmarshalErrorView(response.Body)
+		Expect(err).To(BeNil())
+		Expect(errorView.Error).To(Equal(message))
+	}
+}
+
+func Test_JournalHandler_PostCount_SearchesEntriesWithRequestMatcher(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyJournalStub{}
+	unit := JournalHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("POST", "/api/v2/journal/count", bytes.NewBufferString(`{"path": {"exactMatch": "/hello"}}`))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PostCount, request)
+
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(*stubHoverfly.requestMatcher.Path.ExactMatch).To(Equal("/hello"))
+
+	var resultView JournalSearchResultView
+	Expect(json.Unmarshal(response.Body.Bytes(), &resultView)).To(Succeed())
+
+	Expect(resultView.Count).To(Equal(1))
+	Expect(resultView.Journal).To(HaveLen(1))
+	Expect(resultView.Journal[0].Mode).To(Equal("test"))
+}
+
+func Test_JournalHandler_PostCount_ErrorsOnMalformedJSON(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := JournalHandler{Hoverfly: &HoverflyJournalStub{}}
+
+	request, err := http.NewRequest("POST", "/api/v2/journal/count", bytes.NewBufferString("{{"))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PostCount, request)
+
+	Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("Malformed JSON"))
+}
+
+func Test_JournalHandler_PostVerify_VerifiesEntries(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyJournalStub{}
+	unit := JournalHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("POST", "/api/v2/journal/verify", bytes.NewBufferString(`{
+		"requests": [{"path": {"exactMatch": "/one"}}, {"path": {"exactMatch": "/two"}}],
+		"times": {"exactly": 2},
+		"inOrder": true
+	}`))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PostVerify, request)
+
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(stubHoverfly.verification.Requests).To(HaveLen(2))
+	Expect(*stubHoverfly.verification.Times.Exactly).To(Equal(2))
+	Expect(stubHoverfly.verification.InOrder).To(BeTrue())
+
+	var resultView JournalVerificationResultView
+	Expect(json.Unmarshal(response.Body.Bytes(), &resultView)).To(Succeed())
+
+	Expect(resultView.Verified).To(BeFalse())
+	Expect(resultView.Message).To(Equal("requests were not made in the order given"))
+}
+
+func Test_JournalHandler_PostVerify_ErrorsWithoutRequests(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := JournalHandler{Hoverfly: &HoverflyJournalStub{}}
+
+	request, err := http.NewRequest("POST", "/api/v2/journal/verify", bytes.NewBufferString(`{"requests": []}`))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PostVerify, request)
+
+	Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("No requests to verify"))
+}
+
+func Test_JournalHandler_PostVerify_ErrorsWhenExactlyIsCombinedWithRange(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := JournalHandler{Hoverfly: &HoverflyJournalStub{}}
+
+	request, err := http.NewRequest("POST", "/api/v2/journal/verify", bytes.NewBufferString(`{
+		"requests": [{"path": {"exactMatch": "/one"}}],
+		"times": {"exactly": 2, "atLeast": 1}
+	}`))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PostVerify, request)
+
+	Expect(response.Code).To(Equal(http.StatusBadRequest))
+}
+
+func Test_JournalHandler_PostVerify_Error(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := JournalHandler{Hoverfly: &HoverflyJournalStub{error: true}}
+
+	request, err := http.NewRequest("POST", "/api/v2/journal/verify", bytes.NewBufferString(`{"requests": [{}]}`))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PostVerify, request)
+
+	Expect(response.Code).To(Equal(http.StatusInternalServerError))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("verify error"))
+}
+
+func Test_JournalHandler_OptionsPostOnly_GetsOptions(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := JournalHandler{Hoverfly: &HoverflyJournalStub{}}
+
+	request, err := http.NewRequest("OPTIONS", "/api/v2/journal/verify", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.OptionsPostOnly, request)
+
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(response.Header().Get("Allow")).To(Equal("OPTIONS, POST"))
+}
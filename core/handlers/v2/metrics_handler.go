@@ -0,0 +1,43 @@
+package v2
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/SpectoLabs/hoverfly/core/metrics"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+)
+
+type HoverflyMetrics interface {
+	GetMetrics() *metrics.RequestMetrics
+}
+
+type MetricsHandler struct {
+	Hoverfly HoverflyMetrics
+}
+
+func (this *MetricsHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Get("/metrics", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Get),
+	))
+	mux.Options("/metrics", negroni.New(
+		negroni.HandlerFunc(this.Options),
+	))
+}
+
+// Get - writes the request metrics out in the Prometheus text exposition format, for Prometheus to scrape
+func (this *MetricsHandler) Get(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	var buffer bytes.Buffer
+	this.Hoverfly.GetMetrics().Registry.WriteTo(&buffer)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buffer.Bytes())
+}
+
+func (this *MetricsHandler) Options(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add("Allow", "OPTIONS, GET")
+	handlers.WriteResponse(w, []byte(""))
+}
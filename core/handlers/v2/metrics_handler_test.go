@@ -0,0 +1,51 @@
+package v2
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/metrics"
+	. "github.com/onsi/gomega"
+)
+
+type HoverflyMetricsStub struct {
+	Metrics *metrics.RequestMetrics
+}
+
+func (this HoverflyMetricsStub) GetMetrics() *metrics.RequestMetrics {
+	return this.Metrics
+}
+
+func Test_MetricsHandler_Get_WritesMetricsInPrometheusFormat(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyMetricsStub{Metrics: metrics.NewRequestMetrics(nil, 0)}
+	stubHoverfly.Metrics.Request("simulate", "test.com", 200, time.Millisecond)
+
+	unit := MetricsHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "/metrics", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Get, request)
+
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(response.Header().Get("Content-Type")).To(Equal("text/plain; version=0.0.4; charset=utf-8"))
+	Expect(response.Body.String()).To(ContainSubstring("# TYPE hoverfly_requests_total counter\n"))
+	Expect(response.Body.String()).To(ContainSubstring(`hoverfly_requests_total{mode="simulate",status="200"} 1`))
+}
+
+func Test_MetricsHandler_Options_GetsOptions(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := MetricsHandler{Hoverfly: &HoverflyMetricsStub{}}
+
+	request, err := http.NewRequest("OPTIONS", "/metrics", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Options, request)
+
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(response.Header().Get("Allow")).To(Equal("OPTIONS, GET"))
+}
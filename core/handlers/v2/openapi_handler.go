@@ -0,0 +1,57 @@
+package v2
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+)
+
+type HoverflyOpenApi interface {
+	GetSimulation() (SimulationViewV2, error)
+	PutSimulationOpenApi([]byte) error
+}
+
+type OpenApiHandler struct {
+	Hoverfly HoverflyOpenApi
+}
+
+func (this *OpenApiHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Put("/api/v2/simulation/openapi", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.PutSimulation),
+	))
+	mux.Options("/api/v2/simulation/openapi", negroni.New(
+		negroni.HandlerFunc(this.OptionsSimulation),
+	))
+}
+
+// PutSimulation - replaces the simulation with one generated from the
+// OpenAPI document in the request body, which can be JSON or YAML
+func (this *OpenApiHandler) PutSimulation(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	body, _ := ioutil.ReadAll(req.Body)
+
+	err := this.Hoverfly.PutSimulationOpenApi(body)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	simulationView, err := this.Hoverfly.GetSimulation()
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, _ := json.Marshal(simulationView)
+
+	handlers.WriteResponse(w, bytes)
+}
+
+func (this *OpenApiHandler) OptionsSimulation(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add("Allow", "OPTIONS, PUT")
+	handlers.WriteResponse(w, []byte(""))
+}
@@ -0,0 +1,92 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type HoverflyOpenApiStub struct {
+	document   []byte
+	simulation SimulationViewV2
+}
+
+func (this HoverflyOpenApiStub) GetSimulation() (SimulationViewV2, error) {
+	return this.simulation, nil
+}
+
+func (this *HoverflyOpenApiStub) PutSimulationOpenApi(document []byte) error {
+	if string(document) == "invalid" {
+		return fmt.Errorf("Invalid OpenAPI document: only Swagger 2.0 and OpenAPI 3 documents are supported")
+	}
+
+	this.document = document
+	this.simulation = SimulationViewV2{
+		DataViewV2: DataViewV2{
+			RequestResponsePairs: []RequestMatcherResponsePairViewV2{
+				RequestMatcherResponsePairViewV2{
+					Response: ResponseDetailsView{Status: 200},
+				},
+			},
+		},
+	}
+	return nil
+}
+
+func Test_OpenApiHandler_PutSimulation_ImportsDocumentAndReturnsSimulation(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyOpenApiStub{}
+	unit := OpenApiHandler{Hoverfly: stubHoverfly}
+
+	document := "openapi: 3.0.0\npaths: {}\n"
+
+	request, err := http.NewRequest("PUT", "/api/v2/simulation/openapi", ioutil.NopCloser(bytes.NewBufferString(document)))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PutSimulation, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(string(stubHoverfly.document)).To(Equal(document))
+
+	var simulationView SimulationViewV2
+	err = json.Unmarshal(response.Body.Bytes(), &simulationView)
+	Expect(err).To(BeNil())
+	Expect(simulationView.RequestResponsePairs).To(HaveLen(1))
+}
+
+func Test_OpenApiHandler_PutSimulation_ReturnsBadRequestOnInvalidDocument(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyOpenApiStub{}
+	unit := OpenApiHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("PUT", "/api/v2/simulation/openapi", ioutil.NopCloser(bytes.NewBufferString("invalid")))
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.PutSimulation, request)
+	Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+	errorView, err := unmarshalErrorView(response.Body)
+	Expect(err).To(BeNil())
+	Expect(errorView.Error).To(Equal("Invalid OpenAPI document: only Swagger 2.0 and OpenAPI 3 documents are supported"))
+	Expect(stubHoverfly.document).To(BeNil())
+}
+
+func Test_OpenApiHandler_OptionsSimulation_SetsAllowHeader(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := OpenApiHandler{Hoverfly: &HoverflyOpenApiStub{}}
+
+	request, err := http.NewRequest("OPTIONS", "/api/v2/simulation/openapi", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.OptionsSimulation, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+	Expect(response.Header().Get("Allow")).To(Equal("OPTIONS, PUT"))
+}
@@ -0,0 +1,92 @@
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers"
+	"github.com/codegangsta/negroni"
+	"github.com/go-zoo/bone"
+)
+
+type HoverflyPact interface {
+	GetPact(PactOptionsView, JournalEntryFilterView) (PactView, error)
+}
+
+type PactHandler struct {
+	Hoverfly HoverflyPact
+}
+
+func (this *PactHandler) RegisterRoutes(mux *bone.Mux, am *handlers.AuthHandler) {
+	mux.Get("/api/v2/journal/pact", negroni.New(
+		negroni.HandlerFunc(am.RequireTokenAuthentication),
+		negroni.HandlerFunc(this.Get),
+	))
+	mux.Options("/api/v2/journal/pact", negroni.New(
+		negroni.HandlerFunc(this.Options),
+	))
+}
+
+// Get - exports a Pact contract between the consumer and provider named in the
+// query. Journal entries are chosen with the same query parameters as the journal
+func (this *PactHandler) Get(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	options, err := newPactOptionsFromQuery(req.URL.Query())
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := newJournalEntryFilterFromQuery(req.URL.Query())
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pactView, err := this.Hoverfly.GetPact(options, filter)
+	if err != nil {
+		handlers.WriteErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, _ := json.Marshal(pactView)
+
+	handlers.WriteResponse(w, bytes)
+}
+
+func (this *PactHandler) Options(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add("Allow", "OPTIONS, GET")
+	handlers.WriteResponse(w, []byte(""))
+}
+
+func newPactOptionsFromQuery(query url.Values) (PactOptionsView, error) {
+	options := PactOptionsView{
+		Consumer:      query.Get("consumer"),
+		Provider:      query.Get("provider"),
+		Specification: 3,
+		Source:        query.Get("source"),
+	}
+
+	if options.Consumer == "" || options.Provider == "" {
+		return options, fmt.Errorf("consumer and provider are required")
+	}
+
+	switch query.Get("specification") {
+	case "", "3", "3.0.0":
+	case "2", "2.0.0":
+		options.Specification = 2
+	default:
+		return options, fmt.Errorf("specification must be 2 or 3")
+	}
+
+	if options.Source == "" {
+		options.Source = "journal"
+	}
+
+	if options.Source != "journal" && options.Source != "simulation" {
+		return options, fmt.Errorf("source must be journal or simulation")
+	}
+
+	return options, nil
+}
@@ -0,0 +1,96 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type HoverflyPactStub struct {
+	options PactOptionsView
+	filter  JournalEntryFilterView
+}
+
+func (this *HoverflyPactStub) GetPact(options PactOptionsView, filter JournalEntryFilterView) (PactView, error) {
+	this.options = options
+	this.filter = filter
+
+	return PactView{
+		Consumer: PactParticipantView{Name: options.Consumer},
+		Provider: PactParticipantView{Name: options.Provider},
+		Interactions: []PactInteractionView{
+			PactInteractionView{
+				Description: "GET /pets",
+				Request:     PactRequestView{Method: "GET", Path: "/pets"},
+				Response:    PactResponseView{Status: 200},
+			},
+		},
+	}, nil
+}
+
+func Test_PactHandler_Get_ReturnsPactForFilteredJournal(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyPactStub{}
+	unit := PactHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "/api/v2/journal/pact?consumer=web&provider=pets&specification=2&mode=simulate", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Get, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(stubHoverfly.options).To(Equal(PactOptionsView{
+		Consumer:      "web",
+		Provider:      "pets",
+		Specification: 2,
+		Source:        "journal",
+	}))
+	Expect(stubHoverfly.filter.Mode).To(Equal("simulate"))
+
+	var pactView PactView
+	Expect(json.Unmarshal(response.Body.Bytes(), &pactView)).To(Succeed())
+	Expect(pactView.Consumer.Name).To(Equal("web"))
+	Expect(pactView.Interactions).To(HaveLen(1))
+}
+
+func Test_PactHandler_Get_DefaultsToVersion3(t *testing.T) {
+	RegisterTestingT(t)
+
+	stubHoverfly := &HoverflyPactStub{}
+	unit := PactHandler{Hoverfly: stubHoverfly}
+
+	request, err := http.NewRequest("GET", "/api/v2/journal/pact?consumer=web&provider=pets&source=simulation", nil)
+	Expect(err).To(BeNil())
+
+	response := makeRequestOnHandler(unit.Get, request)
+	Expect(response.Code).To(Equal(http.StatusOK))
+
+	Expect(stubHoverfly.options.Specification).To(Equal(3))
+	Expect(stubHoverfly.options.Source).To(Equal("simulation"))
+}
+
+func Test_PactHandler_Get_ReturnsBadRequestOnInvalidOptions(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := PactHandler{Hoverfly: &HoverflyPactStub{}}
+
+	for query, message := range map[string]string{
+		"provider=pets": "consumer and provider are required",
+		"consumer=web&provider=pets&specification=4":     "specification must be 2 or 3",
+		"consumer=web&provider=pets&source=cache":        "source must be journal or simulation",
+		"consumer=web&provider=pets&status=not-a-number": "status must be a positive number",
+	} {
+		request, err := http.NewRequest("GET", "/api/v2/journal/pact?"+query, nil)
+		Expect(err).To(BeNil())
+
+		response := makeRequestOnHandler(unit.Get, request)
+		Expect(response.Code).To(Equal(http.StatusBadRequest))
+
+		errorView, err := unmarshalErrorView(response.Body)
+		Expect(err).To(BeNil())
+		Expect(errorView.Error).To(Equal(message))
+	}
+}
@@ -0,0 +1,53 @@
+package v2
+
+// PactOptionsView - the names and specification version of a Pact contract, and
+// whether its interactions come from the journal or the simulation
+type PactOptionsView struct {
+	Consumer      string
+	Provider      string
+	Specification int
+	Source        string
+}
+
+type PactView struct {
+	Consumer     PactParticipantView   `json:"consumer"`
+	Provider     PactParticipantView   `json:"provider"`
+	Interactions []PactInteractionView `json:"interactions"`
+	Metadata     PactMetadataView      `json:"metadata"`
+}
+
+type PactParticipantView struct {
+	Name string `json:"name"`
+}
+
+type PactInteractionView struct {
+	Description string           `json:"description"`
+	Request     PactRequestView  `json:"request"`
+	Response    PactResponseView `json:"response"`
+}
+
+type PactRequestView struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+
+	// Query is a string in version 2 of the specification and a map of
+	// parameter names to values in version 3
+	Query         interface{}            `json:"query,omitempty"`
+	Headers       map[string]string      `json:"headers,omitempty"`
+	Body          interface{}            `json:"body,omitempty"`
+	MatchingRules map[string]interface{} `json:"matchingRules,omitempty"`
+}
+
+type PactResponseView struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+type PactMetadataView struct {
+	PactSpecification PactSpecificationView `json:"pactSpecification"`
+}
+
+type PactSpecificationView struct {
+	Version string `json:"version"`
+}
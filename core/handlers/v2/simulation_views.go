@@ -172,8 +172,9 @@ func (this SimulationViewV1) Upgrade() SimulationViewV2 {
 			}
 		}
 
+		// encoded bodies can only be matched exactly
 		if pairV1.Request.Body != nil {
-			if isNotRecording {
+			if isNotRecording && !pairV1.Request.EncodedBody {
 				bodyMatchers = &RequestFieldMatchersView{
 					GlobMatch: pairV1.Request.Body,
 				}
@@ -193,6 +194,7 @@ func (this SimulationViewV1) Upgrade() SimulationViewV2 {
 				Query:       queryMatchers,
 				Body:        bodyMatchers,
 				Headers:     headers,
+				EncodedBody: pairV1.Request.EncodedBody,
 			},
 			Response: pairV1.Response,
 		}
@@ -265,6 +267,10 @@ type RequestMatcherViewV2 struct {
 	Query       *RequestFieldMatchersView `json:"query,omitempty"`
 	Body        *RequestFieldMatchersView `json:"body,omitempty"`
 	Headers     map[string][]string       `json:"headers,omitempty"`
+	Protocol    *RequestFieldMatchersView `json:"protocol,omitempty"`
+
+	// EncodedBody - the body's exactMatch is base64 encoded, as the body it matches is binary
+	EncodedBody bool `json:"encodedBody,omitempty"`
 }
 
 // RequestDetailsView is used when marshalling and unmarshalling RequestDetails
@@ -276,7 +282,9 @@ type RequestDetailsViewV1 struct {
 	Scheme      *string             `json:"scheme"`
 	Query       *string             `json:"query"`
 	Body        *string             `json:"body"`
+	EncodedBody bool                `json:"encodedBody"`
 	Headers     map[string][]string `json:"headers"`
+	Protocol    *string             `json:"protocol,omitempty"`
 }
 
 //Gets Path - required for interfaces.RequestMatcher
@@ -303,17 +311,30 @@ func (this RequestDetailsViewV1) GetQuery() *string {
 //Gets Body - required for interfaces.RequestMatcher
 func (this RequestDetailsViewV1) GetBody() *string { return this.Body }
 
+//Gets EncodedBody - required for interfaces.RequestMatcher
+func (this RequestDetailsViewV1) GetEncodedBody() bool { return this.EncodedBody }
+
 //Gets Headers - required for interfaces.RequestMatcher
 func (this RequestDetailsViewV1) GetHeaders() map[string][]string { return this.Headers }
 
+//Gets Protocol - required for interfaces.RequestMatcher
+func (this RequestDetailsViewV1) GetProtocol() *string { return this.Protocol }
+
 // ResponseDetailsView is used when marshalling and
 // unmarshalling requests. This struct's Body may be Base64
-// encoded based on the EncodedBody field.
+// encoded based on the EncodedBody field. ContentEncoding is
+// the Content-Encoding the body was decoded from, which it is
+// encoded with again for clients which accept it.
 type ResponseDetailsView struct {
-	Status      int                 `json:"status"`
-	Body        string              `json:"body"`
-	EncodedBody bool                `json:"encodedBody"`
-	Headers     map[string][]string `json:"headers,omitempty"`
+	Status          int                 `json:"status"`
+	Body            string              `json:"body"`
+	EncodedBody     bool                `json:"encodedBody"`
+	Headers         map[string][]string `json:"headers,omitempty"`
+	Chunks          []ResponseChunkView `json:"chunks,omitempty"`
+	WebSocket       *WebSocketView      `json:"webSocket,omitempty"`
+	Middleware      string              `json:"middleware,omitempty"`
+	BodyFile        string              `json:"bodyFile,omitempty"`
+	ContentEncoding string              `json:"contentEncoding,omitempty"`
 }
 
 //Gets Status - required for interfaces.Response
@@ -328,6 +349,51 @@ func (this ResponseDetailsView) GetEncodedBody() bool { return this.EncodedBody
 // Gets Headers - required for interfaces.Response
 func (this ResponseDetailsView) GetHeaders() map[string][]string { return this.Headers }
 
+// Gets BodyFile - required for interfaces.Response
+func (this ResponseDetailsView) GetBodyFile() string { return this.BodyFile }
+
+// Gets ContentEncoding - required for interfaces.Response
+func (this ResponseDetailsView) GetContentEncoding() string { return this.ContentEncoding }
+
+// ResponseChunkView is part of a streamed response body, written and
+// flushed Delay milliseconds after the previous chunk. Data is Base64
+// encoded when the response has an encoded body.
+type ResponseChunkView struct {
+	Data  string `json:"data"`
+	Delay int    `json:"delay,omitempty"`
+}
+
+// WebSocketView is the scripted conversation Hoverfly has
+// with a client once a WebSocket connection is upgraded
+type WebSocketView struct {
+	OnConnect []WebSocketFrameView          `json:"onConnect,omitempty"`
+	OnMessage []WebSocketMessageHandlerView `json:"onMessage,omitempty"`
+	Periodic  []WebSocketPeriodicFrameView  `json:"periodic,omitempty"`
+}
+
+// WebSocketFrameView is a message sent to the client after a delay
+// in milliseconds. Data of binary frames is Base64 encoded.
+type WebSocketFrameView struct {
+	Data   string `json:"data"`
+	Binary bool   `json:"binary,omitempty"`
+	Delay  int    `json:"delay,omitempty"`
+}
+
+// WebSocketMessageHandlerView replies to client messages matching Match.
+// Binary client messages are matched against their Base64 encoding.
+type WebSocketMessageHandlerView struct {
+	Match *RequestFieldMatchersView `json:"match,omitempty"`
+	Reply []WebSocketFrameView      `json:"reply"`
+}
+
+// WebSocketPeriodicFrameView is a message pushed to the
+// client every Interval milliseconds
+type WebSocketPeriodicFrameView struct {
+	Data     string `json:"data"`
+	Binary   bool   `json:"binary,omitempty"`
+	Interval int    `json:"interval"`
+}
+
 type GlobalActionsView struct {
 	Delays []v1.ResponseDelayView `json:"delays"`
 }
@@ -392,3 +392,40 @@ func Test_SimulationViewV1_Upgrade_CanReturnAnIncompleteRequest(t *testing.T) {
 	Expect(simulationViewV2.RequestResponsePairs[0].Response.EncodedBody).To(BeFalse())
 	Expect(simulationViewV2.RequestResponsePairs[0].Response.Headers).To(HaveKeyWithValue("Test", []string{"headers"}))
 }
+
+func Test_SimulationViewV1_Upgrade_KeepsEncodedBodiesAsExactMatches(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := v2.SimulationViewV1{
+		v2.DataViewV1{
+			RequestResponsePairViewV1: []v2.RequestResponsePairViewV1{
+				v2.RequestResponsePairViewV1{
+					Request: v2.RequestDetailsViewV1{
+						RequestType: util.StringToPointer("template"),
+						Method:      util.StringToPointer("POST"),
+						Body:        util.StringToPointer("CgD/gA=="),
+						EncodedBody: true,
+					},
+					Response: v2.ResponseDetailsView{
+						Status: 200,
+						Body:   "body",
+					},
+				},
+			},
+		},
+		v2.MetaView{
+			SchemaVersion:   "v1",
+			HoverflyVersion: "test",
+			TimeExported:    "today",
+		},
+	}
+
+	simulationViewV2 := unit.Upgrade()
+
+	Expect(simulationViewV2.RequestResponsePairs).To(HaveLen(1))
+
+	Expect(*simulationViewV2.RequestResponsePairs[0].RequestMatcher.Body).To(Equal(v2.RequestFieldMatchersView{
+		ExactMatch: util.StringToPointer("CgD/gA=="),
+	}))
+	Expect(simulationViewV2.RequestResponsePairs[0].RequestMatcher.EncodedBody).To(BeTrue())
+}
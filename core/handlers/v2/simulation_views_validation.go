@@ -34,6 +34,9 @@ var requestV1Definition = map[string]interface{}{
 		"body": map[string]interface{}{
 			"type": "string",
 		},
+		"encodedBody": map[string]interface{}{
+			"type": "boolean",
+		},
 		"headers": map[string]interface{}{
 			"$ref": "#/definitions/headers",
 		},
@@ -61,6 +64,12 @@ var requestV2Definition = map[string]interface{}{
 		"headers": map[string]interface{}{
 			"$ref": "#/definitions/headers",
 		},
+		"protocol": map[string]interface{}{
+			"$ref": "#/definitions/field-matchers",
+		},
+		"encodedBody": map[string]interface{}{
+			"type": "boolean",
+		},
 	},
 }
 
@@ -70,6 +79,12 @@ var responseDefinition = map[string]interface{}{
 		"body": map[string]interface{}{
 			"type": "string",
 		},
+		"bodyFile": map[string]interface{}{
+			"type": "string",
+		},
+		"contentEncoding": map[string]interface{}{
+			"type": "string",
+		},
 		"encodedBody": map[string]interface{}{
 			"type": "boolean",
 		},
@@ -79,6 +94,85 @@ var responseDefinition = map[string]interface{}{
 		"status": map[string]interface{}{
 			"type": "integer",
 		},
+		"chunks": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"required": []string{
+					"data",
+				},
+				"properties": map[string]interface{}{
+					"data": map[string]interface{}{
+						"type": "string",
+					},
+					"delay": map[string]interface{}{
+						"type": "integer",
+					},
+				},
+			},
+		},
+		"webSocket": map[string]interface{}{
+			"$ref": "#/definitions/web-socket",
+		},
+		"middleware": map[string]interface{}{
+			"type": "string",
+		},
+	},
+}
+
+var webSocketFrameDefinition = map[string]interface{}{
+	"type": "object",
+	"required": []string{
+		"data",
+	},
+	"properties": map[string]interface{}{
+		"data": map[string]interface{}{
+			"type": "string",
+		},
+		"binary": map[string]interface{}{
+			"type": "boolean",
+		},
+		"delay": map[string]interface{}{
+			"type": "integer",
+		},
+		"interval": map[string]interface{}{
+			"type": "integer",
+		},
+	},
+}
+
+var webSocketDefinition = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"onConnect": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"$ref": "#/definitions/web-socket-frame",
+			},
+		},
+		"onMessage": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"match": map[string]interface{}{
+						"$ref": "#/definitions/field-matchers",
+					},
+					"reply": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"$ref": "#/definitions/web-socket-frame",
+						},
+					},
+				},
+			},
+		},
+		"periodic": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"$ref": "#/definitions/web-socket-frame",
+			},
+		},
 	},
 }
 
@@ -185,6 +279,8 @@ var SimulationViewV2Schema = map[string]interface{}{
 		"request":               requestV2Definition,
 		"response":              responseDefinition,
 		"field-matchers":        requestFieldMatchersV2Definition,
+		"web-socket":            webSocketDefinition,
+		"web-socket-frame":      webSocketFrameDefinition,
 		"headers":               headersDefinition,
 		"delay":                 delaysDefinition,
 		"meta":                  metaDefinition,
@@ -229,6 +325,9 @@ var SimulationViewV1Schema = map[string]interface{}{
 		"request-response-pair": requestResponsePairDefinition,
 		"request":               requestV1Definition,
 		"response":              responseDefinition,
+		"field-matchers":        requestFieldMatchersV2Definition,
+		"web-socket":            webSocketDefinition,
+		"web-socket-frame":      webSocketFrameDefinition,
 		"headers":               headersDefinition,
 		"delay":                 delaysDefinition,
 		"meta":                  metaDefinition,
@@ -15,9 +15,61 @@ type UsageView struct {
 }
 
 type MiddlewareView struct {
-	Binary string `json:"binary"`
-	Script string `json:"script"`
-	Remote string `json:"remote"`
+	Binary     string `json:"binary"`
+	Script     string `json:"script"`
+	Remote     string `json:"remote"`
+	Persistent bool   `json:"persistent,omitempty"`
+	Timeout    int    `json:"timeout,omitempty"`
+	Engine     string `json:"engine,omitempty"`
+
+	ErrorPolicy   *MiddlewareErrorPolicyView   `json:"errorPolicy,omitempty"`
+	RemoteOptions *MiddlewareRemoteOptionsView `json:"remoteOptions,omitempty"`
+}
+
+type MiddlewareRemoteOptionsView struct {
+	Headers            map[string]string `json:"headers,omitempty"`
+	CACert             string            `json:"caCert,omitempty"`
+	ClientCert         string            `json:"clientCert,omitempty"`
+	ClientKey          string            `json:"clientKey,omitempty"`
+	InsecureSkipVerify bool              `json:"insecureSkipVerify,omitempty"`
+}
+
+type MiddlewareErrorPolicyView struct {
+	OnTimeout         string               `json:"onTimeout,omitempty"`
+	OnFailure         string               `json:"onFailure,omitempty"`
+	OnInvalidResponse string               `json:"onInvalidResponse,omitempty"`
+	Fallback          *ResponseDetailsView `json:"fallback,omitempty"`
+}
+
+type MiddlewareDiagnosticsView struct {
+	Name      string        `json:"name,omitempty"`
+	Stderr    string        `json:"stderr,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	ErrorKind string        `json:"errorKind,omitempty"`
+	Policy    string        `json:"policy,omitempty"`
+	Latency   time.Duration `json:"latency"`
+}
+
+type MiddlewareTestView struct {
+	Name       string                     `json:"name,omitempty"`
+	Middleware *MiddlewareView            `json:"middleware,omitempty"`
+	Pair       *RequestResponsePairViewV1 `json:"pair,omitempty"`
+}
+
+type MiddlewareTestResultView struct {
+	Pair        RequestResponsePairViewV1 `json:"pair"`
+	Diagnostics MiddlewareDiagnosticsView `json:"diagnostics"`
+}
+
+type MiddlewareChainView struct {
+	Middlewares []ChainedMiddlewareView `json:"middlewares"`
+}
+
+type ChainedMiddlewareView struct {
+	Name           string                `json:"name,omitempty"`
+	Phase          string                `json:"phase,omitempty"`
+	RequestMatcher *RequestMatcherViewV2 `json:"requestMatcher,omitempty"`
+	MiddlewareView
 }
 
 type ModeView struct {
@@ -64,6 +116,25 @@ type CachedResponseView struct {
 
 type JournalView struct {
 	Journal []JournalEntryView `json:"journal"`
+	Offset  int                `json:"offset"`
+	Limit   int                `json:"limit"`
+	Total   int                `json:"total"`
+}
+
+// JournalEntryFilterView - criteria for selecting a page of journal entries,
+// taken from the query parameters of a request for the journal
+type JournalEntryFilterView struct {
+	From        *time.Time
+	To          *time.Time
+	Mode        string
+	Status      int
+	Destination string
+	Path        string
+	Search      string
+	Offset      int
+	Limit       *int
+	Sort        string
+	Order       string
 }
 
 type JournalEntryView struct {
@@ -72,4 +143,89 @@ type JournalEntryView struct {
 	Mode        string               `json:"mode"`
 	TimeStarted string               `json:"timeStarted"`
 	Latency     time.Duration        `json:"latency"`
+
+	Middleware []MiddlewareDiagnosticsView `json:"middleware,omitempty"`
+}
+
+type JournalSearchResultView struct {
+	Count         int                      `json:"count"`
+	Journal       []JournalEntryView       `json:"journal"`
+	ClosestMisses []JournalClosestMissView `json:"closestMisses"`
+}
+
+type JournalClosestMissView struct {
+	Entry        JournalEntryView `json:"entry"`
+	MissedFields []string         `json:"missedFields"`
+}
+
+type JournalVerificationView struct {
+	Requests []RequestMatcherViewV2 `json:"requests"`
+	Times    *JournalTimesView      `json:"times,omitempty"`
+	InOrder  bool                   `json:"inOrder,omitempty"`
+}
+
+type JournalTimesView struct {
+	Exactly *int `json:"exactly,omitempty"`
+	AtLeast *int `json:"atLeast,omitempty"`
+	AtMost  *int `json:"atMost,omitempty"`
+}
+
+type JournalVerificationResultView struct {
+	Verified bool                      `json:"verified"`
+	Message  string                    `json:"message,omitempty"`
+	Results  []JournalSearchResultView `json:"results"`
+}
+
+type ContractView struct {
+	Document              string `json:"document"`
+	RejectInvalidRequests bool   `json:"rejectInvalidRequests"`
+}
+
+type ContractViolationsView struct {
+	Violations []ContractViolationView `json:"violations"`
+}
+
+type ContractViolationView struct {
+	Entry  JournalEntryView `json:"entry"`
+	Errors []string         `json:"errors"`
+}
+
+type SimulationImportView struct {
+	Simulation   SimulationViewV2 `json:"simulation"`
+	Untranslated []string         `json:"untranslated"`
+}
+
+type CertificatesView struct {
+	Certificates []CertificateView `json:"certificates"`
+}
+
+type CertificateView struct {
+	Host         string `json:"host"`
+	SerialNumber string `json:"serialNumber"`
+	KeyType      string `json:"keyType"`
+	NotBefore    string `json:"notBefore"`
+	NotAfter     string `json:"notAfter"`
+}
+
+type CertificateAuthorityView struct {
+	Certificate string `json:"certificate"`
+	Key         string `json:"key"`
+}
+
+type GrpcDescriptorSetView struct {
+	DescriptorSet string `json:"descriptorSet"`
+}
+
+type GrpcMethodsView struct {
+	Methods []GrpcMethodView `json:"methods"`
+}
+
+type GrpcMethodView struct {
+	Path            string `json:"path"`
+	Service         string `json:"service"`
+	Method          string `json:"method"`
+	InputType       string `json:"inputType"`
+	OutputType      string `json:"outputType"`
+	ClientStreaming bool   `json:"clientStreaming"`
+	ServerStreaming bool   `json:"serverStreaming"`
 }
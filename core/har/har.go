@@ -0,0 +1,256 @@
+package har
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/util"
+)
+
+const harVersion = "1.2"
+
+// headers which describe how a body was sent over the wire, HAR content is
+// already decoded so these no longer apply once an entry has been imported
+var transportHeaders = []string{"Content-Encoding", "Content-Length", "Transfer-Encoding"}
+
+// NewHarView - creates an HTTP Archive made by this version of Hoverfly
+func NewHarView(entries []v2.HarEntryView, hoverflyVersion string) v2.HarView {
+	if entries == nil {
+		entries = []v2.HarEntryView{}
+	}
+
+	return v2.HarView{
+		Log: v2.HarLogView{
+			Version: harVersion,
+			Creator: v2.HarCreatorView{
+				Name:    "Hoverfly",
+				Version: hoverflyVersion,
+			},
+			Entries: entries,
+		},
+	}
+}
+
+// NewEntryView - creates a HAR entry for a request and the response returned to it
+func NewEntryView(request models.RequestDetails, response models.ResponseDetails, started time.Time, latency time.Duration) v2.HarEntryView {
+	milliseconds := float64(latency) / float64(time.Millisecond)
+
+	return v2.HarEntryView{
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Time:            milliseconds,
+		Request:         newRequestView(request),
+		Response:        newResponseView(response),
+		Timings: v2.HarTimingsView{
+			Wait: milliseconds,
+		},
+	}
+}
+
+// NewEntryViewFromPair - creates a HAR entry for a simulated request and response. The request is built
+// from the exact matches of its matchers, falling back to the patterns of other matchers
+func NewEntryViewFromPair(pair models.RequestMatcherResponsePair, started time.Time) v2.HarEntryView {
+	requestMatcher := pair.RequestMatcher
+
+	request := models.RequestDetails{
+		Method:      matcherValue(requestMatcher.Method, "GET"),
+		Scheme:      matcherValue(requestMatcher.Scheme, "http"),
+		Destination: matcherValue(requestMatcher.Destination, "localhost"),
+		Path:        matcherValue(requestMatcher.Path, "/"),
+		Query:       matcherValue(requestMatcher.Query, ""),
+		Body:        matcherValue(requestMatcher.Body, ""),
+		Headers:     requestMatcher.Headers,
+	}
+
+	return NewEntryView(request, pair.Response, started, 0)
+}
+
+func matcherValue(matchers *models.RequestFieldMatchers, defaultValue string) string {
+	if matchers == nil {
+		return defaultValue
+	}
+
+	for _, value := range []*string{
+		matchers.ExactMatch,
+		matchers.GlobMatch,
+		matchers.JsonMatch,
+		matchers.XmlMatch,
+		matchers.RegexMatch,
+		matchers.JsonPathMatch,
+		matchers.XpathMatch,
+	} {
+		if value != nil {
+			return *value
+		}
+	}
+
+	return defaultValue
+}
+
+func newRequestView(request models.RequestDetails) v2.HarRequestView {
+	scheme := request.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	requestURL := url.URL{
+		Scheme:   scheme,
+		Host:     request.Destination,
+		Path:     request.Path,
+		RawQuery: request.Query,
+	}
+
+	httpVersion := request.Protocol
+	if httpVersion == "" {
+		httpVersion = "HTTP/1.1"
+	}
+
+	queryString := []v2.HarNameValueView{}
+	query, _ := url.ParseQuery(request.Query)
+	for _, name := range sortedKeys(query) {
+		for _, value := range query[name] {
+			queryString = append(queryString, v2.HarNameValueView{Name: name, Value: value})
+		}
+	}
+
+	view := v2.HarRequestView{
+		Method:      request.Method,
+		URL:         requestURL.String(),
+		HTTPVersion: httpVersion,
+		Cookies:     []v2.HarCookieView{},
+		Headers:     newNameValueViews(request.Headers),
+		QueryString: queryString,
+		HeadersSize: -1,
+		BodySize:    len(request.Body),
+	}
+
+	if request.Body != "" {
+		view.PostData = &v2.HarPostDataView{
+			MimeType: http.Header(request.Headers).Get("Content-Type"),
+			Text:     request.Body,
+		}
+	}
+
+	return view
+}
+
+func newResponseView(response models.ResponseDetails) v2.HarResponseView {
+	body := response.Body + models.JoinResponseChunks(response.Chunks)
+	headers := http.Header(response.Headers)
+
+	content := v2.HarContentView{
+		Size:     len(body),
+		MimeType: headers.Get("Content-Type"),
+		Text:     body,
+	}
+
+	// binary bodies are base64 encoded the same way they are when exporting a simulation
+	if response.ConvertToResponseDetailsView().EncodedBody {
+		content.Text = base64.StdEncoding.EncodeToString([]byte(body))
+		content.Encoding = "base64"
+	}
+
+	return v2.HarResponseView{
+		Status:      response.Status,
+		StatusText:  http.StatusText(response.Status),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []v2.HarCookieView{},
+		Headers:     newNameValueViews(response.Headers),
+		Content:     content,
+		RedirectURL: headers.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+}
+
+// NewRequestResponsePair - turns a HAR entry back into the request and response it recorded
+func NewRequestResponsePair(entry v2.HarEntryView) (models.RequestDetails, models.ResponseDetails, error) {
+	requestURL, err := url.Parse(entry.Request.URL)
+	if err != nil || !requestURL.IsAbs() {
+		return models.RequestDetails{}, models.ResponseDetails{}, fmt.Errorf("Invalid request URL %s", entry.Request.URL)
+	}
+
+	request := models.RequestDetails{
+		Method:      strings.ToUpper(entry.Request.Method),
+		Scheme:      requestURL.Scheme,
+		Destination: strings.ToLower(requestURL.Host),
+		Path:        requestURL.Path,
+		Query:       util.SortQueryString(requestURL.RawQuery),
+		Headers:     newHeaders(entry.Request.Headers),
+	}
+
+	if postData := entry.Request.PostData; postData != nil {
+		request.Body = postData.Text
+
+		if request.Body == "" && len(postData.Params) > 0 {
+			form := url.Values{}
+			for _, param := range postData.Params {
+				form.Add(param.Name, param.Value)
+			}
+			request.Body = form.Encode()
+		}
+	}
+
+	body := entry.Response.Content.Text
+	if entry.Response.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return models.RequestDetails{}, models.ResponseDetails{}, fmt.Errorf("Invalid base64 response content for %s", entry.Request.URL)
+		}
+		body = string(decoded)
+	}
+
+	headers := newHeaders(entry.Response.Headers)
+	for _, header := range transportHeaders {
+		delete(headers, header)
+	}
+
+	response := models.ResponseDetails{
+		Status:  entry.Response.Status,
+		Body:    body,
+		Headers: headers,
+	}
+
+	return request, response, nil
+}
+
+func newNameValueViews(headers map[string][]string) []v2.HarNameValueView {
+	views := []v2.HarNameValueView{}
+	for _, name := range sortedKeys(headers) {
+		for _, value := range headers[name] {
+			views = append(views, v2.HarNameValueView{Name: name, Value: value})
+		}
+	}
+
+	return views
+}
+
+func newHeaders(views []v2.HarNameValueView) map[string][]string {
+	headers := http.Header{}
+	for _, view := range views {
+		// HTTP/2 pseudo headers such as :authority are part of the request line in HTTP/1.1
+		if strings.HasPrefix(view.Name, ":") {
+			continue
+		}
+
+		headers.Add(view.Name, view.Value)
+	}
+
+	return headers
+}
+
+func sortedKeys(values map[string][]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
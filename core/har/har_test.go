@@ -0,0 +1,207 @@
+package har_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/har"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+)
+
+func Test_NewHarView_SetsVersionAndCreator(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := har.NewHarView(nil, "v0.12.1")
+
+	Expect(unit.Log.Version).To(Equal("1.2"))
+	Expect(unit.Log.Creator.Name).To(Equal("Hoverfly"))
+	Expect(unit.Log.Creator.Version).To(Equal("v0.12.1"))
+	Expect(unit.Log.Entries).ToNot(BeNil())
+	Expect(unit.Log.Entries).To(BeEmpty())
+}
+
+func Test_NewEntryView_BuildsRequestAndResponse(t *testing.T) {
+	RegisterTestingT(t)
+
+	started := time.Date(2017, 5, 3, 10, 0, 0, 0, time.UTC)
+
+	unit := har.NewEntryView(models.RequestDetails{
+		Method:      "POST",
+		Scheme:      "https",
+		Destination: "test.com",
+		Path:        "/path",
+		Query:       "b=2&a=1",
+		Body:        `{"name": "hoverfly"}`,
+		Headers: map[string][]string{
+			"Content-Type": []string{"application/json"},
+		},
+	}, models.ResponseDetails{
+		Status: 201,
+		Body:   "created",
+		Headers: map[string][]string{
+			"Content-Type": []string{"text/plain"},
+		},
+	}, started, 1500*time.Microsecond)
+
+	Expect(unit.StartedDateTime).To(Equal("2017-05-03T10:00:00Z"))
+	Expect(unit.Time).To(Equal(1.5))
+	Expect(unit.Timings.Wait).To(Equal(1.5))
+
+	Expect(unit.Request.Method).To(Equal("POST"))
+	Expect(unit.Request.URL).To(Equal("https://test.com/path?b=2&a=1"))
+	Expect(unit.Request.HTTPVersion).To(Equal("HTTP/1.1"))
+	Expect(unit.Request.QueryString).To(Equal([]v2.HarNameValueView{
+		v2.HarNameValueView{Name: "a", Value: "1"},
+		v2.HarNameValueView{Name: "b", Value: "2"},
+	}))
+	Expect(unit.Request.Headers).To(Equal([]v2.HarNameValueView{
+		v2.HarNameValueView{Name: "Content-Type", Value: "application/json"},
+	}))
+	Expect(unit.Request.PostData.MimeType).To(Equal("application/json"))
+	Expect(unit.Request.PostData.Text).To(Equal(`{"name": "hoverfly"}`))
+
+	Expect(unit.Response.Status).To(Equal(201))
+	Expect(unit.Response.StatusText).To(Equal("Created"))
+	Expect(unit.Response.Content.MimeType).To(Equal("text/plain"))
+	Expect(unit.Response.Content.Text).To(Equal("created"))
+	Expect(unit.Response.Content.Size).To(Equal(7))
+	Expect(unit.Response.Content.Encoding).To(Equal(""))
+}
+
+func Test_NewEntryView_Base64EncodesBinaryResponseContent(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := har.NewEntryView(models.RequestDetails{
+		Method:      "GET",
+		Destination: "test.com",
+		Path:        "/image",
+	}, models.ResponseDetails{
+		Status: 200,
+		Body:   "\xff\xd8\xff",
+		Headers: map[string][]string{
+			"Content-Type": []string{"image/jpeg"},
+		},
+	}, time.Now(), 0)
+
+	Expect(unit.Response.Content.Encoding).To(Equal("base64"))
+	Expect(unit.Response.Content.Text).To(Equal("/9j/"))
+}
+
+func Test_NewEntryView_JoinsStreamedResponseChunks(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := har.NewEntryView(models.RequestDetails{
+		Method:      "GET",
+		Destination: "test.com",
+		Path:        "/events",
+	}, models.ResponseDetails{
+		Status: 200,
+		Chunks: []models.ResponseChunk{
+			models.ResponseChunk{Data: "data: one\n\n"},
+			models.ResponseChunk{Data: "data: two\n\n", Delay: 100},
+		},
+	}, time.Now(), 0)
+
+	Expect(unit.Response.Content.Text).To(Equal("data: one\n\ndata: two\n\n"))
+}
+
+func Test_NewEntryViewFromPair_UsesMatcherValues(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := har.NewEntryViewFromPair(models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Destination: &models.RequestFieldMatchers{
+				ExactMatch: util.StringToPointer("test.com"),
+			},
+			Path: &models.RequestFieldMatchers{
+				GlobMatch: util.StringToPointer("/api/*"),
+			},
+		},
+		Response: models.ResponseDetails{
+			Status: 200,
+			Body:   "body",
+		},
+	}, time.Now())
+
+	Expect(unit.Request.Method).To(Equal("GET"))
+	Expect(unit.Request.URL).To(Equal("http://test.com/api/%2A"))
+	Expect(unit.Response.Content.Text).To(Equal("body"))
+}
+
+func Test_NewRequestResponsePair_ConvertsEntry(t *testing.T) {
+	RegisterTestingT(t)
+
+	request, response, err := har.NewRequestResponsePair(v2.HarEntryView{
+		Request: v2.HarRequestView{
+			Method: "post",
+			URL:    "https://Test.com/path?b=2&a=1",
+			Headers: []v2.HarNameValueView{
+				v2.HarNameValueView{Name: ":authority", Value: "test.com"},
+				v2.HarNameValueView{Name: "Content-Type", Value: "application/x-www-form-urlencoded"},
+			},
+			PostData: &v2.HarPostDataView{
+				MimeType: "application/x-www-form-urlencoded",
+				Params: []v2.HarNameValueView{
+					v2.HarNameValueView{Name: "name", Value: "hoverfly"},
+				},
+			},
+		},
+		Response: v2.HarResponseView{
+			Status: 200,
+			Headers: []v2.HarNameValueView{
+				v2.HarNameValueView{Name: "Content-Encoding", Value: "gzip"},
+				v2.HarNameValueView{Name: "Content-Type", Value: "text/plain"},
+			},
+			Content: v2.HarContentView{
+				Text:     "aGVsbG8=",
+				Encoding: "base64",
+			},
+		},
+	})
+
+	Expect(err).To(BeNil())
+
+	Expect(request.Method).To(Equal("POST"))
+	Expect(request.Scheme).To(Equal("https"))
+	Expect(request.Destination).To(Equal("test.com"))
+	Expect(request.Path).To(Equal("/path"))
+	Expect(request.Query).To(Equal("a=1&b=2"))
+	Expect(request.Body).To(Equal("name=hoverfly"))
+	Expect(request.Headers).To(Equal(map[string][]string{
+		"Content-Type": []string{"application/x-www-form-urlencoded"},
+	}))
+
+	Expect(response.Status).To(Equal(200))
+	Expect(response.Body).To(Equal("hello"))
+	Expect(response.Headers).To(Equal(map[string][]string{
+		"Content-Type": []string{"text/plain"},
+	}))
+}
+
+func Test_NewRequestResponsePair_ErrorsOnRelativeURL(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, _, err := har.NewRequestResponsePair(v2.HarEntryView{
+		Request: v2.HarRequestView{Method: "GET", URL: "/path"},
+	})
+
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Invalid request URL /path"))
+}
+
+func Test_NewRequestResponsePair_ErrorsOnInvalidBase64Content(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, _, err := har.NewRequestResponsePair(v2.HarEntryView{
+		Request: v2.HarRequestView{Method: "GET", URL: "http://test.com"},
+		Response: v2.HarResponseView{
+			Content: v2.HarContentView{Text: "!!", Encoding: "base64"},
+		},
+	})
+
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Invalid base64 response content for http://test.com"))
+}
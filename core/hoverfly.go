@@ -2,6 +2,7 @@ package hoverfly
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io/ioutil"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"sync"
+	"time"
 
 	"strings"
 
@@ -16,11 +18,16 @@ import (
 	"github.com/SpectoLabs/goproxy"
 	"github.com/SpectoLabs/hoverfly/core/authentication/backends"
 	"github.com/SpectoLabs/hoverfly/core/cache"
+	"github.com/SpectoLabs/hoverfly/core/certs"
+	"github.com/SpectoLabs/hoverfly/core/grpc"
 	"github.com/SpectoLabs/hoverfly/core/journal"
 	"github.com/SpectoLabs/hoverfly/core/matching"
 	"github.com/SpectoLabs/hoverfly/core/metrics"
+	"github.com/SpectoLabs/hoverfly/core/middleware"
 	"github.com/SpectoLabs/hoverfly/core/models"
 	"github.com/SpectoLabs/hoverfly/core/modes"
+	"github.com/SpectoLabs/hoverfly/core/openapi"
+	"github.com/SpectoLabs/hoverfly/core/tracing"
 	"github.com/SpectoLabs/hoverfly/core/util"
 )
 
@@ -37,21 +44,33 @@ func orPanic(err error) {
 type Hoverfly struct {
 	CacheMatcher   matching.CacheMatcher
 	MetadataCache  cache.Cache
+	StateCache     cache.Cache
 	Authentication backends.Authentication
 	HTTP           *http.Client
 	Cfg            *Configuration
 	Counter        *metrics.CounterByMode
+	Metrics        *metrics.RequestMetrics
+	Tracer         *tracing.Tracer
 
 	Proxy   *goproxy.ProxyHttpServer
 	SL      *StoppableListener
 	mu      sync.Mutex
 	version string
 
+	// statePending - whether a save of the state is waiting to be made
+	statePending bool
+	// restoring - whether the state is being restored, during which it is not saved
+	restoring bool
+
 	modeMap map[string]modes.Mode
 
 	Simulation    *models.Simulation
 	StoreLogsHook *StoreLogsHook
 	Journal       *journal.Journal
+
+	CertificateManager *certs.CertificateManager
+	Grpc               *grpc.Registry
+	Contract           *openapi.Contract
 }
 
 func NewHoverfly() *Hoverfly {
@@ -61,9 +80,13 @@ func NewHoverfly() *Hoverfly {
 		Simulation:     models.NewSimulation(),
 		Authentication: authBackend,
 		Counter:        metrics.NewModeCounter([]string{modes.Simulate, modes.Synthesize, modes.Modify, modes.Capture}),
+		Metrics:        metrics.NewRequestMetrics(nil, metrics.DefaultMaxSeries),
 		StoreLogsHook:  NewStoreLogsHook(),
 		Journal:        journal.NewJournal(),
 		Cfg:            InitSettings(),
+
+		CertificateManager: certs.NewCertificateManager(goproxy.GoproxyCa),
+		Grpc:               grpc.NewRegistry(),
 	}
 
 	hoverfly.version = "v0.12.1"
@@ -126,6 +149,53 @@ func GetNewHoverfly(cfg *Configuration, requestCache, metadataCache cache.Cache,
 	return hoverfly
 }
 
+// httpClient - the client requests are made upstream with, which is replaced when the upstream
+// proxy or TLS verification is changed while Hoverfly is running
+func (hf *Hoverfly) httpClient() *http.Client {
+	hf.mu.Lock()
+	defer hf.mu.Unlock()
+
+	return hf.HTTP
+}
+
+func (hf *Hoverfly) setHTTPClient(client *http.Client) {
+	hf.mu.Lock()
+	hf.HTTP = client
+	hf.mu.Unlock()
+}
+
+// middlewares - the middleware and middleware chain, read together so that requests see them
+// either as they were before a change or after it
+func (hf *Hoverfly) middlewares() (middleware.Middleware, []middleware.ChainedMiddleware) {
+	hf.mu.Lock()
+	defer hf.mu.Unlock()
+
+	return hf.Cfg.Middleware, hf.Cfg.MiddlewareChain
+}
+
+// swapMiddleware - replaces the middleware, returning the middleware it replaced so that it
+// can be stopped once requests can no longer pick it up
+func (hf *Hoverfly) swapMiddleware(newMiddleware middleware.Middleware) middleware.Middleware {
+	hf.mu.Lock()
+	defer hf.mu.Unlock()
+
+	old := hf.Cfg.Middleware
+	hf.Cfg.Middleware = newMiddleware
+
+	return old
+}
+
+// swapMiddlewareChain - replaces the middleware chain, returning the chain it replaced
+func (hf *Hoverfly) swapMiddlewareChain(chain []middleware.ChainedMiddleware) []middleware.ChainedMiddleware {
+	hf.mu.Lock()
+	defer hf.mu.Unlock()
+
+	old := hf.Cfg.MiddlewareChain
+	hf.Cfg.MiddlewareChain = chain
+
+	return old
+}
+
 func GetDefaultHoverflyHTTPClient(tlsVerification bool, upstreamProxy string) *http.Client {
 
 	var proxyURL func(*http.Request) (*url.URL, error)
@@ -144,6 +214,8 @@ func GetDefaultHoverflyHTTPClient(tlsVerification bool, upstreamProxy string) *h
 	}, Transport: &http.Transport{
 		Proxy:           proxyURL,
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: !tlsVerification},
+		// a custom TLS config disables HTTP/2 unless it is explicitly asked for
+		ForceAttemptHTTP2: true,
 	}}
 }
 
@@ -179,6 +251,14 @@ func (hf *Hoverfly) StartProxy() error {
 	hf.SL = sl
 	server := http.Server{}
 
+	var serverListener net.Listener = sl
+	if hf.Cfg.Webserver {
+		// the webserver accepts TLS on the same port, offering HTTP/2 via ALPN
+		tlsConfig := hf.CertificateManager.TLSConfig("localhost")
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+		serverListener = newTLSSniffingListener(sl, tlsConfig)
+	}
+
 	hf.Cfg.ProxyControlWG.Add(1)
 
 	go func() {
@@ -187,8 +267,8 @@ func (hf *Hoverfly) StartProxy() error {
 			hf.Cfg.ProxyControlWG.Done()
 		}()
 		log.Info("serving proxy")
-		server.Handler = hf.Proxy
-		log.Warn(server.Serve(sl))
+		server.Handler = hf.webSocketHandler(hf.Proxy)
+		log.Warn(server.Serve(serverListener))
 	}()
 
 	return nil
@@ -203,12 +283,43 @@ func (hf *Hoverfly) StopProxy() {
 // processRequest - processes incoming requests and based on proxy state (record/playback)
 // returns HTTP response.
 func (hf *Hoverfly) processRequest(req *http.Request) *http.Response {
+	started := time.Now()
+	mode := hf.Cfg.GetMode()
+
+	ctx := tracing.ContextWithRemoteParent(req.Context(), req.Header.Get(tracing.TraceparentHeader))
+	ctx = models.ContextWithBodyFiles(ctx, hf.Cfg.BodyFiles())
+	ctx, span := hf.Tracer.Start(ctx, "hoverfly.request", tracing.SpanKindServer)
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.host", req.Host)
+	span.SetAttribute("http.target", req.URL.RequestURI())
+	span.SetAttribute("hoverfly.mode", mode)
+	defer span.Finish()
+
+	response := hf.handleRequest(req.WithContext(ctx), mode)
+
+	if response != nil {
+		span.SetAttribute("http.status_code", response.StatusCode)
+		hf.Metrics.Request(mode, strings.ToLower(req.Host), response.StatusCode, time.Since(started))
+	}
+
+	return response
+}
+
+func (hf *Hoverfly) handleRequest(req *http.Request, mode string) *http.Response {
+	if errorResponse := hf.decodeGrpcRequest(req); errorResponse != nil {
+		return errorResponse
+	}
+
 	requestDetails, err := models.NewRequestDetailsFromHttpRequest(req)
 	if err != nil {
 		return modes.ErrorResponse(req, err, "Could not interpret HTTP request")
 	}
 
-	mode := hf.Cfg.GetMode()
+	if contract := hf.getContract(); mode == modes.Simulate && contract != nil && contract.RejectInvalidRequests {
+		if violations := contract.Document.ValidateRequest(requestDetails); len(violations) > 0 {
+			return contractViolationResponse(req, violations)
+		}
+	}
 
 	response, err := hf.modeMap[mode].Process(req, requestDetails)
 
@@ -220,12 +331,22 @@ func (hf *Hoverfly) processRequest(req *http.Request) *http.Response {
 
 	respDelay := hf.Simulation.ResponseDelays.GetDelay(requestDetails)
 	if respDelay != nil {
+		_, span := hf.Tracer.Start(req.Context(), "hoverfly.delay", tracing.SpanKindInternal)
+		span.SetAttribute("hoverfly.delay_ms", respDelay.Delay)
 		respDelay.Execute()
+		span.Finish()
 	}
 
 	return response
 }
 
+// contractViolationResponse - rejects a request which does not conform to the contract, explaining why
+func contractViolationResponse(req *http.Request, violations []string) *http.Response {
+	return goproxy.NewResponse(req,
+		goproxy.ContentTypeText, http.StatusBadRequest,
+		fmt.Sprintf("Hoverfly Error!\n\nRequest does not conform to the OpenAPI contract\n\n%s", strings.Join(violations, "\n")))
+}
+
 // DoRequest - performs request and returns response that should be returned to client and error
 func (hf *Hoverfly) DoRequest(request *http.Request) (*http.Response, error) {
 
@@ -234,12 +355,41 @@ func (hf *Hoverfly) DoRequest(request *http.Request) (*http.Response, error) {
 
 	requestBody, _ := ioutil.ReadAll(request.Body)
 
-	request.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	upstreamBody, err := hf.encodeGrpcRequest(request, requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Body = ioutil.NopCloser(bytes.NewReader(upstreamBody))
+
+	_, span := hf.Tracer.Start(request.Context(), "hoverfly.upstream", tracing.SpanKindClient)
+	span.SetAttribute("http.method", request.Method)
+	span.SetAttribute("http.url", request.URL.String())
+	defer span.Finish()
 
-	resp, err := hf.HTTP.Do(request)
+	// the real service becomes a child of the upstream span, the header is copied
+	// first as it can be shared with the request which gets saved to the simulation
+	if span != nil {
+		header := http.Header{}
+		for name, values := range request.Header {
+			header[name] = values
+		}
+		header.Set(tracing.TraceparentHeader, span.Context.Traceparent())
+		request.Header = header
+	}
+
+	started := time.Now()
+	resp, err := hf.httpClient().Do(request)
+	hf.Metrics.Upstream(strings.ToLower(request.Host), time.Since(started))
 
 	request.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
 	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+	span.SetAttribute("http.status_code", resp.StatusCode)
+
+	if err := hf.decodeGrpcResponse(request, resp); err != nil {
 		return nil, err
 	}
 
@@ -250,12 +400,22 @@ func (hf *Hoverfly) DoRequest(request *http.Request) (*http.Response, error) {
 }
 
 // GetResponse returns stored response from cache
-func (hf *Hoverfly) GetResponse(requestDetails models.RequestDetails) (*models.ResponseDetails, *matching.MatchingError) {
+func (hf *Hoverfly) GetResponse(ctx context.Context, requestDetails models.RequestDetails) (*models.ResponseDetails, *matching.MatchingError) {
 
+	_, cacheSpan := hf.Tracer.Start(ctx, "hoverfly.cache_lookup", tracing.SpanKindInternal)
 	cachedResponse, cacheErr := hf.CacheMatcher.GetCachedResponse(&requestDetails)
+	cacheSpan.SetAttribute("hoverfly.cache_hit", cacheErr == nil)
+	cacheSpan.Finish()
+
+	if hf.CacheMatcher.RequestCache != nil {
+		hf.Metrics.CacheLookup(cacheErr == nil)
+	}
+
 	if cacheErr == nil && cachedResponse.MatchingPair == nil {
+		hf.Metrics.MatchFailure(requestDetails.Destination)
 		return nil, matching.MissedError(cachedResponse.ClosestMiss)
 	} else if cacheErr == nil {
+		hf.Metrics.Match(pairLabel(cachedResponse.MatchingPair.RequestMatcher))
 		return &cachedResponse.MatchingPair.Response, nil
 	}
 
@@ -266,11 +426,15 @@ func (hf *Hoverfly) GetResponse(requestDetails models.RequestDetails) (*models.R
 
 	strongestMatch := strings.ToLower(mode.MatchingStrategy) == "strongest"
 
+	_, matchSpan := hf.Tracer.Start(ctx, "hoverfly.match", tracing.SpanKindInternal)
+	matchSpan.SetAttribute("hoverfly.matching_strategy", mode.MatchingStrategy)
 	if strongestMatch {
 		pair, err = matching.StrongestMatchRequestMatcher(requestDetails, hf.Cfg.Webserver, hf.Simulation)
 	} else {
 		pair, err = matching.FirstMatchRequestMatcher(requestDetails, hf.Cfg.Webserver, hf.Simulation)
 	}
+	matchSpan.SetAttribute("hoverfly.matched", err == nil)
+	matchSpan.Finish()
 
 	hf.CacheMatcher.SaveRequestMatcherResponsePair(requestDetails, pair, err)
 
@@ -283,14 +447,49 @@ func (hf *Hoverfly) GetResponse(requestDetails models.RequestDetails) (*models.R
 			"method":      requestDetails.Method,
 		}).Warn("Failed to find matching request from simulation")
 
+		hf.Metrics.MatchFailure(requestDetails.Destination)
 		return nil, matching.MissedError(err.ClosestMiss)
 	}
 
+	hf.Metrics.Match(pairLabel(pair.RequestMatcher))
 	return &pair.Response, nil
 }
 
+// pairLabel - names a request response pair after the method, destination and path it matches
+func pairLabel(requestMatcher models.RequestMatcher) string {
+	parts := []string{}
+	for _, matchers := range []*models.RequestFieldMatchers{requestMatcher.Method, requestMatcher.Destination, requestMatcher.Path} {
+		value := "*"
+		if matchers != nil {
+			for _, matcherValue := range []*string{matchers.ExactMatch, matchers.GlobMatch, matchers.RegexMatch} {
+				if matcherValue != nil {
+					value = *matcherValue
+					break
+				}
+			}
+		}
+		parts = append(parts, value)
+	}
+
+	return strings.Join(parts, " ")
+}
+
 // save gets request fingerprint, extracts request body, status code and headers, then saves it to cache
 func (hf *Hoverfly) Save(request *models.RequestDetails, response *models.ResponseDetails, headersWhitelist []string) error {
+	pair := hf.newCapturedPair(request, response, headersWhitelist)
+
+	hf.mu.Lock()
+	hf.Simulation.AddRequestMatcherResponsePair(&pair)
+	hf.mu.Unlock()
+
+	hf.saveStateLater()
+
+	return nil
+}
+
+// newCapturedPair - a pair which matches the request exactly, along with the headers in the
+// whitelist, and responds with the response
+func (hf *Hoverfly) newCapturedPair(request *models.RequestDetails, response *models.ResponseDetails, headersWhitelist []string) models.RequestMatcherResponsePair {
 	body := &models.RequestFieldMatchers{
 		ExactMatch: util.StringToPointer(request.Body),
 	}
@@ -303,6 +502,10 @@ func (hf *Hoverfly) Save(request *models.RequestDetails, response *models.Respon
 		body = &models.RequestFieldMatchers{
 			XmlMatch: util.StringToPointer(request.Body),
 		}
+	} else if hf.isDecodedGrpcRequest(request) {
+		body = &models.RequestFieldMatchers{
+			JsonMatch: util.StringToPointer(request.Body),
+		}
 	}
 
 	var headers map[string][]string
@@ -345,23 +548,97 @@ func (hf *Hoverfly) Save(request *models.RequestDetails, response *models.Respon
 		Response: *response,
 	}
 
-	hf.Simulation.AddRequestMatcherResponsePair(&pair)
+	// large bodies are kept in files rather than in the simulation
+	bodyFiles := hf.Cfg.BodyFiles()
+	if bodyFiles.ShouldCapture(pair.Response.Body) {
+		name, err := bodyFiles.Write(pair.Response.Body, pair.Response.Headers)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err.Error(),
+			}).Warn("Failed to write captured body to a file, it is kept in the simulation")
+		} else {
+			pair.Response.Body = ""
+			pair.Response.BodyFile = name
+		}
+	}
 
-	return nil
+	return pair
 }
 
-func (this Hoverfly) ApplyMiddleware(pair models.RequestResponsePair) (models.RequestResponsePair, error) {
-	if this.Cfg.Middleware.IsSet() {
-		return this.Cfg.Middleware.Execute(pair)
+// ApplyMiddleware - runs the middleware, followed by each middleware in the chain which applies
+// to the request in the given phase. In the response phase, middleware named by the response
+// is run last, unless it has already run as part of the chain
+func (this *Hoverfly) ApplyMiddleware(ctx context.Context, phase string, pair models.RequestResponsePair) (models.RequestResponsePair, error) {
+	var err error
+
+	current, chain := this.middlewares()
+	if current.IsSet() {
+		pair, err = this.executeMiddleware(ctx, "", &current, pair)
+		if err != nil {
+			return pair, err
+		}
+	}
+
+	named := ""
+	if phase == middleware.ResponsePhase {
+		named = pair.Response.Middleware
+	}
+
+	for _, chained := range chain {
+		if !chained.Applies(phase, pair.Request) {
+			continue
+		}
+
+		if chained.Name != "" && chained.Name == named {
+			named = ""
+		}
+
+		pair, err = this.executeMiddleware(ctx, chained.Name, chained.Middleware, pair)
+		if err != nil {
+			return pair, err
+		}
+	}
+
+	if named != "" {
+		for _, chained := range chain {
+			if chained.Name == named {
+				return this.executeMiddleware(ctx, chained.Name, chained.Middleware, pair)
+			}
+		}
+
+		return pair, fmt.Errorf("There is no middleware named %s in the middleware chain", named)
 	}
 
 	return pair, nil
 }
 
-func (this Hoverfly) IsMiddlewareSet() bool {
-	return this.Cfg.Middleware.IsSet()
+func (this *Hoverfly) executeMiddleware(ctx context.Context, name string, toRun *middleware.Middleware, pair models.RequestResponsePair) (models.RequestResponsePair, error) {
+	_, span := this.Tracer.Start(ctx, "hoverfly.middleware", tracing.SpanKindInternal)
+	if name != "" {
+		span.SetAttribute("hoverfly.middleware", name)
+	}
+	result, diagnostics, err := toRun.ExecuteWithDiagnostics(pair)
+
+	diagnostics.Name = name
+	middleware.RecordDiagnostics(ctx, diagnostics)
+
+	this.Metrics.Middleware(this.Cfg.GetMode(), diagnostics.Latency)
+	if diagnostics.Error != "" {
+		span.SetAttribute("hoverfly.middleware_error", diagnostics.ErrorKind)
+		span.SetAttribute("hoverfly.middleware_policy", diagnostics.Policy)
+	}
+	span.SetError(err)
+	span.Finish()
+
+	return result, err
+}
+
+// IsMiddlewareSet - whether there is any middleware to run, either on its own or in the chain
+func (this *Hoverfly) IsMiddlewareSet() bool {
+	current, chain := this.middlewares()
+	return current.IsSet() || len(chain) > 0
 }
 
-func (this Hoverfly) GetSimulationPairsCount() int {
+func (this *Hoverfly) GetSimulationPairsCount() int {
 	return len(this.Simulation.MatchingPairs)
 }
@@ -9,15 +9,22 @@ import (
 	"github.com/SpectoLabs/hoverfly/core/cache"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/har"
+	"github.com/SpectoLabs/hoverfly/core/matching"
 	"github.com/SpectoLabs/hoverfly/core/metrics"
 	"github.com/SpectoLabs/hoverfly/core/middleware"
 	"github.com/SpectoLabs/hoverfly/core/models"
 	"github.com/SpectoLabs/hoverfly/core/modes"
+	"github.com/SpectoLabs/hoverfly/core/openapi"
+	"github.com/SpectoLabs/hoverfly/core/pact"
+	"github.com/SpectoLabs/hoverfly/core/postman"
 	"github.com/SpectoLabs/hoverfly/core/util"
+	"github.com/SpectoLabs/hoverfly/core/wiremock"
 	"strings"
+	"time"
 )
 
-func (this Hoverfly) GetDestination() string {
+func (this *Hoverfly) GetDestination() string {
 	return this.Cfg.Destination
 }
 
@@ -33,10 +40,14 @@ func (hf *Hoverfly) SetDestination(destination string) (err error) {
 	hf.Cfg.Destination = destination
 	err = hf.StartProxy()
 	hf.mu.Unlock()
+
+	if err == nil {
+		hf.SaveState()
+	}
 	return
 }
 
-func (this Hoverfly) GetMode() v2.ModeView {
+func (this *Hoverfly) GetMode() v2.ModeView {
 	return this.modeMap[this.Cfg.Mode].View()
 }
 
@@ -104,42 +115,191 @@ func (this *Hoverfly) SetModeWithArguments(modeView v2.ModeView) error {
 		"mode": this.Cfg.GetMode(),
 	}).Info("Mode has been changed")
 
+	this.SaveState()
+
 	return nil
 }
 
-func (hf Hoverfly) GetMiddleware() (string, string, string) {
-	script, _ := hf.Cfg.Middleware.GetScript()
-	return hf.Cfg.Middleware.Binary, script, hf.Cfg.Middleware.Remote
+func (hf *Hoverfly) GetMiddleware() (string, string, string) {
+	current, _ := hf.middlewares()
+	script, _ := current.GetScript()
+	return current.Binary, script, current.Remote
+}
+
+func (hf *Hoverfly) GetMiddlewareView() v2.MiddlewareView {
+	current, _ := hf.middlewares()
+	return newMiddlewareView(current)
+}
+
+func newMiddlewareView(middleware middleware.Middleware) v2.MiddlewareView {
+	script, _ := middleware.GetScript()
+
+	middlewareView := v2.MiddlewareView{
+		Binary:     middleware.Binary,
+		Script:     script,
+		Remote:     middleware.Remote,
+		Persistent: middleware.Persistent,
+		Timeout:    int(middleware.Timeout / time.Millisecond),
+		Engine:     middleware.Engine,
+	}
+
+	if middleware.ErrorPolicy.IsSet() {
+		middlewareView.ErrorPolicy = &v2.MiddlewareErrorPolicyView{
+			OnTimeout:         middleware.ErrorPolicy.OnTimeout,
+			OnFailure:         middleware.ErrorPolicy.OnFailure,
+			OnInvalidResponse: middleware.ErrorPolicy.OnInvalidResponse,
+		}
+
+		if middleware.ErrorPolicy.Fallback != nil {
+			fallback := middleware.ErrorPolicy.Fallback.ConvertToResponseDetailsView()
+			middlewareView.ErrorPolicy.Fallback = &fallback
+		}
+	}
+
+	if middleware.RemoteOptions.IsSet() {
+		middlewareView.RemoteOptions = &v2.MiddlewareRemoteOptionsView{
+			Headers:            middleware.RemoteOptions.Headers,
+			CACert:             middleware.RemoteOptions.CACert,
+			ClientCert:         middleware.RemoteOptions.ClientCert,
+			ClientKey:          middleware.RemoteOptions.ClientKey,
+			InsecureSkipVerify: middleware.RemoteOptions.InsecureSkipVerify,
+		}
+	}
+
+	return middlewareView
 }
 
 func (hf *Hoverfly) SetMiddleware(binary, script, remote string) error {
-	newMiddleware := middleware.Middleware{}
+	return hf.SetMiddlewareWithOptions(v2.MiddlewareView{
+		Binary: binary,
+		Script: script,
+		Remote: remote,
+	})
+}
 
-	if binary == "" && script == "" && remote == "" {
-		hf.Cfg.Middleware = newMiddleware
+// SetMiddlewareWithOptions - sets the middleware, which when persistent is kept running between
+// requests, and with an engine is a script run inside Hoverfly. Timeout is how many milliseconds
+// middleware has to respond, and the error policy decides what happens to requests it fails on.
+// Remote options set the headers and TLS remote middleware is called with
+func (hf *Hoverfly) SetMiddlewareWithOptions(middlewareView v2.MiddlewareView) error {
+	if middlewareView.Binary == "" && middlewareView.Script == "" && middlewareView.Remote == "" {
+		old := hf.swapMiddleware(middleware.Middleware{})
+		old.Stop()
+		hf.SaveState()
 		return nil
 	}
 
-	if binary == "" && script != "" {
-		return fmt.Errorf("Cannot run script with no binary")
+	newMiddleware, err := newMiddlewareFromView(middlewareView)
+	if err != nil {
+		return err
+	}
+
+	old := hf.swapMiddleware(*newMiddleware)
+	old.Stop()
+	hf.SaveState()
+	return nil
+}
+
+// newMiddlewareFromView - sets up middleware and checks that it runs. The error policy is only
+// set once it has run, so that middleware which does not work is not hidden by it
+func newMiddlewareFromView(middlewareView v2.MiddlewareView) (*middleware.Middleware, error) {
+	errorPolicy, err := newErrorPolicyFromView(middlewareView.ErrorPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	newMiddleware, err := buildMiddlewareFromView(middlewareView)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = newMiddleware.Execute(newMiddlewareTestPair())
+	if err != nil {
+		newMiddleware.Stop()
+		return nil, err
+	}
+
+	newMiddleware.ErrorPolicy = errorPolicy
+
+	return newMiddleware, nil
+}
+
+// buildMiddlewareFromView - sets up middleware without running it or setting its error policy
+func buildMiddlewareFromView(middlewareView v2.MiddlewareView) (*middleware.Middleware, error) {
+	binary, script, remote, engine := middlewareView.Binary, middlewareView.Script, middlewareView.Remote, middlewareView.Engine
+	newMiddleware := &middleware.Middleware{}
+
+	if engine != "" && binary != "" {
+		return nil, fmt.Errorf("Cannot run script with both a binary and an engine")
+	}
+
+	if binary == "" && engine == "" && script != "" {
+		return nil, fmt.Errorf("Cannot run script with no binary")
 	}
 
 	err := newMiddleware.SetBinary(binary)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = newMiddleware.SetScript(script)
 	if err != nil {
-		return nil
+		return nil, err
+	}
+
+	if middlewareView.RemoteOptions != nil {
+		err = newMiddleware.SetRemoteOptions(middleware.RemoteOptions{
+			Headers:            middlewareView.RemoteOptions.Headers,
+			CACert:             middlewareView.RemoteOptions.CACert,
+			ClientCert:         middlewareView.RemoteOptions.ClientCert,
+			ClientKey:          middlewareView.RemoteOptions.ClientKey,
+			InsecureSkipVerify: middlewareView.RemoteOptions.InsecureSkipVerify,
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	err = newMiddleware.SetRemote(remote)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	testData := models.RequestResponsePair{
+	err = newMiddleware.SetEngine(engine)
+	if err != nil {
+		return nil, err
+	}
+
+	newMiddleware.Timeout = time.Duration(middlewareView.Timeout) * time.Millisecond
+	if middlewareView.Persistent {
+		newMiddleware.SetPersistent(true, newMiddleware.Timeout)
+	}
+
+	return newMiddleware, nil
+}
+
+func newErrorPolicyFromView(errorPolicyView *v2.MiddlewareErrorPolicyView) (middleware.ErrorPolicy, error) {
+	if errorPolicyView == nil {
+		return middleware.ErrorPolicy{}, nil
+	}
+
+	errorPolicy := middleware.ErrorPolicy{
+		OnTimeout:         errorPolicyView.OnTimeout,
+		OnFailure:         errorPolicyView.OnFailure,
+		OnInvalidResponse: errorPolicyView.OnInvalidResponse,
+	}
+
+	if errorPolicyView.Fallback != nil {
+		fallback := models.NewResponseDetailsFromResponse(*errorPolicyView.Fallback)
+		errorPolicy.Fallback = &fallback
+	}
+
+	return errorPolicy, errorPolicy.Validate()
+}
+
+// newMiddlewareTestPair - the pair middleware is run on to check that it works
+func newMiddlewareTestPair() models.RequestResponsePair {
+	return models.RequestResponsePair{
 		Request: models.RequestDetails{
 			Path:        "/",
 			Method:      "GET",
@@ -155,28 +315,157 @@ func (hf *Hoverfly) SetMiddleware(binary, script, remote string) error {
 			Headers: map[string][]string{"test_header": []string{"true"}},
 		},
 	}
-	_, err = newMiddleware.Execute(testData)
-	if err != nil {
-		return err
+}
+
+// TestMiddleware - runs middleware on a pair, returning the pair it returns along with what
+// happened. The middleware is the one given, the one in the chain with the name given, or the
+// middleware which is set, and the pair defaults to the one middleware is checked with when set
+func (hf *Hoverfly) TestMiddleware(testView v2.MiddlewareTestView) (v2.MiddlewareTestResultView, error) {
+	var toTest *middleware.Middleware
+	current, chain := hf.middlewares()
+
+	if testView.Middleware != nil {
+		errorPolicy, err := newErrorPolicyFromView(testView.Middleware.ErrorPolicy)
+		if err != nil {
+			return v2.MiddlewareTestResultView{}, err
+		}
+
+		toTest, err = buildMiddlewareFromView(*testView.Middleware)
+		if err != nil {
+			return v2.MiddlewareTestResultView{}, err
+		}
+		defer toTest.Stop()
+
+		toTest.ErrorPolicy = errorPolicy
+	} else if testView.Name != "" {
+		for _, chained := range chain {
+			if chained.Name == testView.Name {
+				toTest = chained.Middleware
+			}
+		}
+
+		if toTest == nil {
+			return v2.MiddlewareTestResultView{}, fmt.Errorf("There is no middleware named %s in the middleware chain", testView.Name)
+		}
+	} else if current.IsSet() {
+		toTest = &current
+	} else {
+		return v2.MiddlewareTestResultView{}, fmt.Errorf("Middleware not set")
+	}
+
+	pair := newMiddlewareTestPair()
+	if testView.Pair != nil {
+		pair = models.NewRequestResponsePairFromRequestResponsePairView(*testView.Pair)
 	}
 
-	hf.Cfg.Middleware = newMiddleware
+	result, diagnostics, _ := toTest.ExecuteWithDiagnostics(pair)
+	diagnostics.Name = testView.Name
+
+	return v2.MiddlewareTestResultView{
+		Pair:        result.ConvertToRequestResponsePairView(),
+		Diagnostics: diagnostics.BuildView(),
+	}, nil
+}
+
+func (hf *Hoverfly) GetMiddlewareChain() v2.MiddlewareChainView {
+	_, chain := hf.middlewares()
+	return newMiddlewareChainView(chain)
+}
+
+func newMiddlewareChainView(chain []middleware.ChainedMiddleware) v2.MiddlewareChainView {
+	chainView := v2.MiddlewareChainView{Middlewares: []v2.ChainedMiddlewareView{}}
+
+	for _, chained := range chain {
+		middlewareView := newMiddlewareView(*chained.Middleware)
+
+		var requestMatcher *v2.RequestMatcherViewV2
+		if chained.RequestMatcher != nil {
+			pair := models.RequestMatcherResponsePair{RequestMatcher: *chained.RequestMatcher}
+			view := pair.BuildView()
+			requestMatcher = &view.RequestMatcher
+		}
+
+		chainView.Middlewares = append(chainView.Middlewares, v2.ChainedMiddlewareView{
+			Name:           chained.Name,
+			Phase:          chained.Phase,
+			RequestMatcher: requestMatcher,
+			MiddlewareView: middlewareView,
+		})
+	}
+
+	return chainView
+}
+
+// SetMiddlewareChain - replaces the chain of middleware, leaving it as it was if any of the
+// middleware is not valid
+func (hf *Hoverfly) SetMiddlewareChain(chainView v2.MiddlewareChainView) error {
+	chain := []middleware.ChainedMiddleware{}
+	names := map[string]bool{}
+
+	stop := func() {
+		for _, chained := range chain {
+			chained.Middleware.Stop()
+		}
+	}
+
+	for i, chainedView := range chainView.Middlewares {
+		if chainedView.Name != "" && names[chainedView.Name] {
+			stop()
+			return fmt.Errorf("Middleware %d has the same name as another, %s", i, chainedView.Name)
+		}
+		names[chainedView.Name] = true
+
+		if err := middleware.ValidatePhase(chainedView.Phase); err != nil {
+			stop()
+			return err
+		}
+
+		if chainedView.Binary == "" && chainedView.Remote == "" && chainedView.Engine == "" {
+			stop()
+			return fmt.Errorf("Middleware %d has no binary, remote or engine", i)
+		}
+
+		newMiddleware, err := newMiddlewareFromView(chainedView.MiddlewareView)
+		if err != nil {
+			stop()
+			return fmt.Errorf("Middleware %d is not valid: %s", i, err.Error())
+		}
+
+		var requestMatcher *models.RequestMatcher
+		if chainedView.RequestMatcher != nil {
+			matcher := models.NewRequestMatcherFromView(*chainedView.RequestMatcher)
+			requestMatcher = &matcher
+		}
+
+		chain = append(chain, middleware.ChainedMiddleware{
+			Name:           chainedView.Name,
+			Phase:          chainedView.Phase,
+			RequestMatcher: requestMatcher,
+			Middleware:     newMiddleware,
+		})
+	}
+
+	for _, chained := range hf.swapMiddlewareChain(chain) {
+		chained.Middleware.Stop()
+	}
+
+	hf.SaveState()
 	return nil
 }
 
-func (hf Hoverfly) GetRequestCacheCount() (int, error) {
+func (hf *Hoverfly) GetRequestCacheCount() (int, error) {
 	return len(hf.Simulation.MatchingPairs), nil
 }
 
-func (this Hoverfly) GetMetadataCache() cache.Cache {
+func (this *Hoverfly) GetMetadataCache() cache.Cache {
 	return this.MetadataCache
 }
 
-func (this Hoverfly) GetCache() (v2.CacheView, error) {
+func (this *Hoverfly) GetCache() (v2.CacheView, error) {
 	return this.CacheMatcher.GetAllResponses()
 }
 
-func (hf Hoverfly) FlushCache() error {
+func (hf *Hoverfly) FlushCache() error {
 	return hf.CacheMatcher.FlushCache()
 }
 
@@ -201,18 +490,24 @@ func (hf *Hoverfly) SetResponseDelays(payloadView v1.ResponseDelayPayloadView) e
 	}
 
 	hf.Simulation.ResponseDelays = &responseDelays
+	hf.SaveState()
 	return nil
 }
 
 func (hf *Hoverfly) DeleteResponseDelays() {
 	hf.Simulation.ResponseDelays = &models.ResponseDelayList{}
+	hf.SaveState()
 }
 
-func (hf Hoverfly) GetStats() metrics.Stats {
+func (hf *Hoverfly) GetStats() metrics.Stats {
 	return hf.Counter.Flush()
 }
 
-func (hf Hoverfly) GetSimulation() (v2.SimulationViewV2, error) {
+func (this *Hoverfly) GetMetrics() *metrics.RequestMetrics {
+	return this.Metrics
+}
+
+func (hf *Hoverfly) GetSimulation() (v2.SimulationViewV2, error) {
 	pairViews := make([]v2.RequestMatcherResponsePairViewV2, 0)
 
 	for _, v := range hf.Simulation.MatchingPairs {
@@ -251,12 +546,254 @@ func (this *Hoverfly) DeleteSimulation() {
 	this.Simulation.MatchingPairs = pairs
 	this.DeleteResponseDelays()
 	this.FlushCache()
+	this.SaveState()
 }
 
-func (this Hoverfly) GetVersion() string {
+func (this *Hoverfly) GetVersion() string {
 	return this.version
 }
 
-func (this Hoverfly) GetUpstreamProxy() string {
-	return this.Cfg.UpstreamProxy
+func (this *Hoverfly) GetUpstreamProxy() string {
+	return this.Cfg.GetUpstreamProxy()
+}
+
+func (this *Hoverfly) GetCACertificate() []byte {
+	return this.CertificateManager.GetCAPEM()
+}
+
+func (this *Hoverfly) SetCACertificate(certificate, key string) error {
+	return this.CertificateManager.SetCAFromPEM([]byte(certificate), []byte(key))
+}
+
+func (this *Hoverfly) GetIssuedCertificates() v2.CertificatesView {
+	certificateViews := []v2.CertificateView{}
+	for _, issued := range this.CertificateManager.GetIssuedCertificates() {
+		certificateViews = append(certificateViews, v2.CertificateView{
+			Host:         issued.Host,
+			SerialNumber: issued.SerialNumber,
+			KeyType:      issued.KeyType,
+			NotBefore:    issued.NotBefore.Format(time.RFC3339),
+			NotAfter:     issued.NotAfter.Format(time.RFC3339),
+		})
+	}
+
+	return v2.CertificatesView{Certificates: certificateViews}
+}
+
+func (this *Hoverfly) FlushCertificates() {
+	this.CertificateManager.Flush()
+}
+
+func (this *Hoverfly) GetGrpcMethods() v2.GrpcMethodsView {
+	methodViews := []v2.GrpcMethodView{}
+	for _, method := range this.Grpc.GetMethods() {
+		methodViews = append(methodViews, v2.GrpcMethodView{
+			Path:            method.Path(),
+			Service:         method.Service,
+			Method:          method.Name,
+			InputType:       method.InputType,
+			OutputType:      method.OutputType,
+			ClientStreaming: method.ClientStreaming,
+			ServerStreaming: method.ServerStreaming,
+		})
+	}
+
+	return v2.GrpcMethodsView{Methods: methodViews}
+}
+
+func (this *Hoverfly) AddGrpcDescriptorSet(descriptorSet []byte) error {
+	return this.Grpc.AddDescriptorSet(descriptorSet)
+}
+
+func (this *Hoverfly) DeleteGrpcDescriptorSets() {
+	this.Grpc.Reset()
+}
+
+func (this *Hoverfly) GetJournalHar(filter v2.JournalEntryFilterView) (v2.HarView, error) {
+	entries, err := this.Journal.GetPageOfEntries(filter)
+	if err != nil {
+		return v2.HarView{}, err
+	}
+
+	harEntries := []v2.HarEntryView{}
+	for _, entry := range entries {
+		harEntries = append(harEntries, har.NewEntryView(*entry.Request, *entry.Response, entry.TimeStarted, entry.Latency))
+	}
+
+	return har.NewHarView(harEntries, this.version), nil
+}
+
+func (this *Hoverfly) GetSimulationHar() (v2.HarView, error) {
+	started := time.Now()
+
+	entries := []v2.HarEntryView{}
+	for _, pair := range this.Simulation.MatchingPairs {
+		entries = append(entries, har.NewEntryViewFromPair(pair, started))
+	}
+
+	return har.NewHarView(entries, this.version), nil
+}
+
+// PutSimulationHar - replaces the simulation with the entries of an HTTP Archive, saving
+// each entry the same way captured traffic is saved. Nothing is changed if any entry is invalid
+func (this *Hoverfly) PutSimulationHar(harView v2.HarView) error {
+	pairs := []models.RequestMatcherResponsePair{}
+	for _, entry := range harView.Log.Entries {
+		request, response, err := har.NewRequestResponsePair(entry)
+		if err != nil {
+			return err
+		}
+
+		pairs = append(pairs, this.newCapturedPair(&request, &response, nil))
+	}
+
+	this.DeleteSimulation()
+
+	for i := range pairs {
+		this.Simulation.AddRequestMatcherResponsePair(&pairs[i])
+	}
+	this.SaveState()
+
+	return nil
+}
+
+// PutSimulationOpenApi - replaces the simulation with one generated from an OpenAPI 2 or 3 document
+func (this *Hoverfly) PutSimulationOpenApi(data []byte) error {
+	document, err := openapi.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	this.DeleteSimulation()
+
+	return this.PutSimulation(openapi.NewSimulationView(document))
+}
+
+// PutSimulationPostman - replaces the simulation with one converted from a Postman
+// collection, returning the constructs which could not be translated
+func (this *Hoverfly) PutSimulationPostman(data []byte) ([]string, error) {
+	simulation, untranslated, err := postman.NewSimulationView(data)
+	if err != nil {
+		return nil, err
+	}
+
+	this.DeleteSimulation()
+
+	return untranslated, this.PutSimulation(simulation)
+}
+
+// PutSimulationWireMock - replaces the simulation with one converted from WireMock
+// stub mappings, returning the constructs which could not be translated
+func (this *Hoverfly) PutSimulationWireMock(data []byte) ([]string, error) {
+	simulation, untranslated, err := wiremock.NewSimulationView(data)
+	if err != nil {
+		return nil, err
+	}
+
+	this.DeleteSimulation()
+
+	return untranslated, this.PutSimulation(simulation)
+}
+
+// GetPact - builds a Pact contract from the filtered journal, or from the simulation. Matching
+// rules for journaled requests come from the pair in the simulation which matches them
+func (this *Hoverfly) GetPact(options v2.PactOptionsView, filter v2.JournalEntryFilterView) (v2.PactView, error) {
+	contract := pact.NewPact(options)
+
+	if options.Source == "simulation" {
+		for _, pair := range this.Simulation.MatchingPairs {
+			contract.AddPair(pair)
+		}
+
+		return contract.View(), nil
+	}
+
+	pairs, err := this.Journal.GetRequestResponsePairs(filter)
+	if err != nil {
+		return v2.PactView{}, err
+	}
+
+	for _, pair := range pairs {
+		var requestMatcher *models.RequestMatcher
+		if matchingPair, _ := matching.StrongestMatchRequestMatcher(pair.Request, this.Cfg.Webserver, this.Simulation); matchingPair != nil {
+			requestMatcher = &matchingPair.RequestMatcher
+		}
+
+		contract.AddInteraction(pair.Request, pair.Response, requestMatcher)
+	}
+
+	return contract.View(), nil
+}
+
+// getContract - the contract, which can be changed through the admin API while requests are being handled
+func (this *Hoverfly) getContract() *openapi.Contract {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	return this.Contract
+}
+
+func (this *Hoverfly) setContract(contract *openapi.Contract) {
+	this.mu.Lock()
+	this.Contract = contract
+	this.mu.Unlock()
+}
+
+func (this *Hoverfly) GetContract() v2.ContractView {
+	contract := this.getContract()
+	if contract == nil {
+		return v2.ContractView{}
+	}
+
+	return v2.ContractView{
+		Document:              contract.Source,
+		RejectInvalidRequests: contract.RejectInvalidRequests,
+	}
+}
+
+// PutContract - sets the OpenAPI document which the journal is validated against
+func (this *Hoverfly) PutContract(contractView v2.ContractView) error {
+	document, err := openapi.Parse([]byte(contractView.Document))
+	if err != nil {
+		return err
+	}
+
+	this.setContract(&openapi.Contract{
+		Document:              document,
+		Source:                contractView.Document,
+		RejectInvalidRequests: contractView.RejectInvalidRequests,
+	})
+
+	return nil
+}
+
+func (this *Hoverfly) DeleteContract() {
+	this.setContract(nil)
+}
+
+func (this *Hoverfly) GetContractViolations(filter v2.JournalEntryFilterView) (v2.ContractViolationsView, error) {
+	contract := this.getContract()
+	if contract == nil {
+		return v2.ContractViolationsView{}, fmt.Errorf("No contract has been set")
+	}
+
+	entries, err := this.Journal.GetPageOfEntries(filter)
+	if err != nil {
+		return v2.ContractViolationsView{}, err
+	}
+
+	violations := []v2.ContractViolationView{}
+	for _, entry := range entries {
+		errors := contract.Document.ValidateRequest(*entry.Request)
+		errors = append(errors, contract.Document.ValidateResponse(*entry.Request, *entry.Response)...)
+
+		if len(errors) > 0 {
+			violations = append(violations, v2.ContractViolationView{
+				Entry:  entry.BuildView(),
+				Errors: errors,
+			})
+		}
+	}
+
+	return v2.ContractViolationsView{Violations: violations}, nil
 }
@@ -1,14 +1,18 @@
 package hoverfly
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/middleware"
 	"github.com/SpectoLabs/hoverfly/core/models"
 	"github.com/SpectoLabs/hoverfly/core/util"
 	"github.com/gorilla/mux"
@@ -383,6 +387,42 @@ func Test_Hoverfly_SetMiddleware_CanSetRemote(t *testing.T) {
 	Expect(unit.Cfg.Middleware.Remote).To(Equal(server.URL + "/process"))
 }
 
+func Test_Hoverfly_SetMiddlewareWithOptions_SendsRemoteOptionHeadersToRemote(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	muxRouter := mux.NewRouter()
+	muxRouter.HandleFunc("/process", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		processHandlerOkay(w, r)
+	}).Methods("POST")
+	server := httptest.NewServer(muxRouter)
+	defer server.Close()
+
+	err := unit.SetMiddlewareWithOptions(v2.MiddlewareView{Remote: server.URL + "/process"})
+	Expect(err).ToNot(BeNil())
+
+	err = unit.SetMiddlewareWithOptions(v2.MiddlewareView{
+		Remote: server.URL + "/process",
+		RemoteOptions: &v2.MiddlewareRemoteOptionsView{
+			Headers: map[string]string{"Authorization": "Bearer token"},
+		},
+	})
+	Expect(err).To(BeNil())
+
+	Expect(unit.GetMiddlewareView().RemoteOptions).To(Equal(&v2.MiddlewareRemoteOptionsView{
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}))
+
+	pair, err := unit.Cfg.Middleware.Execute(newMiddlewareTestPair())
+	Expect(err).To(BeNil())
+	Expect(pair.Response.Body).To(Equal("You got straight up messed with"))
+}
+
 func Test_Hoverfly_SetMiddleware_WillErrorIfGivenBadRemote(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -475,6 +515,346 @@ func Test_Hoverfly_SetMiddleware_WillSetBinaryWithNoScript(t *testing.T) {
 	Expect(script).To(Equal(""))
 }
 
+func Test_Hoverfly_SetMiddlewareWithOptions_CanSetPersistentMiddleware(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	// cat writes each message back as it is, leaving the pair unchanged
+	err := unit.SetMiddlewareWithOptions(v2.MiddlewareView{Binary: "cat", Persistent: true, Timeout: 500})
+	Expect(err).To(BeNil())
+	defer unit.Cfg.Middleware.Stop()
+
+	Expect(unit.Cfg.Middleware.Persistent).To(BeTrue())
+	Expect(unit.Cfg.Middleware.Timeout).To(Equal(500 * time.Millisecond))
+
+	Expect(unit.GetMiddlewareView()).To(Equal(v2.MiddlewareView{Binary: "cat", Persistent: true, Timeout: 500}))
+}
+
+func Test_Hoverfly_SetMiddlewareWithOptions_WontSetPersistentMiddlewareIfItDoesNotRespondInTime(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	err := unit.SetMiddlewareWithOptions(v2.MiddlewareView{
+		Binary:     "python",
+		Script:     "import time\ntime.sleep(10)",
+		Persistent: true,
+		Timeout:    100,
+	})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Middleware did not respond within 100ms"))
+
+	Expect(unit.Cfg.Middleware.Binary).To(Equal(""))
+	Expect(unit.Cfg.Middleware.Persistent).To(BeFalse())
+}
+
+func Test_Hoverfly_SetMiddlewareWithOptions_CanSetLuaMiddleware(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	err := unit.SetMiddlewareWithOptions(v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = "lua"`})
+	Expect(err).To(BeNil())
+
+	Expect(unit.GetMiddlewareView()).To(Equal(v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = "lua"`}))
+
+	pair, err := unit.ApplyMiddleware(context.Background(), middleware.ResponsePhase, models.RequestResponsePair{})
+	Expect(err).To(BeNil())
+	Expect(pair.Response.Body).To(Equal("lua"))
+}
+
+func Test_Hoverfly_SetMiddlewareWithOptions_WillErrorIfGivenBinaryAndEngine(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	err := unit.SetMiddlewareWithOptions(v2.MiddlewareView{Binary: "python", Engine: "lua", Script: `pair.response.body = "lua"`})
+	Expect(err).ToNot(BeNil())
+
+	Expect(unit.Cfg.Middleware.IsSet()).To(BeFalse())
+}
+
+func Test_Hoverfly_SetMiddlewareWithOptions_CanSetAnErrorPolicy(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	middlewareView := v2.MiddlewareView{
+		Engine: "lua",
+		Script: `pair.response.body = "lua"`,
+		ErrorPolicy: &v2.MiddlewareErrorPolicyView{
+			OnTimeout: "passthrough",
+			OnFailure: "fallback",
+			Fallback: &v2.ResponseDetailsView{
+				Status:  503,
+				Body:    "fallback",
+				Headers: map[string][]string{"Retry-After": {"1"}},
+			},
+		},
+	}
+
+	Expect(unit.SetMiddlewareWithOptions(middlewareView)).To(BeNil())
+
+	Expect(unit.Cfg.Middleware.ErrorPolicy.OnTimeout).To(Equal(middleware.PassThroughPolicy))
+	Expect(unit.Cfg.Middleware.ErrorPolicy.OnFailure).To(Equal(middleware.FallbackPolicy))
+	Expect(unit.Cfg.Middleware.ErrorPolicy.Fallback.Status).To(Equal(503))
+
+	Expect(unit.GetMiddlewareView()).To(Equal(middlewareView))
+}
+
+func Test_Hoverfly_SetMiddlewareWithOptions_WontSetMiddlewareWithAnInvalidErrorPolicy(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	err := unit.SetMiddlewareWithOptions(v2.MiddlewareView{
+		Engine:      "lua",
+		Script:      `pair.response.body = "lua"`,
+		ErrorPolicy: &v2.MiddlewareErrorPolicyView{OnTimeout: "fallback"},
+	})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Middleware error policy fallback needs a fallback response"))
+
+	Expect(unit.Cfg.Middleware.IsSet()).To(BeFalse())
+}
+
+func Test_Hoverfly_SetMiddlewareWithOptions_WontSetMiddlewareWhichFailsEvenWithAPolicyToPassThrough(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	err := unit.SetMiddlewareWithOptions(v2.MiddlewareView{
+		Engine:      "lua",
+		Script:      `error("broken")`,
+		ErrorPolicy: &v2.MiddlewareErrorPolicyView{OnFailure: "passthrough"},
+	})
+	Expect(err).ToNot(BeNil())
+
+	Expect(unit.Cfg.Middleware.IsSet()).To(BeFalse())
+}
+
+func Test_Hoverfly_TestMiddleware_RunsTheMiddlewareGivenOnThePairGiven(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	result, err := unit.TestMiddleware(v2.MiddlewareTestView{
+		Middleware: &v2.MiddlewareView{
+			Engine: "lua",
+			Script: `print(pair.request.path)
+pair.response.body = "tested"`,
+		},
+		Pair: &v2.RequestResponsePairViewV1{
+			Request:  v2.RequestDetailsViewV1{Path: util.StringToPointer("/test")},
+			Response: v2.ResponseDetailsView{Status: 200},
+		},
+	})
+	Expect(err).To(BeNil())
+
+	Expect(result.Pair.Response.Body).To(Equal("tested"))
+	Expect(*result.Pair.Request.Path).To(Equal("/test"))
+	Expect(result.Diagnostics.Stderr).To(Equal("/test\n"))
+	Expect(result.Diagnostics.Error).To(Equal(""))
+
+	Expect(unit.Cfg.Middleware.IsSet()).To(BeFalse())
+}
+
+func Test_Hoverfly_TestMiddleware_ReturnsWhyTheMiddlewareFailed(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	result, err := unit.TestMiddleware(v2.MiddlewareTestView{
+		Middleware: &v2.MiddlewareView{
+			Engine:  "lua",
+			Script:  `while true do end`,
+			Timeout: 100,
+			ErrorPolicy: &v2.MiddlewareErrorPolicyView{
+				OnTimeout: "fallback",
+				Fallback:  &v2.ResponseDetailsView{Status: 503},
+			},
+		},
+	})
+	Expect(err).To(BeNil())
+
+	Expect(result.Pair.Response.Status).To(Equal(503))
+	Expect(result.Pair.Request.Destination).To(Equal(util.StringToPointer("www.test.com")))
+	Expect(result.Diagnostics.Error).To(Equal("Middleware did not respond within 100ms"))
+	Expect(result.Diagnostics.ErrorKind).To(Equal("timeout"))
+	Expect(result.Diagnostics.Policy).To(Equal("fallback"))
+}
+
+func Test_Hoverfly_TestMiddleware_RunsTheMiddlewareWhichIsSetOrNamed(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	_, err := unit.TestMiddleware(v2.MiddlewareTestView{})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Middleware not set"))
+
+	Expect(unit.SetMiddlewareWithOptions(v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = "set"`})).To(BeNil())
+	Expect(unit.SetMiddlewareChain(v2.MiddlewareChainView{
+		Middlewares: []v2.ChainedMiddlewareView{
+			{Name: "named", MiddlewareView: v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = "named"`}},
+		},
+	})).To(BeNil())
+
+	result, err := unit.TestMiddleware(v2.MiddlewareTestView{})
+	Expect(err).To(BeNil())
+	Expect(result.Pair.Response.Body).To(Equal("set"))
+
+	result, err = unit.TestMiddleware(v2.MiddlewareTestView{Name: "named"})
+	Expect(err).To(BeNil())
+	Expect(result.Pair.Response.Body).To(Equal("named"))
+	Expect(result.Diagnostics.Name).To(Equal("named"))
+
+	_, err = unit.TestMiddleware(v2.MiddlewareTestView{Name: "unknown"})
+	Expect(err).ToNot(BeNil())
+}
+
+func Test_Hoverfly_SetMiddlewareChain_CanSetAndGetTheChain(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	chain := v2.MiddlewareChainView{
+		Middlewares: []v2.ChainedMiddlewareView{
+			{
+				Name:  "first",
+				Phase: "request",
+				RequestMatcher: &v2.RequestMatcherViewV2{
+					Destination: &v2.RequestFieldMatchersView{
+						ExactMatch: util.StringToPointer("test.com"),
+					},
+				},
+				MiddlewareView: v2.MiddlewareView{Engine: "lua", Script: `pair.request.body = "first"`},
+			},
+			{
+				Name:           "second",
+				MiddlewareView: v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = "second"`},
+			},
+		},
+	}
+
+	Expect(unit.SetMiddlewareChain(chain)).To(BeNil())
+
+	Expect(unit.Cfg.MiddlewareChain).To(HaveLen(2))
+	Expect(unit.IsMiddlewareSet()).To(BeTrue())
+
+	Expect(unit.GetMiddlewareChain()).To(Equal(chain))
+}
+
+func Test_Hoverfly_SetMiddlewareChain_WontChangeTheChainIfAnyMiddlewareIsNotValid(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	Expect(unit.SetMiddlewareChain(v2.MiddlewareChainView{
+		Middlewares: []v2.ChainedMiddlewareView{
+			{Name: "valid", MiddlewareView: v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = "valid"`}},
+		},
+	})).To(BeNil())
+
+	for _, chain := range []v2.MiddlewareChainView{
+		{Middlewares: []v2.ChainedMiddlewareView{
+			{Name: "same", MiddlewareView: v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = "same"`}},
+			{Name: "same", MiddlewareView: v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = "same"`}},
+		}},
+		{Middlewares: []v2.ChainedMiddlewareView{
+			{Phase: "never", MiddlewareView: v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = "never"`}},
+		}},
+		{Middlewares: []v2.ChainedMiddlewareView{
+			{Name: "nothing"},
+		}},
+		{Middlewares: []v2.ChainedMiddlewareView{
+			{Name: "broken", MiddlewareView: v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = `}},
+		}},
+	} {
+		Expect(unit.SetMiddlewareChain(chain)).ToNot(BeNil())
+	}
+
+	Expect(unit.Cfg.MiddlewareChain).To(HaveLen(1))
+	Expect(unit.Cfg.MiddlewareChain[0].Name).To(Equal("valid"))
+}
+
+func Test_Hoverfly_ApplyMiddleware_RunsTheChainInOrderOnMatchingRequestsInThePhase(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	Expect(unit.SetMiddlewareWithOptions(v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = "global"`})).To(BeNil())
+
+	Expect(unit.SetMiddlewareChain(v2.MiddlewareChainView{
+		Middlewares: []v2.ChainedMiddlewareView{
+			{
+				Phase:          "response",
+				MiddlewareView: v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = pair.response.body .. " response"`},
+			},
+			{
+				Phase: "both",
+				RequestMatcher: &v2.RequestMatcherViewV2{
+					Destination: &v2.RequestFieldMatchersView{
+						ExactMatch: util.StringToPointer("test.com"),
+					},
+				},
+				MiddlewareView: v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = pair.response.body .. " test.com"`},
+			},
+			{
+				Phase:          "request",
+				MiddlewareView: v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = pair.response.body .. " request"`},
+			},
+		},
+	})).To(BeNil())
+
+	pair, err := unit.ApplyMiddleware(context.Background(), middleware.ResponsePhase, models.RequestResponsePair{
+		Request: models.RequestDetails{Destination: "test.com"},
+	})
+	Expect(err).To(BeNil())
+	Expect(pair.Response.Body).To(Equal("global response test.com"))
+
+	pair, err = unit.ApplyMiddleware(context.Background(), middleware.RequestPhase, models.RequestResponsePair{
+		Request: models.RequestDetails{Destination: "other.com"},
+	})
+	Expect(err).To(BeNil())
+	Expect(pair.Response.Body).To(Equal("global request"))
+}
+
+func Test_Hoverfly_ApplyMiddleware_RunsMiddlewareNamedByTheResponseOnce(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	Expect(unit.SetMiddlewareChain(v2.MiddlewareChainView{
+		Middlewares: []v2.ChainedMiddlewareView{
+			{
+				Name:           "named",
+				Phase:          "request",
+				MiddlewareView: v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = pair.response.body .. " named"`},
+			},
+		},
+	})).To(BeNil())
+
+	pair, err := unit.ApplyMiddleware(context.Background(), middleware.ResponsePhase, models.RequestResponsePair{
+		Response: models.ResponseDetails{Body: "body", Middleware: "named"},
+	})
+	Expect(err).To(BeNil())
+	Expect(pair.Response.Body).To(Equal("body named"))
+
+	pair, err = unit.ApplyMiddleware(context.Background(), middleware.RequestPhase, models.RequestResponsePair{
+		Response: models.ResponseDetails{Body: "body", Middleware: "named"},
+	})
+	Expect(err).To(BeNil())
+	Expect(pair.Response.Body).To(Equal("body named"))
+
+	_, err = unit.ApplyMiddleware(context.Background(), middleware.ResponsePhase, models.RequestResponsePair{
+		Response: models.ResponseDetails{Body: "body", Middleware: "unknown"},
+	})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("There is no middleware named unknown in the middleware chain"))
+}
+
 func Test_Hoverfly_GetVersion_GetsVersion(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -580,3 +960,375 @@ func Test_Hoverfly_SetModeWithARguments_AsteriskCanOnlyBeValidAsTheOnlyHeader(t
 	})).ToNot(Succeed())
 
 }
+
+func Test_Hoverfly_GetIssuedCertificates_ReturnsCertificatesIssuedByCertificateManager(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	_, err := unit.CertificateManager.GetCertificate("hoverfly.io:443")
+	Expect(err).To(BeNil())
+
+	certificatesView := unit.GetIssuedCertificates()
+	Expect(certificatesView.Certificates).To(HaveLen(1))
+	Expect(certificatesView.Certificates[0].Host).To(Equal("hoverfly.io"))
+	Expect(certificatesView.Certificates[0].KeyType).To(Equal("rsa"))
+	Expect(certificatesView.Certificates[0].SerialNumber).ToNot(BeEmpty())
+
+	unit.FlushCertificates()
+	Expect(unit.GetIssuedCertificates().Certificates).To(HaveLen(0))
+}
+
+func Test_Hoverfly_SetCACertificate_ReturnsErrorWhenPEMIsInvalid(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	caBefore := unit.GetCACertificate()
+
+	Expect(unit.SetCACertificate("certificate", "key")).ToNot(Succeed())
+	Expect(unit.GetCACertificate()).To(Equal(caBefore))
+}
+
+func Test_Hoverfly_PutSimulationHar_ReplacesSimulationWithSavedEntries(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{pairOne},
+		},
+		MetaView: v2.MetaView{},
+	})
+
+	err := unit.PutSimulationHar(v2.HarView{
+		Log: v2.HarLogView{
+			Entries: []v2.HarEntryView{
+				v2.HarEntryView{
+					Request: v2.HarRequestView{
+						Method: "GET",
+						URL:    "http://test.com/har?q=1",
+					},
+					Response: v2.HarResponseView{
+						Status:  200,
+						Content: v2.HarContentView{Text: "har-body"},
+					},
+				},
+			},
+		},
+	})
+	Expect(err).To(BeNil())
+
+	Expect(unit.Simulation.MatchingPairs).To(HaveLen(1))
+
+	pair := unit.Simulation.MatchingPairs[0]
+	Expect(*pair.RequestMatcher.Method.ExactMatch).To(Equal("GET"))
+	Expect(*pair.RequestMatcher.Destination.ExactMatch).To(Equal("test.com"))
+	Expect(*pair.RequestMatcher.Path.ExactMatch).To(Equal("/har"))
+	Expect(*pair.RequestMatcher.Query.ExactMatch).To(Equal("q=1"))
+	Expect(pair.Response.Body).To(Equal("har-body"))
+
+	harView, err := unit.GetSimulationHar()
+	Expect(err).To(BeNil())
+	Expect(harView.Log.Entries).To(HaveLen(1))
+	Expect(harView.Log.Entries[0].Request.URL).To(Equal("http://test.com/har?q=1"))
+}
+
+func Test_Hoverfly_PutSimulationHar_LeavesSimulationWhenEntryIsInvalid(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{pairOne},
+		},
+		MetaView: v2.MetaView{},
+	})
+
+	err := unit.PutSimulationHar(v2.HarView{
+		Log: v2.HarLogView{
+			Entries: []v2.HarEntryView{
+				v2.HarEntryView{Request: v2.HarRequestView{Method: "GET", URL: "http://test.com/"}},
+				v2.HarEntryView{Request: v2.HarRequestView{Method: "GET", URL: "/relative"}},
+			},
+		},
+	})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Invalid request URL /relative"))
+
+	Expect(unit.Simulation.MatchingPairs).To(HaveLen(1))
+	Expect(*unit.Simulation.MatchingPairs[0].RequestMatcher.Path.ExactMatch).To(Equal("/testing"))
+}
+
+func Test_Hoverfly_GetJournalHar_ReturnsJournalEntries(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	request, _ := http.NewRequest("GET", "http://test.com/journal", nil)
+	response := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("journal-body")),
+	}
+	Expect(unit.Journal.NewEntry(request, response, "simulate", time.Now())).To(Succeed())
+
+	harView, err := unit.GetJournalHar(v2.JournalEntryFilterView{})
+	Expect(err).To(BeNil())
+
+	Expect(harView.Log.Creator.Version).To(Equal(unit.version))
+	Expect(harView.Log.Entries).To(HaveLen(1))
+	Expect(harView.Log.Entries[0].Request.URL).To(Equal("http://test.com/journal"))
+	Expect(harView.Log.Entries[0].Response.Content.Text).To(Equal("journal-body"))
+}
+
+func Test_Hoverfly_GetPact_DerivesMatchingRulesFromMatchingPair(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{
+				v2.RequestMatcherResponsePairViewV2{
+					RequestMatcher: v2.RequestMatcherViewV2{
+						Path: &v2.RequestFieldMatchersView{
+							GlobMatch: util.StringToPointer("/pets/*"),
+						},
+					},
+					Response: v2.ResponseDetailsView{
+						Status: 200,
+						Body:   "pet",
+					},
+				},
+			},
+		},
+		MetaView: v2.MetaView{},
+	})
+
+	for _, path := range []string{"/pets/1", "/owners/1"} {
+		request, _ := http.NewRequest("GET", "http://test.com"+path, nil)
+		response := &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader("journal-body")),
+		}
+		Expect(unit.Journal.NewEntry(request, response, "simulate", time.Now())).To(Succeed())
+	}
+
+	pactView, err := unit.GetPact(v2.PactOptionsView{
+		Consumer:      "web",
+		Provider:      "pets",
+		Specification: 2,
+		Source:        "journal",
+	}, v2.JournalEntryFilterView{})
+	Expect(err).To(BeNil())
+
+	Expect(pactView.Consumer.Name).To(Equal("web"))
+	Expect(pactView.Provider.Name).To(Equal("pets"))
+	Expect(pactView.Interactions).To(HaveLen(2))
+
+	Expect(pactView.Interactions[0].Request.Path).To(Equal("/pets/1"))
+	Expect(pactView.Interactions[0].Request.MatchingRules).To(HaveKey("$.path"))
+	Expect(pactView.Interactions[0].Response.Body).To(Equal("journal-body"))
+
+	Expect(pactView.Interactions[1].Request.Path).To(Equal("/owners/1"))
+	Expect(pactView.Interactions[1].Request.MatchingRules).To(BeNil())
+}
+
+func Test_Hoverfly_GetPact_FromSimulation(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{pairOne, pairTwo},
+		},
+		MetaView: v2.MetaView{},
+	})
+
+	pactView, err := unit.GetPact(v2.PactOptionsView{
+		Consumer:      "web",
+		Provider:      "test",
+		Specification: 3,
+		Source:        "simulation",
+	}, v2.JournalEntryFilterView{})
+	Expect(err).To(BeNil())
+
+	Expect(pactView.Metadata.PactSpecification.Version).To(Equal("3.0.0"))
+	Expect(pactView.Interactions).To(HaveLen(2))
+	Expect(pactView.Interactions[0].Request.Path).To(Equal("/testing"))
+	Expect(pactView.Interactions[0].Response.Body).To(Equal("test-body"))
+	Expect(pactView.Interactions[1].Request.Path).To(Equal("/path"))
+}
+
+func Test_Hoverfly_PutSimulationOpenApi_ReplacesSimulation(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{pairOne},
+		},
+		MetaView: v2.MetaView{},
+	})
+
+	err := unit.PutSimulationOpenApi([]byte(`{
+		"swagger": "2.0",
+		"paths": {
+			"/health": {
+				"get": {
+					"responses": {
+						"200": {"examples": {"text/plain": "OK"}}
+					}
+				}
+			}
+		}
+	}`))
+	Expect(err).To(BeNil())
+
+	Expect(unit.Simulation.MatchingPairs).To(HaveLen(1))
+	Expect(*unit.Simulation.MatchingPairs[0].RequestMatcher.Path.RegexMatch).To(Equal("^/health$"))
+	Expect(unit.Simulation.MatchingPairs[0].Response.Body).To(Equal("OK"))
+}
+
+func Test_Hoverfly_PutSimulationPostman_ReplacesSimulationAndReportsUntranslatedConstructs(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{pairOne},
+		},
+		MetaView: v2.MetaView{},
+	})
+
+	untranslated, err := unit.PutSimulationPostman([]byte(`{
+		"info": {"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"item": [
+			{
+				"name": "Health",
+				"request": {"method": "GET", "url": "http://test.com/health"},
+				"response": [{"name": "Healthy", "code": 200, "body": "OK"}]
+			},
+			{
+				"name": "Ready",
+				"request": {"method": "GET", "url": "http://test.com/ready"}
+			}
+		]
+	}`))
+	Expect(err).To(BeNil())
+	Expect(untranslated).To(Equal([]string{
+		`item "Ready": request without example responses is not translated`,
+	}))
+
+	Expect(unit.Simulation.MatchingPairs).To(HaveLen(1))
+	Expect(*unit.Simulation.MatchingPairs[0].RequestMatcher.Path.ExactMatch).To(Equal("/health"))
+	Expect(unit.Simulation.MatchingPairs[0].Response.Body).To(Equal("OK"))
+}
+
+func Test_Hoverfly_PutSimulationWireMock_ReplacesSimulationAndDelays(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{pairOne},
+		},
+		MetaView: v2.MetaView{},
+	})
+
+	untranslated, err := unit.PutSimulationWireMock([]byte(`{
+		"request": {"method": "GET", "urlPath": "/health"},
+		"response": {"status": 200, "body": "OK", "fixedDelayMilliseconds": 100}
+	}`))
+	Expect(err).To(BeNil())
+	Expect(untranslated).To(BeEmpty())
+
+	Expect(unit.Simulation.MatchingPairs).To(HaveLen(1))
+	Expect(*unit.Simulation.MatchingPairs[0].RequestMatcher.Path.ExactMatch).To(Equal("/health"))
+
+	delays := unit.Simulation.ResponseDelays.ConvertToResponseDelayPayloadView().Data
+	Expect(delays).To(HaveLen(1))
+	Expect(delays[0].Delay).To(Equal(100))
+}
+
+func Test_Hoverfly_PutSimulationWireMock_LeavesSimulationWhenMappingIsInvalid(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{pairOne},
+		},
+		MetaView: v2.MetaView{},
+	})
+
+	_, err := unit.PutSimulationWireMock([]byte(`{"mappings": [`))
+	Expect(err).ToNot(BeNil())
+
+	Expect(unit.Simulation.MatchingPairs).To(HaveLen(1))
+}
+
+func Test_Hoverfly_PutSimulationOpenApi_LeavesSimulationWhenDocumentIsInvalid(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{pairOne},
+		},
+		MetaView: v2.MetaView{},
+	})
+
+	err := unit.PutSimulationOpenApi([]byte(`{"data": {}}`))
+	Expect(err).ToNot(BeNil())
+
+	Expect(unit.Simulation.MatchingPairs).To(HaveLen(1))
+}
+
+func Test_Hoverfly_GetContractViolations_ReturnsJournalEntriesWhichDoNotConform(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	Expect(unit.PutContract(v2.ContractView{
+		Document: `{
+			"swagger": "2.0",
+			"paths": {
+				"/pets": {
+					"get": {"responses": {"200": {"description": "pets"}}}
+				}
+			}
+		}`,
+	})).To(BeNil())
+
+	for _, request := range [][]string{{"GET", "/pets"}, {"DELETE", "/pets"}, {"GET", "/owners"}} {
+		httpRequest, _ := http.NewRequest(request[0], "http://test.com"+request[1], nil)
+		Expect(unit.Journal.NewEntry(httpRequest, &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, "simulate", time.Now())).To(Succeed())
+	}
+
+	violationsView, err := unit.GetContractViolations(v2.JournalEntryFilterView{})
+	Expect(err).To(BeNil())
+
+	Expect(violationsView.Violations).To(HaveLen(2))
+	Expect(*violationsView.Violations[0].Entry.Request.Path).To(Equal("/pets"))
+	Expect(violationsView.Violations[0].Errors).To(Equal([]string{"method DELETE is not allowed for path /pets"}))
+	Expect(*violationsView.Violations[1].Entry.Request.Path).To(Equal("/owners"))
+	Expect(violationsView.Violations[1].Errors).To(Equal([]string{"path /owners is not in the contract"}))
+
+	violationsView, err = unit.GetContractViolations(v2.JournalEntryFilterView{Path: "/owners"})
+	Expect(err).To(BeNil())
+	Expect(violationsView.Violations).To(HaveLen(1))
+}
+
+func Test_Hoverfly_GetContractViolations_WithoutAContractReturnsError(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	_, err := unit.GetContractViolations(v2.JournalEntryFilterView{})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("No contract has been set"))
+}
@@ -2,15 +2,21 @@ package hoverfly
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/SpectoLabs/hoverfly/core/authentication/backends"
 	"github.com/SpectoLabs/hoverfly/core/cache"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/metrics"
+	"github.com/SpectoLabs/hoverfly/core/tracing"
 	"github.com/SpectoLabs/hoverfly/core/util"
 	. "github.com/onsi/gomega"
 	"github.com/SpectoLabs/hoverfly/core/models"
@@ -174,6 +180,203 @@ func Test_Hoverfly_processRequest_CanSimulateRequest(t *testing.T) {
 	Expect(newResp.StatusCode).To(Equal(http.StatusCreated))
 }
 
+func Test_Hoverfly_processRequest_RecordsMetrics(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	unit.Metrics = metrics.NewRequestMetrics([]string{"destination", "pair"}, 0)
+
+	r, err := http.NewRequest("GET", "http://somehost.com/path", nil)
+	Expect(err).To(BeNil())
+
+	unit.Cfg.SetMode("capture")
+	unit.processRequest(r)
+
+	unit.Cfg.SetMode("simulate")
+	unit.processRequest(r)
+
+	missed, err := http.NewRequest("GET", "http://somehost.com/missed", nil)
+	Expect(err).To(BeNil())
+	unit.processRequest(missed)
+
+	var buffer bytes.Buffer
+	unit.Metrics.Registry.WriteTo(&buffer)
+
+	Expect(buffer.String()).To(ContainSubstring(`hoverfly_requests_total{mode="capture",destination="somehost.com",status="201"} 1`))
+	Expect(buffer.String()).To(ContainSubstring(`hoverfly_requests_total{mode="simulate",destination="somehost.com",status="201"} 1`))
+	Expect(buffer.String()).To(ContainSubstring(`hoverfly_requests_total{mode="simulate",destination="somehost.com",status="502"} 1`))
+	Expect(buffer.String()).To(ContainSubstring(`hoverfly_simulation_matches_total{pair="GET somehost.com /path"} 1`))
+	Expect(buffer.String()).To(ContainSubstring(`hoverfly_simulation_match_failures_total{destination="somehost.com"} 1`))
+	Expect(buffer.String()).To(ContainSubstring(`hoverfly_upstream_duration_seconds_count{destination="somehost.com"} 1`))
+}
+
+type recordingExporter struct {
+	spans []*tracing.Span
+}
+
+func (this *recordingExporter) ExportSpan(span *tracing.Span) {
+	this.spans = append(this.spans, span)
+}
+
+func Test_Hoverfly_processRequest_TracesRequestAndPropagatesTraceparentUpstream(t *testing.T) {
+	RegisterTestingT(t)
+
+	var upstreamTraceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get("Traceparent")
+	}))
+	defer server.Close()
+
+	_, unit := testTools(200, "")
+	unit.HTTP = &http.Client{Transport: &http.Transport{
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			return url.Parse(server.URL)
+		},
+	}}
+
+	exporter := &recordingExporter{}
+	unit.Tracer = tracing.NewTracer(exporter)
+	unit.Cfg.SetMode("capture")
+
+	r, err := http.NewRequest("GET", "http://somehost.com/path", nil)
+	Expect(err).To(BeNil())
+	r.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	unit.processRequest(r)
+
+	Expect(exporter.spans).To(HaveLen(2))
+	upstream, request := exporter.spans[0], exporter.spans[1]
+
+	Expect(request.Name).To(Equal("hoverfly.request"))
+	Expect(request.Context.TraceID.String()).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+	Expect(request.ParentSpanID.String()).To(Equal("00f067aa0ba902b7"))
+	Expect(request.Attributes).To(HaveKeyWithValue("http.status_code", 200))
+
+	Expect(upstream.Name).To(Equal("hoverfly.upstream"))
+	Expect(upstream.ParentSpanID).To(Equal(request.Context.SpanID))
+	Expect(upstreamTraceparent).To(Equal(upstream.Context.Traceparent()))
+
+	Expect(unit.Simulation.MatchingPairs).To(HaveLen(1))
+	Expect(unit.Simulation.MatchingPairs[0].RequestMatcher.Headers).ToNot(HaveKey("Traceparent"))
+}
+
+func Test_Hoverfly_processRequest_TracesCacheLookupAndMatching(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	r, err := http.NewRequest("GET", "http://somehost.com", nil)
+	Expect(err).To(BeNil())
+
+	unit.Cfg.SetMode("capture")
+	unit.processRequest(r)
+
+	exporter := &recordingExporter{}
+	unit.Tracer = tracing.NewTracer(exporter)
+	unit.Cfg.SetMode("simulate")
+	unit.processRequest(r)
+
+	names := []string{}
+	for _, span := range exporter.spans {
+		names = append(names, span.Name)
+	}
+	Expect(names).To(Equal([]string{"hoverfly.cache_lookup", "hoverfly.match", "hoverfly.request"}))
+
+	for _, span := range exporter.spans[:2] {
+		Expect(span.ParentSpanID).To(Equal(exporter.spans[2].Context.SpanID))
+	}
+}
+
+func Test_Hoverfly_processRequest_RejectsRequestsWhichDoNotConformToContract(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	Expect(unit.PutContract(v2.ContractView{
+		Document: `{
+			"swagger": "2.0",
+			"paths": {
+				"/pets": {
+					"get": {"responses": {"200": {"description": "pets"}}}
+				}
+			}
+		}`,
+		RejectInvalidRequests: true,
+	})).To(Succeed())
+
+	unit.Cfg.SetMode("simulate")
+
+	r, err := http.NewRequest("GET", "http://somehost.com/owners", nil)
+	Expect(err).To(BeNil())
+
+	resp := unit.processRequest(r)
+	Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	Expect(err).To(BeNil())
+	Expect(string(body)).To(Equal("Hoverfly Error!\n\nRequest does not conform to the OpenAPI contract\n\npath /owners is not in the contract"))
+
+	// requests which conform are simulated as usual
+	r, err = http.NewRequest("GET", "http://somehost.com/pets", nil)
+	Expect(err).To(BeNil())
+
+	resp = unit.processRequest(r)
+	Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+}
+
+func Test_Hoverfly_processRequest_OnlyRejectsInvalidRequestsWhenAskedTo(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	Expect(unit.PutContract(v2.ContractView{
+		Document: `{"swagger": "2.0", "paths": {}}`,
+	})).To(Succeed())
+
+	unit.Cfg.SetMode("simulate")
+
+	r, err := http.NewRequest("GET", "http://somehost.com/owners", nil)
+	Expect(err).To(BeNil())
+
+	resp := unit.processRequest(r)
+	Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+}
+
+func Test_Hoverfly_processRequest_CanHaveItsContractChangedWhileHandlingRequests(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	unit.Cfg.SetMode("simulate")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			unit.PutContract(v2.ContractView{
+				Document:              `{"swagger": "2.0", "paths": {}}`,
+				RejectInvalidRequests: true,
+			})
+			unit.DeleteContract()
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		r, err := http.NewRequest("GET", "http://somehost.com/owners", nil)
+		Expect(err).To(BeNil())
+
+		unit.processRequest(r)
+	}
+
+	<-done
+}
+
 func Test_Hoverfly_processRequest_CanUseMiddlewareToSynthesizeRequest(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -254,7 +457,7 @@ func Test_Hoverfly_GetResponse_CanReturnResponseFromCache(t *testing.T) {
 		},
 	}, nil)
 
-	response, err := unit.GetResponse(models.RequestDetails{
+	response, err := unit.GetResponse(context.Background(), models.RequestDetails{
 		Destination: "somehost.com",
 		Method:      "POST",
 		Scheme:      "http",
@@ -290,7 +493,7 @@ func Test_Hoverfly_GetResponse_CanReturnResponseFromSimulationAndNotCache(t *tes
 		},
 	})
 
-	response, err := unit.GetResponse(models.RequestDetails{
+	response, err := unit.GetResponse(context.Background(), models.RequestDetails{
 		Destination: "somehost.com",
 		Method:      "POST",
 		Scheme:      "http",
@@ -326,7 +529,7 @@ func Test_Hoverfly_GetResponse_WillCacheResponseIfNotInCache(t *testing.T) {
 		},
 	})
 
-	unit.GetResponse(models.RequestDetails{
+	unit.GetResponse(context.Background(), models.RequestDetails{
 		Destination: "somehost.com",
 		Method:      "POST",
 		Scheme:      "http",
@@ -343,7 +546,7 @@ func Test_Hoverfly_GetResponse_WillCacheResponseIfNotInCache(t *testing.T) {
 	Expect(cachedRequestResponsePair.MatchingPair.Response.Body).To(Equal("response body"))
 
 	unit.Simulation = models.NewSimulation()
-	response, err := unit.GetResponse(models.RequestDetails{
+	response, err := unit.GetResponse(context.Background(), models.RequestDetails{
 		Destination: "somehost.com",
 		Method:      "POST",
 		Scheme:      "http",
@@ -374,7 +577,7 @@ func Test_Hoverfly_GetResponse_WillReturnCachedResponseIfHeaderMatchIsFalse(t *t
 		},
 	}, nil)
 
-	response, err := unit.GetResponse(requestDetails)
+	response, err := unit.GetResponse(context.Background(), requestDetails)
 	Expect(err).To(BeNil())
 
 	Expect(response.Body).To(Equal("cached response"))
@@ -414,7 +617,7 @@ func Test_Hoverfly_GetResponse_WillCheckRequestMatchersAndReturnRequestMatcherRe
 		},
 	})
 
-	response, err := unit.GetResponse(requestDetails)
+	response, err := unit.GetResponse(context.Background(), requestDetails)
 	Expect(err).To(BeNil())
 
 	Expect(response.Body).To(Equal("response body"))
@@ -431,7 +634,7 @@ func Test_Hoverfly_GetResponse_WillCacheMisses(t *testing.T) {
 		Scheme:      "http",
 	}
 
-	_, err := unit.GetResponse(requestDetails)
+	_, err := unit.GetResponse(context.Background(), requestDetails)
 	Expect(err.Error()).To(Equal("Could not find a match for request, create or record a valid matcher first!"))
 
 	cachedResponse, err := unit.CacheMatcher.GetCachedResponse(&requestDetails)
@@ -471,7 +674,7 @@ func Test_Hoverfly_GetResponse_WillCacheClosestMiss(t *testing.T) {
 		Scheme:      "http",
 	}
 
-	_, err := unit.GetResponse(requestDetails)
+	_, err := unit.GetResponse(context.Background(), requestDetails)
 	Expect(err.Error()).ToNot(BeNil())
 
 	cachedResponse, err := unit.CacheMatcher.GetCachedResponse(&requestDetails)
@@ -747,6 +950,37 @@ func Test_Hoverfly_Save_SavesRequestAndResponseToSimulation(t *testing.T) {
 	Expect(unit.Simulation.MatchingPairs[0].Response.Status).To(Equal(200))
 }
 
+func Test_Hoverfly_Save_WritesLargeResponseBodiesToBodyFiles(t *testing.T) {
+	RegisterTestingT(t)
+
+	directory, _ := ioutil.TempDir("", "hoverfly-body-files")
+	defer os.RemoveAll(directory)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{SimulationDirectory: directory, BodyFileCaptureSize: 5})
+
+	response := &models.ResponseDetails{
+		Body:    "a large response body",
+		Headers: map[string][]string{"Content-Type": []string{"text/plain"}},
+		Status:  200,
+	}
+
+	unit.Save(&models.RequestDetails{Path: "/large"}, response, nil)
+	unit.Save(&models.RequestDetails{Path: "/small"}, &models.ResponseDetails{Body: "small", Status: 200}, nil)
+
+	Expect(unit.Simulation.MatchingPairs).To(HaveLen(2))
+
+	Expect(unit.Simulation.MatchingPairs[0].Response.Body).To(Equal(""))
+	Expect(unit.Simulation.MatchingPairs[0].Response.BodyFile).To(MatchRegexp(`^bodies/[0-9a-f]{64}\.txt$`))
+	Expect(response.Body).To(Equal("a large response body"))
+
+	content, err := ioutil.ReadFile(filepath.Join(directory, unit.Simulation.MatchingPairs[0].Response.BodyFile))
+	Expect(err).To(BeNil())
+	Expect(string(content)).To(Equal("a large response body"))
+
+	Expect(unit.Simulation.MatchingPairs[1].Response.Body).To(Equal("small"))
+	Expect(unit.Simulation.MatchingPairs[1].Response.BodyFile).To(Equal(""))
+}
+
 func Test_Hoverfly_Save_DoesNotSaveRequestHeadersWhenGivenHeadersArrayIsNil(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -15,6 +15,9 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
 	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/openapi"
+	"github.com/SpectoLabs/hoverfly/core/postman"
+	"github.com/SpectoLabs/hoverfly/core/wiremock"
 )
 
 // Import is a function that based on input decides whether it is a local resource or whether
@@ -32,8 +35,8 @@ func (hf *Hoverfly) Import(uri string) error {
 	}
 	// assuming file URI is disk location
 	ext := path.Ext(uri)
-	if ext != ".json" {
-		return fmt.Errorf("Failed to import payloads, only JSON files are acceppted. Given file: %s", uri)
+	if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+		return fmt.Errorf("Failed to import payloads, only JSON files or YAML OpenAPI documents are acceppted. Given file: %s", uri)
 	}
 	// checking whether it exists
 	exists, err := exists(uri)
@@ -90,19 +93,12 @@ func (hf *Hoverfly) ImportFromDisk(path string) error {
 		return fmt.Errorf("Got error while opening payloads file, error %s", err.Error())
 	}
 
-	var simulation v2.SimulationViewV2
-
 	body, err := ioutil.ReadAll(pairsFile)
 	if err != nil {
 		return fmt.Errorf("Got error while parsing payloads, error %s", err.Error())
 	}
 
-	err = json.Unmarshal(body, &simulation)
-	if err != nil {
-		return fmt.Errorf("Got error while parsing payloads, error %s", err.Error())
-	}
-
-	return hf.PutSimulation(simulation)
+	return hf.importSimulation(body)
 }
 
 // ImportFromURL - takes one string value and tries connect to a remote server, then parse response body into
@@ -115,14 +111,51 @@ func (hf *Hoverfly) ImportFromURL(url string) error {
 		return fmt.Errorf("Failed to fetch given URL, error %s", err.Error())
 	}
 
-	var simulation v2.SimulationViewV2
-
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("Got error while parsing payloads, error %s", err.Error())
 	}
 
-	err = json.Unmarshal(body, &simulation)
+	return hf.importSimulation(body)
+}
+
+// importSimulation - imports either a simulation or an OpenAPI document, which is
+// turned into a simulation with a request response pair for each of its operations.
+// Postman collections and WireMock mappings are converted too, logging what could
+// not be translated
+func (hf *Hoverfly) importSimulation(body []byte) error {
+	if openapi.IsDocument(body) {
+		document, err := openapi.Parse(body)
+		if err != nil {
+			return fmt.Errorf("Got error while parsing payloads, error %s", err.Error())
+		}
+
+		return hf.PutSimulation(openapi.NewSimulationView(document))
+	}
+
+	var convert func([]byte) (v2.SimulationViewV2, []string, error)
+	if postman.IsCollection(body) {
+		convert = postman.NewSimulationView
+	} else if wiremock.IsMapping(body) {
+		convert = wiremock.NewSimulationView
+	}
+
+	if convert != nil {
+		simulation, untranslated, err := convert(body)
+		if err != nil {
+			return fmt.Errorf("Got error while parsing payloads, error %s", err.Error())
+		}
+
+		for _, construct := range untranslated {
+			log.Warn(construct)
+		}
+
+		return hf.PutSimulation(simulation)
+	}
+
+	var simulation v2.SimulationViewV2
+
+	err := json.Unmarshal(body, &simulation)
 	if err != nil {
 		return fmt.Errorf("Got error while parsing payloads, error %s", err.Error())
 	}
@@ -138,6 +171,12 @@ func isJSON(s string) bool {
 
 // ImportRequestResponsePairViews - a function to save given pairs into the database.
 func (hf *Hoverfly) ImportRequestResponsePairViews(pairViews []v2.RequestMatcherResponsePairViewV2) error {
+	for _, pairView := range pairViews {
+		if err := hf.validateBodyFile(pairView.Response); err != nil {
+			return err
+		}
+	}
+
 	if len(pairViews) > 0 {
 		success := 0
 		failed := 0
@@ -158,3 +197,18 @@ func (hf *Hoverfly) ImportRequestResponsePairViews(pairViews []v2.RequestMatcher
 	}
 	return nil
 }
+
+// validateBodyFile - checks that a response with a body file does not also have a body, and that
+// the file is within the simulation directory. The file itself is only read when it is served
+func (hf *Hoverfly) validateBodyFile(response v2.ResponseDetailsView) error {
+	if response.BodyFile == "" {
+		return nil
+	}
+
+	if response.Body != "" {
+		return fmt.Errorf("Response cannot have both a body and a bodyFile, %s", response.BodyFile)
+	}
+
+	_, err := hf.Cfg.BodyFiles().Path(response.BodyFile)
+	return err
+}
@@ -91,6 +91,64 @@ func TestImportFromDisk(t *testing.T) {
 	Expect(dbClient.Simulation.MatchingPairs).To(HaveLen(2))
 }
 
+func TestImportFromDisk_ImportsOpenApiDocument(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, dbClient := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	err := dbClient.Import("../examples/simulations/petstore.openapi.yaml")
+	Expect(err).To(BeNil())
+
+	Expect(dbClient.Simulation.MatchingPairs).To(HaveLen(3))
+
+	pair := dbClient.Simulation.MatchingPairs[2]
+	Expect(*pair.RequestMatcher.Method.ExactMatch).To(Equal("GET"))
+	Expect(*pair.RequestMatcher.Path.RegexMatch).To(Equal("^/v1/pets/[^/]+$"))
+	Expect(*pair.RequestMatcher.Destination.ExactMatch).To(Equal("petstore.example.com"))
+	Expect(pair.Response.Status).To(Equal(200))
+	Expect(pair.Response.Body).To(MatchJSON(`{"id": 0, "name": "Rex", "tag": "string"}`))
+}
+
+func TestImportFromURL_ImportsWireMockMappings(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, dbClient := testTools(200, `{
+		"mappings": [
+			{"request": {"method": "GET", "urlPath": "/pets"}, "response": {"status": 200, "body": "Rex"}}
+		]
+	}`)
+	defer server.Close()
+
+	err := dbClient.Import(server.URL)
+	Expect(err).To(BeNil())
+
+	Expect(dbClient.Simulation.MatchingPairs).To(HaveLen(1))
+	Expect(*dbClient.Simulation.MatchingPairs[0].RequestMatcher.Path.ExactMatch).To(Equal("/pets"))
+	Expect(dbClient.Simulation.MatchingPairs[0].Response.Body).To(Equal("Rex"))
+}
+
+func TestImportFromURL_ImportsPostmanCollection(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, dbClient := testTools(200, `{
+		"info": {"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"item": [{
+			"name": "List pets",
+			"request": {"method": "GET", "url": "http://api.example.com/pets"},
+			"response": [{"name": "Pets", "code": 200, "body": "Rex"}]
+		}]
+	}`)
+	defer server.Close()
+
+	err := dbClient.Import(server.URL)
+	Expect(err).To(BeNil())
+
+	Expect(dbClient.Simulation.MatchingPairs).To(HaveLen(1))
+	Expect(*dbClient.Simulation.MatchingPairs[0].RequestMatcher.Destination.ExactMatch).To(Equal("api.example.com"))
+	Expect(dbClient.Simulation.MatchingPairs[0].Response.Body).To(Equal("Rex"))
+}
+
 func TestImportFromDiskBlankPath(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -497,3 +555,41 @@ func TestImportImportRequestResponsePairs_CanImportASingleBase64EncodedPair(t *t
 			Body:        "",
 			Headers:     map[string][]string{"Hoverfly": []string{"testing"}}}}))
 }
+
+func TestImportImportRequestResponsePairs_ErrorsOnBodyFilesOutsideOfTheSimulationDirectory(t *testing.T) {
+	RegisterTestingT(t)
+
+	cfg := Configuration{SimulationDirectory: "simulations"}
+	hv := Hoverfly{Cfg: &cfg, Simulation: models.NewSimulation()}
+
+	err := hv.ImportRequestResponsePairViews([]v2.RequestMatcherResponsePairViewV2{
+		v2.RequestMatcherResponsePairViewV2{
+			Response: v2.ResponseDetailsView{Status: 200, BodyFile: "bodies/report.pdf"},
+		},
+		v2.RequestMatcherResponsePairViewV2{
+			Response: v2.ResponseDetailsView{Status: 200, BodyFile: "../../etc/passwd"},
+		},
+	})
+
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Body file ../../etc/passwd is outside of the simulation directory"))
+	Expect(hv.Simulation.MatchingPairs).To(BeEmpty())
+
+	err = hv.ImportRequestResponsePairViews([]v2.RequestMatcherResponsePairViewV2{
+		v2.RequestMatcherResponsePairViewV2{
+			Response: v2.ResponseDetailsView{Status: 200, Body: "body", BodyFile: "bodies/report.pdf"},
+		},
+	})
+
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Response cannot have both a body and a bodyFile, bodies/report.pdf"))
+
+	err = hv.ImportRequestResponsePairViews([]v2.RequestMatcherResponsePairViewV2{
+		v2.RequestMatcherResponsePairViewV2{
+			Response: v2.ResponseDetailsView{Status: 200, BodyFile: "bodies/report.pdf"},
+		},
+	})
+
+	Expect(err).To(BeNil())
+	Expect(hv.Simulation.MatchingPairs[0].Response.BodyFile).To(Equal("bodies/report.pdf"))
+}
@@ -12,7 +12,9 @@ type Request interface {
 	GetScheme() *string
 	GetQuery() *string
 	GetBody() *string
+	GetEncodedBody() bool
 	GetHeaders() map[string][]string
+	GetProtocol() *string
 }
 
 type Response interface {
@@ -20,4 +22,6 @@ type Response interface {
 	GetBody() string
 	GetEncodedBody() bool
 	GetHeaders() map[string][]string
+	GetBodyFile() string
+	GetContentEncoding() string
 }
@@ -0,0 +1,246 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"sync"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/middleware"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/boltdb/bolt"
+)
+
+// JournalBucketName - default name of the BoltDB bucket for the journal
+const JournalBucketName = "journalBucket"
+
+// BoltJournalStore - keeps the journal in a BoltDB bucket so that it is kept across
+// restarts. Entries are keyed by the time they were started, followed by a sequence
+// number, so that the bucket's cursor reads them in order
+type BoltJournalStore struct {
+	DS     *bolt.DB
+	Bucket []byte
+
+	// counting the entries in the bucket means reading all of it, so they are counted
+	// the first time the count is needed, and then it is kept up to date
+	mutex   sync.Mutex
+	count   int
+	counted bool
+}
+
+// storedEntry - the journal entry as it is encoded in the bucket. Gob keeps bodies
+// byte for byte, where JSON would replace bytes which are not valid UTF-8
+type storedEntry struct {
+	Request     models.RequestDetails
+	Response    models.ResponseDetails
+	Mode        string
+	TimeStarted time.Time
+	Latency     time.Duration
+	Middleware  []middleware.Diagnostics
+}
+
+func NewBoltDBJournalStore(db *bolt.DB, bucket []byte) *BoltJournalStore {
+	return &BoltJournalStore{
+		DS:     db,
+		Bucket: bucket,
+	}
+}
+
+func (this *BoltJournalStore) Add(entry JournalEntry) error {
+	return this.AddAndTrim(entry, -1, time.Time{})
+}
+
+func (this *BoltJournalStore) AddAndTrim(entry JournalEntry, limit int, expiredBefore time.Time) error {
+	var value bytes.Buffer
+	err := gob.NewEncoder(&value).Encode(storedEntry{
+		Request:     *entry.Request,
+		Response:    *entry.Response,
+		Mode:        entry.Mode,
+		TimeStarted: entry.TimeStarted,
+		Latency:     entry.Latency,
+		Middleware:  entry.Middleware,
+	})
+	if err != nil {
+		return err
+	}
+
+	return this.update(func(tx *bolt.Tx, count int) (int, error) {
+		bucket, err := tx.CreateBucketIfNotExists(this.Bucket)
+		if err != nil {
+			return count, err
+		}
+
+		if !expiredBefore.IsZero() {
+			deleted, err := deleteBefore(bucket, expiredBefore)
+			if err != nil {
+				return count, err
+			}
+			count -= deleted
+		}
+
+		if limit > 0 && count >= limit {
+			deleted, err := deleteOldest(bucket, count-limit+1)
+			if err != nil {
+				return count, err
+			}
+			count -= deleted
+		}
+
+		sequence, err := bucket.NextSequence()
+		if err != nil {
+			return count, err
+		}
+
+		key := make([]byte, 16)
+		binary.BigEndian.PutUint64(key, uint64(entry.TimeStarted.UnixNano()))
+		binary.BigEndian.PutUint64(key[8:], sequence)
+
+		return count + 1, bucket.Put(key, value.Bytes())
+	})
+}
+
+func (this *BoltJournalStore) Count() (int, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.counted {
+		return this.count, nil
+	}
+
+	err := this.DS.View(func(tx *bolt.Tx) error {
+		this.count = countEntries(tx, this.Bucket)
+		return nil
+	})
+	this.counted = err == nil
+
+	return this.count, err
+}
+
+func (this *BoltJournalStore) ForEach(reverse bool, fn func(entry JournalEntry) bool) error {
+	return this.DS.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(this.Bucket)
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+		first, next := cursor.First, cursor.Next
+		if reverse {
+			first, next = cursor.Last, cursor.Prev
+		}
+
+		for key, value := first(); key != nil; key, value = next() {
+			var stored storedEntry
+			if err := gob.NewDecoder(bytes.NewReader(value)).Decode(&stored); err != nil {
+				return err
+			}
+
+			if !fn(JournalEntry{
+				Request:     &stored.Request,
+				Response:    &stored.Response,
+				Mode:        stored.Mode,
+				TimeStarted: stored.TimeStarted,
+				Latency:     stored.Latency,
+				Middleware:  stored.Middleware,
+			}) {
+				break
+			}
+		}
+
+		return nil
+	})
+}
+
+func (this *BoltJournalStore) DeleteOldest(count int) error {
+	return this.update(func(tx *bolt.Tx, current int) (int, error) {
+		bucket := tx.Bucket(this.Bucket)
+		if bucket == nil {
+			return current, nil
+		}
+
+		deleted, err := deleteOldest(bucket, count)
+		return current - deleted, err
+	})
+}
+
+func (this *BoltJournalStore) DeleteBefore(before time.Time) error {
+	return this.update(func(tx *bolt.Tx, current int) (int, error) {
+		bucket := tx.Bucket(this.Bucket)
+		if bucket == nil {
+			return current, nil
+		}
+
+		deleted, err := deleteBefore(bucket, before)
+		return current - deleted, err
+	})
+}
+
+func (this *BoltJournalStore) DeleteAll() error {
+	return this.update(func(tx *bolt.Tx, current int) (int, error) {
+		if tx.Bucket(this.Bucket) == nil {
+			return 0, nil
+		}
+
+		return 0, tx.DeleteBucket(this.Bucket)
+	})
+}
+
+// update - makes a change to the bucket in a single transaction, given the number of entries
+// before the change and returning the number after it. The count is only kept when the
+// transaction is committed
+func (this *BoltJournalStore) update(fn func(tx *bolt.Tx, count int) (int, error)) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	count := this.count
+	err := this.DS.Update(func(tx *bolt.Tx) (err error) {
+		if !this.counted {
+			count = countEntries(tx, this.Bucket)
+		}
+
+		count, err = fn(tx, count)
+		return err
+	})
+
+	if err == nil {
+		this.count, this.counted = count, true
+	}
+
+	return err
+}
+
+func countEntries(tx *bolt.Tx, name []byte) int {
+	bucket := tx.Bucket(name)
+	if bucket == nil {
+		return 0
+	}
+
+	return bucket.Stats().KeyN
+}
+
+func deleteOldest(bucket *bolt.Bucket, count int) (deleted int, err error) {
+	cursor := bucket.Cursor()
+	for key, _ := cursor.First(); key != nil && deleted < count; key, _ = cursor.First() {
+		if err = cursor.Delete(); err != nil {
+			return
+		}
+		deleted++
+	}
+
+	return
+}
+
+func deleteBefore(bucket *bolt.Bucket, before time.Time) (deleted int, err error) {
+	limit := uint64(before.UnixNano())
+
+	cursor := bucket.Cursor()
+	for key, _ := cursor.First(); key != nil && binary.BigEndian.Uint64(key) < limit; key, _ = cursor.First() {
+		if err = cursor.Delete(); err != nil {
+			return
+		}
+		deleted++
+	}
+
+	return
+}
@@ -3,9 +3,14 @@ package journal
 import (
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/matching"
+	"github.com/SpectoLabs/hoverfly/core/middleware"
 	"github.com/SpectoLabs/hoverfly/core/models"
 	"github.com/SpectoLabs/hoverfly/core/util"
 )
@@ -16,66 +21,472 @@ type JournalEntry struct {
 	Mode        string
 	TimeStarted time.Time
 	Latency     time.Duration
+	Middleware  []middleware.Diagnostics
 }
 
+func (this JournalEntry) BuildView() v2.JournalEntryView {
+	view := v2.JournalEntryView{
+		Request:     this.Request.ConvertToRequestDetailsView(),
+		Response:    this.Response.ConvertToResponseDetailsView(),
+		Mode:        this.Mode,
+		TimeStarted: this.TimeStarted.Format(time.RFC3339),
+		Latency:     (this.Latency / time.Millisecond),
+	}
+
+	for _, diagnostics := range this.Middleware {
+		view.Middleware = append(view.Middleware, diagnostics.BuildView())
+	}
+
+	return view
+}
+
+type journalMiss struct {
+	entry        JournalEntry
+	missedFields []string
+	score        int
+}
+
+const closestMissesLimit = 3
+
+// Journal - records the requests Hoverfly has handled. Once EntryLimit entries have been
+// recorded the oldest entries are removed, unless EntryLimit is negative, and entries older
+// than Retention are removed when it is set. An EntryLimit of 0 disables the journal
 type Journal struct {
-	entries    []JournalEntry
+	Store      JournalStore
 	EntryLimit int
+	Retention  time.Duration
 }
 
 func NewJournal() *Journal {
 	return &Journal{
-		entries:    []JournalEntry{},
+		Store:      NewMemoryJournalStore(),
 		EntryLimit: 1000,
 	}
 }
 
+// NewEntry - records the request and response. What happened when middleware ran on the request
+// is recorded along with them when the request's context was made with middleware.ContextWithDiagnostics
 func (this *Journal) NewEntry(request *http.Request, response *http.Response, mode string, started time.Time) error {
 	if this.EntryLimit == 0 {
 		return fmt.Errorf("Journal disabled")
 	}
 
 	payloadRequest, _ := models.NewRequestDetailsFromHttpRequest(request)
+	middlewareDiagnostics := middleware.DiagnosticsFromContext(request.Context())
 
-	respBody, _ := util.GetResponseBody(response)
+	// streamed responses are added once the stream has ended, so they can be recorded in full
+	if body, ok := response.Body.(*models.StreamedBody); ok {
+		latency := time.Since(started)
+		body.OnComplete(func(chunks []models.ResponseChunk) {
+			this.addEntry(JournalEntry{
+				Request: &payloadRequest,
+				Response: &models.ResponseDetails{
+					Status:  response.StatusCode,
+					Headers: response.Header,
+					Chunks:  chunks,
+				},
+				Mode:        mode,
+				TimeStarted: started,
+				Latency:     latency,
+				Middleware:  middlewareDiagnostics,
+			})
+		})
 
-	payloadResponse := &models.ResponseDetails{
-		Status:  response.StatusCode,
-		Body:    string(respBody),
-		Headers: response.Header,
+		return nil
+	}
+
+	// bodies read from body files are recorded by the file they were read from
+	if body, ok := response.Body.(*models.FileBody); ok {
+		this.addEntry(JournalEntry{
+			Request: &payloadRequest,
+			Response: &models.ResponseDetails{
+				Status:   response.StatusCode,
+				Headers:  response.Header,
+				BodyFile: body.Name,
+			},
+			Mode:        mode,
+			TimeStarted: started,
+			Latency:     time.Since(started),
+			Middleware:  middlewareDiagnostics,
+		})
+
+		return nil
 	}
 
-	if len(this.entries) >= this.EntryLimit {
-		this.entries = append(this.entries[:0], this.entries[1:]...)
+	respBody, _ := util.GetResponseBody(response)
+	body, headers, err := models.DecodeBody(respBody, response.Header)
+	if err != nil {
+		log.WithField("error", err.Error()).Warn("Failed to decode response body, keeping it as it is")
+	}
+
+	payloadResponse := &models.ResponseDetails{
+		Status:          response.StatusCode,
+		Body:            body,
+		Headers:         headers,
+		ContentEncoding: models.DecodedContentEncoding(response.Header, headers),
 	}
 
-	this.entries = append(this.entries, JournalEntry{
+	this.addEntry(JournalEntry{
 		Request:     &payloadRequest,
 		Response:    payloadResponse,
 		Mode:        mode,
 		TimeStarted: started,
 		Latency:     time.Since(started),
+		Middleware:  middlewareDiagnostics,
 	})
 
 	return nil
 }
 
+func (this *Journal) addEntry(entry JournalEntry) {
+	var expiredBefore time.Time
+	if this.Retention > 0 {
+		expiredBefore = time.Now().Add(-this.Retention)
+	}
+
+	if err := this.Store.AddAndTrim(entry, this.EntryLimit, expiredBefore); err != nil {
+		log.WithField("error", err.Error()).Error("Failed to add journal entry")
+	}
+}
+
+// forEach - reads the entries of the journal one at a time, leaving out those
+// which have expired since the journal was last added to
+func (this Journal) forEach(reverse bool, fn func(entry JournalEntry) bool) {
+	var expired time.Time
+	if this.Retention > 0 {
+		expired = time.Now().Add(-this.Retention)
+	}
+
+	err := this.Store.ForEach(reverse, func(entry JournalEntry) bool {
+		if entry.TimeStarted.Before(expired) {
+			return true
+		}
+
+		return fn(entry)
+	})
+
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Failed to read journal")
+	}
+}
+
 func (this Journal) GetEntries() ([]v2.JournalEntryView, error) {
 	if this.EntryLimit == 0 {
 		return []v2.JournalEntryView{}, fmt.Errorf("Journal disabled")
 	}
 
 	journalEntryViews := []v2.JournalEntryView{}
-	for _, journalEntry := range this.entries {
-		journalEntryViews = append(journalEntryViews, v2.JournalEntryView{
-			Request:     journalEntry.Request.ConvertToRequestDetailsView(),
-			Response:    journalEntry.Response.ConvertToResponseDetailsView(),
-			Mode:        journalEntry.Mode,
-			TimeStarted: journalEntry.TimeStarted.Format(time.RFC3339),
-			Latency:     (journalEntry.Latency / time.Millisecond),
+	this.forEach(false, func(entry JournalEntry) bool {
+		journalEntryViews = append(journalEntryViews, entry.BuildView())
+		return true
+	})
+
+	return journalEntryViews, nil
+}
+
+// GetFilteredEntries - returns a page of the entries which pass the filter, sorted by the time
+// they were started unless sorted by latency. Entries are filtered before being converted to
+// views so that large journals can be paged through quickly
+func (this Journal) GetFilteredEntries(filter v2.JournalEntryFilterView) (v2.JournalView, error) {
+	if this.EntryLimit == 0 {
+		return v2.JournalView{}, fmt.Errorf("Journal disabled")
+	}
+
+	entries, journalView := this.filterEntries(filter)
+
+	journalView.Journal = []v2.JournalEntryView{}
+	for _, entry := range entries {
+		journalView.Journal = append(journalView.Journal, entry.BuildView())
+	}
+
+	return journalView, nil
+}
+
+// GetRequestResponsePairs - returns the requests and responses of the same page of entries as GetFilteredEntries
+func (this Journal) GetRequestResponsePairs(filter v2.JournalEntryFilterView) ([]models.RequestResponsePair, error) {
+	if this.EntryLimit == 0 {
+		return []models.RequestResponsePair{}, fmt.Errorf("Journal disabled")
+	}
+
+	entries, _ := this.filterEntries(filter)
+
+	pairs := []models.RequestResponsePair{}
+	for _, entry := range entries {
+		pairs = append(pairs, models.RequestResponsePair{
+			Request:  *entry.Request,
+			Response: *entry.Response,
 		})
 	}
-	return journalEntryViews, nil
+
+	return pairs, nil
+}
+
+// GetPageOfEntries - returns the same page of entries as GetFilteredEntries, for them to be
+// checked or exported by whatever needs more than their views
+func (this Journal) GetPageOfEntries(filter v2.JournalEntryFilterView) ([]JournalEntry, error) {
+	if this.EntryLimit == 0 {
+		return []JournalEntry{}, fmt.Errorf("Journal disabled")
+	}
+
+	entries, _ := this.filterEntries(filter)
+
+	return entries, nil
+}
+
+// filterEntries - returns the page of entries which pass the filter. Stores keep entries in the
+// order they were started, so only the page is held in memory unless sorting by latency
+func (this Journal) filterEntries(filter v2.JournalEntryFilterView) ([]JournalEntry, v2.JournalView) {
+	if filter.Sort == "latency" {
+		return this.filterEntriesByLatency(filter)
+	}
+
+	page := []JournalEntry{}
+	total := 0
+
+	this.forEach(filter.Order == "desc", func(entry JournalEntry) bool {
+		if !entryPassesFilter(entry, filter) {
+			return true
+		}
+
+		if total >= filter.Offset && (filter.Limit == nil || len(page) < *filter.Limit) {
+			page = append(page, entry)
+		}
+		total++
+
+		return true
+	})
+
+	journalView := v2.JournalView{
+		Offset: filter.Offset,
+		Limit:  total,
+		Total:  total,
+	}
+
+	if filter.Limit != nil {
+		journalView.Limit = *filter.Limit
+	}
+
+	return page, journalView
+}
+
+func (this Journal) filterEntriesByLatency(filter v2.JournalEntryFilterView) ([]JournalEntry, v2.JournalView) {
+	entries := []JournalEntry{}
+	this.forEach(false, func(entry JournalEntry) bool {
+		if entryPassesFilter(entry, filter) {
+			entries = append(entries, entry)
+		}
+		return true
+	})
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Latency < entries[j].Latency
+	})
+
+	if filter.Order == "desc" {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+
+	journalView := v2.JournalView{
+		Offset: filter.Offset,
+		Limit:  len(entries),
+		Total:  len(entries),
+	}
+
+	if filter.Limit != nil {
+		journalView.Limit = *filter.Limit
+	}
+
+	page := []JournalEntry{}
+	for i := filter.Offset; i < len(entries) && i < filter.Offset+journalView.Limit; i++ {
+		page = append(page, entries[i])
+	}
+
+	return page, journalView
+}
+
+func entryPassesFilter(entry JournalEntry, filter v2.JournalEntryFilterView) bool {
+	if filter.From != nil && entry.TimeStarted.Before(*filter.From) {
+		return false
+	}
+
+	if filter.To != nil && entry.TimeStarted.After(*filter.To) {
+		return false
+	}
+
+	if filter.Mode != "" && entry.Mode != filter.Mode {
+		return false
+	}
+
+	if filter.Status != 0 && entry.Response.Status != filter.Status {
+		return false
+	}
+
+	if filter.Destination != "" && !matching.GlobMatch(filter.Destination, entry.Request.Destination) {
+		return false
+	}
+
+	if filter.Path != "" && !matching.GlobMatch(filter.Path, entry.Request.Path) {
+		return false
+	}
+
+	if filter.Search != "" && !strings.Contains(entry.Request.Body, filter.Search) &&
+		!strings.Contains(entry.Response.Body, filter.Search) &&
+		!strings.Contains(models.JoinResponseChunks(entry.Response.Chunks), filter.Search) {
+		return false
+	}
+
+	return true
+}
+
+// SearchEntries - finds the entries with requests matching the request matcher, along
+// with the entries which came closest to matching
+func (this Journal) SearchEntries(requestMatcher v2.RequestMatcherViewV2) (v2.JournalSearchResultView, error) {
+	if this.EntryLimit == 0 {
+		return v2.JournalSearchResultView{}, fmt.Errorf("Journal disabled")
+	}
+
+	result, _ := this.search(models.NewRequestMatcherFromView(requestMatcher))
+
+	return result, nil
+}
+
+// VerifyEntries - checks that requests matching each of the request matchers were made the expected
+// number of times, defaulting to at least once, and optionally that they were made in the order given
+func (this Journal) VerifyEntries(verification v2.JournalVerificationView) (v2.JournalVerificationResultView, error) {
+	if this.EntryLimit == 0 {
+		return v2.JournalVerificationResultView{}, fmt.Errorf("Journal disabled")
+	}
+
+	result := v2.JournalVerificationResultView{
+		Results: []v2.JournalSearchResultView{},
+	}
+
+	failures := []string{}
+	matchingIndexes := [][]int{}
+
+	for i, requestMatcher := range verification.Requests {
+		searchResult, indexes := this.search(models.NewRequestMatcherFromView(requestMatcher))
+
+		result.Results = append(result.Results, searchResult)
+		matchingIndexes = append(matchingIndexes, indexes)
+
+		if failure := verifyTimes(verification.Times, searchResult.Count); failure != "" {
+			failures = append(failures, fmt.Sprintf("requests[%v] %s", i, failure))
+		}
+	}
+
+	if verification.InOrder && !madeInOrder(matchingIndexes) {
+		failures = append(failures, "requests were not made in the order given")
+	}
+
+	result.Verified = len(failures) == 0
+	result.Message = strings.Join(failures, ", ")
+
+	return result, nil
+}
+
+func (this Journal) search(requestMatcher models.RequestMatcher) (v2.JournalSearchResultView, []int) {
+	result := v2.JournalSearchResultView{
+		Journal:       []v2.JournalEntryView{},
+		ClosestMisses: []v2.JournalClosestMissView{},
+	}
+
+	indexes := []int{}
+	misses := []journalMiss{}
+
+	i := 0
+	this.forEach(false, func(entry JournalEntry) bool {
+		match, missedFields := matching.ScoredRequestMatcher(requestMatcher, *entry.Request)
+		if match.Matched {
+			result.Journal = append(result.Journal, entry.BuildView())
+			indexes = append(indexes, i)
+		} else {
+			misses = closestMisses(append(misses, journalMiss{
+				entry:        entry,
+				missedFields: missedFields,
+				score:        match.MatchScore,
+			}))
+		}
+		i++
+
+		return true
+	})
+
+	result.Count = len(result.Journal)
+
+	for i := 0; i < len(misses); i++ {
+		result.ClosestMisses = append(result.ClosestMisses, v2.JournalClosestMissView{
+			Entry:        misses[i].entry.BuildView(),
+			MissedFields: misses[i].missedFields,
+		})
+	}
+
+	return result, indexes
+}
+
+// closestMisses - the closest misses are those which missed the fewest fields, then those
+// which matched on the most. Only the closest are kept while the journal is searched
+func closestMisses(misses []journalMiss) []journalMiss {
+	sort.SliceStable(misses, func(i, j int) bool {
+		if len(misses[i].missedFields) != len(misses[j].missedFields) {
+			return len(misses[i].missedFields) < len(misses[j].missedFields)
+		}
+
+		return misses[i].score > misses[j].score
+	})
+
+	if len(misses) > closestMissesLimit {
+		return misses[:closestMissesLimit]
+	}
+
+	return misses
+}
+
+func verifyTimes(times *v2.JournalTimesView, count int) string {
+	if times == nil {
+		if count == 0 {
+			return "expected at least 1 matching request but found 0"
+		}
+		return ""
+	}
+
+	if times.Exactly != nil && count != *times.Exactly {
+		return fmt.Sprintf("expected exactly %v matching requests but found %v", *times.Exactly, count)
+	}
+
+	if times.AtLeast != nil && count < *times.AtLeast {
+		return fmt.Sprintf("expected at least %v matching requests but found %v", *times.AtLeast, count)
+	}
+
+	if times.AtMost != nil && count > *times.AtMost {
+		return fmt.Sprintf("expected at most %v matching requests but found %v", *times.AtMost, count)
+	}
+
+	return ""
+}
+
+// madeInOrder - whether there is a matching entry for each request matcher
+// which comes after the matching entry picked for the previous one
+func madeInOrder(matchingIndexes [][]int) bool {
+	last := -1
+	for _, indexes := range matchingIndexes {
+		next := -1
+		for _, index := range indexes {
+			if index > last {
+				next = index
+				break
+			}
+		}
+
+		if next == -1 {
+			return false
+		}
+		last = next
+	}
+
+	return true
 }
 
 func (this *Journal) DeleteEntries() error {
@@ -83,7 +494,5 @@ func (this *Journal) DeleteEntries() error {
 		return fmt.Errorf("Journal disabled")
 	}
 
-	this.entries = []JournalEntry{}
-
-	return nil
+	return this.Store.DeleteAll()
 }
@@ -8,7 +8,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
 	"github.com/SpectoLabs/hoverfly/core/journal"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/util"
 	. "github.com/onsi/gomega"
 )
 
@@ -101,6 +104,48 @@ func Test_Journal_NewEntry_RespectsEntryLimit(t *testing.T) {
 
 }
 
+func Test_Journal_NewEntry_HasNoLimitWhenEntryLimitIsNegative(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+	unit.EntryLimit = -1
+
+	request, _ := http.NewRequest("GET", "http://hoverfly.io", nil)
+
+	for i := 0; i < 1005; i++ {
+		unit.NewEntry(request, &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+		}, "simulate", time.Now())
+	}
+
+	entries, err := unit.GetEntries()
+	Expect(err).To(BeNil())
+	Expect(entries).To(HaveLen(1005))
+}
+
+func Test_Journal_LeavesOutEntriesOlderThanRetention(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+	unit.Retention = time.Hour
+
+	request, _ := http.NewRequest("GET", "http://hoverfly.io", nil)
+
+	for _, started := range []time.Time{time.Now().Add(-2 * time.Hour), time.Now()} {
+		unit.NewEntry(request, &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+		}, "simulate", started)
+	}
+
+	entries, err := unit.GetEntries()
+	Expect(err).To(BeNil())
+	Expect(entries).To(HaveLen(1))
+
+	Expect(unit.Store.Count()).To(Equal(1))
+}
+
 func Test_Journal_NewEntry_KeepsOrder(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -213,3 +258,394 @@ func Test_Journal_GetEntries_WhenDisabledReturnsError(t *testing.T) {
 	Expect(err).ToNot(BeNil())
 	Expect(err.Error()).To(Equal("Journal disabled"))
 }
+
+func Test_Journal_NewEntry_WhenResponseIsStreamed_AddsEntryOnceStreamHasEnded(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+
+	request, _ := http.NewRequest("GET", "http://hoverfly.io/events", nil)
+
+	body := models.NewStreamedBody([]models.ResponseChunk{
+		models.ResponseChunk{Data: "data: one\n\n", Delay: 5},
+	})
+
+	err := unit.NewEntry(request, &http.Response{
+		StatusCode: 200,
+		Body:       body,
+		Header: http.Header{
+			"Content-Type": []string{"text/event-stream"},
+		},
+	}, "simulate", time.Now())
+	Expect(err).To(BeNil())
+
+	entries, err := unit.GetEntries()
+	Expect(err).To(BeNil())
+	Expect(entries).To(HaveLen(0))
+
+	ioutil.ReadAll(body)
+
+	entries, err = unit.GetEntries()
+	Expect(err).To(BeNil())
+	Expect(entries).To(HaveLen(1))
+
+	Expect(entries[0].Response.Chunks).To(HaveLen(1))
+	Expect(entries[0].Response.Chunks[0].Data).To(Equal("data: one\n\n"))
+	Expect(entries[0].Response.Chunks[0].Delay).To(Equal(5))
+}
+
+func addJournalEntry(unit *journal.Journal, method, path string) {
+	request, _ := http.NewRequest(method, "http://hoverfly.io"+path, nil)
+
+	unit.NewEntry(request, &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+	}, "simulate", time.Now())
+}
+
+func Test_Journal_SearchEntries_ReturnsMatchingEntriesAndClosestMisses(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+
+	addJournalEntry(unit, "GET", "/one")
+	addJournalEntry(unit, "POST", "/one")
+	addJournalEntry(unit, "GET", "/two")
+	addJournalEntry(unit, "POST", "/two")
+
+	result, err := unit.SearchEntries(v2.RequestMatcherViewV2{
+		Method: &v2.RequestFieldMatchersView{
+			ExactMatch: util.StringToPointer("GET"),
+		},
+		Path: &v2.RequestFieldMatchersView{
+			ExactMatch: util.StringToPointer("/one"),
+		},
+	})
+	Expect(err).To(BeNil())
+
+	Expect(result.Count).To(Equal(1))
+	Expect(result.Journal).To(HaveLen(1))
+	Expect(*result.Journal[0].Request.Method).To(Equal("GET"))
+	Expect(*result.Journal[0].Request.Path).To(Equal("/one"))
+
+	Expect(result.ClosestMisses).To(HaveLen(3))
+	Expect(result.ClosestMisses[0].MissedFields).To(ConsistOf("method"))
+	Expect(*result.ClosestMisses[0].Entry.Request.Method).To(Equal("POST"))
+	Expect(result.ClosestMisses[1].MissedFields).To(ConsistOf("path"))
+	Expect(result.ClosestMisses[2].MissedFields).To(ConsistOf("method", "path"))
+}
+
+func Test_Journal_SearchEntries_WhenDisabledReturnsError(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+	unit.EntryLimit = 0
+
+	_, err := unit.SearchEntries(v2.RequestMatcherViewV2{})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Journal disabled"))
+}
+
+func Test_Journal_VerifyEntries_DefaultsToAtLeastOnce(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+
+	addJournalEntry(unit, "GET", "/one")
+
+	result, err := unit.VerifyEntries(v2.JournalVerificationView{
+		Requests: []v2.RequestMatcherViewV2{
+			v2.RequestMatcherViewV2{
+				Path: &v2.RequestFieldMatchersView{ExactMatch: util.StringToPointer("/one")},
+			},
+			v2.RequestMatcherViewV2{
+				Path: &v2.RequestFieldMatchersView{ExactMatch: util.StringToPointer("/two")},
+			},
+		},
+	})
+	Expect(err).To(BeNil())
+
+	Expect(result.Verified).To(BeFalse())
+	Expect(result.Message).To(Equal("requests[1] expected at least 1 matching request but found 0"))
+	Expect(result.Results).To(HaveLen(2))
+	Expect(result.Results[0].Count).To(Equal(1))
+	Expect(result.Results[1].Count).To(Equal(0))
+}
+
+func Test_Journal_VerifyEntries_MatchesExactQueryInAnyOrder(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+
+	addJournalEntry(unit, "GET", "/one?a=1&b=2")
+
+	result, err := unit.VerifyEntries(v2.JournalVerificationView{
+		Requests: []v2.RequestMatcherViewV2{
+			v2.RequestMatcherViewV2{
+				Path:  &v2.RequestFieldMatchersView{ExactMatch: util.StringToPointer("/one")},
+				Query: &v2.RequestFieldMatchersView{ExactMatch: util.StringToPointer("b=2&a=1")},
+			},
+		},
+	})
+	Expect(err).To(BeNil())
+
+	Expect(result.Verified).To(BeTrue())
+	Expect(result.Results[0].Count).To(Equal(1))
+}
+
+func Test_Journal_VerifyEntries_ChecksExactNumberOfTimes(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+
+	addJournalEntry(unit, "GET", "/one")
+	addJournalEntry(unit, "GET", "/one")
+
+	verification := v2.JournalVerificationView{
+		Requests: []v2.RequestMatcherViewV2{
+			v2.RequestMatcherViewV2{
+				Path: &v2.RequestFieldMatchersView{ExactMatch: util.StringToPointer("/one")},
+			},
+		},
+		Times: &v2.JournalTimesView{
+			Exactly: intToPointer(2),
+		},
+	}
+
+	result, err := unit.VerifyEntries(verification)
+	Expect(err).To(BeNil())
+	Expect(result.Verified).To(BeTrue())
+	Expect(result.Message).To(Equal(""))
+
+	verification.Times.Exactly = intToPointer(3)
+
+	result, err = unit.VerifyEntries(verification)
+	Expect(err).To(BeNil())
+	Expect(result.Verified).To(BeFalse())
+	Expect(result.Message).To(Equal("requests[0] expected exactly 3 matching requests but found 2"))
+}
+
+func Test_Journal_VerifyEntries_ChecksRangeOfTimes(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+
+	addJournalEntry(unit, "GET", "/one")
+	addJournalEntry(unit, "GET", "/one")
+
+	result, err := unit.VerifyEntries(v2.JournalVerificationView{
+		Requests: []v2.RequestMatcherViewV2{
+			v2.RequestMatcherViewV2{},
+		},
+		Times: &v2.JournalTimesView{
+			AtLeast: intToPointer(1),
+			AtMost:  intToPointer(1),
+		},
+	})
+	Expect(err).To(BeNil())
+	Expect(result.Verified).To(BeFalse())
+	Expect(result.Message).To(Equal("requests[0] expected at most 1 matching requests but found 2"))
+}
+
+func Test_Journal_VerifyEntries_ChecksRequestsWereMadeInOrder(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+
+	addJournalEntry(unit, "GET", "/two")
+	addJournalEntry(unit, "GET", "/one")
+	addJournalEntry(unit, "GET", "/three")
+
+	matcher := func(path string) v2.RequestMatcherViewV2 {
+		return v2.RequestMatcherViewV2{
+			Path: &v2.RequestFieldMatchersView{ExactMatch: util.StringToPointer(path)},
+		}
+	}
+
+	result, err := unit.VerifyEntries(v2.JournalVerificationView{
+		Requests: []v2.RequestMatcherViewV2{matcher("/one"), matcher("/three")},
+		InOrder:  true,
+	})
+	Expect(err).To(BeNil())
+	Expect(result.Verified).To(BeTrue())
+
+	result, err = unit.VerifyEntries(v2.JournalVerificationView{
+		Requests: []v2.RequestMatcherViewV2{matcher("/one"), matcher("/two")},
+		InOrder:  true,
+	})
+	Expect(err).To(BeNil())
+	Expect(result.Verified).To(BeFalse())
+	Expect(result.Message).To(Equal("requests were not made in the order given"))
+}
+
+func intToPointer(value int) *int {
+	return &value
+}
+
+func Test_Journal_GetFilteredEntries_FiltersEntries(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+
+	started := time.Date(2017, 3, 13, 12, 0, 0, 0, time.UTC)
+
+	for i, path := range []string{"/api/one", "/api/two", "/other", "/api/three"} {
+		request, _ := http.NewRequest("POST", "http://hoverfly.io"+path, bytes.NewBufferString("body "+path))
+
+		status := 200
+		if path == "/api/two" {
+			status = 404
+		}
+
+		unit.NewEntry(request, &http.Response{
+			StatusCode: status,
+			Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+		}, "simulate", started.Add(time.Duration(i)*time.Minute))
+	}
+
+	journalView, err := unit.GetFilteredEntries(v2.JournalEntryFilterView{
+		Path: "/api/*",
+	})
+	Expect(err).To(BeNil())
+	Expect(journalView.Total).To(Equal(3))
+	Expect(journalView.Journal).To(HaveLen(3))
+
+	journalView, err = unit.GetFilteredEntries(v2.JournalEntryFilterView{
+		Status: 404,
+	})
+	Expect(err).To(BeNil())
+	Expect(journalView.Journal).To(HaveLen(1))
+	Expect(*journalView.Journal[0].Request.Path).To(Equal("/api/two"))
+
+	from := started.Add(time.Minute)
+	to := started.Add(2 * time.Minute)
+
+	journalView, err = unit.GetFilteredEntries(v2.JournalEntryFilterView{
+		From: &from,
+		To:   &to,
+	})
+	Expect(err).To(BeNil())
+	Expect(journalView.Journal).To(HaveLen(2))
+	Expect(*journalView.Journal[0].Request.Path).To(Equal("/api/two"))
+	Expect(*journalView.Journal[1].Request.Path).To(Equal("/other"))
+
+	journalView, err = unit.GetFilteredEntries(v2.JournalEntryFilterView{
+		Search:      "three",
+		Destination: "*.io",
+		Mode:        "simulate",
+	})
+	Expect(err).To(BeNil())
+	Expect(journalView.Journal).To(HaveLen(1))
+	Expect(*journalView.Journal[0].Request.Path).To(Equal("/api/three"))
+
+	journalView, err = unit.GetFilteredEntries(v2.JournalEntryFilterView{
+		Mode: "capture",
+	})
+	Expect(err).To(BeNil())
+	Expect(journalView.Journal).To(BeEmpty())
+}
+
+func Test_Journal_GetFilteredEntries_PaginatesAndSortsEntries(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+
+	for i := 0; i < 5; i++ {
+		addJournalEntry(unit, "GET", "/"+strconv.Itoa(i))
+	}
+
+	limit := 2
+
+	journalView, err := unit.GetFilteredEntries(v2.JournalEntryFilterView{
+		Offset: 1,
+		Limit:  &limit,
+	})
+	Expect(err).To(BeNil())
+	Expect(journalView.Offset).To(Equal(1))
+	Expect(journalView.Limit).To(Equal(2))
+	Expect(journalView.Total).To(Equal(5))
+	Expect(journalView.Journal).To(HaveLen(2))
+	Expect(*journalView.Journal[0].Request.Path).To(Equal("/1"))
+	Expect(*journalView.Journal[1].Request.Path).To(Equal("/2"))
+
+	journalView, err = unit.GetFilteredEntries(v2.JournalEntryFilterView{
+		Order: "desc",
+		Limit: &limit,
+	})
+	Expect(err).To(BeNil())
+	Expect(*journalView.Journal[0].Request.Path).To(Equal("/4"))
+	Expect(*journalView.Journal[1].Request.Path).To(Equal("/3"))
+
+	journalView, err = unit.GetFilteredEntries(v2.JournalEntryFilterView{
+		Offset: 10,
+	})
+	Expect(err).To(BeNil())
+	Expect(journalView.Total).To(Equal(5))
+	Expect(journalView.Journal).To(BeEmpty())
+}
+
+func Test_Journal_GetFilteredEntries_WhenDisabledReturnsError(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+	unit.EntryLimit = 0
+
+	_, err := unit.GetFilteredEntries(v2.JournalEntryFilterView{})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Journal disabled"))
+}
+
+func Test_Journal_GetRequestResponsePairs_ReturnsFilteredEntries(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+
+	addJournalEntry(unit, "GET", "/pets")
+	addJournalEntry(unit, "GET", "/owners")
+
+	pairs, err := unit.GetRequestResponsePairs(v2.JournalEntryFilterView{Path: "/owners"})
+	Expect(err).To(BeNil())
+
+	Expect(pairs).To(HaveLen(1))
+	Expect(pairs[0].Request.Method).To(Equal("GET"))
+	Expect(pairs[0].Request.Path).To(Equal("/owners"))
+}
+
+func Test_Journal_GetRequestResponsePairs_WhenDisabledReturnsError(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+	unit.EntryLimit = 0
+
+	_, err := unit.GetRequestResponsePairs(v2.JournalEntryFilterView{})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Journal disabled"))
+}
+
+func Test_Journal_GetPageOfEntries_ReturnsFilteredEntries(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+
+	addJournalEntry(unit, "GET", "/pets")
+	addJournalEntry(unit, "DELETE", "/pets")
+	addJournalEntry(unit, "GET", "/owners")
+
+	entries, err := unit.GetPageOfEntries(v2.JournalEntryFilterView{Path: "/pets"})
+	Expect(err).To(BeNil())
+
+	Expect(entries).To(HaveLen(2))
+	Expect(entries[0].Request.Method).To(Equal("GET"))
+	Expect(entries[1].Request.Method).To(Equal("DELETE"))
+}
+
+func Test_Journal_GetPageOfEntries_WhenDisabledReturnsError(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := journal.NewJournal()
+	unit.EntryLimit = 0
+
+	_, err := unit.GetPageOfEntries(v2.JournalEntryFilterView{})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Journal disabled"))
+}
@@ -0,0 +1,135 @@
+package journal
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// JournalStore - keeps the entries of the journal in the order they were started. Entries
+// are read one at a time, so that a store does not have to hold every entry in memory
+// to answer a query
+type JournalStore interface {
+	Add(entry JournalEntry) error
+
+	// AddAndTrim - adds the entry, first removing the entries which started before expiredBefore
+	// unless it is zero, and the oldest entries so that there are no more than limit unless limit is
+	// not positive. Other changes to the store are not made in between
+	AddAndTrim(entry JournalEntry, limit int, expiredBefore time.Time) error
+	Count() (int, error)
+
+	// ForEach - calls fn with each entry, oldest first or newest first when reverse
+	// is set, until fn returns false
+	ForEach(reverse bool, fn func(entry JournalEntry) bool) error
+
+	DeleteOldest(count int) error
+	DeleteBefore(before time.Time) error
+	DeleteAll() error
+}
+
+// MemoryJournalStore - keeps the journal in memory, losing it when Hoverfly stops
+type MemoryJournalStore struct {
+	mutex   sync.RWMutex
+	entries []JournalEntry
+}
+
+func NewMemoryJournalStore() *MemoryJournalStore {
+	return &MemoryJournalStore{
+		entries: []JournalEntry{},
+	}
+}
+
+func (this *MemoryJournalStore) Add(entry JournalEntry) error {
+	return this.AddAndTrim(entry, -1, time.Time{})
+}
+
+func (this *MemoryJournalStore) AddAndTrim(entry JournalEntry, limit int, expiredBefore time.Time) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if !expiredBefore.IsZero() {
+		this.deleteBefore(expiredBefore)
+	}
+
+	if limit > 0 && len(this.entries) >= limit {
+		this.deleteOldest(len(this.entries) - limit + 1)
+	}
+
+	// entries nearly always arrive in order, apart from streamed responses
+	// which are only added once the stream has ended
+	index := sort.Search(len(this.entries), func(i int) bool {
+		return this.entries[i].TimeStarted.After(entry.TimeStarted)
+	})
+
+	this.entries = append(this.entries, JournalEntry{})
+	copy(this.entries[index+1:], this.entries[index:])
+	this.entries[index] = entry
+
+	return nil
+}
+
+func (this *MemoryJournalStore) Count() (int, error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return len(this.entries), nil
+}
+
+func (this *MemoryJournalStore) ForEach(reverse bool, fn func(entry JournalEntry) bool) error {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	for i := range this.entries {
+		index := i
+		if reverse {
+			index = len(this.entries) - 1 - i
+		}
+
+		if !fn(this.entries[index]) {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (this *MemoryJournalStore) DeleteOldest(count int) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.deleteOldest(count)
+
+	return nil
+}
+
+func (this *MemoryJournalStore) DeleteBefore(before time.Time) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.deleteBefore(before)
+
+	return nil
+}
+
+func (this *MemoryJournalStore) deleteOldest(count int) {
+	if count > len(this.entries) {
+		count = len(this.entries)
+	}
+	this.entries = append(this.entries[:0], this.entries[count:]...)
+}
+
+func (this *MemoryJournalStore) deleteBefore(before time.Time) {
+	index := sort.Search(len(this.entries), func(i int) bool {
+		return !this.entries[i].TimeStarted.Before(before)
+	})
+	this.entries = append(this.entries[:0], this.entries[index:]...)
+}
+
+func (this *MemoryJournalStore) DeleteAll() error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.entries = []JournalEntry{}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+package journal_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/journal"
+	"github.com/SpectoLabs/hoverfly/core/middleware"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/boltdb/bolt"
+	. "github.com/onsi/gomega"
+)
+
+var storeStarted = time.Date(2017, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func newStoreEntry(path string, started time.Time) journal.JournalEntry {
+	return journal.JournalEntry{
+		Request: &models.RequestDetails{
+			Method: "GET",
+			Path:   path,
+		},
+		Response: &models.ResponseDetails{
+			Status: 200,
+		},
+		Mode:        "simulate",
+		TimeStarted: started,
+		Latency:     time.Millisecond,
+	}
+}
+
+func storePaths(store journal.JournalStore, reverse bool) []string {
+	paths := []string{}
+	store.ForEach(reverse, func(entry journal.JournalEntry) bool {
+		paths = append(paths, entry.Request.Path)
+		return true
+	})
+
+	return paths
+}
+
+// withStores - runs the test against each store, each one starting empty
+func withStores(t *testing.T, test func(store journal.JournalStore)) {
+	test(journal.NewMemoryJournalStore())
+
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := bolt.Open(filepath.Join(dir, "journal.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	test(journal.NewBoltDBJournalStore(db, []byte(journal.JournalBucketName)))
+}
+
+func Test_JournalStore_ForEach_ReadsEntriesInTheOrderTheyStarted(t *testing.T) {
+	RegisterTestingT(t)
+
+	withStores(t, func(store journal.JournalStore) {
+		Expect(store.Add(newStoreEntry("/b", storeStarted.Add(time.Second)))).To(Succeed())
+		Expect(store.Add(newStoreEntry("/c", storeStarted.Add(2*time.Second)))).To(Succeed())
+		Expect(store.Add(newStoreEntry("/a", storeStarted))).To(Succeed())
+
+		Expect(store.Count()).To(Equal(3))
+		Expect(storePaths(store, false)).To(Equal([]string{"/a", "/b", "/c"}))
+		Expect(storePaths(store, true)).To(Equal([]string{"/c", "/b", "/a"}))
+	})
+}
+
+func Test_JournalStore_ForEach_StopsWhenToldTo(t *testing.T) {
+	RegisterTestingT(t)
+
+	withStores(t, func(store journal.JournalStore) {
+		store.Add(newStoreEntry("/a", storeStarted))
+		store.Add(newStoreEntry("/b", storeStarted.Add(time.Second)))
+
+		paths := []string{}
+		Expect(store.ForEach(true, func(entry journal.JournalEntry) bool {
+			paths = append(paths, entry.Request.Path)
+			return false
+		})).To(Succeed())
+
+		Expect(paths).To(Equal([]string{"/b"}))
+	})
+}
+
+func Test_JournalStore_KeepsEntriesWhichStartedAtTheSameTime(t *testing.T) {
+	RegisterTestingT(t)
+
+	withStores(t, func(store journal.JournalStore) {
+		store.Add(newStoreEntry("/a", storeStarted))
+		store.Add(newStoreEntry("/b", storeStarted))
+
+		Expect(storePaths(store, false)).To(Equal([]string{"/a", "/b"}))
+	})
+}
+
+func Test_JournalStore_DeleteOldest(t *testing.T) {
+	RegisterTestingT(t)
+
+	withStores(t, func(store journal.JournalStore) {
+		for i, path := range []string{"/a", "/b", "/c"} {
+			store.Add(newStoreEntry(path, storeStarted.Add(time.Duration(i)*time.Second)))
+		}
+
+		Expect(store.DeleteOldest(2)).To(Succeed())
+		Expect(storePaths(store, false)).To(Equal([]string{"/c"}))
+
+		Expect(store.DeleteOldest(5)).To(Succeed())
+		Expect(store.Count()).To(Equal(0))
+	})
+}
+
+func Test_JournalStore_DeleteBefore(t *testing.T) {
+	RegisterTestingT(t)
+
+	withStores(t, func(store journal.JournalStore) {
+		for i, path := range []string{"/a", "/b", "/c"} {
+			store.Add(newStoreEntry(path, storeStarted.Add(time.Duration(i)*time.Second)))
+		}
+
+		Expect(store.DeleteBefore(storeStarted.Add(time.Second))).To(Succeed())
+		Expect(storePaths(store, false)).To(Equal([]string{"/b", "/c"}))
+	})
+}
+
+func Test_JournalStore_AddAndTrim_RemovesExpiredAndOldestEntries(t *testing.T) {
+	RegisterTestingT(t)
+
+	withStores(t, func(store journal.JournalStore) {
+		for i, path := range []string{"/a", "/b", "/c", "/d"} {
+			store.Add(newStoreEntry(path, storeStarted.Add(time.Duration(i)*time.Second)))
+		}
+
+		Expect(store.AddAndTrim(newStoreEntry("/e", storeStarted.Add(4*time.Second)), 3, storeStarted.Add(time.Second))).To(Succeed())
+		Expect(storePaths(store, false)).To(Equal([]string{"/c", "/d", "/e"}))
+		Expect(store.Count()).To(Equal(3))
+
+		Expect(store.AddAndTrim(newStoreEntry("/f", storeStarted.Add(5*time.Second)), -1, time.Time{})).To(Succeed())
+		Expect(storePaths(store, false)).To(Equal([]string{"/c", "/d", "/e", "/f"}))
+		Expect(store.Count()).To(Equal(4))
+	})
+}
+
+func Test_JournalStore_DeleteAll(t *testing.T) {
+	RegisterTestingT(t)
+
+	withStores(t, func(store journal.JournalStore) {
+		store.Add(newStoreEntry("/a", storeStarted))
+
+		Expect(store.DeleteAll()).To(Succeed())
+		Expect(store.Count()).To(Equal(0))
+
+		Expect(store.Add(newStoreEntry("/b", storeStarted))).To(Succeed())
+		Expect(storePaths(store, false)).To(Equal([]string{"/b"}))
+	})
+}
+
+func Test_BoltJournalStore_KeepsEntriesAcrossRestarts(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir, _ := ioutil.TempDir("", "journal")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "journal.db")
+
+	db, err := bolt.Open(file, 0600, nil)
+	Expect(err).To(BeNil())
+
+	entry := newStoreEntry("/binary", storeStarted)
+	entry.Response.Body = string([]byte{0xff, 0x00, 0xfe})
+	entry.Response.Headers = map[string][]string{"Content-Type": []string{"application/octet-stream"}}
+	entry.Middleware = []middleware.Diagnostics{{Stderr: "debugging", Latency: time.Millisecond}}
+	Expect(journal.NewBoltDBJournalStore(db, []byte(journal.JournalBucketName)).Add(entry)).To(Succeed())
+	db.Close()
+
+	db, err = bolt.Open(file, 0600, nil)
+	Expect(err).To(BeNil())
+	defer db.Close()
+
+	entries := []journal.JournalEntry{}
+	journal.NewBoltDBJournalStore(db, []byte(journal.JournalBucketName)).ForEach(false, func(entry journal.JournalEntry) bool {
+		entries = append(entries, entry)
+		return true
+	})
+
+	Expect(entries).To(HaveLen(1))
+	Expect(entries[0].Response.Body).To(Equal(string([]byte{0xff, 0x00, 0xfe})))
+	Expect(entries[0].Response.Headers).To(Equal(entry.Response.Headers))
+	Expect(entries[0].TimeStarted.Equal(storeStarted)).To(BeTrue())
+	Expect(entries[0].Latency).To(Equal(time.Millisecond))
+	Expect(entries[0].Mode).To(Equal("simulate"))
+	Expect(entries[0].Middleware).To(Equal(entry.Middleware))
+}
+
+func Test_BoltJournalStore_CountsEntriesAddedBeforeARestart(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir, err := ioutil.TempDir("", "journal")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	db, err := bolt.Open(filepath.Join(dir, "journal.db"), 0600, nil)
+	Expect(err).To(BeNil())
+	defer db.Close()
+
+	store := journal.NewBoltDBJournalStore(db, []byte(journal.JournalBucketName))
+	for i, path := range []string{"/a", "/b"} {
+		Expect(store.Add(newStoreEntry(path, storeStarted.Add(time.Duration(i)*time.Second)))).To(Succeed())
+	}
+
+	unit := journal.NewBoltDBJournalStore(db, []byte(journal.JournalBucketName))
+	Expect(unit.AddAndTrim(newStoreEntry("/c", storeStarted.Add(2*time.Second)), 2, time.Time{})).To(Succeed())
+
+	Expect(unit.Count()).To(Equal(2))
+	Expect(storePaths(unit, false)).To(Equal([]string{"/b", "/c"}))
+}
@@ -1,8 +1,12 @@
 package hoverfly
 
 import (
+	"bufio"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
+	"sync"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -59,3 +63,134 @@ func (sl *StoppableListener) Accept() (net.Conn, error) {
 func (sl *StoppableListener) Stop() {
 	close(sl.stop)
 }
+
+// connListener - listener which hands out a single, already accepted connection. It is used to serve
+// MITM'd connections with http.Server so that HTTP/2 negotiated via ALPN is handled the same way
+// as on any other TLS listener
+type connListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+	addr  net.Addr
+}
+
+func newConnListener(conn net.Conn) *connListener {
+	conns := make(chan net.Conn, 1)
+	conns <- conn
+
+	return &connListener{
+		conns: conns,
+		done:  make(chan struct{}),
+		addr:  conn.LocalAddr(),
+	}
+}
+
+// Accept - returns the connection on the first call, then blocks until the listener is closed
+func (cl *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-cl.conns:
+		return conn, nil
+	case <-cl.done:
+		return nil, io.EOF
+	}
+}
+
+// Close - closes listener, the connection itself is left to the server
+func (cl *connListener) Close() error {
+	cl.once.Do(func() {
+		close(cl.done)
+	})
+	return nil
+}
+
+func (cl *connListener) Addr() net.Addr {
+	return cl.addr
+}
+
+// tlsSniffingListener - wrapper for listener that accepts both plain and TLS connections on
+// the same port. TLS connections are detected by their first byte and handed out as *tls.Conn
+// so that http.Server can negotiate HTTP/2
+type tlsSniffingListener struct {
+	net.Listener
+	tlsConfig *tls.Config
+	conns     chan net.Conn
+	done      chan struct{}
+	err       error
+}
+
+// tlsRecordTypeHandshake - first byte of every TLS ClientHello
+const tlsRecordTypeHandshake = 0x16
+
+// sniffTimeout - how long a client has to send its first byte before its connection is closed
+var sniffTimeout = 10 * time.Second
+
+func newTLSSniffingListener(l net.Listener, tlsConfig *tls.Config) *tlsSniffingListener {
+	sl := &tlsSniffingListener{
+		Listener:  l,
+		tlsConfig: tlsConfig,
+		conns:     make(chan net.Conn),
+		done:      make(chan struct{}),
+	}
+
+	go sl.acceptLoop()
+
+	return sl
+}
+
+func (sl *tlsSniffingListener) acceptLoop() {
+	for {
+		conn, err := sl.Listener.Accept()
+		if err != nil {
+			sl.err = err
+			close(sl.done)
+			return
+		}
+
+		// sniffing is done away from the accept loop so a slow client can't hold up others
+		go sl.sniff(conn)
+	}
+}
+
+func (sl *tlsSniffingListener) sniff(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+
+	// a client which never sends anything would otherwise hold the connection open for good
+	conn.SetReadDeadline(time.Now().Add(sniffTimeout))
+	first, err := reader.Peek(1)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	var sniffed net.Conn = &bufferedConn{Conn: conn, reader: reader}
+	if first[0] == tlsRecordTypeHandshake {
+		sniffed = tls.Server(sniffed, sl.tlsConfig)
+	}
+
+	select {
+	case sl.conns <- sniffed:
+	case <-sl.done:
+		conn.Close()
+	}
+}
+
+// Accept - returns the next sniffed connection
+func (sl *tlsSniffingListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-sl.conns:
+		return conn, nil
+	case <-sl.done:
+		return nil, sl.err
+	}
+}
+
+// bufferedConn - connection which reads through the buffer used for sniffing
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (bc *bufferedConn) Read(b []byte) (int, error) {
+	return bc.reader.Read(b)
+}
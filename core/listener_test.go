@@ -1,11 +1,15 @@
 package hoverfly
 
 import (
+	"crypto/tls"
 	"fmt"
 	. "github.com/onsi/gomega"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestHoverflyListener(t *testing.T) {
@@ -82,3 +86,56 @@ func TestRestartHoverflyListener(t *testing.T) {
 	Expect(err).To(BeNil())
 	Expect(newResponse.StatusCode).To(Equal(http.StatusInternalServerError))
 }
+
+func TestWebserverListenerNegotiatesHTTP2OverTLS(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, dbClient := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	proxyPort := "9780"
+
+	dbClient.Cfg.ProxyPort = proxyPort
+	dbClient.Cfg.Webserver = true
+	dbClient.StartProxy()
+	defer dbClient.StopProxy()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+
+	response, err := client.Get(fmt.Sprintf("https://localhost:%s/", proxyPort))
+	Expect(err).To(BeNil())
+	Expect(response.Proto).To(Equal("HTTP/2.0"))
+
+	// plain HTTP is still served on the same port
+	response, err = http.Get(fmt.Sprintf("http://localhost:%s/", proxyPort))
+	Expect(err).To(BeNil())
+	Expect(response.Proto).To(Equal("HTTP/1.1"))
+}
+
+func TestWebserverListenerClosesConnectionsWhichSendNothing(t *testing.T) {
+	RegisterTestingT(t)
+
+	defer func(timeout time.Duration) { sniffTimeout = timeout }(sniffTimeout)
+	sniffTimeout = 100 * time.Millisecond
+
+	server, dbClient := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	proxyPort := "9781"
+
+	dbClient.Cfg.ProxyPort = proxyPort
+	dbClient.Cfg.Webserver = true
+	dbClient.StartProxy()
+	defer dbClient.StopProxy()
+
+	conn, err := net.Dial("tcp", "localhost:"+proxyPort)
+	Expect(err).To(BeNil())
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	Expect(err).To(Equal(io.EOF))
+}
@@ -135,6 +135,10 @@ func (this *CacheMatcher) SaveRequestMatcherResponsePair(request models.RequestD
 		// but with different headers will need to go through matching
 	} else if pair != nil && pair.RequestMatcher.IncludesHeaderMatching() {
 		return nil
+		// The protocol version is not part of the cache key either, so the same applies to hits
+		// which matched on protocol
+	} else if pair != nil && pair.RequestMatcher.IncludesProtocolMatching() {
+		return nil
 	}
 
 	var key string
@@ -56,6 +56,25 @@ func Test_CacheMatcher_SaveRequestMatcherResponsePair_CanSaveNilPairs(t *testing
 	Expect(cachedResponse.MatchingPair).To(BeNil())
 }
 
+func Test_CacheMatcher_SaveRequestMatcherResponsePair_WillNotCachePairsMatchingOnProtocol(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := matching.CacheMatcher{
+		RequestCache: cache.NewInMemoryCache(),
+	}
+
+	err := unit.SaveRequestMatcherResponsePair(models.RequestDetails{Protocol: "HTTP/2.0"}, &models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Protocol: &models.RequestFieldMatchers{
+				ExactMatch: util.StringToPointer("HTTP/2.0"),
+			},
+		},
+	}, nil)
+	Expect(err).To(BeNil())
+
+	Expect(unit.RequestCache.GetAllKeys()).To(HaveLen(0))
+}
+
 func Test_CacheMatcher_FlushCache_WillReturnErrorIfCacheIsNil(t *testing.T) {
 	RegisterTestingT(t)
 	unit := matching.CacheMatcher{}
@@ -43,6 +43,15 @@ func FirstMatchRequestMatcher(req models.RequestDetails, webserver bool, simulat
 			continue
 		}
 
+		// Like headers, the protocol version is not part of the cache key so a miss on it
+		// is treated the same way as a miss on headers
+		if !UnscoredFieldMatcher(requestMatcher.Protocol, req.Protocol).Matched {
+			if matchedOnAllButHeaders {
+				matchedOnAllButHeadersAtLeastOnce = true
+			}
+			continue
+		}
+
 		if !CountlessHeaderMatcher(requestMatcher.Headers, req.Headers).Matched {
 			if matchedOnAllButHeaders {
 				matchedOnAllButHeadersAtLeastOnce = true
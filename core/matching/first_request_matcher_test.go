@@ -674,4 +674,33 @@ func Test_ShouldStoreIfMatchedOnEverythingApartFromHeadersZeroTimes(t *testing.T
 
 	Expect(err).ToNot(BeNil())
 	Expect(err.MatchedOnAllButHeadersAtLeastOnce).To(BeFalse())
-}
\ No newline at end of file
+}
+func Test_FirstMatchRequestMatcher_RequestMatchersShouldMatchOnProtocol(t *testing.T) {
+	RegisterTestingT(t)
+
+	simulation := models.NewSimulation()
+
+	simulation.MatchingPairs = append(simulation.MatchingPairs, models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Protocol: &models.RequestFieldMatchers{
+				ExactMatch: StringToPointer("HTTP/2.0"),
+			},
+		},
+		Response: testResponse,
+	})
+
+	r := models.RequestDetails{
+		Method:   "GET",
+		Protocol: "HTTP/1.1",
+	}
+
+	_, err := matching.FirstMatchRequestMatcher(r, false, simulation)
+	Expect(err).ToNot(BeNil())
+	Expect(err.MatchedOnAllButHeadersAtLeastOnce).To(BeTrue())
+
+	r.Protocol = "HTTP/2.0"
+
+	result, err := matching.FirstMatchRequestMatcher(r, false, simulation)
+	Expect(err).To(BeNil())
+	Expect(result.Response.Body).To(Equal("request matched"))
+}
@@ -0,0 +1,50 @@
+package matching
+
+import (
+	"github.com/SpectoLabs/hoverfly/core/models"
+)
+
+// ScoredRequestMatcher - matches a request against every field of a request matcher, returning
+// how strongly the request matched and the names of the fields it missed
+func ScoredRequestMatcher(requestMatcher models.RequestMatcher, req models.RequestDetails) (*FieldMatch, []string) {
+	requestMatch := &FieldMatch{Matched: true}
+	missedFields := make([]string, 0)
+
+	fields := []struct {
+		name    string
+		matcher *models.RequestFieldMatchers
+		value   string
+	}{
+		{"body", requestMatcher.Body, req.Body},
+		{"destination", requestMatcher.Destination, req.Destination},
+		{"path", requestMatcher.Path, req.Path},
+		{"query", requestMatcher.Query, req.Query},
+		{"method", requestMatcher.Method, req.Method},
+		{"scheme", requestMatcher.Scheme, req.Scheme},
+		{"protocol", requestMatcher.Protocol, req.Protocol},
+	}
+
+	for _, field := range fields {
+		fieldMatch := ScoredFieldMatcher(field.matcher, field.value)
+		if !fieldMatch.Matched {
+			requestMatch.Matched = false
+			missedFields = append(missedFields, field.name)
+		}
+		requestMatch.MatchScore += fieldMatch.MatchScore
+	}
+
+	// the header matcher lowercases the keys of the headers it is given
+	headers := map[string][]string{}
+	for key, values := range req.Headers {
+		headers[key] = values
+	}
+
+	fieldMatch := CountingHeaderMatcher(requestMatcher.Headers, headers)
+	if !fieldMatch.Matched {
+		requestMatch.Matched = false
+		missedFields = append(missedFields, "headers")
+	}
+	requestMatch.MatchScore += fieldMatch.MatchScore
+
+	return requestMatch, missedFields
+}
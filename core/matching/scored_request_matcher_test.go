@@ -0,0 +1,72 @@
+package matching_test
+
+import (
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/matching"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+)
+
+func Test_ScoredRequestMatcher_MatchesEveryField(t *testing.T) {
+	RegisterTestingT(t)
+
+	match, missedFields := matching.ScoredRequestMatcher(models.RequestMatcher{
+		Path: &models.RequestFieldMatchers{
+			ExactMatch: util.StringToPointer("/hello"),
+		},
+		Scheme: &models.RequestFieldMatchers{
+			ExactMatch: util.StringToPointer("https"),
+		},
+	}, models.RequestDetails{
+		Path:   "/hello",
+		Scheme: "https",
+	})
+
+	Expect(match.Matched).To(BeTrue())
+	Expect(match.MatchScore).To(Equal(2))
+	Expect(missedFields).To(BeEmpty())
+}
+
+func Test_ScoredRequestMatcher_ReturnsMissedFields(t *testing.T) {
+	RegisterTestingT(t)
+
+	match, missedFields := matching.ScoredRequestMatcher(models.RequestMatcher{
+		Path: &models.RequestFieldMatchers{
+			ExactMatch: util.StringToPointer("/hello"),
+		},
+		Method: &models.RequestFieldMatchers{
+			ExactMatch: util.StringToPointer("GET"),
+		},
+		Headers: map[string][]string{
+			"X-Test": []string{"one"},
+		},
+	}, models.RequestDetails{
+		Path:   "/goodbye",
+		Method: "GET",
+	})
+
+	Expect(match.Matched).To(BeFalse())
+	Expect(match.MatchScore).To(Equal(1))
+	Expect(missedFields).To(Equal([]string{"path", "headers"}))
+}
+
+func Test_ScoredRequestMatcher_DoesNotModifyRequestHeaders(t *testing.T) {
+	RegisterTestingT(t)
+
+	headers := map[string][]string{
+		"X-Test": []string{"one"},
+	}
+
+	match, _ := matching.ScoredRequestMatcher(models.RequestMatcher{
+		Headers: map[string][]string{
+			"X-Test": []string{"one"},
+		},
+	}, models.RequestDetails{
+		Headers: headers,
+	})
+
+	Expect(match.Matched).To(BeTrue())
+	Expect(headers).To(HaveKey("X-Test"))
+}
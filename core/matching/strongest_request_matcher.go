@@ -64,6 +64,18 @@ func StrongestMatchRequestMatcher(req models.RequestDetails, webserver bool, sim
 		}
 		matchScore += fieldMatch.MatchScore
 
+		// Like headers, the protocol version is not part of the cache key so a miss on it
+		// is treated the same way as a miss on headers
+		fieldMatch = ScoredFieldMatcher(requestMatcher.Protocol, req.Protocol)
+		if !fieldMatch.Matched {
+			matched = false
+			missedFields = append(missedFields, "protocol")
+			if matchedOnAllButHeaders {
+				matchedOnAllButHeadersAtLeastOnce = true
+			}
+		}
+		matchScore += fieldMatch.MatchScore
+
 		fieldMatch = CountingHeaderMatcher(requestMatcher.Headers, req.Headers)
 		if !fieldMatch.Matched {
 			matched = false
@@ -1298,3 +1298,56 @@ Which if hit would have given the following response:
         ]
     }
 }`))}
+
+func Test_ClosestRequestMatcherRequestMatcher_RequestMatchersShouldMatchOnProtocol(t *testing.T) {
+	RegisterTestingT(t)
+
+	simulation := models.NewSimulation()
+
+	simulation.MatchingPairs = append(simulation.MatchingPairs, models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Protocol: &models.RequestFieldMatchers{
+				ExactMatch: StringToPointer("HTTP/2.0"),
+			},
+		},
+		Response: testResponse,
+	})
+
+	r := models.RequestDetails{
+		Method:   "GET",
+		Protocol: "HTTP/2.0",
+	}
+	result, err := matching.StrongestMatchRequestMatcher(r, false, simulation)
+	Expect(err).To(BeNil())
+
+	Expect(result.Response.Body).To(Equal("request matched"))
+}
+
+func Test_ClosestRequestMatcherRequestMatcher_ProtocolMissIsTreatedLikeHeaderMiss(t *testing.T) {
+	RegisterTestingT(t)
+
+	simulation := models.NewSimulation()
+
+	simulation.MatchingPairs = append(simulation.MatchingPairs, models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Method: &models.RequestFieldMatchers{
+				ExactMatch: StringToPointer("GET"),
+			},
+			Protocol: &models.RequestFieldMatchers{
+				ExactMatch: StringToPointer("HTTP/2.0"),
+			},
+		},
+		Response: testResponse,
+	})
+
+	r := models.RequestDetails{
+		Method:   "GET",
+		Protocol: "HTTP/1.1",
+	}
+	result, err := matching.StrongestMatchRequestMatcher(r, false, simulation)
+
+	Expect(result).To(BeNil())
+	Expect(err).ToNot(BeNil())
+	Expect(err.MatchedOnAllButHeadersAtLeastOnce).To(BeTrue())
+	Expect(err.ClosestMiss.MissedFields).To(ConsistOf("protocol"))
+}
@@ -0,0 +1,248 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OverflowLabelValue - replaces the values of optional labels once a metric has as many series as it is allowed
+const OverflowLabelValue = "other"
+
+// DefaultBuckets - upper bounds of histogram buckets, in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Labels - label values of a series, keyed by label name
+type Labels map[string]string
+
+// Registry - metrics which are written out in the Prometheus text exposition format. Labels
+// which could take an unbounded number of values are optional, and are left out unless they
+// have been enabled. No metric gets more than MaxSeries series, further label values of
+// optional labels are counted as OverflowLabelValue instead
+type Registry struct {
+	mutex     sync.Mutex
+	families  []*family
+	optional  map[string]bool
+	enabled   map[string]bool
+	MaxSeries int
+}
+
+type family struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	buckets    []float64
+	series     map[string]*series
+}
+
+type series struct {
+	labelValues  []string
+	value        float64
+	bucketCounts []uint64
+	count        uint64
+}
+
+// Counter - a metric which only goes up
+type Counter struct {
+	registry *Registry
+	family   *family
+}
+
+// Histogram - a metric which counts observations into buckets
+type Histogram struct {
+	registry *Registry
+	family   *family
+}
+
+func NewRegistry(optionalLabels, enabledLabels []string, maxSeries int) *Registry {
+	registry := &Registry{
+		optional:  map[string]bool{},
+		enabled:   map[string]bool{},
+		MaxSeries: maxSeries,
+	}
+
+	for _, label := range optionalLabels {
+		registry.optional[label] = true
+	}
+
+	for _, label := range enabledLabels {
+		registry.enabled[label] = true
+	}
+
+	return registry
+}
+
+func (this *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	return &Counter{
+		registry: this,
+		family:   this.register(name, help, "counter", labelNames, nil),
+	}
+}
+
+func (this *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	return &Histogram{
+		registry: this,
+		family:   this.register(name, help, "histogram", labelNames, buckets),
+	}
+}
+
+func (this *Registry) register(name, help, kind string, labelNames []string, buckets []float64) *family {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	family := &family{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: []string{},
+		buckets:    buckets,
+		series:     map[string]*series{},
+	}
+
+	for _, labelName := range labelNames {
+		if !this.optional[labelName] || this.enabled[labelName] {
+			family.labelNames = append(family.labelNames, labelName)
+		}
+	}
+
+	this.families = append(this.families, family)
+
+	return family
+}
+
+func (this *Counter) Inc(labels Labels) {
+	this.Add(1, labels)
+}
+
+func (this *Counter) Add(value float64, labels Labels) {
+	this.registry.mutex.Lock()
+	defer this.registry.mutex.Unlock()
+
+	this.registry.series(this.family, labels).value += value
+}
+
+func (this *Histogram) Observe(value float64, labels Labels) {
+	this.registry.mutex.Lock()
+	defer this.registry.mutex.Unlock()
+
+	series := this.registry.series(this.family, labels)
+	series.value += value
+	series.count++
+
+	for i, bound := range this.family.buckets {
+		if value <= bound {
+			series.bucketCounts[i]++
+		}
+	}
+}
+
+// series - finds or creates the series for the label values, which the caller has to hold the lock for
+func (this *Registry) series(family *family, labels Labels) *series {
+	values := make([]string, len(family.labelNames))
+	for i, labelName := range family.labelNames {
+		values[i] = labels[labelName]
+	}
+
+	key := strings.Join(values, "\xff")
+	if found, ok := family.series[key]; ok {
+		return found
+	}
+
+	if this.MaxSeries > 0 && len(family.series) >= this.MaxSeries {
+		for i, labelName := range family.labelNames {
+			if this.optional[labelName] {
+				values[i] = OverflowLabelValue
+			}
+		}
+
+		key = strings.Join(values, "\xff")
+		if found, ok := family.series[key]; ok {
+			return found
+		}
+	}
+
+	created := &series{
+		labelValues:  values,
+		bucketCounts: make([]uint64, len(family.buckets)),
+	}
+	family.series[key] = created
+
+	return created
+}
+
+// WriteTo - writes every metric out in the Prometheus text exposition format
+func (this *Registry) WriteTo(w io.Writer) (int64, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	var buffer bytes.Buffer
+
+	for _, family := range this.families {
+		fmt.Fprintf(&buffer, "# HELP %s %s\n", family.name, escapeHelp(family.help))
+		fmt.Fprintf(&buffer, "# TYPE %s %s\n", family.name, family.kind)
+
+		keys := []string{}
+		for key := range family.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			series := family.series[key]
+
+			if family.kind == "counter" {
+				fmt.Fprintf(&buffer, "%s%s %s\n", family.name, formatLabels(family.labelNames, series.labelValues, "", ""), formatValue(series.value))
+				continue
+			}
+
+			for i, bound := range family.buckets {
+				fmt.Fprintf(&buffer, "%s_bucket%s %d\n", family.name, formatLabels(family.labelNames, series.labelValues, "le", formatValue(bound)), series.bucketCounts[i])
+			}
+			fmt.Fprintf(&buffer, "%s_bucket%s %d\n", family.name, formatLabels(family.labelNames, series.labelValues, "le", "+Inf"), series.count)
+			fmt.Fprintf(&buffer, "%s_sum%s %s\n", family.name, formatLabels(family.labelNames, series.labelValues, "", ""), formatValue(series.value))
+			fmt.Fprintf(&buffer, "%s_count%s %d\n", family.name, formatLabels(family.labelNames, series.labelValues, "", ""), series.count)
+		}
+	}
+
+	written, err := w.Write(buffer.Bytes())
+	return int64(written), err
+}
+
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	pairs := []string{}
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escapeLabelValue(values[i])))
+	}
+
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extraName, extraValue))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
@@ -0,0 +1,108 @@
+package metrics_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/metrics"
+	. "github.com/onsi/gomega"
+)
+
+func writeMetrics(registry *metrics.Registry) string {
+	var buffer bytes.Buffer
+	registry.WriteTo(&buffer)
+
+	return buffer.String()
+}
+
+func Test_Registry_WriteTo_WritesCounters(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := metrics.NewRegistry(nil, nil, 0)
+	counter := unit.NewCounter("requests_total", "Requests.", "mode")
+
+	counter.Inc(metrics.Labels{"mode": "simulate"})
+	counter.Inc(metrics.Labels{"mode": "simulate"})
+	counter.Inc(metrics.Labels{"mode": "capture"})
+
+	Expect(writeMetrics(unit)).To(Equal(`# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{mode="capture"} 1
+requests_total{mode="simulate"} 2
+`))
+}
+
+func Test_Registry_WriteTo_WritesHistograms(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := metrics.NewRegistry(nil, nil, 0)
+	histogram := unit.NewHistogram("duration_seconds", "Duration.", []float64{0.1, 1})
+
+	histogram.Observe(0.05, nil)
+	histogram.Observe(0.5, nil)
+	histogram.Observe(2, nil)
+
+	Expect(writeMetrics(unit)).To(Equal(`# HELP duration_seconds Duration.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{le="0.1"} 1
+duration_seconds_bucket{le="1"} 2
+duration_seconds_bucket{le="+Inf"} 3
+duration_seconds_sum 2.55
+duration_seconds_count 3
+`))
+}
+
+func Test_Registry_LeavesOutOptionalLabelsUnlessEnabled(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := metrics.NewRegistry([]string{"destination", "pair"}, []string{"pair"}, 0)
+	counter := unit.NewCounter("requests_total", "Requests.", "destination", "pair")
+
+	counter.Inc(metrics.Labels{"destination": "test.com", "pair": "GET test.com /"})
+
+	Expect(writeMetrics(unit)).To(ContainSubstring(`requests_total{pair="GET test.com /"} 1`))
+}
+
+func Test_Registry_CountsOptionalLabelsAsOtherOnceMaxSeriesIsReached(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := metrics.NewRegistry([]string{"destination"}, []string{"destination"}, 2)
+	counter := unit.NewCounter("requests_total", "Requests.", "mode", "destination")
+
+	for _, destination := range []string{"one.com", "two.com", "three.com", "four.com", "one.com"} {
+		counter.Inc(metrics.Labels{"mode": "simulate", "destination": destination})
+	}
+
+	Expect(writeMetrics(unit)).To(Equal(`# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{mode="simulate",destination="one.com"} 2
+requests_total{mode="simulate",destination="other"} 2
+requests_total{mode="simulate",destination="two.com"} 1
+`))
+}
+
+func Test_Registry_EscapesLabelValues(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := metrics.NewRegistry(nil, nil, 0)
+	counter := unit.NewCounter("requests_total", "Requests.", "pair")
+
+	counter.Inc(metrics.Labels{"pair": "GET \"quoted\" \\path\n"})
+
+	Expect(writeMetrics(unit)).To(ContainSubstring(`requests_total{pair="GET \"quoted\" \\path\n"} 1`))
+}
+
+func Test_RequestMetrics_Request_CountsRequestsByModeAndStatus(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := metrics.NewRequestMetrics(nil, metrics.DefaultMaxSeries)
+
+	unit.Request("simulate", "test.com", 200, 20*time.Millisecond)
+	unit.CacheLookup(true)
+
+	written := writeMetrics(unit.Registry)
+	Expect(written).To(ContainSubstring(`hoverfly_requests_total{mode="simulate",status="200"} 1`))
+	Expect(written).To(ContainSubstring(`hoverfly_request_duration_seconds_bucket{mode="simulate",le="0.025"} 1`))
+	Expect(written).To(ContainSubstring(`hoverfly_cache_requests_total{result="hit"} 1`))
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+)
+
+// OptionalLabels - labels which take a value per destination or per request response pair, and
+// so are only added to metrics when enabled
+var OptionalLabels = []string{"destination", "pair"}
+
+// DefaultMaxSeries - the number of series each metric is allowed by default
+const DefaultMaxSeries = 1000
+
+// RequestMetrics - the metrics Hoverfly keeps about the requests it handles
+type RequestMetrics struct {
+	Registry *Registry
+
+	requests           *Counter
+	requestDuration    *Histogram
+	matches            *Counter
+	matchFailures      *Counter
+	cacheRequests      *Counter
+	middlewareDuration *Histogram
+	upstreamDuration   *Histogram
+}
+
+// NewRequestMetrics - enabledLabels are the optional labels to add, and maxSeries limits
+// the number of series of each metric, with 0 leaving it unlimited
+func NewRequestMetrics(enabledLabels []string, maxSeries int) *RequestMetrics {
+	registry := NewRegistry(OptionalLabels, enabledLabels, maxSeries)
+
+	return &RequestMetrics{
+		Registry: registry,
+
+		requests: registry.NewCounter("hoverfly_requests_total",
+			"Requests handled by Hoverfly.", "mode", "destination", "status"),
+		requestDuration: registry.NewHistogram("hoverfly_request_duration_seconds",
+			"Time taken to respond to requests, including delays.", DefaultBuckets, "mode", "destination"),
+		matches: registry.NewCounter("hoverfly_simulation_matches_total",
+			"Requests matched by a request response pair of the simulation.", "pair"),
+		matchFailures: registry.NewCounter("hoverfly_simulation_match_failures_total",
+			"Requests which did not match any request response pair of the simulation.", "destination"),
+		cacheRequests: registry.NewCounter("hoverfly_cache_requests_total",
+			"Lookups of the request cache, by whether the request had been matched before.", "result"),
+		middlewareDuration: registry.NewHistogram("hoverfly_middleware_duration_seconds",
+			"Time taken by middleware.", DefaultBuckets, "mode"),
+		upstreamDuration: registry.NewHistogram("hoverfly_upstream_duration_seconds",
+			"Time taken by the real service to respond to requests Hoverfly made to it.", DefaultBuckets, "destination"),
+	}
+}
+
+func (this *RequestMetrics) Request(mode, destination string, status int, duration time.Duration) {
+	this.requests.Inc(Labels{"mode": mode, "destination": destination, "status": strconv.Itoa(status)})
+	this.requestDuration.Observe(duration.Seconds(), Labels{"mode": mode, "destination": destination})
+}
+
+func (this *RequestMetrics) Match(pair string) {
+	this.matches.Inc(Labels{"pair": pair})
+}
+
+func (this *RequestMetrics) MatchFailure(destination string) {
+	this.matchFailures.Inc(Labels{"destination": destination})
+}
+
+func (this *RequestMetrics) CacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	this.cacheRequests.Inc(Labels{"result": result})
+}
+
+func (this *RequestMetrics) Middleware(mode string, duration time.Duration) {
+	this.middlewareDuration.Observe(duration.Seconds(), Labels{"mode": mode})
+}
+
+func (this *RequestMetrics) Upstream(destination string, duration time.Duration) {
+	this.upstreamDuration.Observe(duration.Seconds(), Labels{"destination": destination})
+}
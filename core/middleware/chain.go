@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/SpectoLabs/hoverfly/core/matching"
+	"github.com/SpectoLabs/hoverfly/core/models"
+)
+
+// Phases of a request in which middleware can run. Requests are only in the request phase
+// when Hoverfly forwards them on, which is in capture and modify mode, and responses are in
+// the response phase in simulate, synthesize and modify mode
+const (
+	RequestPhase  = "request"
+	ResponsePhase = "response"
+	BothPhases    = "both"
+)
+
+// ChainedMiddleware - middleware which is one of a chain, run in order on the requests its
+// request matcher matches, in its phase. Middleware with a name can also be run on the
+// response of a request response pair by naming it in the response
+type ChainedMiddleware struct {
+	Name           string
+	Phase          string
+	RequestMatcher *models.RequestMatcher
+	Middleware     *Middleware
+}
+
+// ValidatePhase - returns an error unless phase is empty, which is the same as both phases, or one of the phases
+func ValidatePhase(phase string) error {
+	switch phase {
+	case "", RequestPhase, ResponsePhase, BothPhases:
+		return nil
+	}
+
+	return fmt.Errorf("Unknown middleware phase %s", phase)
+}
+
+// Applies - whether the middleware runs on a request in the phase given
+func (this ChainedMiddleware) Applies(phase string, request models.RequestDetails) bool {
+	if this.Phase != "" && this.Phase != BothPhases && this.Phase != phase {
+		return false
+	}
+
+	if this.RequestMatcher == nil {
+		return true
+	}
+
+	match, _ := matching.ScoredRequestMatcher(*this.RequestMatcher, request)
+	return match.Matched
+}
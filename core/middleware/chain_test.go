@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+)
+
+func Test_ValidatePhase_ReturnsErrorForUnknownPhase(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, phase := range []string{"", RequestPhase, ResponsePhase, BothPhases} {
+		Expect(ValidatePhase(phase)).To(BeNil())
+	}
+
+	err := ValidatePhase("never")
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Unknown middleware phase never"))
+}
+
+func Test_ChainedMiddleware_Applies_OnlyInItsPhase(t *testing.T) {
+	RegisterTestingT(t)
+
+	request := models.RequestDetails{Destination: "test.com"}
+
+	Expect(ChainedMiddleware{Phase: RequestPhase}.Applies(RequestPhase, request)).To(BeTrue())
+	Expect(ChainedMiddleware{Phase: RequestPhase}.Applies(ResponsePhase, request)).To(BeFalse())
+	Expect(ChainedMiddleware{Phase: ResponsePhase}.Applies(ResponsePhase, request)).To(BeTrue())
+	Expect(ChainedMiddleware{Phase: ResponsePhase}.Applies(RequestPhase, request)).To(BeFalse())
+
+	for _, phase := range []string{"", BothPhases} {
+		Expect(ChainedMiddleware{Phase: phase}.Applies(RequestPhase, request)).To(BeTrue())
+		Expect(ChainedMiddleware{Phase: phase}.Applies(ResponsePhase, request)).To(BeTrue())
+	}
+}
+
+func Test_ChainedMiddleware_Applies_OnlyToRequestsItsRequestMatcherMatches(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := ChainedMiddleware{
+		RequestMatcher: &models.RequestMatcher{
+			Destination: &models.RequestFieldMatchers{
+				ExactMatch: util.StringToPointer("test.com"),
+			},
+		},
+	}
+
+	Expect(unit.Applies(ResponsePhase, models.RequestDetails{Destination: "test.com"})).To(BeTrue())
+	Expect(unit.Applies(ResponsePhase, models.RequestDetails{Destination: "other.com"})).To(BeFalse())
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+)
+
+// Diagnostics - what happened when middleware ran on a pair, to help with debugging it. Stderr
+// is what the middleware wrote to stderr, or printed when it is a script run inside Hoverfly.
+// Persistent and remote middleware have no stderr of their own for each pair
+type Diagnostics struct {
+	Name      string
+	Stderr    string
+	Error     string
+	ErrorKind string
+	Policy    string
+	Latency   time.Duration
+}
+
+func (this Diagnostics) BuildView() v2.MiddlewareDiagnosticsView {
+	return v2.MiddlewareDiagnosticsView{
+		Name:      this.Name,
+		Stderr:    this.Stderr,
+		Error:     this.Error,
+		ErrorKind: this.ErrorKind,
+		Policy:    this.Policy,
+		Latency:   this.Latency / time.Millisecond,
+	}
+}
+
+type diagnosticsKey struct{}
+
+type diagnosticsRecorder struct {
+	mutex       sync.Mutex
+	diagnostics []Diagnostics
+}
+
+// ContextWithDiagnostics - returns a context which records the diagnostics of the middleware run with it
+func ContextWithDiagnostics(ctx context.Context) context.Context {
+	return context.WithValue(ctx, diagnosticsKey{}, &diagnosticsRecorder{})
+}
+
+// RecordDiagnostics - records the diagnostics in the context, when it was made with ContextWithDiagnostics
+func RecordDiagnostics(ctx context.Context, diagnostics Diagnostics) {
+	recorder, ok := ctx.Value(diagnosticsKey{}).(*diagnosticsRecorder)
+	if !ok {
+		return
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.diagnostics = append(recorder.diagnostics, diagnostics)
+}
+
+// DiagnosticsFromContext - the diagnostics recorded in the context, in the order the middleware ran
+func DiagnosticsFromContext(ctx context.Context) []Diagnostics {
+	recorder, ok := ctx.Value(diagnosticsKey{}).(*diagnosticsRecorder)
+	if !ok {
+		return nil
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	return append([]Diagnostics{}, recorder.diagnostics...)
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+)
+
+// Kinds of error middleware can fail with, each of which can be given its own policy
+const (
+	TimeoutError         = "timeout"
+	FailureError         = "failure"
+	InvalidResponseError = "invalidResponse"
+)
+
+// What is done with a request when middleware fails on it. Requests fail unless the
+// ErrorPolicy says otherwise
+const (
+	FailPolicy        = "fail"
+	PassThroughPolicy = "passthrough"
+	FallbackPolicy    = "fallback"
+)
+
+// Error - an error from running middleware, along with the kind of error it is
+type Error struct {
+	Kind string
+	Err  error
+}
+
+func newError(kind string, err error) error {
+	return &Error{Kind: kind, Err: err}
+}
+
+func (this *Error) Error() string {
+	return this.Err.Error()
+}
+
+// ErrorKind - the kind of error middleware failed with, which is a failure unless it was
+// a timeout or an invalid response
+func ErrorKind(err error) string {
+	if middlewareError, ok := err.(*Error); ok {
+		return middlewareError.Kind
+	}
+
+	return FailureError
+}
+
+// ErrorPolicy - the policy for each kind of error. Passing through leaves the pair as it was
+// before the middleware ran, and falling back replaces the response with Fallback
+type ErrorPolicy struct {
+	OnTimeout         string
+	OnFailure         string
+	OnInvalidResponse string
+	Fallback          *models.ResponseDetails
+}
+
+// Validate - returns an error if any of the policies is unknown, or there is no fallback to fall back to
+func (this ErrorPolicy) Validate() error {
+	for _, policy := range []string{this.OnTimeout, this.OnFailure, this.OnInvalidResponse} {
+		switch policy {
+		case "", FailPolicy, PassThroughPolicy:
+		case FallbackPolicy:
+			if this.Fallback == nil {
+				return fmt.Errorf("Middleware error policy %s needs a fallback response", FallbackPolicy)
+			}
+		default:
+			return fmt.Errorf("Unknown middleware error policy %s", policy)
+		}
+	}
+
+	return nil
+}
+
+// IsSet - whether any of the policies is set, or there is a fallback
+func (this ErrorPolicy) IsSet() bool {
+	return this.OnTimeout != "" || this.OnFailure != "" || this.OnInvalidResponse != "" || this.Fallback != nil
+}
+
+func (this ErrorPolicy) policyFor(kind string) string {
+	policy := this.OnFailure
+	switch kind {
+	case TimeoutError:
+		policy = this.OnTimeout
+	case InvalidResponseError:
+		policy = this.OnInvalidResponse
+	}
+
+	if policy == "" {
+		return FailPolicy
+	}
+
+	return policy
+}
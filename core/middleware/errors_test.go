@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/onsi/gomega"
+)
+
+func newPythonMiddleware(script string) *Middleware {
+	unit := &Middleware{}
+	Expect(unit.SetBinary("python")).To(BeNil())
+	Expect(unit.SetScript(script)).To(BeNil())
+
+	return unit
+}
+
+func Test_ErrorPolicy_Validate(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(ErrorPolicy{}.Validate()).To(BeNil())
+	Expect(ErrorPolicy{OnTimeout: PassThroughPolicy, OnFailure: FailPolicy}.Validate()).To(BeNil())
+	Expect(ErrorPolicy{OnInvalidResponse: FallbackPolicy, Fallback: &models.ResponseDetails{Status: 503}}.Validate()).To(BeNil())
+
+	err := ErrorPolicy{OnFailure: "retry"}.Validate()
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Unknown middleware error policy retry"))
+
+	err = ErrorPolicy{OnTimeout: FallbackPolicy}.Validate()
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Middleware error policy fallback needs a fallback response"))
+}
+
+func Test_Middleware_ExecuteWithDiagnostics_CapturesStderr(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newPythonMiddleware("import sys\nsys.stderr.write('debugging')\nsys.stdout.write(sys.stdin.read())")
+
+	pair, diagnostics, err := unit.ExecuteWithDiagnostics(newPairWithRequestBody("body"))
+	Expect(err).To(BeNil())
+
+	Expect(pair.Request.Body).To(Equal("body"))
+	Expect(diagnostics.Stderr).To(Equal("debugging"))
+	Expect(diagnostics.Error).To(Equal(""))
+}
+
+func Test_Middleware_ExecuteWithDiagnostics_CapturesWhatLuaPrints(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit, err := newLuaMiddleware(`print("path", pair.request.path)`)
+	Expect(err).To(BeNil())
+
+	_, diagnostics, err := unit.ExecuteWithDiagnostics(newPairWithRequestBody("body"))
+	Expect(err).To(BeNil())
+
+	Expect(diagnostics.Stderr).To(Equal("path\t/\n"))
+}
+
+func Test_Middleware_Execute_TimesOutMiddlewareStartedForEachPair(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newPythonMiddleware("import time\ntime.sleep(10)")
+	unit.Timeout = 100 * time.Millisecond
+
+	started := time.Now()
+	_, diagnostics, err := unit.ExecuteWithDiagnostics(newPairWithRequestBody("body"))
+	Expect(time.Since(started)).To(BeNumerically("<", 5*time.Second))
+
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Middleware did not respond within 100ms"))
+	Expect(diagnostics.ErrorKind).To(Equal(TimeoutError))
+	Expect(diagnostics.Policy).To(Equal(FailPolicy))
+}
+
+func Test_Middleware_Execute_TimesOutRemoteMiddleware(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(500 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	unit := &Middleware{Remote: server.URL, Timeout: 100 * time.Millisecond}
+
+	_, err := unit.Execute(newPairWithRequestBody("body"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Middleware did not respond within 100ms"))
+	Expect(ErrorKind(err)).To(Equal(TimeoutError))
+}
+
+func Test_Middleware_Execute_ReturnsWhatRemoteMiddlewareRespondedWithWhenItIsNotOk(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("could not parse body\n"))
+	}))
+	defer server.Close()
+
+	unit := &Middleware{Remote: server.URL}
+
+	_, err := unit.Execute(newPairWithRequestBody("body"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Remote middleware responded with status 500: could not parse body"))
+	Expect(ErrorKind(err)).To(Equal(FailureError))
+}
+
+func Test_Middleware_Execute_PassesThePairThroughWithThePolicyForTheKindOfError(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newPythonMiddleware("print('not json')")
+	unit.ErrorPolicy = ErrorPolicy{OnInvalidResponse: PassThroughPolicy}
+
+	pair, diagnostics, err := unit.ExecuteWithDiagnostics(newPairWithRequestBody("body"))
+	Expect(err).To(BeNil())
+
+	Expect(pair).To(Equal(newPairWithRequestBody("body")))
+	Expect(diagnostics.Error).To(Equal("Failed to unmarshal JSON from middleware"))
+	Expect(diagnostics.ErrorKind).To(Equal(InvalidResponseError))
+	Expect(diagnostics.Policy).To(Equal(PassThroughPolicy))
+
+	unit.ErrorPolicy = ErrorPolicy{OnFailure: PassThroughPolicy}
+
+	_, err = unit.Execute(newPairWithRequestBody("body"))
+	Expect(err).ToNot(BeNil())
+}
+
+func Test_Middleware_Execute_UsesTheFallbackResponseWithTheFallbackPolicy(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newPythonMiddleware("import sys\nsys.exit(1)")
+	unit.ErrorPolicy = ErrorPolicy{
+		OnFailure: FallbackPolicy,
+		Fallback:  &models.ResponseDetails{Status: 503, Body: "fallback"},
+	}
+
+	pair, err := unit.Execute(newPairWithRequestBody("body"))
+	Expect(err).To(BeNil())
+
+	Expect(pair.Request.Body).To(Equal("body"))
+	Expect(pair.Response.Status).To(Equal(503))
+	Expect(pair.Response.Body).To(Equal("fallback"))
+}
+
+func Test_DiagnosticsFromContext_ReturnsTheDiagnosticsRecordedInTheContext(t *testing.T) {
+	RegisterTestingT(t)
+
+	RecordDiagnostics(context.Background(), Diagnostics{Name: "ignored"})
+	Expect(DiagnosticsFromContext(context.Background())).To(BeNil())
+
+	ctx := ContextWithDiagnostics(context.Background())
+	RecordDiagnostics(ctx, Diagnostics{Name: "first"})
+	RecordDiagnostics(ctx, Diagnostics{Name: "second", Stderr: "debugging"})
+
+	Expect(DiagnosticsFromContext(ctx)).To(Equal([]Diagnostics{
+		{Name: "first"},
+		{Name: "second", Stderr: "debugging"},
+	}))
+}
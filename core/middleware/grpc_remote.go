@@ -0,0 +1,393 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/grpc"
+	"github.com/SpectoLabs/hoverfly/core/middleware/middlewarepb"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/golang/protobuf/proto"
+)
+
+// executeMiddlewareOverGrpc - calls Process on the remote middleware with the pair, or when
+// the middleware is persistent sends it over a ProcessStream stream which is kept open
+func (this Middleware) executeMiddlewareOverGrpc(pair models.RequestResponsePair) (models.RequestResponsePair, error) {
+	var result *middlewarepb.RequestResponsePair
+	var err error
+
+	if this.Persistent {
+		result, err = this.remote.stream.execute(NewPairMessage(pair), this.timeout())
+	} else {
+		result, err = this.remote.process(NewPairMessage(pair), this.timeout())
+	}
+
+	if err != nil {
+		log.WithFields(log.Fields{
+			"middleware": this.toString(),
+			"error":      err.Error(),
+		}).Error("Error when communicating with remote middleware")
+		return pair, err
+	}
+
+	if result == nil {
+		return pair, nil
+	}
+
+	return NewPairFromMessage(pair, result), nil
+}
+
+// NewPairMessage - the pair as it is sent to middleware over gRPC
+func NewPairMessage(pair models.RequestResponsePair) *middlewarepb.RequestResponsePair {
+	return &middlewarepb.RequestResponsePair{
+		Request: &middlewarepb.Request{
+			Path:        pair.Request.Path,
+			Method:      pair.Request.Method,
+			Destination: pair.Request.Destination,
+			Scheme:      pair.Request.Scheme,
+			Query:       pair.Request.Query,
+			Body:        []byte(pair.Request.Body),
+			Headers:     newHeadersMessage(pair.Request.Headers),
+			Protocol:    pair.Request.Protocol,
+		},
+		Response: &middlewarepb.Response{
+			Status:  int32(pair.Response.Status),
+			Body:    []byte(pair.Response.Body),
+			Headers: newHeadersMessage(pair.Response.Headers),
+		},
+	}
+}
+
+// NewPairFromMessage - the pair with the request and response from the message middleware
+// returned over gRPC. What is not part of the message is kept from the pair
+func NewPairFromMessage(pair models.RequestResponsePair, message *middlewarepb.RequestResponsePair) models.RequestResponsePair {
+	if request := message.GetRequest(); request != nil {
+		pair.Request = models.RequestDetails{
+			Path:        request.Path,
+			Method:      request.Method,
+			Destination: request.Destination,
+			Scheme:      request.Scheme,
+			Query:       request.Query,
+			Body:        string(request.Body),
+			Headers:     newHeadersFromMessage(request.Headers),
+			Protocol:    request.Protocol,
+		}
+	}
+
+	if response := message.GetResponse(); response != nil {
+		pair.Response.Status = int(response.Status)
+		pair.Response.Body = string(response.Body)
+		pair.Response.Headers = newHeadersFromMessage(response.Headers)
+	}
+
+	return pair
+}
+
+func newHeadersMessage(headers map[string][]string) map[string]*middlewarepb.HeaderValues {
+	message := map[string]*middlewarepb.HeaderValues{}
+	for name, values := range headers {
+		message[name] = &middlewarepb.HeaderValues{Values: values}
+	}
+
+	return message
+}
+
+func newHeadersFromMessage(message map[string]*middlewarepb.HeaderValues) map[string][]string {
+	headers := map[string][]string{}
+	for name, values := range message {
+		headers[name] = values.GetValues()
+	}
+
+	return headers
+}
+
+// newGrpcRequest - a request calling the method on the remote middleware, which is given
+// the timeout as its deadline
+func (this *remote) newGrpcRequest(ctx context.Context, method string, body io.Reader, timeout time.Duration) (*http.Request, error) {
+	req, err := this.newRequest(ctx, this.url+method, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/grpc+proto")
+	req.Header.Set("Te", "trailers")
+	if timeout > 0 {
+		req.Header.Set("Grpc-Timeout", strconv.FormatInt(int64(timeout/time.Millisecond), 10)+"m")
+	}
+
+	return req, nil
+}
+
+// process - calls Process with the pair, returning the pair the middleware responds with
+func (this *remote) process(pair *middlewarepb.RequestResponsePair, timeout time.Duration) (*middlewarepb.RequestResponsePair, error) {
+	message, err := proto.Marshal(pair)
+	if err != nil {
+		return nil, errors.New("Failed to marshal request to protobuf")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := this.newGrpcRequest(ctx, middlewarepb.ProcessMethod, bytes.NewReader(grpc.AppendFrame(nil, message)), timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := this.client.Do(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, newError(TimeoutError, fmt.Errorf("Middleware did not respond within %s", timeout))
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, newError(TimeoutError, fmt.Errorf("Middleware did not respond within %s", timeout))
+		}
+		return nil, err
+	}
+
+	if err := grpcStatusError(resp); err != nil {
+		return nil, err
+	}
+
+	frames, err := grpc.ReadFrames(body, resp.Header.Get("Grpc-Encoding"))
+	if err != nil {
+		return nil, newError(InvalidResponseError, err)
+	}
+
+	if len(frames) != 1 {
+		return nil, newError(InvalidResponseError, fmt.Errorf("Expected a single message from middleware, got %d", len(frames)))
+	}
+
+	result := &middlewarepb.RequestResponsePair{}
+	if err := proto.Unmarshal(frames[0], result); err != nil {
+		return nil, newError(InvalidResponseError, errors.New("Failed to unmarshal protobuf from middleware"))
+	}
+
+	return result, nil
+}
+
+// grpcStatusError - the error for the status the call ended with, which is in the trailers,
+// or the headers when the middleware responded without a body
+func grpcStatusError(resp *http.Response) error {
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("Remote middleware responded with status %d", resp.StatusCode)
+	}
+
+	status, message := resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status, message = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+
+	if status == "" {
+		return newError(InvalidResponseError, errors.New("Remote middleware responded without a gRPC status"))
+	}
+
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return newError(InvalidResponseError, fmt.Errorf("Remote middleware responded with an invalid gRPC status %s", status))
+	}
+
+	switch code {
+	case grpc.StatusOK:
+		return nil
+	case grpc.StatusDeadlineExceeded:
+		return newError(TimeoutError, fmt.Errorf("Remote middleware exceeded its deadline: %s", grpc.DecodeStatusMessage(message)))
+	}
+
+	return fmt.Errorf("Remote middleware failed with gRPC status %d: %s", code, grpc.DecodeStatusMessage(message))
+}
+
+// grpcStream - a ProcessStream stream to remote middleware which is kept open, so that pairs
+// can be sent over it as they arrive rather than calling Process for each one. Pairs are told
+// apart by their ID, so the middleware can answer them in any order. When the stream ends
+// another is opened for the next pair
+type grpcStream struct {
+	remote  *remote
+	mutex   sync.Mutex
+	running *openStream
+	nextID  uint64
+}
+
+// openStream - a call to ProcessStream, along with the pairs waiting on the middleware to respond
+type openStream struct {
+	requests *io.PipeWriter
+	writing  sync.Mutex
+	cancel   context.CancelFunc
+	pending  map[uint64]chan *middlewarepb.StreamMessage
+}
+
+func newGrpcStream(remote *remote) *grpcStream {
+	return &grpcStream{remote: remote}
+}
+
+// execute - sends the pair over the stream, returning the pair the middleware responds with,
+// or nil when it responds without one to leave the pair as it was
+func (this *grpcStream) execute(pair *middlewarepb.RequestResponsePair, timeout time.Duration) (*middlewarepb.RequestResponsePair, error) {
+	this.mutex.Lock()
+
+	if this.running == nil {
+		if err := this.open(); err != nil {
+			this.mutex.Unlock()
+			return nil, err
+		}
+	}
+	running := this.running
+
+	this.nextID++
+	id := this.nextID
+
+	message, err := proto.Marshal(&middlewarepb.StreamMessage{Id: id, Pair: pair})
+	if err != nil {
+		this.mutex.Unlock()
+		return nil, errors.New("Failed to marshal request to protobuf")
+	}
+
+	response := make(chan *middlewarepb.StreamMessage, 1)
+	running.pending[id] = response
+	this.mutex.Unlock()
+
+	// written without holding the lock, which responses are passed on with, as the middleware
+	// may not read another pair until it has written its responses
+	running.writing.Lock()
+	_, err = running.requests.Write(grpc.AppendFrame(nil, message))
+	running.writing.Unlock()
+
+	if err != nil {
+		this.forget(running, id)
+		return nil, fmt.Errorf("Failed to write to middleware: %s", err.Error())
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case message := <-response:
+		if message.Error != "" {
+			return nil, errors.New(message.Error)
+		}
+
+		return message.Pair, nil
+	case <-timer.C:
+		this.forget(running, id)
+		return nil, newError(TimeoutError, fmt.Errorf("Middleware did not respond within %s", timeout))
+	}
+}
+
+func (this *grpcStream) forget(running *openStream, id uint64) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	delete(running.pending, id)
+}
+
+// open - opens the stream, which the caller has to hold the lock for. The call is made as the
+// stream is read, as it only returns once the middleware has responded with its headers
+func (this *grpcStream) open() error {
+	requests, requestsWriter := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req, err := this.remote.newGrpcRequest(ctx, middlewarepb.ProcessStreamMethod, requests, 0)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	this.running = &openStream{
+		requests: requestsWriter,
+		cancel:   cancel,
+		pending:  map[uint64]chan *middlewarepb.StreamMessage{},
+	}
+
+	go this.read(this.running, req)
+
+	return nil
+}
+
+func (this *grpcStream) read(running *openStream, req *http.Request) {
+	err := this.receive(running, req)
+
+	running.requests.CloseWithError(io.ErrClosedPipe)
+	running.cancel()
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.running == running {
+		this.running = nil
+	}
+
+	for id, response := range running.pending {
+		response <- &middlewarepb.StreamMessage{Id: id, Error: "Middleware stream ended before responding"}
+		delete(running.pending, id)
+	}
+
+	fields := log.Fields{}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	log.WithFields(fields).Warn("Remote middleware stream ended, it will be opened again for the next request")
+}
+
+// receive - makes the call and passes the messages the middleware responds with to the pairs
+// waiting on them, until the stream ends
+func (this *grpcStream) receive(running *openStream, req *http.Request) error {
+	resp, err := this.remote.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	encoding := resp.Header.Get("Grpc-Encoding")
+	for {
+		frame, err := grpc.ReadFrame(resp.Body, encoding)
+		if err == io.EOF {
+			return grpcStatusError(resp)
+		} else if err != nil {
+			return err
+		}
+
+		message := &middlewarepb.StreamMessage{}
+		if err := proto.Unmarshal(frame, message); err != nil {
+			log.Warn("Failed to unmarshal protobuf from middleware")
+			continue
+		}
+
+		this.mutex.Lock()
+		response, ok := running.pending[message.Id]
+		delete(running.pending, message.Id)
+		this.mutex.Unlock()
+
+		if ok {
+			response <- message
+		}
+	}
+}
+
+// stop - ends the stream, failing any pairs still waiting on it
+func (this *grpcStream) stop() {
+	this.mutex.Lock()
+	running := this.running
+	this.running = nil
+	this.mutex.Unlock()
+
+	if running == nil {
+		return
+	}
+
+	running.requests.Close()
+	running.cancel()
+}
@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/grpc"
+	"github.com/SpectoLabs/hoverfly/core/middleware/middlewarepb"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/golang/protobuf/proto"
+	. "github.com/onsi/gomega"
+)
+
+// newGrpcMiddlewareServer - serves the middleware contract over HTTP/2 without TLS, running
+// modify on each pair, and returns the URL of the middleware
+func newGrpcMiddlewareServer(modify func(*http.Request, *middlewarepb.RequestResponsePair) error) (*httptest.Server, string) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+
+		status, message := "0", ""
+
+		switch r.URL.Path {
+		case middlewarepb.ProcessMethod:
+			body, _ := ioutil.ReadAll(r.Body)
+			frames, _ := grpc.ReadFrames(body, "")
+
+			pair := &middlewarepb.RequestResponsePair{}
+			proto.Unmarshal(frames[0], pair)
+
+			if err := modify(r, pair); err != nil {
+				status, message = "13", err.Error()
+				break
+			}
+
+			response, _ := proto.Marshal(pair)
+			w.Write(grpc.AppendFrame(nil, response))
+		case middlewarepb.ProcessStreamMethod:
+			w.WriteHeader(200)
+			w.(http.Flusher).Flush()
+
+			for {
+				frame, err := grpc.ReadFrame(r.Body, "")
+				if err != nil {
+					break
+				}
+
+				streamMessage := &middlewarepb.StreamMessage{}
+				proto.Unmarshal(frame, streamMessage)
+
+				if err := modify(r, streamMessage.Pair); err != nil {
+					streamMessage = &middlewarepb.StreamMessage{Id: streamMessage.Id, Error: err.Error()}
+				}
+
+				response, _ := proto.Marshal(streamMessage)
+				w.Write(grpc.AppendFrame(nil, response))
+				w.(http.Flusher).Flush()
+			}
+		default:
+			status, message = "12", "unknown method"
+		}
+
+		w.Header().Set("Grpc-Status", status)
+		w.Header().Set("Grpc-Message", message)
+	}))
+
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+
+	return server, strings.Replace(server.URL, "http://", "grpc://", 1)
+}
+
+func Test_NewPairMessage_KeepsBinaryBodies(t *testing.T) {
+	RegisterTestingT(t)
+
+	pair := models.RequestResponsePair{
+		Request: models.RequestDetails{
+			Method:  "POST",
+			Body:    string([]byte{0x0a, 0xff, 0x00, 0x80}),
+			Headers: map[string][]string{"Content-Type": []string{"application/x-protobuf"}},
+		},
+		Response: models.ResponseDetails{
+			Status: 201,
+			Body:   string([]byte{0x89, 'P', 'N', 'G'}),
+		},
+	}
+
+	message, err := proto.Marshal(NewPairMessage(pair))
+	Expect(err).To(BeNil())
+
+	unmarshalled := &middlewarepb.RequestResponsePair{}
+	Expect(proto.Unmarshal(message, unmarshalled)).To(BeNil())
+
+	result := NewPairFromMessage(models.RequestResponsePair{}, unmarshalled)
+	Expect(result.Request.Body).To(Equal(pair.Request.Body))
+	Expect(result.Request.Headers).To(Equal(pair.Request.Headers))
+	Expect(result.Response.Body).To(Equal(pair.Response.Body))
+	Expect(result.Response.Status).To(Equal(201))
+}
+
+func Test_Middleware_Execute_CallsRemoteMiddlewareOverGrpc(t *testing.T) {
+	RegisterTestingT(t)
+
+	var authorization string
+	server, remoteUrl := newGrpcMiddlewareServer(func(r *http.Request, pair *middlewarepb.RequestResponsePair) error {
+		authorization = r.Header.Get("Authorization")
+		pair.Response.Body = []byte("modified by gRPC middleware")
+		return nil
+	})
+	defer server.Close()
+
+	unit := &Middleware{}
+	Expect(unit.SetRemoteOptions(RemoteOptions{Headers: map[string]string{"Authorization": "Bearer token"}})).To(BeNil())
+	Expect(unit.SetRemote(remoteUrl)).To(BeNil())
+	defer unit.Stop()
+
+	pair, err := unit.Execute(newPairWithRequestBody("body"))
+	Expect(err).To(BeNil())
+
+	Expect(pair.Request.Body).To(Equal("body"))
+	Expect(pair.Response.Body).To(Equal("modified by gRPC middleware"))
+	Expect(authorization).To(Equal("Bearer token"))
+}
+
+func Test_Middleware_Execute_ReturnsTheStatusGrpcMiddlewareFailedWith(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, remoteUrl := newGrpcMiddlewareServer(func(r *http.Request, pair *middlewarepb.RequestResponsePair) error {
+		return io.ErrUnexpectedEOF
+	})
+	defer server.Close()
+
+	unit := &Middleware{}
+	Expect(unit.SetRemote(remoteUrl)).To(BeNil())
+	defer unit.Stop()
+
+	_, err := unit.Execute(newPairWithRequestBody("body"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Remote middleware failed with gRPC status 13: unexpected EOF"))
+	Expect(ErrorKind(err)).To(Equal(FailureError))
+}
+
+func Test_Middleware_Execute_TimesOutGrpcMiddleware(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, remoteUrl := newGrpcMiddlewareServer(func(r *http.Request, pair *middlewarepb.RequestResponsePair) error {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(500 * time.Millisecond):
+		}
+		return nil
+	})
+	defer server.Close()
+
+	unit := &Middleware{Timeout: 100 * time.Millisecond}
+	Expect(unit.SetRemote(remoteUrl)).To(BeNil())
+	defer unit.Stop()
+
+	_, err := unit.Execute(newPairWithRequestBody("body"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Middleware did not respond within 100ms"))
+	Expect(ErrorKind(err)).To(Equal(TimeoutError))
+}
+
+func Test_Middleware_Execute_StreamsPairsToPersistentGrpcMiddleware(t *testing.T) {
+	RegisterTestingT(t)
+
+	var mutex sync.Mutex
+	calls := map[*http.Request]string{}
+	server, remoteUrl := newGrpcMiddlewareServer(func(r *http.Request, pair *middlewarepb.RequestResponsePair) error {
+		mutex.Lock()
+		calls[r] = r.URL.Path
+		mutex.Unlock()
+
+		if string(pair.Request.Body) == "fail" {
+			return io.ErrUnexpectedEOF
+		}
+		pair.Response.Body = append([]byte("streamed "), pair.Request.Body...)
+		return nil
+	})
+	defer server.Close()
+
+	unit := &Middleware{}
+	Expect(unit.SetRemote(remoteUrl)).To(BeNil())
+	unit.SetPersistent(true, time.Second)
+	defer unit.Stop()
+
+	pair, err := unit.Execute(newPairWithRequestBody("one"))
+	Expect(err).To(BeNil())
+	Expect(pair.Response.Body).To(Equal("streamed one"))
+
+	pair, err = unit.Execute(newPairWithRequestBody("two"))
+	Expect(err).To(BeNil())
+	Expect(pair.Response.Body).To(Equal("streamed two"))
+
+	_, err = unit.Execute(newPairWithRequestBody("fail"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("unexpected EOF"))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	Expect(calls).To(HaveLen(1))
+	for _, method := range calls {
+		Expect(method).To(Equal(middlewarepb.ProcessStreamMethod))
+	}
+}
+
+func Test_Middleware_SetRemoteOptions_ErrorsOnInvalidCertificates(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := &Middleware{}
+
+	err := unit.SetRemoteOptions(RemoteOptions{CACert: "not a certificate"})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Remote middleware CA certificate is not a PEM encoded certificate"))
+
+	err = unit.SetRemoteOptions(RemoteOptions{ClientCert: "not a certificate", ClientKey: "not a key"})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(ContainSubstring("Remote middleware client certificate is not valid"))
+}
@@ -0,0 +1,319 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// LuaEngine - runs middleware written in Lua inside Hoverfly, rather than in another process
+const LuaEngine = "lua"
+
+// luaLibraries - the standard libraries scripts are given. Anything which reaches outside of
+// the script, such as io, os and loading other files, is left out
+var luaLibraries = map[string]lua.LGFunction{
+	lua.BaseLibName:   lua.OpenBase,
+	lua.TabLibName:    lua.OpenTable,
+	lua.StringLibName: lua.OpenString,
+	lua.MathLibName:   lua.OpenMath,
+}
+
+// luaUnsafeGlobals - functions of the base library which load code from files or strings, or write to stdout
+var luaUnsafeGlobals = []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "_printregs"}
+
+// luaScript - a Lua script compiled once, so that each pair only has to run it. The pair is
+// given to the script as the global table pair, which the script can either change, or return
+// a new pair in place of
+type luaScript struct {
+	proto *lua.FunctionProto
+}
+
+func newLuaScript(script string) (*luaScript, error) {
+	chunk, err := parse.Parse(strings.NewReader(script), "middleware")
+	if err != nil {
+		return nil, err
+	}
+
+	proto, err := lua.Compile(chunk, "middleware")
+	if err != nil {
+		return nil, err
+	}
+
+	return &luaScript{proto: proto}, nil
+}
+
+// execute - runs the script on the pair, returning what the script printed along with the new pair
+func (this *luaScript) execute(pairView interface{}, timeout time.Duration) (*RequestResponsePairView, string, error) {
+	state := lua.NewState(lua.Options{SkipOpenLibs: true, RegistryMaxSize: 1024 * 1024})
+	defer state.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	state.SetContext(ctx)
+
+	var output bytes.Buffer
+	if err := openLuaLibraries(state, &output); err != nil {
+		return nil, "", err
+	}
+
+	pair, err := toLuaValue(state, pairView)
+	if err != nil {
+		return nil, "", errors.New("Failed to marshal request to JSON")
+	}
+	state.SetGlobal("pair", pair)
+
+	state.Push(state.NewFunctionFromProto(this.proto))
+	if err := state.PCall(0, 1, nil); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, output.String(), newError(TimeoutError, fmt.Errorf("Middleware did not respond within %s", timeout))
+		}
+		return nil, output.String(), err
+	}
+
+	result := state.Get(-1)
+	if result == lua.LNil {
+		result = state.GetGlobal("pair")
+	}
+
+	newPair, err := fromLuaValue(result, 0)
+	if err != nil {
+		return nil, output.String(), newError(InvalidResponseError, err)
+	}
+
+	pairBytes, err := json.Marshal(newPair)
+	if err != nil {
+		return nil, output.String(), newError(InvalidResponseError, err)
+	}
+
+	var newPairView RequestResponsePairView
+	if err := json.Unmarshal(pairBytes, &newPairView); err != nil {
+		return nil, output.String(), newError(InvalidResponseError, errors.New("Failed to unmarshal JSON from middleware"))
+	}
+
+	return &newPairView, output.String(), nil
+}
+
+func openLuaLibraries(state *lua.LState, output *bytes.Buffer) error {
+	for name, open := range luaLibraries {
+		if err := state.CallByParam(lua.P{Fn: state.NewFunction(open), Protect: true}, lua.LString(name)); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range luaUnsafeGlobals {
+		state.SetGlobal(name, lua.LNil)
+	}
+
+	state.SetGlobal("print", state.NewFunction(newLuaPrint(output)))
+	state.SetGlobal("json", state.SetFuncs(state.NewTable(), map[string]lua.LGFunction{
+		"encode": luaJSONEncode,
+		"decode": luaJSONDecode,
+	}))
+	state.SetGlobal("base64", state.SetFuncs(state.NewTable(), map[string]lua.LGFunction{
+		"encode": luaBase64Encode,
+		"decode": luaBase64Decode,
+	}))
+	state.SetGlobal("random", state.SetFuncs(state.NewTable(), map[string]lua.LGFunction{
+		"int":    luaRandomInt,
+		"float":  luaRandomFloat,
+		"string": luaRandomString,
+	}))
+
+	return nil
+}
+
+// newLuaPrint - print, which logs what it is given as well as writing it to output
+func newLuaPrint(output *bytes.Buffer) lua.LGFunction {
+	return func(state *lua.LState) int {
+		values := []string{}
+		for i := 1; i <= state.GetTop(); i++ {
+			values = append(values, state.ToStringMeta(state.Get(i)).String())
+		}
+
+		line := strings.Join(values, "\t")
+		output.WriteString(line + "\n")
+
+		log.WithFields(log.Fields{
+			"output": line,
+		}).Info("Information from middleware")
+
+		return 0
+	}
+}
+
+func luaJSONEncode(state *lua.LState) int {
+	value, err := fromLuaValue(state.CheckAny(1), 0)
+	if err != nil {
+		state.RaiseError("json.encode: %s", err.Error())
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		state.RaiseError("json.encode: %s", err.Error())
+	}
+
+	state.Push(lua.LString(encoded))
+	return 1
+}
+
+func luaJSONDecode(state *lua.LState) int {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(state.CheckString(1)), &decoded); err != nil {
+		state.RaiseError("json.decode: %s", err.Error())
+	}
+
+	state.Push(newLuaValue(state, decoded))
+	return 1
+}
+
+func luaBase64Encode(state *lua.LState) int {
+	state.Push(lua.LString(base64.StdEncoding.EncodeToString([]byte(state.CheckString(1)))))
+	return 1
+}
+
+func luaBase64Decode(state *lua.LState) int {
+	decoded, err := base64.StdEncoding.DecodeString(state.CheckString(1))
+	if err != nil {
+		state.RaiseError("base64.decode: %s", err.Error())
+	}
+
+	state.Push(lua.LString(decoded))
+	return 1
+}
+
+// luaRandomInt - a random integer from min to max, both included
+func luaRandomInt(state *lua.LState) int {
+	min, max := state.CheckInt(1), state.CheckInt(2)
+	if max < min {
+		state.ArgError(2, "max is less than min")
+	}
+
+	state.Push(lua.LNumber(min + rand.Intn(max-min+1)))
+	return 1
+}
+
+func luaRandomFloat(state *lua.LState) int {
+	state.Push(lua.LNumber(rand.Float64()))
+	return 1
+}
+
+// luaRandomString - a random string of letters and digits, of the given length
+func luaRandomString(state *lua.LState) int {
+	const characters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	random := make([]byte, state.CheckInt(1))
+	for i := range random {
+		random[i] = characters[rand.Intn(len(characters))]
+	}
+
+	state.Push(lua.LString(random))
+	return 1
+}
+
+// toLuaValue - converts anything which can be marshalled to JSON into Lua tables and values
+func toLuaValue(state *lua.LState, value interface{}) (lua.LValue, error) {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return lua.LNil, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(valueBytes, &decoded); err != nil {
+		return lua.LNil, err
+	}
+
+	return newLuaValue(state, decoded), nil
+}
+
+func newLuaValue(state *lua.LState, value interface{}) lua.LValue {
+	switch typed := value.(type) {
+	case string:
+		return lua.LString(typed)
+	case float64:
+		return lua.LNumber(typed)
+	case bool:
+		return lua.LBool(typed)
+	case []interface{}:
+		table := state.NewTable()
+		for _, item := range typed {
+			table.Append(newLuaValue(state, item))
+		}
+		return table
+	case map[string]interface{}:
+		table := state.NewTable()
+		for key, item := range typed {
+			table.RawSetString(key, newLuaValue(state, item))
+		}
+		return table
+	}
+
+	return lua.LNil
+}
+
+// luaMaxDepth - how deeply tables can be nested when converting them to JSON, which stops
+// tables which refer to themselves being followed forever
+const luaMaxDepth = 64
+
+// fromLuaValue - converts Lua tables and values into values which can be marshalled to JSON.
+// Tables with only the keys 1 to n become arrays, other tables become objects, and empty
+// tables become null, as they could be either
+func fromLuaValue(value lua.LValue, depth int) (interface{}, error) {
+	switch typed := value.(type) {
+	case lua.LString:
+		return string(typed), nil
+	case lua.LNumber:
+		return float64(typed), nil
+	case lua.LBool:
+		return bool(typed), nil
+	case *lua.LTable:
+		if depth >= luaMaxDepth {
+			return nil, errors.New("Tables are nested too deeply to convert to JSON")
+		}
+
+		length := typed.Len()
+
+		keys := 0
+		typed.ForEach(func(lua.LValue, lua.LValue) {
+			keys++
+		})
+
+		if keys == 0 {
+			return nil, nil
+		}
+
+		if length == keys {
+			array := make([]interface{}, 0, length)
+			for i := 1; i <= length; i++ {
+				item, err := fromLuaValue(typed.RawGetInt(i), depth+1)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, item)
+			}
+			return array, nil
+		}
+
+		var err error
+		object := map[string]interface{}{}
+		typed.ForEach(func(key, value lua.LValue) {
+			item, itemErr := fromLuaValue(value, depth+1)
+			if itemErr != nil {
+				err = itemErr
+			}
+			object[key.String()] = item
+		})
+		return object, err
+	}
+
+	return nil, nil
+}
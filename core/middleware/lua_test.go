@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/onsi/gomega"
+)
+
+func newLuaMiddleware(script string) (*Middleware, error) {
+	unit := &Middleware{}
+
+	if err := unit.SetScript(script); err != nil {
+		return nil, err
+	}
+
+	return unit, unit.SetEngine(LuaEngine)
+}
+
+func Test_Middleware_SetEngine_ReturnsErrorForUnknownEngine(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := &Middleware{}
+
+	err := unit.SetEngine("javascript")
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Unknown middleware engine javascript"))
+
+	Expect(unit.IsSet()).To(BeFalse())
+}
+
+func Test_Middleware_SetEngine_ReturnsErrorWhenScriptDoesNotCompile(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := newLuaMiddleware("pair.response.status = ")
+	Expect(err).ToNot(BeNil())
+}
+
+func Test_Middleware_Execute_Lua_CanChangeThePair(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit, err := newLuaMiddleware(`
+pair.response.status = 201
+pair.response.body = pair.request.method .. " " .. pair.request.path
+pair.response.headers["X-Lua"] = {"true"}
+`)
+	Expect(err).To(BeNil())
+	Expect(unit.IsSet()).To(BeTrue())
+
+	pair, err := unit.Execute(models.RequestResponsePair{
+		Request:  models.RequestDetails{Path: "/lua", Method: "GET", Destination: "hostname-x"},
+		Response: models.ResponseDetails{Status: 200, Body: "original body", Headers: map[string][]string{"Content-Type": {"text/plain"}}},
+	})
+	Expect(err).To(BeNil())
+
+	Expect(pair.Response.Status).To(Equal(201))
+	Expect(pair.Response.Body).To(Equal("GET /lua"))
+	Expect(pair.Response.Headers["X-Lua"]).To(Equal([]string{"true"}))
+	Expect(pair.Response.Headers["Content-Type"]).To(Equal([]string{"text/plain"}))
+	Expect(pair.Request.Destination).To(Equal("hostname-x"))
+}
+
+func Test_Middleware_Execute_Lua_CanReturnANewPair(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit, err := newLuaMiddleware(`
+return {
+	request = pair.request,
+	response = {status = 404, body = "replaced"}
+}
+`)
+	Expect(err).To(BeNil())
+
+	pair, err := unit.Execute(newPairWithRequestBody("body"))
+	Expect(err).To(BeNil())
+
+	Expect(pair.Response.Status).To(Equal(404))
+	Expect(pair.Response.Body).To(Equal("replaced"))
+	Expect(pair.Request.Body).To(Equal("body"))
+}
+
+func Test_Middleware_Execute_Lua_HasJSONBase64AndRandomHelpers(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit, err := newLuaMiddleware(`
+local request = json.decode(pair.request.body)
+local number = random.int(1, 3)
+assert(number >= 1 and number <= 3)
+assert(#random.string(8) == 8)
+assert(random.float() < 1)
+
+pair.response.body = json.encode({name = base64.decode(request.name), encoded = base64.encode("hoverfly")})
+`)
+	Expect(err).To(BeNil())
+
+	pair, err := unit.Execute(newPairWithRequestBody(`{"name": "aG92ZXJmbHk="}`))
+	Expect(err).To(BeNil())
+
+	Expect(pair.Response.Body).To(MatchJSON(`{"name": "hoverfly", "encoded": "aG92ZXJmbHk="}`))
+}
+
+func Test_Middleware_Execute_Lua_CannotReachOutsideOfTheScript(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, script := range []string{
+		`os.exit(1)`,
+		`io.open("/etc/passwd")`,
+		`dofile("/etc/passwd")`,
+		`require("os")`,
+		`loadstring("return 1")()`,
+	} {
+		unit, err := newLuaMiddleware(script)
+		Expect(err).To(BeNil())
+
+		_, err = unit.Execute(newPairWithRequestBody("body"))
+		Expect(err).ToNot(BeNil(), script)
+	}
+}
+
+func Test_Middleware_Execute_Lua_ReturnsErrorRaisedByScript(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit, err := newLuaMiddleware(`error("bad pair")`)
+	Expect(err).To(BeNil())
+
+	pair, err := unit.Execute(newPairWithRequestBody("body"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(ContainSubstring("bad pair"))
+
+	Expect(pair.Response.Body).To(Equal("original body"))
+}
+
+func Test_Middleware_Execute_Lua_ReturnsErrorWhenScriptTimesOut(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit, err := newLuaMiddleware(`while true do end`)
+	Expect(err).To(BeNil())
+	unit.Timeout = 100 * time.Millisecond
+
+	_, err = unit.Execute(newPairWithRequestBody("body"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Middleware did not respond within 100ms"))
+}
+
+func Test_Middleware_Execute_Lua_ReturnsErrorForTablesWhichReferToThemselves(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit, err := newLuaMiddleware(`pair.response.self = pair.response`)
+	Expect(err).To(BeNil())
+
+	_, err = unit.Execute(newPairWithRequestBody("body"))
+	Expect(err).ToNot(BeNil())
+}
+
+func Test_ConvertToNewMiddleware_WillCreateLuaMiddlewareFromALuaScript(t *testing.T) {
+	RegisterTestingT(t)
+
+	script := path.Join(os.TempDir(), "hoverfly_test_middleware.lua")
+	Expect(ioutil.WriteFile(script, []byte(`pair.response.body = "lua"`), 0644)).To(BeNil())
+	defer os.Remove(script)
+
+	unit, err := ConvertToNewMiddleware(script)
+	Expect(err).To(BeNil())
+
+	Expect(unit.Binary).To(Equal(""))
+	Expect(unit.Engine).To(Equal(LuaEngine))
+
+	pair, err := unit.Execute(newPairWithRequestBody("body"))
+	Expect(err).To(BeNil())
+	Expect(pair.Response.Body).To(Equal("lua"))
+}
@@ -2,15 +2,17 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"os"
 	"os/exec"
 	"path"
 	"strings"
+	"time"
 
 	"errors"
+	"io"
 	"io/ioutil"
-	"net/http"
 
 	"fmt"
 
@@ -18,17 +20,54 @@ import (
 	"github.com/SpectoLabs/hoverfly/core/models"
 )
 
+// DefaultTimeout - how long middleware is given to respond to a pair
+const DefaultTimeout = 5 * time.Second
+
 type Middleware struct {
-	Binary string
-	Script *os.File
-	Remote string
+	Binary     string
+	Script     *os.File
+	Remote     string
+	Persistent bool
+	Timeout    time.Duration
+	Engine     string
+
+	ErrorPolicy   ErrorPolicy
+	RemoteOptions RemoteOptions
+
+	process *process
+	lua     *luaScript
+	remote  *remote
 }
 
 func ConvertToNewMiddleware(middleware string) (*Middleware, error) {
+	return ConvertToNewMiddlewareWithRemoteOptions(middleware, RemoteOptions{})
+}
+
+// ConvertToNewMiddlewareWithRemoteOptions - the same as ConvertToNewMiddleware, connecting
+// to remote middleware with the options given
+func ConvertToNewMiddlewareWithRemoteOptions(middleware string, remoteOptions RemoteOptions) (*Middleware, error) {
 	newMiddleware := &Middleware{}
-	if strings.HasPrefix(middleware, "http") {
+	if isRemote(middleware) {
+		err := newMiddleware.SetRemoteOptions(remoteOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		err = newMiddleware.SetRemote(middleware)
+		if err != nil {
+			return nil, err
+		}
+
+		return newMiddleware, nil
+	} else if strings.HasSuffix(middleware, ".lua") {
+		fileContents, err := ioutil.ReadFile(middleware)
+		if err != nil {
+			return nil, err
+		}
 
-		err := newMiddleware.SetRemote(middleware)
+		newMiddleware.SetScript(string(fileContents))
+
+		err = newMiddleware.SetEngine(LuaEngine)
 		if err != nil {
 			return nil, err
 		}
@@ -56,7 +95,12 @@ func ConvertToNewMiddleware(middleware string) (*Middleware, error) {
 
 func (this *Middleware) SetScript(scriptContent string) error {
 	tempDir := path.Join(os.TempDir(), "hoverfly")
-	this.DeleteScripts(tempDir)
+
+	// Only this middleware's previous script is removed, as other middleware in a chain keep theirs in the same directory
+	if this.Script != nil {
+		os.Remove(this.Script.Name())
+		this.Script = nil
+	}
 
 	//We ignore the error it outputs as this directory may already exist
 	os.Mkdir(tempDir, 0777)
@@ -114,34 +158,217 @@ func (this *Middleware) SetBinary(binary string) error {
 	return nil
 }
 
+// SetRemote - sets the URL of remote middleware, which is sent pairs over gRPC when its
+// scheme is grpc or grpcs, and as JSON in an HTTP POST otherwise. Middleware sent pairs over
+// HTTP has to respond to an empty POST with a 200 to be set
 func (this *Middleware) SetRemote(remoteUrl string) error {
+	if this.remote != nil {
+		this.remote.stop()
+		this.remote = nil
+	}
+
 	if remoteUrl == "" {
 		this.Remote = ""
 		return nil
 	}
 
-	response, err := http.Post(remoteUrl, "", nil)
-	if err != nil || response.StatusCode != 200 {
-		return fmt.Errorf("Could not reach remote middleware")
+	remote, err := newRemote(remoteUrl, this.RemoteOptions)
+	if err != nil {
+		return err
 	}
+
+	if err := remote.check(); err != nil {
+		remote.stop()
+		return err
+	}
+
 	this.Remote = remoteUrl
+	this.remote = remote
+	return nil
+}
+
+// SetRemoteOptions - sets how remote middleware is connected to, connecting to it again
+// when it is already set
+func (this *Middleware) SetRemoteOptions(options RemoteOptions) error {
+	if _, err := options.tlsConfig(); err != nil {
+		return err
+	}
+
+	this.RemoteOptions = options
+
+	if this.Remote != "" {
+		return this.SetRemote(this.Remote)
+	}
+
 	return nil
 }
 
+func isRemote(middleware string) bool {
+	return strings.HasPrefix(middleware, "http") || strings.HasPrefix(middleware, GrpcScheme+"://") || strings.HasPrefix(middleware, GrpcTlsScheme+"://")
+}
+
+// SetPersistent - when persistent, the binary is started once and kept running, with pairs
+// streamed to it as newline delimited JSON rather than a process being started for each pair.
+// A timeout of 0 uses DefaultTimeout
+func (this *Middleware) SetPersistent(persistent bool, timeout time.Duration) {
+	this.Stop()
+
+	this.Persistent = persistent
+	this.Timeout = timeout
+
+	if persistent {
+		this.process = newProcess()
+	}
+}
+
+// SetEngine - runs the script inside Hoverfly with the given scripting engine rather than
+// with a binary. LuaEngine is the only engine there is
+func (this *Middleware) SetEngine(engine string) error {
+	if engine == "" {
+		this.Engine = ""
+		this.lua = nil
+		return nil
+	}
+
+	if engine != LuaEngine {
+		return fmt.Errorf("Unknown middleware engine %s", engine)
+	}
+
+	script, err := this.GetScript()
+	if err != nil {
+		return err
+	}
+
+	compiled, err := newLuaScript(script)
+	if err != nil {
+		return err
+	}
+
+	this.Engine = engine
+	this.lua = compiled
+
+	return nil
+}
+
+// Stop - stops the persistent middleware process, if there is one running, and closes the
+// connections to remote middleware
+func (this *Middleware) Stop() {
+	if this.process != nil {
+		this.process.stop()
+	}
+	if this.remote != nil {
+		this.remote.stop()
+	}
+}
+
 func (this *Middleware) Execute(pair models.RequestResponsePair) (models.RequestResponsePair, error) {
+	result, _, err := this.ExecuteWithDiagnostics(pair)
+	return result, err
+}
+
+// ExecuteWithDiagnostics - runs the middleware on the pair, applying the error policy when it
+// fails, and returns what happened along with the pair
+func (this *Middleware) ExecuteWithDiagnostics(pair models.RequestResponsePair) (models.RequestResponsePair, Diagnostics, error) {
+	diagnostics := Diagnostics{}
+
 	if !this.IsSet() {
-		return pair, fmt.Errorf("Cannot execute middleware as middleware has not been correctly set")
+		return pair, diagnostics, fmt.Errorf("Cannot execute middleware as middleware has not been correctly set")
 	}
 
-	if this.Remote == "" {
-		return this.executeMiddlewareLocally(pair)
+	started := time.Now()
+	result, stderr, err := this.execute(pair)
+	diagnostics.Latency = time.Since(started)
+	diagnostics.Stderr = stderr
+
+	if err == nil {
+		return result, diagnostics, nil
+	}
+
+	diagnostics.Error = err.Error()
+	diagnostics.ErrorKind = ErrorKind(err)
+	diagnostics.Policy = this.ErrorPolicy.policyFor(diagnostics.ErrorKind)
+
+	switch diagnostics.Policy {
+	case PassThroughPolicy:
+		log.WithFields(log.Fields{
+			"middleware": this.toString(),
+			"error":      err.Error(),
+		}).Warn("Middleware failed, passing the pair through unmodified")
+		return pair, diagnostics, nil
+	case FallbackPolicy:
+		log.WithFields(log.Fields{
+			"middleware": this.toString(),
+			"error":      err.Error(),
+		}).Warn("Middleware failed, using the fallback response")
+		pair.Response = *this.ErrorPolicy.Fallback
+		return pair, diagnostics, nil
+	}
+
+	return result, diagnostics, err
+}
+
+func (this *Middleware) execute(pair models.RequestResponsePair) (models.RequestResponsePair, string, error) {
+	if this.Remote != "" && this.remote != nil && this.remote.grpc {
+		result, err := this.executeMiddlewareOverGrpc(pair)
+		return result, "", err
+	} else if this.Remote != "" {
+		result, err := this.executeMiddlewareRemotely(pair)
+		return result, "", err
+	} else if this.Engine != "" {
+		return this.executeMiddlewareEmbedded(pair)
+	} else if this.Persistent && this.process != nil {
+		result, err := this.executeMiddlewarePersistently(pair)
+		return result, "", err
 	} else {
-		return this.executeMiddlewareRemotely(pair)
+		return this.executeMiddlewareLocally(pair)
 	}
 }
 
-// ExecuteMiddleware - takes command (middleware string) and payload, which is passed to middleware
-func (this Middleware) executeMiddlewareLocally(pair models.RequestResponsePair) (models.RequestResponsePair, error) {
+// executeMiddlewareEmbedded - runs the script with the scripting engine, within the timeout
+func (this Middleware) executeMiddlewareEmbedded(pair models.RequestResponsePair) (models.RequestResponsePair, string, error) {
+	newPairView, output, err := this.lua.execute(pair.ConvertToRequestResponsePairView(), this.timeout())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"engine": this.Engine,
+			"error":  err.Error(),
+		}).Error("Middleware script failed")
+		return pair, output, err
+	}
+
+	return models.NewRequestResponsePairFromRequestResponsePairView(*newPairView), output, nil
+}
+
+// executeMiddlewarePersistently - sends the pair to the persistent middleware process, starting it if it is not running
+func (this Middleware) executeMiddlewarePersistently(pair models.RequestResponsePair) (models.RequestResponsePair, error) {
+	commandAndArgs := []string{this.Binary}
+
+	// binaries set without a script are still given an empty one, which is left off
+	if this.Script != nil {
+		if info, err := this.Script.Stat(); err == nil && info.Size() > 0 {
+			commandAndArgs = append(commandAndArgs, this.Script.Name())
+		}
+	}
+
+	newPairView, err := this.process.execute(commandAndArgs, pair.ConvertToRequestResponsePairView(), this.timeout())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"middleware": this.toString(),
+			"error":      err.Error(),
+		}).Error("Persistent middleware failed")
+		return pair, err
+	}
+
+	if newPairView == nil {
+		return pair, nil
+	}
+
+	return models.NewRequestResponsePairFromRequestResponsePairView(*newPairView), nil
+}
+
+// ExecuteMiddleware - takes command (middleware string) and payload, which is passed to middleware,
+// returning what the middleware wrote to stderr along with the pair. The middleware is killed
+// when it runs for longer than the timeout
+func (this Middleware) executeMiddlewareLocally(pair models.RequestResponsePair) (models.RequestResponsePair, string, error) {
 	commandAndArgs := []string{this.Binary, this.Script.Name()}
 
 	middlewareCommand := exec.Command(commandAndArgs[0], commandAndArgs[1:]...)
@@ -150,7 +377,7 @@ func (this Middleware) executeMiddlewareLocally(pair models.RequestResponsePair)
 	pairViewBytes, err := json.Marshal(pair.ConvertToRequestResponsePairView())
 
 	if err != nil {
-		return pair, errors.New("Failed to marshal request to JSON")
+		return pair, "", errors.New("Failed to marshal request to JSON")
 	}
 
 	log.WithFields(log.Fields{
@@ -172,16 +399,29 @@ func (this Middleware) executeMiddlewareLocally(pair models.RequestResponsePair)
 			"sdtderr": string(stderr.Bytes()),
 			"error":   err.Error(),
 		}).Error("Middleware failed to start")
-		return pair, err
+		return pair, string(stderr.Bytes()), err
 	}
 
-	if err := middlewareCommand.Wait(); err != nil {
+	timeout := this.timeout()
+	timer := time.AfterFunc(timeout, func() {
+		middlewareCommand.Process.Kill()
+	})
+
+	err = middlewareCommand.Wait()
+	if !timer.Stop() {
+		log.WithFields(log.Fields{
+			"sdtderr": string(stderr.Bytes()),
+		}).Error("Middleware did not respond in time")
+		return pair, string(stderr.Bytes()), newError(TimeoutError, fmt.Errorf("Middleware did not respond within %s", timeout))
+	}
+
+	if err != nil {
 		log.WithFields(log.Fields{
 			"sdtdout": string(stdout.Bytes()),
 			"sdtderr": string(stderr.Bytes()),
 			"error":   err.Error(),
 		}).Error("Middleware failed to stop successfully")
-		return pair, err
+		return pair, string(stderr.Bytes()), err
 	}
 
 	// log stderr, middleware executed successfully
@@ -197,7 +437,7 @@ func (this Middleware) executeMiddlewareLocally(pair models.RequestResponsePair)
 		err = json.Unmarshal(stdout.Bytes(), &newPairView)
 
 		if err != nil {
-			return pair, errors.New("Failed to unmarshal JSON from middleware")
+			return pair, string(stderr.Bytes()), newError(InvalidResponseError, errors.New("Failed to unmarshal JSON from middleware"))
 		} else {
 			if log.GetLevel() == log.DebugLevel {
 				log.WithFields(log.Fields{
@@ -206,7 +446,7 @@ func (this Middleware) executeMiddlewareLocally(pair models.RequestResponsePair)
 				}).Debug("payload after modifications")
 			}
 			// payload unmarshalled into RequestResponsePair struct, returning it
-			return models.NewRequestResponsePairFromRequestResponsePairView(newPairView), nil
+			return models.NewRequestResponsePairFromRequestResponsePairView(newPairView), string(stderr.Bytes()), nil
 		}
 	} else {
 		log.WithFields(log.Fields{
@@ -214,7 +454,7 @@ func (this Middleware) executeMiddlewareLocally(pair models.RequestResponsePair)
 		}).Warn("No response from middleware.")
 	}
 
-	return pair, nil
+	return pair, string(stderr.Bytes()), nil
 
 }
 
@@ -225,26 +465,46 @@ func (this Middleware) executeMiddlewareRemotely(pair models.RequestResponsePair
 		return pair, fmt.Errorf("Error when communicating with remote middleware")
 	}
 
-	req, err := http.NewRequest("POST", this.Remote, bytes.NewBuffer(pairViewBytes))
-	req.Header.Add("Content-Type", "application/json")
+	// middleware which was not set with SetRemote has no client of its own
+	remote := this.remote
+	if remote == nil {
+		if remote, err = newRemote(this.Remote, this.RemoteOptions); err != nil {
+			return pair, err
+		}
+		defer remote.stop()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), this.timeout())
+	defer cancel()
+
+	req, err := remote.newRequest(ctx, this.Remote, bytes.NewBuffer(pairViewBytes))
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error": err.Error(),
 		}).Error("Error when building request to remote middleware")
 		return pair, err
 	}
+	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := remote.client.Do(req)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error": err.Error(),
 		}).Error("Error when communicating with remote middleware")
+		if ctx.Err() == context.DeadlineExceeded {
+			return pair, newError(TimeoutError, fmt.Errorf("Middleware did not respond within %s", this.timeout()))
+		}
 		return pair, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		log.Error("Remote middleware did not process payload")
-		return pair, errors.New("Error when communicating with remote middleware")
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		log.WithFields(log.Fields{
+			"status": resp.StatusCode,
+			"body":   string(body),
+		}).Error("Remote middleware did not process payload")
+		return pair, fmt.Errorf("Remote middleware responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
 
 	returnedPairViewBytes, err := ioutil.ReadAll(resp.Body)
@@ -262,18 +522,28 @@ func (this Middleware) executeMiddlewareRemotely(pair models.RequestResponsePair
 		log.WithFields(log.Fields{
 			"error": err.Error(),
 		}).Error("Error when trying to serialize response from remote middleware")
-		return pair, err
+		return pair, newError(InvalidResponseError, err)
 	}
 	return models.NewRequestResponsePairFromRequestResponsePairView(newPairView), nil
 }
 
 func (this Middleware) IsSet() bool {
-	return this.Binary != "" || this.Remote != ""
+	return this.Binary != "" || this.Remote != "" || this.Engine != ""
+}
+
+func (this Middleware) timeout() time.Duration {
+	if this.Timeout <= 0 {
+		return DefaultTimeout
+	}
+
+	return this.Timeout
 }
 
 func (this Middleware) toString() string {
 	if this.Remote != "" {
 		return this.Remote
+	} else if this.Engine != "" {
+		return this.Engine
 	} else {
 		if this.Script != nil {
 			return this.Binary + " " + this.Script.Name()
@@ -182,7 +182,7 @@ func TestChangeBodyMiddleware(t *testing.T) {
 	err = unit.SetScript(pythonModifyResponse)
 	Expect(err).To(BeNil())
 
-	newPair, err := unit.executeMiddlewareLocally(originalPair)
+	newPair, _, err := unit.executeMiddlewareLocally(originalPair)
 
 	Expect(err).To(BeNil())
 	Expect(newPair.Response.Body).To(Equal("body was replaced by middleware"))
@@ -204,7 +204,7 @@ func TestMalformedRequestResponsePairWithMiddleware(t *testing.T) {
 	err = unit.SetScript(rubyEcho)
 	Expect(err).To(BeNil())
 
-	newPair, err := unit.executeMiddlewareLocally(malformedPair)
+	newPair, _, err := unit.executeMiddlewareLocally(malformedPair)
 
 	Expect(err).To(BeNil())
 	Expect(newPair.Response.Body).To(Equal("original body"))
@@ -225,7 +225,7 @@ func TestReflectBody(t *testing.T) {
 	err = unit.SetScript(pythonReflectBody)
 	Expect(err).To(BeNil())
 
-	newPair, err := unit.executeMiddlewareLocally(originalPair)
+	newPair, _, err := unit.executeMiddlewareLocally(originalPair)
 
 	Expect(err).To(BeNil())
 	Expect(newPair.Response.Body).To(Equal(req.Body))
@@ -233,6 +233,31 @@ func TestReflectBody(t *testing.T) {
 	Expect(newPair.Request.Destination).To(Equal(req.Destination))
 }
 
+func TestBinaryBodiesAreKeptThroughMiddleware(t *testing.T) {
+	RegisterTestingT(t)
+
+	binaryBody := string([]byte{0x0a, 0x03, 'a', 'b', 'c', 0xff, 0x00})
+
+	originalPair := models.RequestResponsePair{
+		Request:  models.RequestDetails{Path: "/", Method: "POST", Body: binaryBody},
+		Response: models.ResponseDetails{Status: 200, Body: binaryBody},
+	}
+
+	unit := &Middleware{}
+
+	err := unit.SetBinary("python")
+	Expect(err).To(BeNil())
+
+	err = unit.SetScript("import sys\nsys.stdout.write(sys.stdin.read())")
+	Expect(err).To(BeNil())
+
+	newPair, _, err := unit.executeMiddlewareLocally(originalPair)
+
+	Expect(err).To(BeNil())
+	Expect(newPair.Request.Body).To(Equal(binaryBody))
+	Expect(newPair.Response.Body).To(Equal(binaryBody))
+}
+
 func TestExecuteMiddlewareRemotely(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -0,0 +1,111 @@
+// Package middlewarepb holds the messages of middleware.proto, the contract for remote
+// middleware served over gRPC.
+//
+// The types are written to match what protoc-gen-go generates from middleware.proto, using
+// struct tags for the vendored protobuf package to marshal them with, and must be kept in
+// step with it.
+package middlewarepb
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// Full names of the service's methods, which are the paths they are called on
+const (
+	ProcessMethod       = "/hoverfly.middleware.v1.Middleware/Process"
+	ProcessStreamMethod = "/hoverfly.middleware.v1.Middleware/ProcessStream"
+)
+
+type RequestResponsePair struct {
+	Request  *Request  `protobuf:"bytes,1,opt,name=request" json:"request,omitempty"`
+	Response *Response `protobuf:"bytes,2,opt,name=response" json:"response,omitempty"`
+}
+
+func (m *RequestResponsePair) Reset()         { *m = RequestResponsePair{} }
+func (m *RequestResponsePair) String() string { return proto.CompactTextString(m) }
+func (*RequestResponsePair) ProtoMessage()    {}
+
+func (m *RequestResponsePair) GetRequest() *Request {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+func (m *RequestResponsePair) GetResponse() *Response {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+type Request struct {
+	Path        string                   `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	Method      string                   `protobuf:"bytes,2,opt,name=method" json:"method,omitempty"`
+	Destination string                   `protobuf:"bytes,3,opt,name=destination" json:"destination,omitempty"`
+	Scheme      string                   `protobuf:"bytes,4,opt,name=scheme" json:"scheme,omitempty"`
+	Query       string                   `protobuf:"bytes,5,opt,name=query" json:"query,omitempty"`
+	Body        []byte                   `protobuf:"bytes,6,opt,name=body,proto3" json:"body,omitempty"`
+	Headers     map[string]*HeaderValues `protobuf:"bytes,7,rep,name=headers" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Protocol    string                   `protobuf:"bytes,8,opt,name=protocol" json:"protocol,omitempty"`
+}
+
+func (m *Request) Reset()         { *m = Request{} }
+func (m *Request) String() string { return proto.CompactTextString(m) }
+func (*Request) ProtoMessage()    {}
+
+func (m *Request) GetHeaders() map[string]*HeaderValues {
+	if m != nil {
+		return m.Headers
+	}
+	return nil
+}
+
+type Response struct {
+	Status  int32                    `protobuf:"varint,1,opt,name=status" json:"status,omitempty"`
+	Body    []byte                   `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
+	Headers map[string]*HeaderValues `protobuf:"bytes,3,rep,name=headers" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *Response) Reset()         { *m = Response{} }
+func (m *Response) String() string { return proto.CompactTextString(m) }
+func (*Response) ProtoMessage()    {}
+
+func (m *Response) GetHeaders() map[string]*HeaderValues {
+	if m != nil {
+		return m.Headers
+	}
+	return nil
+}
+
+type HeaderValues struct {
+	Values []string `protobuf:"bytes,1,rep,name=values" json:"values,omitempty"`
+}
+
+func (m *HeaderValues) Reset()         { *m = HeaderValues{} }
+func (m *HeaderValues) String() string { return proto.CompactTextString(m) }
+func (*HeaderValues) ProtoMessage()    {}
+
+func (m *HeaderValues) GetValues() []string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+type StreamMessage struct {
+	Id    uint64               `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Pair  *RequestResponsePair `protobuf:"bytes,2,opt,name=pair" json:"pair,omitempty"`
+	Error string               `protobuf:"bytes,3,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *StreamMessage) Reset()         { *m = StreamMessage{} }
+func (m *StreamMessage) String() string { return proto.CompactTextString(m) }
+func (*StreamMessage) ProtoMessage()    {}
+
+func (m *StreamMessage) GetPair() *RequestResponsePair {
+	if m != nil {
+		return m.Pair
+	}
+	return nil
+}
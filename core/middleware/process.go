@@ -0,0 +1,270 @@
+package middleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// maxMessageSize - the longest line persistent middleware can write to stdout
+const maxMessageSize = 64 * 1024 * 1024
+
+// maxConsecutiveTimeouts - how many pairs in a row persistent middleware can fail to respond to
+// in time before it is taken to have hung, and is killed so that it is started again
+const maxConsecutiveTimeouts = 3
+
+// ProcessMessage - a line of the persistent middleware protocol. Hoverfly writes the pair
+// along with an ID to stdin, and the middleware writes the same ID back to stdout along
+// with either the modified pair or an error
+type ProcessMessage struct {
+	ID    uint64          `json:"id"`
+	Pair  json.RawMessage `json:"pair,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// process - a middleware process which is started once and kept running, so that pairs
+// can be sent to it as they arrive rather than starting a process for each one. Pairs are
+// told apart by their ID, so the middleware can work on more than one at a time and answer
+// them in any order. When the process exits it is started again for the next pair, unless
+// it has been stopped
+type process struct {
+	mutex   sync.Mutex
+	running *instance
+	nextID  uint64
+	stopped bool
+}
+
+// instance - a run of the process, along with the pairs waiting on it to respond
+type instance struct {
+	command    *exec.Cmd
+	stdin      io.WriteCloser
+	pending    map[uint64]chan ProcessMessage
+	timeouts   int
+	stderrRead chan struct{}
+}
+
+func newProcess() *process {
+	return &process{}
+}
+
+// execute - sends the pair to the process, returning the pair it responds with, or nil when
+// it responds without one to leave the pair as it was
+func (this *process) execute(commandAndArgs []string, pairView interface{}, timeout time.Duration) (*RequestResponsePairView, error) {
+	this.mutex.Lock()
+
+	if this.stopped {
+		this.mutex.Unlock()
+		return nil, errors.New("Middleware has been stopped")
+	}
+
+	if this.running == nil {
+		if err := this.start(commandAndArgs); err != nil {
+			this.mutex.Unlock()
+			return nil, err
+		}
+	}
+	running := this.running
+
+	this.nextID++
+	id := this.nextID
+
+	pairBytes, err := json.Marshal(pairView)
+	if err != nil {
+		this.mutex.Unlock()
+		return nil, errors.New("Failed to marshal request to JSON")
+	}
+
+	line, _ := json.Marshal(ProcessMessage{ID: id, Pair: pairBytes})
+
+	response := make(chan ProcessMessage, 1)
+	running.pending[id] = response
+
+	_, err = running.stdin.Write(append(line, '\n'))
+	this.mutex.Unlock()
+
+	if err != nil {
+		this.forget(running, id)
+		return nil, fmt.Errorf("Failed to write to middleware: %s", err.Error())
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case message := <-response:
+		if message.Error != "" {
+			return nil, errors.New(message.Error)
+		}
+
+		if len(message.Pair) == 0 || string(message.Pair) == "null" {
+			return nil, nil
+		}
+
+		var newPairView RequestResponsePairView
+		if err := json.Unmarshal(message.Pair, &newPairView); err != nil {
+			return nil, newError(InvalidResponseError, errors.New("Failed to unmarshal JSON from middleware"))
+		}
+
+		return &newPairView, nil
+	case <-timer.C:
+		this.timedOut(running, id)
+		return nil, newError(TimeoutError, fmt.Errorf("Middleware did not respond within %s", timeout))
+	}
+}
+
+// timedOut - forgets the pair, killing the process when it has not responded to the last
+// maxConsecutiveTimeouts pairs in time. The pairs still waiting on it are failed once it has exited
+func (this *process) timedOut(running *instance, id uint64) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	delete(running.pending, id)
+
+	running.timeouts++
+	if running.timeouts < maxConsecutiveTimeouts || this.running != running {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"pid":      running.command.Process.Pid,
+		"timeouts": running.timeouts,
+	}).Warn("Persistent middleware has stopped responding, killing it")
+
+	this.running = nil
+	running.command.Process.Kill()
+}
+
+func (this *process) forget(running *instance, id uint64) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	delete(running.pending, id)
+}
+
+// start - starts the process, which the caller has to hold the lock for
+func (this *process) start(commandAndArgs []string) error {
+	command := exec.Command(commandAndArgs[0], commandAndArgs[1:]...)
+
+	stdin, err := command.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	stderr, err := command.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := command.Start(); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Middleware failed to start")
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"pid": command.Process.Pid,
+	}).Info("Persistent middleware started")
+
+	this.running = &instance{
+		command:    command,
+		stdin:      stdin,
+		pending:    map[uint64]chan ProcessMessage{},
+		stderrRead: make(chan struct{}),
+	}
+
+	go this.readStderr(this.running, stderr)
+	go this.readStdout(this.running, stdout)
+
+	return nil
+}
+
+func (this *process) readStdout(running *instance, stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+
+	for scanner.Scan() {
+		var message ProcessMessage
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			log.WithFields(log.Fields{
+				"stdout": scanner.Text(),
+			}).Warn("Failed to unmarshal JSON from middleware")
+			continue
+		}
+
+		this.mutex.Lock()
+		response, ok := running.pending[message.ID]
+		delete(running.pending, message.ID)
+		running.timeouts = 0
+		this.mutex.Unlock()
+
+		if ok {
+			response <- message
+		}
+	}
+
+	// Wait closes the pipes, so stderr has to have been read to the end first
+	<-running.stderrRead
+	err := running.command.Wait()
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.running == running {
+		this.running = nil
+	}
+
+	for id, response := range running.pending {
+		response <- ProcessMessage{ID: id, Error: "Middleware exited before responding"}
+		delete(running.pending, id)
+	}
+
+	fields := log.Fields{}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	log.WithFields(fields).Warn("Persistent middleware exited, it will be started again for the next request")
+}
+
+func (this *process) readStderr(running *instance, stderr io.Reader) {
+	defer close(running.stderrRead)
+
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		log.WithFields(log.Fields{
+			"sdtderr": scanner.Text(),
+		}).Info("Information from middleware")
+	}
+}
+
+// stop - closes stdin so that the middleware can finish, killing it if it has not after a second
+func (this *process) stop() {
+	this.mutex.Lock()
+	running := this.running
+	this.running = nil
+	this.stopped = true
+	this.mutex.Unlock()
+
+	if running == nil {
+		return
+	}
+
+	running.stdin.Close()
+
+	go func() {
+		time.Sleep(time.Second)
+		running.command.Process.Kill()
+	}()
+}
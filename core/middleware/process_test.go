@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/onsi/gomega"
+)
+
+const pythonPersistent = "import json, os, sys, threading, time\n" +
+	"lock = threading.Lock()\n" +
+
+	"def reply(message):\n" +
+	"	with lock:\n" +
+	"		sys.stdout.write(json.dumps(message) + '\\n')\n" +
+	"		sys.stdout.flush()\n" +
+
+	"def handle(message):\n" +
+	"	body = message['pair']['request']['body']\n" +
+	"	if body == 'slow':\n" +
+	"		return\n" +
+	"	if body == 'crash':\n" +
+	"		os._exit(1)\n" +
+	"	if body == 'error':\n" +
+	"		reply({'id': message['id'], 'error': 'middleware error'})\n" +
+	"		return\n" +
+	"	if body == 'unchanged':\n" +
+	"		reply({'id': message['id']})\n" +
+	"		return\n" +
+	"	if body.startswith('sleep '):\n" +
+	"		time.sleep(float(body.split(' ')[1]))\n" +
+	"	message['pair']['response']['body'] = body + ' ' + str(os.getpid())\n" +
+	"	reply(message)\n" +
+
+	"for line in iter(sys.stdin.readline, ''):\n" +
+	"	threading.Thread(target=handle, args=(json.loads(line),)).start()\n"
+
+func newPersistentMiddleware(timeout time.Duration) *Middleware {
+	unit := &Middleware{}
+
+	Expect(unit.SetBinary("python")).To(BeNil())
+	Expect(unit.SetScript(pythonPersistent)).To(BeNil())
+
+	unit.SetPersistent(true, timeout)
+
+	return unit
+}
+
+func newPairWithRequestBody(body string) models.RequestResponsePair {
+	return models.RequestResponsePair{
+		Request:  models.RequestDetails{Path: "/", Method: "POST", Destination: "hostname-x", Body: body},
+		Response: models.ResponseDetails{Status: 200, Body: "original body"},
+	}
+}
+
+func Test_Middleware_Execute_Persistent_KeepsTheSameProcessRunning(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newPersistentMiddleware(5 * time.Second)
+	defer unit.Stop()
+
+	first, err := unit.Execute(newPairWithRequestBody("first"))
+	Expect(err).To(BeNil())
+
+	second, err := unit.Execute(newPairWithRequestBody("second"))
+	Expect(err).To(BeNil())
+
+	firstPid := first.Response.Body[len("first "):]
+	Expect(second.Response.Body).To(Equal("second " + firstPid))
+}
+
+func Test_Middleware_Execute_Persistent_HandlesRequestsConcurrently(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newPersistentMiddleware(5 * time.Second)
+	defer unit.Stop()
+
+	_, err := unit.Execute(newPairWithRequestBody("start"))
+	Expect(err).To(BeNil())
+
+	bodies := make([]string, 10)
+	errs := make([]error, 10)
+
+	started := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// the first requests sleep the longest, so their responses come back out of order
+			pair, err := unit.Execute(newPairWithRequestBody("sleep 0." + strconv.Itoa(9-i)))
+			bodies[i], errs[i] = pair.Response.Body, err
+		}(i)
+	}
+	wg.Wait()
+
+	Expect(time.Since(started)).To(BeNumerically("<", 3*time.Second))
+
+	for i := 0; i < 10; i++ {
+		Expect(errs[i]).To(BeNil())
+		Expect(bodies[i]).To(HavePrefix("sleep 0." + strconv.Itoa(9-i) + " "))
+	}
+}
+
+func Test_Middleware_Execute_Persistent_LeavesPairUnchangedWhenResponseHasNoPair(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newPersistentMiddleware(5 * time.Second)
+	defer unit.Stop()
+
+	pair, err := unit.Execute(newPairWithRequestBody("unchanged"))
+	Expect(err).To(BeNil())
+
+	Expect(pair.Response.Body).To(Equal("original body"))
+}
+
+func Test_Middleware_Execute_Persistent_ReturnsErrorFromMiddleware(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newPersistentMiddleware(5 * time.Second)
+	defer unit.Stop()
+
+	pair, err := unit.Execute(newPairWithRequestBody("error"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("middleware error"))
+
+	Expect(pair.Response.Body).To(Equal("original body"))
+}
+
+func Test_Middleware_Execute_Persistent_ReturnsErrorWhenMiddlewareTimesOut(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newPersistentMiddleware(100 * time.Millisecond)
+	defer unit.Stop()
+
+	_, err := unit.Execute(newPairWithRequestBody("slow"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Middleware did not respond within 100ms"))
+
+	pair, err := unit.Execute(newPairWithRequestBody("after"))
+	Expect(err).To(BeNil())
+	Expect(pair.Response.Body).To(HavePrefix("after "))
+}
+
+func Test_Middleware_Execute_Persistent_RestartsMiddlewareWhenItExits(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newPersistentMiddleware(5 * time.Second)
+	defer unit.Stop()
+
+	before, err := unit.Execute(newPairWithRequestBody("before"))
+	Expect(err).To(BeNil())
+
+	_, err = unit.Execute(newPairWithRequestBody("crash"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Middleware exited before responding"))
+
+	after, err := unit.Execute(newPairWithRequestBody("after"))
+	Expect(err).To(BeNil())
+	Expect(after.Response.Body[len("after "):]).ToNot(Equal(before.Response.Body[len("before "):]))
+}
+
+func Test_Middleware_Execute_Persistent_RestartsMiddlewareWhichKeepsTimingOut(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newPersistentMiddleware(500 * time.Millisecond)
+	defer unit.Stop()
+
+	before, err := unit.Execute(newPairWithRequestBody("before"))
+	Expect(err).To(BeNil())
+
+	for i := 0; i < maxConsecutiveTimeouts; i++ {
+		_, err = unit.Execute(newPairWithRequestBody("slow"))
+		Expect(err).ToNot(BeNil())
+	}
+
+	after, err := unit.Execute(newPairWithRequestBody("after"))
+	Expect(err).To(BeNil())
+	Expect(after.Response.Body[len("after "):]).ToNot(Equal(before.Response.Body[len("before "):]))
+}
+
+func Test_Middleware_Execute_Persistent_DoesNotStartMiddlewareOnceStopped(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newPersistentMiddleware(5 * time.Second)
+
+	_, err := unit.Execute(newPairWithRequestBody("before"))
+	Expect(err).To(BeNil())
+
+	unit.Stop()
+
+	_, err = unit.Execute(newPairWithRequestBody("after"))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Middleware has been stopped"))
+}
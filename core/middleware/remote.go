@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Schemes of remote middleware served over gRPC, in plain text or over TLS. Remote middleware
+// with any other scheme is sent pairs as JSON in an HTTP POST
+const (
+	GrpcScheme    = "grpc"
+	GrpcTlsScheme = "grpcs"
+)
+
+// maxIdleRemoteConnections - how many connections to remote middleware are kept open between requests
+const maxIdleRemoteConnections = 32
+
+// RemoteOptions - how Hoverfly connects to remote middleware. Headers are sent with every
+// request, as HTTP headers or as gRPC metadata, i.e. to authenticate with a bearer token.
+// Certificates and keys are PEM encoded, with the client certificate and key used for mutual TLS
+type RemoteOptions struct {
+	Headers            map[string]string
+	CACert             string
+	ClientCert         string
+	ClientKey          string
+	InsecureSkipVerify bool
+}
+
+// IsSet - whether any of the options is set
+func (this RemoteOptions) IsSet() bool {
+	return len(this.Headers) > 0 || this.CACert != "" || this.ClientCert != "" || this.ClientKey != "" || this.InsecureSkipVerify
+}
+
+func (this RemoteOptions) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: this.InsecureSkipVerify}
+
+	if this.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(this.CACert)) {
+			return nil, fmt.Errorf("Remote middleware CA certificate is not a PEM encoded certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if this.ClientCert != "" || this.ClientKey != "" {
+		certificate, err := tls.X509KeyPair([]byte(this.ClientCert), []byte(this.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("Remote middleware client certificate is not valid: %s", err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// remote - a client for remote middleware, kept for as long as the middleware is set so that
+// connections to it are reused
+type remote struct {
+	url     string
+	grpc    bool
+	client  *http.Client
+	headers map[string]string
+	stream  *grpcStream
+}
+
+func newRemote(remoteUrl string, options RemoteOptions) (*remote, error) {
+	parsed, err := url.Parse(remoteUrl)
+	if err != nil {
+		return nil, fmt.Errorf("Remote middleware is not a valid URL: %s", err.Error())
+	}
+
+	tlsConfig, err := options.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     tlsConfig,
+		MaxIdleConnsPerHost: maxIdleRemoteConnections,
+		IdleConnTimeout:     90 * time.Second,
+		ForceAttemptHTTP2:   true,
+	}
+
+	newRemote := &remote{
+		url:     remoteUrl,
+		client:  &http.Client{Transport: transport},
+		headers: options.Headers,
+	}
+
+	// gRPC is always HTTP/2, which without TLS is started with prior knowledge
+	switch parsed.Scheme {
+	case GrpcScheme:
+		newRemote.grpc = true
+		parsed.Scheme = "http"
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	case GrpcTlsScheme:
+		newRemote.grpc = true
+		parsed.Scheme = "https"
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP2(true)
+	}
+
+	if newRemote.grpc {
+		newRemote.url = parsed.String()
+		newRemote.stream = newGrpcStream(newRemote)
+	}
+
+	return newRemote, nil
+}
+
+// newRequest - a request to the remote middleware with the headers from its options, which
+// is cancelled once the context is
+func (this *remote) newRequest(ctx context.Context, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range this.headers {
+		req.Header.Set(name, value)
+	}
+
+	return req.WithContext(ctx), nil
+}
+
+// check - checks that remote middleware sent pairs over HTTP can be reached
+func (this *remote) check() error {
+	if this.grpc {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	req, err := this.newRequest(ctx, this.url, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := this.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Could not reach remote middleware")
+	}
+	response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return fmt.Errorf("Could not reach remote middleware")
+	}
+
+	return nil
+}
+
+func (this *remote) stop() {
+	if this.stream != nil {
+		this.stream.stop()
+	}
+	if transport, ok := this.client.Transport.(*http.Transport); ok {
+		transport.CloseIdleConnections()
+	}
+}
@@ -26,7 +26,9 @@ type RequestDetailsView struct {
 	Scheme      *string             `json:"scheme"`
 	Query       *string             `json:"query"`
 	Body        *string             `json:"body"`
+	EncodedBody bool                `json:"encodedBody"`
 	Headers     map[string][]string `json:"headers"`
+	Protocol    *string             `json:"protocol,omitempty"`
 }
 
 func (this RequestDetailsView) GetPath() *string { return this.Path }
@@ -47,13 +49,19 @@ func (this RequestDetailsView) GetQuery() *string {
 
 func (this RequestDetailsView) GetBody() *string { return this.Body }
 
+func (this RequestDetailsView) GetEncodedBody() bool { return this.EncodedBody }
+
 func (this RequestDetailsView) GetHeaders() map[string][]string { return this.Headers }
 
+func (this RequestDetailsView) GetProtocol() *string { return this.Protocol }
+
 type ResponseDetailsView struct {
-	Status      int                 `json:"status"`
-	Body        string              `json:"body"`
-	EncodedBody bool                `json:"encodedBody"`
-	Headers     map[string][]string `json:"headers"`
+	Status          int                 `json:"status"`
+	Body            string              `json:"body"`
+	EncodedBody     bool                `json:"encodedBody"`
+	Headers         map[string][]string `json:"headers"`
+	BodyFile        string              `json:"bodyFile,omitempty"`
+	ContentEncoding string              `json:"contentEncoding,omitempty"`
 }
 
 func (this ResponseDetailsView) GetStatus() int { return this.Status }
@@ -63,3 +71,7 @@ func (this ResponseDetailsView) GetBody() string { return this.Body }
 func (this ResponseDetailsView) GetEncodedBody() bool { return this.EncodedBody }
 
 func (this ResponseDetailsView) GetHeaders() map[string][]string { return this.Headers }
+
+func (this ResponseDetailsView) GetBodyFile() string { return this.BodyFile }
+
+func (this ResponseDetailsView) GetContentEncoding() string { return this.ContentEncoding }
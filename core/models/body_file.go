@@ -0,0 +1,199 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BodyFileDirectory - the directory captured bodies are written to, within the simulation directory
+const BodyFileDirectory = "bodies"
+
+// bodyFileExtensions - extensions for common content types, which the system's mime types can
+// list other extensions before
+var bodyFileExtensions = map[string]string{
+	"application/json":       ".json",
+	"application/xml":        ".xml",
+	"application/pdf":        ".pdf",
+	"application/javascript": ".js",
+	"text/plain":             ".txt",
+	"text/html":              ".html",
+	"text/xml":               ".xml",
+	"text/csv":               ".csv",
+	"image/jpeg":             ".jpg",
+}
+
+// BodyFiles - where the files response bodies are kept in are read from. Body files are
+// referenced relative to Directory, which is the working directory when it is not set, and
+// bodies larger than CaptureSize bytes are written to files when capturing, if it is set
+type BodyFiles struct {
+	Directory   string
+	CaptureSize int
+}
+
+// Path - the path of the body file, which has to be within the directory
+func (this BodyFiles) Path(name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("Body file %s must be relative to the simulation directory", name)
+	}
+
+	cleaned := filepath.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("Body file %s is outside of the simulation directory", name)
+	}
+
+	return filepath.Join(this.Directory, cleaned), nil
+}
+
+// Open - opens the body file to be read as it is written to the client
+func (this BodyFiles) Open(name string) (*FileBody, error) {
+	path, err := this.Path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &FileBody{Reader: file, Name: name, Size: info.Size(), Length: info.Size(), file: file}, nil
+}
+
+// ShouldCapture - whether a captured body is large enough to be written to a file
+func (this BodyFiles) ShouldCapture(body string) bool {
+	return this.CaptureSize > 0 && len(body) > this.CaptureSize
+}
+
+// Write - writes the body to a file named after its content, with an extension for its
+// Content-Type, returning the name it is referenced by. Bodies which have been written
+// before are not written again
+func (this BodyFiles) Write(body string, headers map[string][]string) (string, error) {
+	extension := ".bin"
+	if contentType := headers["Content-Type"]; len(contentType) > 0 {
+		mediaType, _, _ := mime.ParseMediaType(contentType[0])
+		if known, ok := bodyFileExtensions[mediaType]; ok {
+			extension = known
+		} else if extensions, _ := mime.ExtensionsByType(mediaType); len(extensions) > 0 {
+			extension = extensions[0]
+		}
+	}
+
+	name := fmt.Sprintf("%s/%x%s", BodyFileDirectory, sha256.Sum256([]byte(body)), extension)
+
+	path, err := this.Path(name)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return name, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	if err := ioutil.WriteFile(path, []byte(body), 0644); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+type bodyFilesKey struct{}
+
+// ContextWithBodyFiles - a context which responses are reconstructed with body files from
+func ContextWithBodyFiles(ctx context.Context, bodyFiles BodyFiles) context.Context {
+	return context.WithValue(ctx, bodyFilesKey{}, bodyFiles)
+}
+
+// BodyFilesFromContext - where body files are read from for the context, which is relative to the
+// working directory when the context was not made with ContextWithBodyFiles
+func BodyFilesFromContext(ctx context.Context) BodyFiles {
+	bodyFiles, _ := ctx.Value(bodyFilesKey{}).(BodyFiles)
+	return bodyFiles
+}
+
+// FileBody - a response body read from a body file as it is written to the client, rather than
+// being held in memory. Name is the body file it is read from, Size the size of the file and
+// Length how much of it is read, which is less than Size when a range of it is asked for
+type FileBody struct {
+	io.Reader
+	Name   string
+	Size   int64
+	Length int64
+	file   *os.File
+}
+
+func (this *FileBody) Close() error {
+	return this.file.Close()
+}
+
+// Range - limits the body to the range of bytes asked for with a Range header, returning the
+// Content-Range of the part which is read. Ranges which are not satisfiable return an error,
+// and only a single range is supported, so a header asking for more returns false
+func (this *FileBody) Range(header string) (string, bool, error) {
+	if !strings.HasPrefix(header, "bytes=") || strings.Contains(header, ",") {
+		return "", false, nil
+	}
+
+	bounds := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(header, "bytes=")), "-", 2)
+	if len(bounds) != 2 {
+		return "", false, nil
+	}
+
+	var start, end int64
+	var err error
+
+	if bounds[0] == "" {
+		// a suffix range asks for the last bytes of the body
+		length, err := strconv.ParseInt(bounds[1], 10, 64)
+		if err != nil || length < 0 {
+			return "", false, nil
+		}
+		if length > this.Size {
+			length = this.Size
+		}
+		start, end = this.Size-length, this.Size-1
+		if length == 0 {
+			return "", false, fmt.Errorf("Range %s is not satisfiable", header)
+		}
+	} else {
+		if start, err = strconv.ParseInt(bounds[0], 10, 64); err != nil {
+			return "", false, nil
+		}
+
+		end = this.Size - 1
+		if bounds[1] != "" {
+			if end, err = strconv.ParseInt(bounds[1], 10, 64); err != nil || end < start {
+				return "", false, nil
+			}
+			if end >= this.Size {
+				end = this.Size - 1
+			}
+		}
+
+		if start >= this.Size {
+			return "", false, fmt.Errorf("Range %s is not satisfiable", header)
+		}
+	}
+
+	this.Length = end - start + 1
+	this.Reader = io.NewSectionReader(this.file, start, this.Length)
+
+	return fmt.Sprintf("bytes %d-%d/%d", start, end, this.Size), true, nil
+}
@@ -0,0 +1,112 @@
+package models_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/onsi/gomega"
+)
+
+func Test_BodyFiles_Path_IsRelativeToTheDirectory(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := models.BodyFiles{Directory: "simulations"}
+
+	path, err := unit.Path("bodies/../report.pdf")
+	Expect(err).To(BeNil())
+	Expect(path).To(Equal(filepath.Join("simulations", "report.pdf")))
+}
+
+func Test_BodyFiles_Path_ErrorsOnFilesOutsideOfTheDirectory(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := models.BodyFiles{Directory: "simulations"}
+
+	_, err := unit.Path("/etc/passwd")
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Body file /etc/passwd must be relative to the simulation directory"))
+
+	_, err = unit.Path("bodies/../../secret")
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Body file bodies/../../secret is outside of the simulation directory"))
+}
+
+func Test_BodyFiles_Write_WritesTheBodyToAFileWhichCanBeOpened(t *testing.T) {
+	RegisterTestingT(t)
+
+	directory, _ := ioutil.TempDir("", "hoverfly-body-files")
+	defer os.RemoveAll(directory)
+
+	unit := models.BodyFiles{Directory: directory}
+
+	name, err := unit.Write(`{"large":"body"}`, map[string][]string{"Content-Type": []string{"application/json; charset=utf-8"}})
+	Expect(err).To(BeNil())
+	Expect(name).To(HavePrefix("bodies/"))
+	Expect(name).To(HaveSuffix(".json"))
+
+	again, err := unit.Write(`{"large":"body"}`, nil)
+	Expect(err).To(BeNil())
+	Expect(again).To(Equal(name[:len(name)-len(".json")] + ".bin"))
+
+	body, err := unit.Open(name)
+	Expect(err).To(BeNil())
+	defer body.Close()
+
+	Expect(body.Name).To(Equal(name))
+	Expect(body.Size).To(Equal(int64(16)))
+
+	content, _ := ioutil.ReadAll(body)
+	Expect(string(content)).To(Equal(`{"large":"body"}`))
+}
+
+func Test_BodyFiles_ShouldCapture(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(models.BodyFiles{}.ShouldCapture("body")).To(BeFalse())
+	Expect(models.BodyFiles{CaptureSize: 4}.ShouldCapture("body")).To(BeFalse())
+	Expect(models.BodyFiles{CaptureSize: 3}.ShouldCapture("body")).To(BeTrue())
+}
+
+func Test_FileBody_Range(t *testing.T) {
+	RegisterTestingT(t)
+
+	directory, _ := ioutil.TempDir("", "hoverfly-body-files")
+	defer os.RemoveAll(directory)
+	ioutil.WriteFile(filepath.Join(directory, "body.txt"), []byte("0123456789"), 0644)
+
+	unit := models.BodyFiles{Directory: directory}
+
+	for header, expected := range map[string][]string{
+		"bytes=2-4":  []string{"bytes 2-4/10", "234"},
+		"bytes=7-":   []string{"bytes 7-9/10", "789"},
+		"bytes=-2":   []string{"bytes 8-9/10", "89"},
+		"bytes=8-20": []string{"bytes 8-9/10", "89"},
+	} {
+		body, err := unit.Open("body.txt")
+		Expect(err).To(BeNil())
+
+		contentRange, ok, err := body.Range(header)
+		Expect(err).To(BeNil())
+		Expect(ok).To(BeTrue())
+		Expect(contentRange).To(Equal(expected[0]))
+
+		content, _ := ioutil.ReadAll(body)
+		Expect(string(content)).To(Equal(expected[1]))
+		Expect(body.Length).To(Equal(int64(len(expected[1]))))
+		body.Close()
+	}
+
+	body, _ := unit.Open("body.txt")
+	defer body.Close()
+
+	_, ok, err := body.Range("bytes=0-1,4-5")
+	Expect(err).To(BeNil())
+	Expect(ok).To(BeFalse())
+
+	_, ok, err = body.Range("bytes=10-")
+	Expect(err).ToNot(BeNil())
+	Expect(ok).To(BeFalse())
+}
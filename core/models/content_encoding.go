@@ -0,0 +1,210 @@
+package models
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Content codings Hoverfly decodes bodies from, so that they can be matched, changed by
+// middleware and stored as they are, and encodes them with again when responding
+const (
+	GzipEncoding    = "gzip"
+	DeflateEncoding = "deflate"
+	BrotliEncoding  = "br"
+)
+
+// MaxDecodedBodySize - the most a body is decoded to, so that a small body which decodes to a
+// very large one cannot use up all of Hoverfly's memory
+var MaxDecodedBodySize int64 = 64 * 1024 * 1024
+
+var errDecodedBodyTooLarge = errors.New("decoded body is too large")
+
+// DecodeBody - the body decoded from the codings in its Content-Encoding header, along with
+// headers which no longer have Content-Encoding, or Content-Length as it would be wrong for the
+// decoded body. When the body cannot be decoded, as a coding is not supported or it is not
+// valid, the body and headers are returned as they are. They are also returned as they are
+// along with an error when the body decodes to more than MaxDecodedBodySize. The headers passed
+// in are not changed
+func DecodeBody(body string, headers map[string][]string) (string, map[string][]string, error) {
+	codings := contentCodings(headers["Content-Encoding"])
+	if len(codings) == 0 || body == "" {
+		return body, headers, nil
+	}
+
+	decoded := []byte(body)
+
+	// codings are listed in the order they were applied, so are removed in reverse
+	for i := len(codings) - 1; i >= 0; i-- {
+		var err error
+		decoded, err = decodeWith(decoded, codings[i])
+		if err == errDecodedBodyTooLarge {
+			return body, headers, fmt.Errorf("Body decodes to more than %d bytes", MaxDecodedBodySize)
+		} else if err != nil {
+			return body, headers, nil
+		}
+	}
+
+	decodedHeaders := map[string][]string{}
+	for name, values := range headers {
+		if name != "Content-Encoding" && name != "Content-Length" {
+			decodedHeaders[name] = values
+		}
+	}
+
+	return string(decoded), decodedHeaders, nil
+}
+
+// DecodedContentEncoding - the Content-Encoding a body was decoded from, given its headers before
+// and after DecodeBody, or "" when it was not decoded
+func DecodedContentEncoding(headers, decodedHeaders map[string][]string) string {
+	if len(decodedHeaders["Content-Encoding"]) > 0 {
+		return ""
+	}
+
+	return strings.Join(headers["Content-Encoding"], ", ")
+}
+
+// EncodeBody - the body encoded with the coding, which is one of gzip, deflate or br
+func EncodeBody(body string, coding string) (string, error) {
+	var buffer bytes.Buffer
+	var writer io.WriteCloser
+
+	switch coding {
+	case GzipEncoding, "x-gzip":
+		writer = gzip.NewWriter(&buffer)
+	case DeflateEncoding:
+		writer = zlib.NewWriter(&buffer)
+	case BrotliEncoding:
+		writer = brotli.NewWriter(&buffer)
+	default:
+		return "", fmt.Errorf("Content encoding %s is not supported", coding)
+	}
+
+	if _, err := writer.Write([]byte(body)); err != nil {
+		return "", err
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// EncodeBodyWith - the body encoded with each of the codings in a Content-Encoding header value,
+// in the order they are listed
+func EncodeBodyWith(body string, contentEncoding string) (string, error) {
+	for _, coding := range contentCodings([]string{contentEncoding}) {
+		var err error
+		body, err = EncodeBody(body, coding)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return body, nil
+}
+
+// AcceptsEncoding - whether a client accepts each of the codings in a Content-Encoding header
+// value, given its Accept-Encoding header. A coding is accepted when it, or *, is listed
+// without a quality value of 0
+func AcceptsEncoding(acceptEncoding []string, contentEncoding string) bool {
+	qualities := map[string]float64{}
+	wildcard := 0.0
+
+	for _, coding := range contentCodings(acceptEncoding) {
+		parts := strings.Split(coding, ";")
+		name := strings.TrimSpace(parts[0])
+
+		quality := 1.0
+		for _, parameter := range parts[1:] {
+			parameter = strings.TrimSpace(parameter)
+			if strings.HasPrefix(parameter, "q=") {
+				if value, err := strconv.ParseFloat(strings.TrimPrefix(parameter, "q="), 64); err == nil {
+					quality = value
+				}
+			}
+		}
+
+		if name == "*" {
+			wildcard = quality
+		} else {
+			qualities[name] = quality
+		}
+	}
+
+	for _, coding := range contentCodings([]string{contentEncoding}) {
+		quality, ok := qualities[coding]
+		if !ok {
+			quality = wildcard
+		}
+
+		if quality <= 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// contentCodings - the codings in Content-Encoding or Accept-Encoding header values, which
+// can each be a comma separated list, ignoring identity
+func contentCodings(values []string) []string {
+	codings := []string{}
+	for _, value := range values {
+		for _, coding := range strings.Split(value, ",") {
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if coding != "" && coding != "identity" {
+				codings = append(codings, coding)
+			}
+		}
+	}
+
+	return codings
+}
+
+func decodeWith(body []byte, coding string) ([]byte, error) {
+	var reader io.Reader
+	var err error
+
+	switch coding {
+	case GzipEncoding, "x-gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(body))
+	case DeflateEncoding:
+		// deflate should be zlib wrapped, though some servers send it raw
+		reader, err = zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			reader, err = flate.NewReader(bytes.NewReader(body)), nil
+		}
+	case BrotliEncoding:
+		reader = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return nil, fmt.Errorf("Content encoding %s is not supported", coding)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// one byte more than the limit is read, to tell a body which is too large from one which fits
+	decoded, err := ioutil.ReadAll(io.LimitReader(reader, MaxDecodedBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(decoded)) > MaxDecodedBodySize {
+		return nil, errDecodedBodyTooLarge
+	}
+
+	return decoded, nil
+}
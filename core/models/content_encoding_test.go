@@ -0,0 +1,145 @@
+package models_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/onsi/gomega"
+)
+
+func Test_DecodeBody_DecodesEachSupportedEncoding(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, coding := range []string{models.GzipEncoding, models.DeflateEncoding, models.BrotliEncoding} {
+		encoded, err := models.EncodeBody(`{"hello":"world"}`, coding)
+		Expect(err).To(BeNil())
+		Expect(encoded).ToNot(Equal(`{"hello":"world"}`))
+
+		body, headers, _ := models.DecodeBody(encoded, map[string][]string{
+			"Content-Encoding": []string{coding},
+			"Content-Length":   []string{"32"},
+			"Content-Type":     []string{"application/json"},
+		})
+
+		Expect(body).To(Equal(`{"hello":"world"}`))
+		Expect(headers).To(Equal(map[string][]string{
+			"Content-Type": []string{"application/json"},
+		}))
+	}
+}
+
+func Test_DecodeBody_RemovesCodingsInTheReverseOrderTheyWereApplied(t *testing.T) {
+	RegisterTestingT(t)
+
+	gzipped, _ := models.EncodeBody("hello world", models.GzipEncoding)
+	encoded, _ := models.EncodeBody(gzipped, models.BrotliEncoding)
+
+	body, headers, _ := models.DecodeBody(encoded, map[string][]string{"Content-Encoding": []string{"gzip, br"}})
+
+	Expect(body).To(Equal("hello world"))
+	Expect(headers).To(BeEmpty())
+}
+
+func Test_DecodeBody_DecodesRawDeflate(t *testing.T) {
+	RegisterTestingT(t)
+
+	var buffer bytes.Buffer
+	writer, _ := flate.NewWriter(&buffer, flate.DefaultCompression)
+	writer.Write([]byte("hello world"))
+	writer.Close()
+
+	body, _, _ := models.DecodeBody(buffer.String(), map[string][]string{"Content-Encoding": []string{"deflate"}})
+
+	Expect(body).To(Equal("hello world"))
+}
+
+func Test_DecodeBody_LeavesBodiesWhichCannotBeDecoded(t *testing.T) {
+	RegisterTestingT(t)
+
+	headers := map[string][]string{"Content-Encoding": []string{"gzip"}}
+	body, decodedHeaders, _ := models.DecodeBody("not gzipped", headers)
+	Expect(body).To(Equal("not gzipped"))
+	Expect(decodedHeaders).To(Equal(headers))
+
+	headers = map[string][]string{"Content-Encoding": []string{"compress"}}
+	body, decodedHeaders, _ = models.DecodeBody("compressed", headers)
+	Expect(body).To(Equal("compressed"))
+	Expect(decodedHeaders).To(Equal(headers))
+}
+
+func Test_DecodeBody_DoesNotChangeTheHeadersPassedIn(t *testing.T) {
+	RegisterTestingT(t)
+
+	encoded, _ := models.EncodeBody("hello world", models.GzipEncoding)
+	headers := map[string][]string{"Content-Encoding": []string{"gzip"}}
+
+	models.DecodeBody(encoded, headers)
+
+	Expect(headers).To(HaveKeyWithValue("Content-Encoding", []string{"gzip"}))
+}
+
+func Test_AcceptsEncoding(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(models.AcceptsEncoding(nil, "gzip")).To(BeFalse())
+	Expect(models.AcceptsEncoding([]string{"identity"}, "gzip")).To(BeFalse())
+	Expect(models.AcceptsEncoding([]string{"gzip, deflate, br"}, "br")).To(BeTrue())
+	Expect(models.AcceptsEncoding([]string{"deflate", "br"}, "gzip")).To(BeFalse())
+	Expect(models.AcceptsEncoding([]string{"gzip;q=0.5, deflate;q=0.8"}, "gzip")).To(BeTrue())
+	Expect(models.AcceptsEncoding([]string{"gzip;q=0, *"}, "gzip")).To(BeFalse())
+	Expect(models.AcceptsEncoding([]string{"gzip;q=0, *"}, "br")).To(BeTrue())
+	Expect(models.AcceptsEncoding([]string{"*;q=0"}, "br")).To(BeFalse())
+	Expect(models.AcceptsEncoding([]string{"gzip, br"}, "gzip, br")).To(BeTrue())
+	Expect(models.AcceptsEncoding([]string{"gzip"}, "gzip, br")).To(BeFalse())
+}
+
+func Test_EncodeBodyWith_EncodesWithEachCodingInOrder(t *testing.T) {
+	RegisterTestingT(t)
+
+	encoded, err := models.EncodeBodyWith("hello world", "gzip, br")
+	Expect(err).To(BeNil())
+
+	body, headers, err := models.DecodeBody(encoded, map[string][]string{"Content-Encoding": []string{"gzip, br"}})
+	Expect(err).To(BeNil())
+	Expect(body).To(Equal("hello world"))
+	Expect(headers).To(BeEmpty())
+}
+
+func Test_DecodedContentEncoding(t *testing.T) {
+	RegisterTestingT(t)
+
+	headers := map[string][]string{"Content-Encoding": []string{"gzip"}}
+
+	Expect(models.DecodedContentEncoding(headers, map[string][]string{})).To(Equal("gzip"))
+	Expect(models.DecodedContentEncoding(headers, headers)).To(Equal(""))
+	Expect(models.DecodedContentEncoding(map[string][]string{}, map[string][]string{})).To(Equal(""))
+}
+
+func Test_DecodeBody_ReturnsAnErrorForBodiesWhichDecodeToMoreThanTheLimit(t *testing.T) {
+	RegisterTestingT(t)
+
+	defer func(limit int64) { models.MaxDecodedBodySize = limit }(models.MaxDecodedBodySize)
+	models.MaxDecodedBodySize = 10
+
+	for _, coding := range []string{models.GzipEncoding, models.DeflateEncoding, models.BrotliEncoding} {
+		headers := map[string][]string{"Content-Encoding": []string{coding}}
+
+		encoded, err := models.EncodeBody("more than ten bytes", coding)
+		Expect(err).To(BeNil())
+
+		body, decodedHeaders, err := models.DecodeBody(encoded, headers)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("Body decodes to more than 10 bytes"))
+		Expect(body).To(Equal(encoded))
+		Expect(decodedHeaders).To(Equal(headers))
+
+		encoded, err = models.EncodeBody("ten bytes!", coding)
+		Expect(err).To(BeNil())
+
+		body, _, err = models.DecodeBody(encoded, headers)
+		Expect(err).To(BeNil())
+		Expect(body).To(Equal("ten bytes!"))
+	}
+}
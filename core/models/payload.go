@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"unicode/utf8"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
@@ -47,6 +48,7 @@ type RequestDetails struct {
 	Query       string
 	Body        string
 	Headers     map[string][]string
+	Protocol    string `json:",omitempty"`
 }
 
 func NewRequestDetailsFromHttpRequest(req *http.Request) (RequestDetails, error) {
@@ -64,39 +66,70 @@ func NewRequestDetailsFromHttpRequest(req *http.Request) (RequestDetails, error)
 		return RequestDetails{}, err
 	}
 
+	// the body is matched and stored decoded, so is sent on without its Content-Encoding
+	body, headers, err := DecodeBody(reqBody, req.Header)
+	if err != nil {
+		log.WithField("error", err.Error()).Warn("Failed to decode request body, keeping it as it is")
+	}
+
 	requestDetails := RequestDetails{
 		Path:        req.URL.Path,
 		Method:      req.Method,
 		Destination: strings.ToLower(req.Host),
 		Scheme:      req.URL.Scheme,
 		Query:       util.SortQueryString(req.URL.RawQuery),
-		Body:        string(reqBody),
-		Headers:     req.Header,
+		Body:        body,
+		Headers:     headers,
+		Protocol:    req.Proto,
 	}
 	return requestDetails, nil
 }
 
 func NewRequestDetailsFromRequest(data interfaces.Request) RequestDetails {
+	body := util.PointerToString(data.GetBody())
+
+	if data.GetEncodedBody() == true {
+		decoded, _ := base64.StdEncoding.DecodeString(body)
+		body = string(decoded)
+	}
+
 	return RequestDetails{
 		Path:        util.PointerToString(data.GetPath()),
 		Method:      util.PointerToString(data.GetMethod()),
 		Destination: util.PointerToString(data.GetDestination()),
 		Scheme:      util.PointerToString(data.GetScheme()),
 		Query:       util.PointerToString(data.GetQuery()),
-		Body:        util.PointerToString(data.GetBody()),
+		Body:        body,
 		Headers:     data.GetHeaders(),
+		Protocol:    util.PointerToString(data.GetProtocol()),
 	}
 }
 
+// ConvertToRequestDetailsView - the request as it is sent to middleware and shown in the journal,
+// with its body base64 encoded in the same way as a response body
 func (this *RequestDetails) ConvertToRequestDetailsView() v2.RequestDetailsViewV1 {
+	var protocol *string
+	if this.Protocol != "" {
+		protocol = &this.Protocol
+	}
+
+	needsEncoding := BodyNeedsEncoding(this.Body, this.Headers)
+
+	body := this.Body
+	if needsEncoding {
+		body = base64.StdEncoding.EncodeToString([]byte(this.Body))
+	}
+
 	return v2.RequestDetailsViewV1{
 		Path:        &this.Path,
 		Method:      &this.Method,
 		Destination: &this.Destination,
 		Scheme:      &this.Scheme,
 		Query:       &this.Query,
-		Body:        &this.Body,
+		Body:        &body,
+		EncodedBody: needsEncoding,
 		Headers:     this.Headers,
+		Protocol:    protocol,
 	}
 }
 
@@ -129,13 +162,19 @@ func (r *RequestDetails) HashWithoutHost() string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
-// ResponseDetails structure hold response body from external service, body is not decoded and is supposed
-// to be bytes, however headers should provide all required information for later decoding
-// by the client.
+// ResponseDetails structure hold response body from external service. Bodies with a supported
+// Content-Encoding are decoded, keeping the coding in ContentEncoding to encode them with again
+// when the response is replayed, otherwise the body is kept as bytes and the headers tell the
+// client how to decode it.
 type ResponseDetails struct {
-	Status  int
-	Body    string
-	Headers map[string][]string
+	Status          int
+	Body            string
+	Headers         map[string][]string
+	Chunks          []ResponseChunk
+	WebSocket       *WebSocket
+	Middleware      string
+	BodyFile        string
+	ContentEncoding string
 }
 
 func NewResponseDetailsFromResponse(data interfaces.Response) ResponseDetails {
@@ -146,35 +185,75 @@ func NewResponseDetailsFromResponse(data interfaces.Response) ResponseDetails {
 		body = string(decoded)
 	}
 
-	return ResponseDetails{Status: data.GetStatus(), Body: body, Headers: data.GetHeaders()}
+	decodedBody, headers, err := DecodeBody(body, data.GetHeaders())
+	if err != nil {
+		log.WithField("error", err.Error()).Warn("Failed to decode response body, keeping it as it is")
+	}
+
+	// the body of a simulation which has been exported is already decoded
+	contentEncoding := data.GetContentEncoding()
+	if contentEncoding == "" {
+		contentEncoding = DecodedContentEncoding(data.GetHeaders(), headers)
+	}
+
+	return ResponseDetails{
+		Status:          data.GetStatus(),
+		Body:            decodedBody,
+		Headers:         headers,
+		BodyFile:        data.GetBodyFile(),
+		ContentEncoding: contentEncoding,
+	}
 }
 
-// This function will create a JSON appriopriate version of ResponseDetails for the v2 API
-// If the response headers indicate that the content is encoded, or it has a non-matching
-// supported mimetype, we base64 encode it.
-func (r *ResponseDetails) ConvertToResponseDetailsView() v2.ResponseDetailsView {
-	needsEncoding := false
+// BodyNeedsEncoding - whether a request or response body has to be base64 encoded to be kept as
+// it is in JSON, which is when the headers say it is encoded, it does not have a supported
+// mimetype, or it is not valid UTF-8. Empty bodies are never encoded
+func BodyNeedsEncoding(body string, headers map[string][]string) bool {
+	if body == "" {
+		return false
+	}
 
 	// Check headers for gzip
-	contentEncodingValues := r.Headers["Content-Encoding"]
+	contentEncodingValues := headers["Content-Encoding"]
 	if len(contentEncodingValues) > 0 {
-		needsEncoding = true
-	} else {
-		mimeType := http.DetectContentType([]byte(r.Body))
-		needsEncoding = true
-		for _, v := range supportedMimeTypes {
-			if strings.Contains(mimeType, v) {
-				needsEncoding = false
-				break
-			}
+		return true
+	}
+
+	if !utf8.ValidString(body) {
+		return true
+	}
+
+	mimeType := http.DetectContentType([]byte(body))
+	for _, v := range supportedMimeTypes {
+		if strings.Contains(mimeType, v) {
+			return false
 		}
 	}
 
+	return true
+}
+
+// This function will create a JSON appriopriate version of ResponseDetails for the v2 API
+// If the response headers indicate that the content is encoded, or it has a non-matching
+// supported mimetype, we base64 encode it.
+func (r *ResponseDetails) ConvertToResponseDetailsView() v2.ResponseDetailsView {
+	needsEncoding := BodyNeedsEncoding(r.Body+JoinResponseChunks(r.Chunks), r.Headers)
+
 	// If contains gzip, base64 encode
 	body := r.Body
 	if needsEncoding {
 		body = base64.StdEncoding.EncodeToString([]byte(r.Body))
 	}
 
-	return v2.ResponseDetailsView{Status: r.Status, Body: body, Headers: r.Headers, EncodedBody: needsEncoding}
+	return v2.ResponseDetailsView{
+		Status:          r.Status,
+		Body:            body,
+		Headers:         r.Headers,
+		EncodedBody:     needsEncoding,
+		Chunks:          buildResponseChunkViews(r.Chunks, needsEncoding),
+		WebSocket:       r.WebSocket.BuildView(),
+		Middleware:      r.Middleware,
+		BodyFile:        r.BodyFile,
+		ContentEncoding: r.ContentEncoding,
+	}
 }
@@ -3,6 +3,7 @@ package models_test
 import (
 	"bytes"
 	"compress/gzip"
+	"encoding/base64"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -205,6 +206,52 @@ func TestRequestDetails_ConvertToRequestDetailsView(t *testing.T) {
 	Expect(requestDetailsView.Headers).To(Equal(requestDetails.Headers))
 }
 
+func TestRequestDetails_ConvertToRequestDetailsView_EncodesBinaryBodies(t *testing.T) {
+	RegisterTestingT(t)
+
+	requestDetails := models.RequestDetails{
+		Method:  "POST",
+		Body:    string([]byte{0x0a, 0x03, 'a', 'b', 'c', 0xff}),
+		Headers: map[string][]string{"Content-Type": []string{"application/x-protobuf"}},
+	}
+
+	requestDetailsView := requestDetails.ConvertToRequestDetailsView()
+
+	Expect(requestDetailsView.EncodedBody).To(BeTrue())
+	Expect(*requestDetailsView.Body).To(Equal("CgNhYmP/"))
+
+	Expect(models.NewRequestDetailsFromRequest(requestDetailsView).Body).To(Equal(requestDetails.Body))
+}
+
+func TestRequestDetails_ConvertToRequestDetailsView_DoesNotEncodeTextBodies(t *testing.T) {
+	RegisterTestingT(t)
+
+	requestDetails := models.RequestDetails{
+		Method: "POST",
+		Body:   `{"name": "zoë"}`,
+	}
+
+	requestDetailsView := requestDetails.ConvertToRequestDetailsView()
+
+	Expect(requestDetailsView.EncodedBody).To(BeFalse())
+	Expect(*requestDetailsView.Body).To(Equal(`{"name": "zoë"}`))
+}
+
+func TestResponseDetails_ConvertToResponseDetailsView_EncodesTextWhichIsNotUTF8(t *testing.T) {
+	RegisterTestingT(t)
+
+	responseDetails := models.ResponseDetails{
+		Status:  200,
+		Body:    "caf\xe9",
+		Headers: map[string][]string{"Content-Type": []string{"text/plain; charset=iso-8859-1"}},
+	}
+
+	responseDetailsView := responseDetails.ConvertToResponseDetailsView()
+
+	Expect(responseDetailsView.EncodedBody).To(BeTrue())
+	Expect(models.NewResponseDetailsFromResponse(responseDetailsView).Body).To(Equal("caf\xe9"))
+}
+
 // Helper function for gzipping strings
 func GzipString(s string) string {
 	var b bytes.Buffer
@@ -232,6 +279,61 @@ func Test_NewRequestDetailsFromHttpRequest_LowerCaseDestination(t *testing.T) {
 	Expect(requestDetails.Destination).To(Equal("test.org"))
 }
 
+func Test_NewRequestDetailsFromHttpRequest_RecordsProtocol(t *testing.T) {
+	RegisterTestingT(t)
+
+	request, _ := http.NewRequest("GET", "https://test.org/", nil)
+	request.Proto = "HTTP/2.0"
+	request.ProtoMajor = 2
+	request.ProtoMinor = 0
+
+	requestDetails, err := models.NewRequestDetailsFromHttpRequest(request)
+	Expect(err).To(BeNil())
+
+	Expect(requestDetails.Protocol).To(Equal("HTTP/2.0"))
+	Expect(*requestDetails.ConvertToRequestDetailsView().Protocol).To(Equal("HTTP/2.0"))
+}
+
+func Test_NewRequestDetailsFromHttpRequest_DecodesTheBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	body, _ := models.EncodeBody(`{"hello":"world"}`, models.GzipEncoding)
+
+	request, _ := http.NewRequest("POST", "http://test.org/", bytes.NewBufferString(body))
+	request.Header.Set("Content-Encoding", "gzip")
+	request.Header.Set("Content-Type", "application/json")
+
+	requestDetails, err := models.NewRequestDetailsFromHttpRequest(request)
+	Expect(err).To(BeNil())
+
+	Expect(requestDetails.Body).To(Equal(`{"hello":"world"}`))
+	Expect(requestDetails.Headers).ToNot(HaveKey("Content-Encoding"))
+	Expect(requestDetails.Headers).To(HaveKeyWithValue("Content-Type", []string{"application/json"}))
+	Expect(request.Header.Get("Content-Encoding")).To(Equal("gzip"))
+}
+
+func TestResponseDetailsView_ConvertToResponseDetails_DecodesTheBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	body, _ := models.EncodeBody("hello world", models.BrotliEncoding)
+
+	response := models.NewResponseDetailsFromResponse(v2.ResponseDetailsView{
+		Status:      200,
+		Body:        base64.StdEncoding.EncodeToString([]byte(body)),
+		EncodedBody: true,
+		Headers:     map[string][]string{"Content-Encoding": []string{"br"}},
+	})
+
+	Expect(response.Body).To(Equal("hello world"))
+	Expect(response.Headers).To(BeEmpty())
+	Expect(response.ContentEncoding).To(Equal("br"))
+
+	view := response.ConvertToResponseDetailsView()
+	Expect(view.EncodedBody).To(BeFalse())
+	Expect(view.ContentEncoding).To(Equal("br"))
+	Expect(models.NewResponseDetailsFromResponse(view).ContentEncoding).To(Equal("br"))
+}
+
 func TestRequestResponsePairView_ConvertToRequestResponsePairWithoutEncoding(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/base64"
+
 	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
 	"github.com/SpectoLabs/hoverfly/core/util"
 )
@@ -49,28 +51,20 @@ type RequestMatcherResponsePair struct {
 }
 
 func NewRequestMatcherResponsePairFromView(view *v2.RequestMatcherResponsePairViewV2) *RequestMatcherResponsePair {
-	if view.RequestMatcher.Query != nil && view.RequestMatcher.Query.ExactMatch != nil {
-		sortedQuery := util.SortQueryString(*view.RequestMatcher.Query.ExactMatch)
-		view.RequestMatcher.Query.ExactMatch = &sortedQuery
-	}
+	response := NewResponseDetailsFromResponse(view.Response)
+	response.Chunks = NewResponseChunksFromView(view.Response.Chunks, view.Response.EncodedBody)
+	response.WebSocket = NewWebSocketFromView(view.Response.WebSocket)
+	response.Middleware = view.Response.Middleware
 
 	return &RequestMatcherResponsePair{
-		RequestMatcher: RequestMatcher{
-			Path:        NewRequestFieldMatchersFromView(view.RequestMatcher.Path),
-			Method:      NewRequestFieldMatchersFromView(view.RequestMatcher.Method),
-			Destination: NewRequestFieldMatchersFromView(view.RequestMatcher.Destination),
-			Scheme:      NewRequestFieldMatchersFromView(view.RequestMatcher.Scheme),
-			Query:       NewRequestFieldMatchersFromView(view.RequestMatcher.Query),
-			Body:        NewRequestFieldMatchersFromView(view.RequestMatcher.Body),
-			Headers:     view.RequestMatcher.Headers,
-		},
-		Response: NewResponseDetailsFromResponse(view.Response),
+		RequestMatcher: NewRequestMatcherFromView(view.RequestMatcher),
+		Response:       response,
 	}
 }
 
 func (this *RequestMatcherResponsePair) BuildView() v2.RequestMatcherResponsePairViewV2 {
 
-	var path, method, destination, scheme, query, body *v2.RequestFieldMatchersView
+	var path, method, destination, scheme, query, body, protocol *v2.RequestFieldMatchersView
 
 	if this.RequestMatcher.Path != nil {
 		path = this.RequestMatcher.Path.BuildView()
@@ -92,8 +86,18 @@ func (this *RequestMatcherResponsePair) BuildView() v2.RequestMatcherResponsePai
 		query = this.RequestMatcher.Query.BuildView()
 	}
 
+	// binary bodies are matched exactly, and base64 encoded in the same way as response bodies
+	encodedBody := false
 	if this.RequestMatcher.Body != nil {
 		body = this.RequestMatcher.Body.BuildView()
+		if body.ExactMatch != nil && BodyNeedsEncoding(*body.ExactMatch, this.RequestMatcher.Headers) {
+			body.ExactMatch = util.StringToPointer(base64.StdEncoding.EncodeToString([]byte(*body.ExactMatch)))
+			encodedBody = true
+		}
+	}
+
+	if this.RequestMatcher.Protocol != nil {
+		protocol = this.RequestMatcher.Protocol.BuildView()
 	}
 
 	return v2.RequestMatcherResponsePairViewV2{
@@ -105,6 +109,8 @@ func (this *RequestMatcherResponsePair) BuildView() v2.RequestMatcherResponsePai
 			Query:       query,
 			Body:        body,
 			Headers:     this.RequestMatcher.Headers,
+			Protocol:    protocol,
+			EncodedBody: encodedBody,
 		},
 		Response: this.Response.ConvertToResponseDetailsView(),
 	}
@@ -118,12 +124,42 @@ type RequestMatcher struct {
 	Query       *RequestFieldMatchers
 	Body        *RequestFieldMatchers
 	Headers     map[string][]string
+	Protocol    *RequestFieldMatchers
+}
+
+func NewRequestMatcherFromView(view v2.RequestMatcherViewV2) RequestMatcher {
+	body := NewRequestFieldMatchersFromView(view.Body)
+	if view.EncodedBody && body != nil && body.ExactMatch != nil {
+		decoded, _ := base64.StdEncoding.DecodeString(*body.ExactMatch)
+		body.ExactMatch = util.StringToPointer(string(decoded))
+	}
+
+	// requests have their query sorted, so an exact match on the query is sorted the same way
+	query := NewRequestFieldMatchersFromView(view.Query)
+	if query != nil && query.ExactMatch != nil {
+		query.ExactMatch = util.StringToPointer(util.SortQueryString(*query.ExactMatch))
+	}
+
+	return RequestMatcher{
+		Path:        NewRequestFieldMatchersFromView(view.Path),
+		Method:      NewRequestFieldMatchersFromView(view.Method),
+		Destination: NewRequestFieldMatchersFromView(view.Destination),
+		Scheme:      NewRequestFieldMatchersFromView(view.Scheme),
+		Query:       query,
+		Body:        body,
+		Headers:     view.Headers,
+		Protocol:    NewRequestFieldMatchersFromView(view.Protocol),
+	}
 }
 
 func (this RequestMatcher) IncludesHeaderMatching() bool {
 	return this.Headers != nil && len(this.Headers) > 0
 }
 
+func (this RequestMatcher) IncludesProtocolMatching() bool {
+	return this.Protocol != nil
+}
+
 func (this RequestMatcher) BuildRequestDetailsFromExactMatches() *RequestDetails {
 	if this.Body == nil || this.Body.ExactMatch == nil ||
 		this.Destination == nil || this.Destination.ExactMatch == nil ||
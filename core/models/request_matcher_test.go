@@ -59,6 +59,57 @@ func Test_NewRequestMatcherResponsePairFromView_BuildsPair(t *testing.T) {
 	Expect(unit.Response.Body).To(Equal("body"))
 }
 
+func Test_RequestMatcherResponsePair_BuildView_EncodesBinaryBodyExactMatches(t *testing.T) {
+	RegisterTestingT(t)
+
+	binaryBody := string([]byte{0x89, 'P', 'N', 'G', 0x00, 0xff})
+
+	unit := models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Body: &models.RequestFieldMatchers{
+				ExactMatch: util.StringToPointer(binaryBody),
+			},
+		},
+	}
+
+	view := unit.BuildView()
+	Expect(view.RequestMatcher.EncodedBody).To(BeTrue())
+	Expect(*view.RequestMatcher.Body.ExactMatch).To(Equal("iVBORwD/"))
+
+	pair := models.NewRequestMatcherResponsePairFromView(&view)
+	Expect(*pair.RequestMatcher.Body.ExactMatch).To(Equal(binaryBody))
+}
+
+func Test_RequestMatcherResponsePair_BuildView_DoesNotEncodeTextBodyExactMatches(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Body: &models.RequestFieldMatchers{
+				ExactMatch: util.StringToPointer(`{"id": 1}`),
+			},
+		},
+	}
+
+	view := unit.BuildView()
+	Expect(view.RequestMatcher.EncodedBody).To(BeFalse())
+	Expect(*view.RequestMatcher.Body.ExactMatch).To(Equal(`{"id": 1}`))
+}
+
+func Test_NewRequestMatcherResponsePairFromView_KeepsTheMiddlewareOfTheResponse(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := models.NewRequestMatcherResponsePairFromView(&v2.RequestMatcherResponsePairViewV2{
+		Response: v2.ResponseDetailsView{
+			Body:       "body",
+			Middleware: "lua",
+		},
+	})
+
+	Expect(unit.Response.Middleware).To(Equal("lua"))
+	Expect(unit.BuildView().Response.Middleware).To(Equal("lua"))
+}
+
 func Test_NewRequestMatcherResponsePairFromView_SortsQuery(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -0,0 +1,310 @@
+package models
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+)
+
+// ResponseChunk - part of a streamed response body, written and flushed
+// to the client Delay milliseconds after the previous chunk
+type ResponseChunk struct {
+	Data  string
+	Delay int
+}
+
+func NewResponseChunksFromView(views []v2.ResponseChunkView, encoded bool) []ResponseChunk {
+	var chunks []ResponseChunk
+	for _, view := range views {
+		data := view.Data
+		if encoded {
+			decoded, _ := base64.StdEncoding.DecodeString(data)
+			data = string(decoded)
+		}
+
+		chunks = append(chunks, ResponseChunk{Data: data, Delay: view.Delay})
+	}
+
+	return chunks
+}
+
+func buildResponseChunkViews(chunks []ResponseChunk, encoded bool) []v2.ResponseChunkView {
+	var views []v2.ResponseChunkView
+	for _, chunk := range chunks {
+		data := chunk.Data
+		if encoded {
+			data = base64.StdEncoding.EncodeToString([]byte(data))
+		}
+
+		views = append(views, v2.ResponseChunkView{Data: data, Delay: chunk.Delay})
+	}
+
+	return views
+}
+
+// JoinResponseChunks - returns the whole body the chunks make up
+func JoinResponseChunks(chunks []ResponseChunk) string {
+	data := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		data[i] = chunk.Data
+	}
+
+	return strings.Join(data, "")
+}
+
+// IsStreamedResponse - whether a response body is delivered as a stream of
+// events or chunks which should be passed on to the client as they arrive
+func IsStreamedResponse(response *http.Response) bool {
+	if IsEventStream(response) {
+		return true
+	}
+
+	for _, encoding := range response.TransferEncoding {
+		if encoding == "chunked" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsEventStream - whether a response body is a stream of Server-Sent Events
+func IsEventStream(response *http.Response) bool {
+	return strings.HasPrefix(response.Header.Get("Content-Type"), "text/event-stream")
+}
+
+// StreamedBody - response body which is written to the client chunk by chunk, flushing
+// after each chunk. It either replays chunks with their delays, or reads them from a
+// source body recording when each one arrived. Chunks which have been read are handed
+// to the completion callbacks once the stream ends or is closed
+type StreamedBody struct {
+	source     io.ReadCloser
+	events     bool
+	buffered   string
+	pending    []ResponseChunk
+	chunks     []ResponseChunk
+	remaining  string
+	last       time.Time
+	onComplete []func([]ResponseChunk)
+	completed  bool
+	mutex      sync.Mutex
+}
+
+// NewStreamedBody - body which replays chunks, waiting for the delay of each chunk
+func NewStreamedBody(chunks []ResponseChunk) *StreamedBody {
+	return &StreamedBody{
+		pending: chunks,
+		last:    time.Now(),
+	}
+}
+
+// NewRecordingStreamedBody - body which passes on chunks read from source,
+// recording the time between each one
+func NewRecordingStreamedBody(source io.ReadCloser) *StreamedBody {
+	return &StreamedBody{
+		source: source,
+		last:   time.Now(),
+	}
+}
+
+// NewRecordingEventStreamBody - body which passes on the Server-Sent Events read from source a
+// whole event at a time, recording the time between each one. How the events happen to be split
+// up as they are read does not change the chunks which are recorded
+func NewRecordingEventStreamBody(source io.ReadCloser) *StreamedBody {
+	return &StreamedBody{
+		source: source,
+		events: true,
+		last:   time.Now(),
+	}
+}
+
+// OnComplete - registers a function called with the chunks read once the stream has ended
+func (this *StreamedBody) OnComplete(callback func([]ResponseChunk)) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.onComplete = append(this.onComplete, callback)
+}
+
+func (this *StreamedBody) Read(p []byte) (int, error) {
+	if this.remaining == "" {
+		chunk, err := this.next()
+		if err != nil {
+			return 0, err
+		}
+
+		this.remaining = chunk.Data
+	}
+
+	n := copy(p, this.remaining)
+	this.remaining = this.remaining[n:]
+
+	return n, nil
+}
+
+// WriteTo - writes each chunk as it becomes available, flushing
+// the writer after each one so that it reaches the client
+func (this *StreamedBody) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+
+	flusher, _ := w.(http.Flusher)
+
+	for {
+		data := this.remaining
+		this.remaining = ""
+
+		if data == "" {
+			chunk, err := this.next()
+			if err == io.EOF {
+				return written, nil
+			} else if err != nil {
+				return written, err
+			}
+
+			data = chunk.Data
+		}
+
+		n, err := io.WriteString(w, data)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+func (this *StreamedBody) Close() error {
+	var err error
+	if this.source != nil {
+		err = this.source.Close()
+	}
+
+	this.complete()
+
+	return err
+}
+
+func (this *StreamedBody) next() (ResponseChunk, error) {
+	if this.source == nil {
+		if len(this.pending) == 0 {
+			this.complete()
+			return ResponseChunk{}, io.EOF
+		}
+
+		chunk := this.pending[0]
+		this.pending = this.pending[1:]
+
+		time.Sleep(time.Duration(chunk.Delay) * time.Millisecond)
+		this.record(chunk)
+
+		return chunk, nil
+	}
+
+	if this.events {
+		return this.nextEvent()
+	}
+
+	buffer := make([]byte, 32*1024)
+	for {
+		n, err := this.source.Read(buffer)
+		if n > 0 {
+			return this.recordRead(string(buffer[:n])), nil
+		}
+
+		if err == io.EOF {
+			this.complete()
+		}
+
+		if err != nil {
+			return ResponseChunk{}, err
+		}
+	}
+}
+
+// nextEvent - reads from the source until there is a whole event, which ends with a blank line.
+// Whatever is left when the source ends is passed on as the last chunk
+func (this *StreamedBody) nextEvent() (ResponseChunk, error) {
+	buffer := make([]byte, 32*1024)
+	for {
+		if end := eventEnd(this.buffered); end > 0 {
+			data := this.buffered[:end]
+			this.buffered = this.buffered[end:]
+
+			return this.recordRead(data), nil
+		}
+
+		n, err := this.source.Read(buffer)
+		this.buffered += string(buffer[:n])
+
+		if err == io.EOF && this.buffered != "" {
+			data := this.buffered
+			this.buffered = ""
+
+			return this.recordRead(data), nil
+		}
+
+		if err == io.EOF {
+			this.complete()
+		}
+
+		if err != nil {
+			return ResponseChunk{}, err
+		}
+	}
+}
+
+// eventEnd - where the first event in data ends, after the blank line which ends it, or 0 when
+// data does not hold a whole event yet
+func eventEnd(data string) int {
+	end := 0
+	for _, terminator := range []string{"\n\n", "\r\n\r\n"} {
+		if i := strings.Index(data, terminator); i >= 0 && (end == 0 || i+len(terminator) < end) {
+			end = i + len(terminator)
+		}
+	}
+
+	return end
+}
+
+func (this *StreamedBody) recordRead(data string) ResponseChunk {
+	chunk := ResponseChunk{
+		Data:  data,
+		Delay: int(time.Since(this.last) / time.Millisecond),
+	}
+	this.record(chunk)
+
+	return chunk
+}
+
+func (this *StreamedBody) record(chunk ResponseChunk) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.chunks = append(this.chunks, chunk)
+	this.last = time.Now()
+}
+
+func (this *StreamedBody) complete() {
+	this.mutex.Lock()
+	if this.completed {
+		this.mutex.Unlock()
+		return
+	}
+
+	this.completed = true
+	callbacks := this.onComplete
+	chunks := this.chunks
+	this.mutex.Unlock()
+
+	for _, callback := range callbacks {
+		callback(chunks)
+	}
+}
@@ -0,0 +1,159 @@
+package models_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	. "github.com/onsi/gomega"
+)
+
+func Test_StreamedBody_WriteTo_WritesAndFlushesEachChunkAfterItsDelay(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := models.NewStreamedBody([]models.ResponseChunk{
+		models.ResponseChunk{Data: "data: one\n\n"},
+		models.ResponseChunk{Data: "data: two\n\n", Delay: 50},
+	})
+
+	recorder := httptest.NewRecorder()
+
+	started := time.Now()
+	written, err := unit.WriteTo(recorder)
+	Expect(err).To(BeNil())
+
+	Expect(time.Since(started)).To(BeNumerically(">=", 50*time.Millisecond))
+	Expect(written).To(Equal(int64(22)))
+	Expect(recorder.Body.String()).To(Equal("data: one\n\ndata: two\n\n"))
+	Expect(recorder.Flushed).To(BeTrue())
+}
+
+func Test_StreamedBody_Read_ReadsChunksInOrder(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := models.NewStreamedBody([]models.ResponseChunk{
+		models.ResponseChunk{Data: "one"},
+		models.ResponseChunk{Data: "two"},
+	})
+
+	body, err := ioutil.ReadAll(unit)
+	Expect(err).To(BeNil())
+	Expect(string(body)).To(Equal("onetwo"))
+}
+
+func Test_StreamedBody_WhenRecording_CallsOnCompleteWithChunksRead(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := models.NewRecordingStreamedBody(ioutil.NopCloser(bytes.NewBufferString("data: one\n\n")))
+
+	var recorded []models.ResponseChunk
+	unit.OnComplete(func(chunks []models.ResponseChunk) {
+		recorded = chunks
+	})
+
+	body, err := ioutil.ReadAll(unit)
+	Expect(err).To(BeNil())
+	Expect(string(body)).To(Equal("data: one\n\n"))
+
+	Expect(recorded).To(HaveLen(1))
+	Expect(recorded[0].Data).To(Equal("data: one\n\n"))
+}
+
+func Test_StreamedBody_Close_CallsOnCompleteOnce(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := models.NewStreamedBody([]models.ResponseChunk{
+		models.ResponseChunk{Data: "one"},
+	})
+
+	calls := 0
+	unit.OnComplete(func(chunks []models.ResponseChunk) {
+		calls++
+		Expect(chunks).To(BeEmpty())
+	})
+
+	Expect(unit.Close()).To(Succeed())
+	Expect(unit.Close()).To(Succeed())
+
+	Expect(calls).To(Equal(1))
+}
+
+func Test_ResponseDetails_ConvertToResponseDetailsView_EncodesBinaryChunks(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := models.ResponseDetails{
+		Status: 200,
+		Chunks: []models.ResponseChunk{
+			models.ResponseChunk{Data: "\xff\x00", Delay: 10},
+		},
+	}
+
+	view := unit.ConvertToResponseDetailsView()
+
+	Expect(view.EncodedBody).To(BeTrue())
+	Expect(view.Chunks).To(Equal([]v2.ResponseChunkView{
+		v2.ResponseChunkView{Data: "/wA=", Delay: 10},
+	}))
+
+	Expect(models.NewResponseChunksFromView(view.Chunks, view.EncodedBody)).To(Equal(unit.Chunks))
+}
+
+func Test_IsStreamedResponse_IsTrueForServerSentEvents(t *testing.T) {
+	RegisterTestingT(t)
+
+	response := &http.Response{Header: http.Header{"Content-Type": []string{"text/event-stream; charset=utf-8"}}}
+
+	Expect(models.IsStreamedResponse(response)).To(BeTrue())
+}
+
+func Test_IsStreamedResponse_IsTrueForChunkedResponses(t *testing.T) {
+	RegisterTestingT(t)
+
+	response := &http.Response{
+		Header:           http.Header{"Content-Type": []string{"application/json"}},
+		TransferEncoding: []string{"chunked"},
+	}
+
+	Expect(models.IsStreamedResponse(response)).To(BeTrue())
+	Expect(models.IsEventStream(response)).To(BeFalse())
+}
+
+func Test_IsStreamedResponse_IsFalseForOtherResponses(t *testing.T) {
+	RegisterTestingT(t)
+
+	response := &http.Response{Header: http.Header{"Content-Type": []string{"application/json"}}}
+
+	Expect(models.IsStreamedResponse(response)).To(BeFalse())
+}
+
+func Test_StreamedBody_WhenRecordingEvents_RecordsAChunkForEachEvent(t *testing.T) {
+	RegisterTestingT(t)
+
+	source := io.MultiReader(
+		bytes.NewBufferString("data: one\n\ndata: t"),
+		bytes.NewBufferString("wo\n\ndata: three\r\n\r\n"),
+		bytes.NewBufferString("data: unterminated"),
+	)
+	unit := models.NewRecordingEventStreamBody(ioutil.NopCloser(source))
+
+	var recorded []models.ResponseChunk
+	unit.OnComplete(func(chunks []models.ResponseChunk) {
+		recorded = chunks
+	})
+
+	body, err := ioutil.ReadAll(unit)
+	Expect(err).To(BeNil())
+	Expect(string(body)).To(Equal("data: one\n\ndata: two\n\ndata: three\r\n\r\ndata: unterminated"))
+
+	Expect(recorded).To(HaveLen(4))
+	Expect(recorded[0].Data).To(Equal("data: one\n\n"))
+	Expect(recorded[1].Data).To(Equal("data: two\n\n"))
+	Expect(recorded[2].Data).To(Equal("data: three\r\n\r\n"))
+	Expect(recorded[3].Data).To(Equal("data: unterminated"))
+}
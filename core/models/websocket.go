@@ -0,0 +1,138 @@
+package models
+
+import (
+	"encoding/base64"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+)
+
+// WebSocket - scripted conversation Hoverfly has with a client once a WebSocket
+// connection has been upgraded
+type WebSocket struct {
+	OnConnect []WebSocketFrame
+	OnMessage []WebSocketMessageHandler
+	Periodic  []WebSocketPeriodicFrame
+}
+
+// WebSocketFrame - message sent to the client after a delay in milliseconds,
+// Data holds the raw message even when the frame is binary
+type WebSocketFrame struct {
+	Data   string
+	Binary bool
+	Delay  int
+}
+
+// WebSocketMessageHandler - replies to client messages which match
+type WebSocketMessageHandler struct {
+	Match *RequestFieldMatchers
+	Reply []WebSocketFrame
+}
+
+// WebSocketPeriodicFrame - message pushed to the client every interval in milliseconds
+type WebSocketPeriodicFrame struct {
+	Data     string
+	Binary   bool
+	Interval int
+}
+
+func NewWebSocketFromView(view *v2.WebSocketView) *WebSocket {
+	if view == nil {
+		return nil
+	}
+
+	webSocket := &WebSocket{}
+
+	webSocket.OnConnect = newWebSocketFramesFromView(view.OnConnect)
+
+	for _, handler := range view.OnMessage {
+		webSocket.OnMessage = append(webSocket.OnMessage, WebSocketMessageHandler{
+			Match: NewRequestFieldMatchersFromView(handler.Match),
+			Reply: newWebSocketFramesFromView(handler.Reply),
+		})
+	}
+
+	for _, periodic := range view.Periodic {
+		webSocket.Periodic = append(webSocket.Periodic, WebSocketPeriodicFrame{
+			Data:     decodeWebSocketData(periodic.Data, periodic.Binary),
+			Binary:   periodic.Binary,
+			Interval: periodic.Interval,
+		})
+	}
+
+	return webSocket
+}
+
+func (this *WebSocket) BuildView() *v2.WebSocketView {
+	if this == nil {
+		return nil
+	}
+
+	view := &v2.WebSocketView{}
+
+	view.OnConnect = buildWebSocketFrameViews(this.OnConnect)
+
+	for _, handler := range this.OnMessage {
+		var match *v2.RequestFieldMatchersView
+		if handler.Match != nil {
+			match = handler.Match.BuildView()
+		}
+
+		view.OnMessage = append(view.OnMessage, v2.WebSocketMessageHandlerView{
+			Match: match,
+			Reply: buildWebSocketFrameViews(handler.Reply),
+		})
+	}
+
+	for _, periodic := range this.Periodic {
+		view.Periodic = append(view.Periodic, v2.WebSocketPeriodicFrameView{
+			Data:     encodeWebSocketData(periodic.Data, periodic.Binary),
+			Binary:   periodic.Binary,
+			Interval: periodic.Interval,
+		})
+	}
+
+	return view
+}
+
+func newWebSocketFramesFromView(views []v2.WebSocketFrameView) []WebSocketFrame {
+	var frames []WebSocketFrame
+	for _, view := range views {
+		frames = append(frames, WebSocketFrame{
+			Data:   decodeWebSocketData(view.Data, view.Binary),
+			Binary: view.Binary,
+			Delay:  view.Delay,
+		})
+	}
+
+	return frames
+}
+
+func buildWebSocketFrameViews(frames []WebSocketFrame) []v2.WebSocketFrameView {
+	var views []v2.WebSocketFrameView
+	for _, frame := range frames {
+		views = append(views, v2.WebSocketFrameView{
+			Data:   encodeWebSocketData(frame.Data, frame.Binary),
+			Binary: frame.Binary,
+			Delay:  frame.Delay,
+		})
+	}
+
+	return views
+}
+
+func decodeWebSocketData(data string, binary bool) string {
+	if !binary {
+		return data
+	}
+
+	decoded, _ := base64.StdEncoding.DecodeString(data)
+	return string(decoded)
+}
+
+func encodeWebSocketData(data string, binary bool) string {
+	if !binary {
+		return data
+	}
+
+	return base64.StdEncoding.EncodeToString([]byte(data))
+}
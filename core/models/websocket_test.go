@@ -0,0 +1,74 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+)
+
+func Test_NewWebSocketFromView_DecodesBinaryFrames(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := models.NewWebSocketFromView(&v2.WebSocketView{
+		OnConnect: []v2.WebSocketFrameView{
+			v2.WebSocketFrameView{Data: "/wA=", Binary: true, Delay: 5},
+		},
+		OnMessage: []v2.WebSocketMessageHandlerView{
+			v2.WebSocketMessageHandlerView{
+				Match: &v2.RequestFieldMatchersView{
+					ExactMatch: util.StringToPointer("ping"),
+				},
+				Reply: []v2.WebSocketFrameView{
+					v2.WebSocketFrameView{Data: "pong"},
+				},
+			},
+		},
+		Periodic: []v2.WebSocketPeriodicFrameView{
+			v2.WebSocketPeriodicFrameView{Data: "tick", Interval: 100},
+		},
+	})
+
+	Expect(unit.OnConnect).To(Equal([]models.WebSocketFrame{
+		models.WebSocketFrame{Data: "\xff\x00", Binary: true, Delay: 5},
+	}))
+
+	Expect(unit.OnMessage).To(HaveLen(1))
+	Expect(*unit.OnMessage[0].Match.ExactMatch).To(Equal("ping"))
+	Expect(unit.OnMessage[0].Reply).To(Equal([]models.WebSocketFrame{
+		models.WebSocketFrame{Data: "pong"},
+	}))
+
+	Expect(unit.Periodic).To(Equal([]models.WebSocketPeriodicFrame{
+		models.WebSocketPeriodicFrame{Data: "tick", Interval: 100},
+	}))
+}
+
+func Test_WebSocket_BuildView_EncodesBinaryFrames(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := &models.WebSocket{
+		OnConnect: []models.WebSocketFrame{
+			models.WebSocketFrame{Data: "\xff\x00", Binary: true},
+			models.WebSocketFrame{Data: "hello"},
+		},
+	}
+
+	view := unit.BuildView()
+
+	Expect(view.OnConnect).To(Equal([]v2.WebSocketFrameView{
+		v2.WebSocketFrameView{Data: "/wA=", Binary: true},
+		v2.WebSocketFrameView{Data: "hello"},
+	}))
+}
+
+func Test_WebSocket_BuildView_ReturnsNilWhenNil(t *testing.T) {
+	RegisterTestingT(t)
+
+	var unit *models.WebSocket
+
+	Expect(unit.BuildView()).To(BeNil())
+	Expect(models.NewWebSocketFromView(nil)).To(BeNil())
+}
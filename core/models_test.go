@@ -2,6 +2,7 @@ package hoverfly
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -60,7 +61,7 @@ func TestMatchOnRequestBody(t *testing.T) {
 		requestDetails, err := models.NewRequestDetailsFromHttpRequest(request)
 		Expect(err).To(BeNil())
 
-		response, err := dbClient.GetResponse(requestDetails)
+		response, err := dbClient.GetResponse(context.Background(), requestDetails)
 		Expect(err).To(BeNil())
 
 		Expect(response.Body).To(Equal(fmt.Sprintf("body here, number=%d", i)))
@@ -81,7 +82,7 @@ func TestGetNotRecordedRequest(t *testing.T) {
 	requestDetails, err := models.NewRequestDetailsFromHttpRequest(request)
 	Expect(err).To(BeNil())
 
-	response, err := dbClient.GetResponse(requestDetails)
+	response, err := dbClient.GetResponse(context.Background(), requestDetails)
 	Expect(err).ToNot(BeNil())
 
 	Expect(response).To(BeNil())
@@ -2,9 +2,11 @@ package modes
 
 import (
 	"bytes"
+	"context"
 	"io/ioutil"
 	"net/http"
 
+	"github.com/SpectoLabs/hoverfly/core/middleware"
 	"github.com/SpectoLabs/hoverfly/core/models"
 	"github.com/SpectoLabs/hoverfly/core/util"
 
@@ -13,7 +15,7 @@ import (
 )
 
 type HoverflyCapture interface {
-	ApplyMiddleware(models.RequestResponsePair) (models.RequestResponsePair, error)
+	ApplyMiddleware(context.Context, string, models.RequestResponsePair) (models.RequestResponsePair, error)
 	DoRequest(*http.Request) (*http.Response, error)
 	Save(*models.RequestDetails, *models.ResponseDetails, []string) error
 }
@@ -47,7 +49,7 @@ func (this CaptureMode) Process(request *http.Request, details models.RequestDet
 		request.Body = ioutil.NopCloser(bytes.NewBuffer([]byte("")))
 	}
 
-	pair, err := this.Hoverfly.ApplyMiddleware(models.RequestResponsePair{Request: details})
+	pair, err := this.Hoverfly.ApplyMiddleware(requestContext(request), middleware.RequestPhase, models.RequestResponsePair{Request: details})
 	if err != nil {
 		return ReturnErrorAndLog(request, err, &pair, "There was an error when applying middleware to http request", Capture)
 	}
@@ -57,34 +59,81 @@ func (this CaptureMode) Process(request *http.Request, details models.RequestDet
 		return ReturnErrorAndLog(request, err, &pair, "There was an error when applying middleware to http request", Capture)
 	}
 
-	response, err := this.Hoverfly.DoRequest(modifiedRequest)
+	if err := forwardOriginalBody(request, details, pair, modifiedRequest); err != nil {
+		return ReturnErrorAndLog(request, err, &pair, "There was an error when reading the http request body", Capture)
+	}
+
+	response, err := this.Hoverfly.DoRequest(modifiedRequest.WithContext(requestContext(request)))
 	if err != nil {
 		return ReturnErrorAndLog(request, err, &pair, "There was an error when forwarding the request to the intended desintation", Capture)
 	}
 
-	respBody, _ := util.GetResponseBody(response)
+	if this.Arguments.Headers == nil {
+		this.Arguments.Headers = []string{}
+	}
 
-	responseObj := &models.ResponseDetails{
-		Status:  response.StatusCode,
-		Body:    string(respBody),
-		Headers: response.Header,
+	// streamed responses are passed on to the client as they arrive
+	// and saved once the stream has ended
+	if models.IsStreamedResponse(response) {
+		var body *models.StreamedBody
+		if models.IsEventStream(response) {
+			body = models.NewRecordingEventStreamBody(response.Body)
+		} else {
+			body = models.NewRecordingStreamedBody(response.Body)
+		}
+		body.OnComplete(func(chunks []models.ResponseChunk) {
+			err := this.save(&pair, &models.ResponseDetails{
+				Status:  response.StatusCode,
+				Headers: response.Header,
+				Chunks:  chunks,
+			})
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error":   err.Error(),
+					"mode":    Capture,
+					"request": GetRequestLogFields(&pair.Request),
+				}).Error("There was an error when saving request and response")
+			}
+		})
+
+		response.Body = body
+
+		return response, nil
 	}
 
-	if this.Arguments.Headers == nil {
-		this.Arguments.Headers = []string{}
+	respBody, _ := util.GetResponseBody(response)
+	body, headers, err := models.DecodeBody(respBody, response.Header)
+	if err != nil {
+		log.WithField("error", err.Error()).Warn("Failed to decode response body, keeping it as it is")
+	}
+
+	responseObj := &models.ResponseDetails{
+		Status:          response.StatusCode,
+		Body:            body,
+		Headers:         headers,
+		ContentEncoding: models.DecodedContentEncoding(response.Header, headers),
 	}
 
 	// saving response body with request/response meta to cache
-	err = this.Hoverfly.Save(&pair.Request, responseObj, this.Arguments.Headers)
+	err = this.save(&pair, responseObj)
 	if err != nil {
 		return ReturnErrorAndLog(request, err, &pair, "There was an error when saving request and response", Capture)
 	}
 
+	return response, nil
+}
+
+func (this CaptureMode) save(pair *models.RequestResponsePair, response *models.ResponseDetails) error {
+	err := this.Hoverfly.Save(&pair.Request, response, this.Arguments.Headers)
+	if err != nil {
+		return err
+	}
+
 	log.WithFields(log.Fields{
 		"mode":     Capture,
 		"request":  GetRequestLogFields(&pair.Request),
 		"response": GetResponseLogFields(&pair.Response),
 	}).Info("request and response captured")
 
-	return response, nil
+	return nil
 }
@@ -2,6 +2,7 @@ package modes_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -13,19 +14,26 @@ import (
 )
 
 type hoverflyCaptureStub struct {
-	SavedRequest  *models.RequestDetails
-	SavedResponse *models.ResponseDetails
-	SavedHeaders  []string
-	MiddlewareSet bool
+	SavedRequest     *models.RequestDetails
+	SavedResponse    *models.ResponseDetails
+	SavedHeaders     []string
+	MiddlewareSet    bool
+	ForwardedRequest *http.Request
 }
 
 // ApplyMiddleware - Stub implementation of modes.HoverflyCapture interface
-func (this hoverflyCaptureStub) ApplyMiddleware(pair models.RequestResponsePair) (models.RequestResponsePair, error) {
+func (this hoverflyCaptureStub) ApplyMiddleware(ctx context.Context, phase string, pair models.RequestResponsePair) (models.RequestResponsePair, error) {
+	if pair.Request.Path == "/middleware-body" {
+		pair.Request.Body = "changed by middleware"
+	}
+
 	return pair, nil
 }
 
 // DoRequest - Stub implementation of modes.HoverflyCapture interface
-func (this hoverflyCaptureStub) DoRequest(request *http.Request) (*http.Response, error) {
+func (this *hoverflyCaptureStub) DoRequest(request *http.Request) (*http.Response, error) {
+	this.ForwardedRequest = request
+
 	response := &http.Response{}
 	if request.Host == "error.com" {
 		return nil, errors.New("Could not reach error.com")
@@ -34,6 +42,12 @@ func (this hoverflyCaptureStub) DoRequest(request *http.Request) (*http.Response
 	response.StatusCode = 200
 	response.Body = ioutil.NopCloser(bytes.NewBufferString("test"))
 
+	if request.Host == "gzip.com" {
+		body, _ := models.EncodeBody("test", models.GzipEncoding)
+		response.Body = ioutil.NopCloser(bytes.NewBufferString(body))
+		response.Header = http.Header{"Content-Encoding": []string{"gzip"}}
+	}
+
 	return response, nil
 }
 
@@ -92,6 +106,93 @@ func Test_CaptureMode_WhenGivenARequestItWillMakeTheRequestAndSaveIt(t *testing.
 	Expect(hoverflyStub.SavedResponse.Body).To(Equal("test"))
 }
 
+func Test_CaptureMode_SavesTheResponseBodyDecoded(t *testing.T) {
+	RegisterTestingT(t)
+
+	hoverflyStub := &hoverflyCaptureStub{}
+
+	unit := &modes.CaptureMode{
+		Hoverfly: hoverflyStub,
+	}
+
+	requestDetails := models.RequestDetails{
+		Scheme:      "http",
+		Destination: "gzip.com",
+	}
+
+	request, err := http.NewRequest("GET", "http://gzip.com", nil)
+	Expect(err).To(BeNil())
+
+	response, err := unit.Process(request, requestDetails)
+	Expect(err).To(BeNil())
+
+	Expect(response.Header.Get("Content-Encoding")).To(Equal("gzip"))
+
+	Expect(hoverflyStub.SavedResponse.Body).To(Equal("test"))
+	Expect(hoverflyStub.SavedResponse.Headers).ToNot(HaveKey("Content-Encoding"))
+	Expect(hoverflyStub.SavedResponse.ContentEncoding).To(Equal("gzip"))
+}
+
+func Test_CaptureMode_ForwardsEncodedRequestBodyAsTheClientSentIt(t *testing.T) {
+	RegisterTestingT(t)
+
+	hoverflyStub := &hoverflyCaptureStub{}
+
+	unit := &modes.CaptureMode{
+		Hoverfly: hoverflyStub,
+	}
+
+	encoded, err := models.EncodeBody("request body", models.GzipEncoding)
+	Expect(err).To(BeNil())
+
+	request, err := http.NewRequest("POST", "http://positive-match.com", bytes.NewBufferString(encoded))
+	Expect(err).To(BeNil())
+	request.Header.Set("Content-Encoding", "gzip")
+
+	requestDetails, err := models.NewRequestDetailsFromHttpRequest(request)
+	Expect(err).To(BeNil())
+
+	_, err = unit.Process(request, requestDetails)
+	Expect(err).To(BeNil())
+
+	forwardedBody, err := ioutil.ReadAll(hoverflyStub.ForwardedRequest.Body)
+	Expect(err).To(BeNil())
+	Expect(string(forwardedBody)).To(Equal(encoded))
+	Expect(hoverflyStub.ForwardedRequest.ContentLength).To(Equal(int64(len(encoded))))
+	Expect(hoverflyStub.ForwardedRequest.Header.Get("Content-Encoding")).To(Equal("gzip"))
+
+	Expect(hoverflyStub.SavedRequest.Body).To(Equal("request body"))
+	Expect(hoverflyStub.SavedRequest.Headers).ToNot(HaveKey("Content-Encoding"))
+}
+
+func Test_CaptureMode_ForwardsDecodedRequestBodyWhenMiddlewareChangesIt(t *testing.T) {
+	RegisterTestingT(t)
+
+	hoverflyStub := &hoverflyCaptureStub{}
+
+	unit := &modes.CaptureMode{
+		Hoverfly: hoverflyStub,
+	}
+
+	encoded, err := models.EncodeBody("request body", models.GzipEncoding)
+	Expect(err).To(BeNil())
+
+	request, err := http.NewRequest("POST", "http://positive-match.com/middleware-body", bytes.NewBufferString(encoded))
+	Expect(err).To(BeNil())
+	request.Header.Set("Content-Encoding", "gzip")
+
+	requestDetails, err := models.NewRequestDetailsFromHttpRequest(request)
+	Expect(err).To(BeNil())
+
+	_, err = unit.Process(request, requestDetails)
+	Expect(err).To(BeNil())
+
+	forwardedBody, err := ioutil.ReadAll(hoverflyStub.ForwardedRequest.Body)
+	Expect(err).To(BeNil())
+	Expect(string(forwardedBody)).To(Equal("changed by middleware"))
+	Expect(hoverflyStub.ForwardedRequest.Header).ToNot(HaveKey("Content-Encoding"))
+}
+
 func Test_CaptureMode_IfHeadersArgumentNotSet_CallsSaveWithEmptyList(t *testing.T) {
 	RegisterTestingT(t)
 
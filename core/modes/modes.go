@@ -2,17 +2,21 @@ package modes
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	log "github.com/Sirupsen/logrus"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/SpectoLabs/goproxy"
+	"github.com/SpectoLabs/hoverfly/core/grpc"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
 	"github.com/SpectoLabs/hoverfly/core/matching"
 	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/util"
 )
 
 // SimulateMode - default mode when Hoverfly looks for captured requests to respond
@@ -34,8 +38,8 @@ type Mode interface {
 }
 
 type Hoverfly interface {
-	GetResponse(models.RequestDetails) (*models.ResponseDetails, *matching.MatchingError)
-	ApplyMiddleware(models.RequestResponsePair) (models.RequestResponsePair, error)
+	GetResponse(context.Context, models.RequestDetails) (*models.ResponseDetails, *matching.MatchingError)
+	ApplyMiddleware(context.Context, string, models.RequestResponsePair) (models.RequestResponsePair, error)
 	DoRequest(*http.Request) (*http.Response, error)
 	IsMiddlewareSet() bool
 	Save(*models.RequestDetails, *models.ResponseDetails)
@@ -46,6 +50,15 @@ type ModeArguments struct {
 	MatchingStrategy *string
 }
 
+// requestContext - the context of the request, which carries its trace through the mode
+func requestContext(request *http.Request) context.Context {
+	if request == nil {
+		return context.Background()
+	}
+
+	return request.Context()
+}
+
 // ReconstructRequest replaces original request with details provided in Constructor Payload.RequestMatcher
 func ReconstructRequest(pair models.RequestResponsePair) (*http.Request, error) {
 	if pair.Request.Destination == "" {
@@ -69,6 +82,34 @@ func ReconstructRequest(pair models.RequestResponsePair) (*http.Request, error)
 	return newRequest, nil
 }
 
+// forwardOriginalBody - puts the body back on the outgoing request as the client sent it, along with
+// its Content-Encoding, when it was decoded and middleware has left it alone. The decoded body is only
+// for matching, middleware and the journal, the real service gets what the client sent
+func forwardOriginalBody(request *http.Request, details models.RequestDetails, pair models.RequestResponsePair, outgoing *http.Request) error {
+	contentEncoding := request.Header["Content-Encoding"]
+	if len(contentEncoding) == 0 || len(details.Headers["Content-Encoding"]) > 0 || pair.Request.Body != details.Body {
+		return nil
+	}
+
+	body, err := util.GetRequestBody(request)
+	if err != nil {
+		return err
+	}
+
+	// the headers are shared with the pair, which is what gets saved, so they are copied rather than changed
+	headers := make(http.Header)
+	for k, v := range outgoing.Header {
+		headers[k] = v
+	}
+	headers["Content-Encoding"] = contentEncoding
+
+	outgoing.Header = headers
+	outgoing.Body = ioutil.NopCloser(bytes.NewBufferString(body))
+	outgoing.ContentLength = int64(len(body))
+
+	return nil
+}
+
 // ReconstructResponse changes original response with details provided in Constructor Payload.Response
 func ReconstructResponse(request *http.Request, pair models.RequestResponsePair) *http.Response {
 	response := &http.Response{}
@@ -88,9 +129,104 @@ func ReconstructResponse(request *http.Request, pair models.RequestResponsePair)
 
 	response.Header = headers
 
+	// streamed responses are written chunk by chunk, so their length isn't known up front
+	if len(pair.Response.Chunks) > 0 {
+		response.Body = models.NewStreamedBody(pair.Response.Chunks)
+		response.ContentLength = -1
+		response.Header.Del("Content-Length")
+	} else if pair.Response.BodyFile != "" && pair.Response.Body == "" {
+		// a body given by middleware replaces the body file
+		if err := readBodyFile(request, response, pair.Response.BodyFile); err != nil {
+			log.WithFields(log.Fields{
+				"error":    err.Error(),
+				"bodyFile": pair.Response.BodyFile,
+			}).Error("There was an error when reading the response body file")
+
+			return ErrorResponse(request, err, "There was an error when reading the response body file")
+		}
+	} else {
+		encodeResponseBody(request, response, pair.Response.Body, pair.Response.ContentEncoding)
+	}
+
 	return response
 }
 
+// readBodyFile - sets the body to the body file, which is read from the simulation directory
+// as the response is written. Only the part of the file asked for with a Range header is read
+// when the response is successful
+func readBodyFile(request *http.Request, response *http.Response, name string) error {
+	body, err := models.BodyFilesFromContext(requestContext(request)).Open(name)
+	if err != nil {
+		return err
+	}
+
+	response.Header.Del("Content-Length")
+	response.Header.Set("Accept-Ranges", "bytes")
+
+	if request != nil && request.Header.Get("Range") != "" && response.StatusCode == http.StatusOK {
+		contentRange, ok, err := body.Range(request.Header.Get("Range"))
+		if err != nil {
+			body.Close()
+			response.StatusCode = http.StatusRequestedRangeNotSatisfiable
+			response.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", body.Size))
+			response.Body = ioutil.NopCloser(bytes.NewBuffer(nil))
+			response.ContentLength = 0
+			return nil
+		}
+
+		if ok {
+			response.StatusCode = http.StatusPartialContent
+			response.Header.Set("Content-Range", contentRange)
+		}
+	}
+
+	response.Body = body
+	response.ContentLength = body.Length
+
+	return nil
+}
+
+// encodeResponseBody - encodes the body with the content coding it was decoded from, when the
+// client accepts it in its Accept-Encoding header. Bodies which were not decoded, either as they
+// had no Content-Encoding or as it could not be decoded, are sent as they are
+func encodeResponseBody(request *http.Request, response *http.Response, body string, contentEncoding string) {
+	if request == nil || body == "" || contentEncoding == "" || len(response.Header["Content-Encoding"]) > 0 {
+		return
+	}
+
+	// gRPC messages are compressed by the framing rather than the body
+	if grpc.IsGrpcContentType(request.Header.Get("Content-Type")) {
+		return
+	}
+
+	if !models.AcceptsEncoding(request.Header["Accept-Encoding"], contentEncoding) {
+		return
+	}
+
+	encoded, err := models.EncodeBodyWith(body, contentEncoding)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err.Error(),
+			"encoding": contentEncoding,
+		}).Warn("Failed to encode response body, sending it as it is")
+		return
+	}
+
+	response.Body = ioutil.NopCloser(bytes.NewBufferString(encoded))
+	response.ContentLength = int64(len(encoded))
+	response.Header.Set("Content-Encoding", contentEncoding)
+	response.Header.Del("Content-Length")
+
+	// the values are copied, as they can be shared with the simulation
+	vary := response.Header["Vary"]
+	for _, value := range vary {
+		if strings.Contains(strings.ToLower(value), "accept-encoding") || value == "*" {
+			return
+		}
+	}
+	response.Header["Vary"] = append(append([]string{}, vary...), "Accept-Encoding")
+}
+
 func GetRequestLogFields(request *models.RequestDetails) *logrus.Fields {
 	if request == nil {
 		return &log.Fields{
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/SpectoLabs/hoverfly/core/models"
@@ -241,3 +243,199 @@ func Test_errorResponse_ShouldAlwaysIncludeBothMessageAndErrorInResponseBody(t *
 	Expect(string(responseBody)).To(ContainSubstring("This is a test error"))
 	Expect(string(responseBody)).To(ContainSubstring("error doing something"))
 }
+
+func Test_ReconstructResponse_EncodesTheBodyWithTheCodingItWasDecodedFrom(t *testing.T) {
+	RegisterTestingT(t)
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+
+	pair := models.RequestResponsePair{
+		Response: models.ResponseDetails{
+			Body:            "test body",
+			Headers:         map[string][]string{"Content-Length": []string{"9"}},
+			ContentEncoding: "br",
+		},
+	}
+
+	response := modes.ReconstructResponse(req, pair)
+
+	Expect(response.Header.Get("Content-Encoding")).To(Equal("br"))
+	Expect(response.Header.Get("Content-Length")).To(Equal(""))
+	Expect(response.Header.Get("Vary")).To(Equal("Accept-Encoding"))
+	Expect(pair.Response.Headers).ToNot(HaveKey("Content-Encoding"))
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+	Expect(response.ContentLength).To(Equal(int64(len(responseBody))))
+
+	body, _, err := models.DecodeBody(string(responseBody), response.Header)
+	Expect(err).To(BeNil())
+	Expect(body).To(Equal("test body"))
+}
+
+func Test_ReconstructResponse_DoesNotEncodeBodiesWhichWereNotDecoded(t *testing.T) {
+	RegisterTestingT(t)
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+
+	response := modes.ReconstructResponse(req, models.RequestResponsePair{
+		Response: models.ResponseDetails{
+			Body: "test body",
+		},
+	})
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+
+	Expect(string(responseBody)).To(Equal("test body"))
+	Expect(response.Header.Get("Content-Encoding")).To(Equal(""))
+}
+
+func Test_ReconstructResponse_DoesNotEncodeBodiesWithACodingTheClientDoesNotAccept(t *testing.T) {
+	RegisterTestingT(t)
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	response := modes.ReconstructResponse(req, models.RequestResponsePair{
+		Response: models.ResponseDetails{
+			Body:            "test body",
+			ContentEncoding: "br",
+		},
+	})
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+
+	Expect(string(responseBody)).To(Equal("test body"))
+	Expect(response.Header.Get("Content-Encoding")).To(Equal(""))
+}
+
+func Test_ReconstructResponse_DoesNotEncodeBodiesWhichAlreadyHaveAnEncoding(t *testing.T) {
+	RegisterTestingT(t)
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	pair := models.RequestResponsePair{
+		Response: models.ResponseDetails{
+			Body:    "compressed body",
+			Headers: map[string][]string{"Content-Encoding": []string{"compress"}},
+		},
+	}
+
+	response := modes.ReconstructResponse(req, pair)
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+
+	Expect(string(responseBody)).To(Equal("compressed body"))
+	Expect(response.Header.Get("Content-Encoding")).To(Equal("compress"))
+}
+
+func Test_ReconstructResponse_ReadsTheBodyFromTheBodyFile(t *testing.T) {
+	RegisterTestingT(t)
+
+	directory, _ := ioutil.TempDir("", "hoverfly-body-files")
+	defer os.RemoveAll(directory)
+	ioutil.WriteFile(filepath.Join(directory, "body.txt"), []byte("body from file"), 0644)
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	req = req.WithContext(models.ContextWithBodyFiles(req.Context(), models.BodyFiles{Directory: directory}))
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	pair := models.RequestResponsePair{
+		Response: models.ResponseDetails{
+			Status:   200,
+			BodyFile: "body.txt",
+		},
+	}
+
+	response := modes.ReconstructResponse(req, pair)
+
+	Expect(response.StatusCode).To(Equal(200))
+	Expect(response.ContentLength).To(Equal(int64(14)))
+	Expect(response.Header.Get("Accept-Ranges")).To(Equal("bytes"))
+	Expect(response.Header.Get("Content-Encoding")).To(Equal(""))
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+	Expect(string(responseBody)).To(Equal("body from file"))
+	Expect(response.Body.Close()).To(BeNil())
+}
+
+func Test_ReconstructResponse_ReadsTheRangeOfTheBodyFileAskedFor(t *testing.T) {
+	RegisterTestingT(t)
+
+	directory, _ := ioutil.TempDir("", "hoverfly-body-files")
+	defer os.RemoveAll(directory)
+	ioutil.WriteFile(filepath.Join(directory, "body.txt"), []byte("body from file"), 0644)
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	req = req.WithContext(models.ContextWithBodyFiles(req.Context(), models.BodyFiles{Directory: directory}))
+	req.Header.Set("Range", "bytes=5-8")
+
+	pair := models.RequestResponsePair{
+		Response: models.ResponseDetails{
+			Status:   200,
+			BodyFile: "body.txt",
+		},
+	}
+
+	response := modes.ReconstructResponse(req, pair)
+
+	Expect(response.StatusCode).To(Equal(http.StatusPartialContent))
+	Expect(response.Header.Get("Content-Range")).To(Equal("bytes 5-8/14"))
+	Expect(response.ContentLength).To(Equal(int64(4)))
+
+	responseBody, _ := ioutil.ReadAll(response.Body)
+	Expect(string(responseBody)).To(Equal("from"))
+	response.Body.Close()
+
+	req.Header.Set("Range", "bytes=20-")
+	response = modes.ReconstructResponse(req, pair)
+
+	Expect(response.StatusCode).To(Equal(http.StatusRequestedRangeNotSatisfiable))
+	Expect(response.Header.Get("Content-Range")).To(Equal("bytes */14"))
+}
+
+func Test_ReconstructResponse_ReturnsAnErrorResponseWhenTheBodyFileCannotBeRead(t *testing.T) {
+	RegisterTestingT(t)
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+
+	pair := models.RequestResponsePair{
+		Response: models.ResponseDetails{
+			Status:   200,
+			BodyFile: "../outside.txt",
+		},
+	}
+
+	response := modes.ReconstructResponse(req, pair)
+
+	Expect(response.StatusCode).To(Equal(http.StatusBadGateway))
+
+	responseBody, _ := ioutil.ReadAll(response.Body)
+	Expect(string(responseBody)).To(ContainSubstring("There was an error when reading the response body file"))
+}
+
+func Test_ReconstructResponse_UsesTheBodyRatherThanTheBodyFileWhenBothAreSet(t *testing.T) {
+	RegisterTestingT(t)
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+
+	pair := models.RequestResponsePair{
+		Response: models.ResponseDetails{
+			Status:   200,
+			Body:     "body from middleware",
+			BodyFile: "missing.txt",
+		},
+	}
+
+	response := modes.ReconstructResponse(req, pair)
+
+	responseBody, _ := ioutil.ReadAll(response.Body)
+	Expect(string(responseBody)).To(Equal("body from middleware"))
+}
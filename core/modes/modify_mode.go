@@ -1,15 +1,18 @@
 package modes
 
 import (
+	"context"
 	"io/ioutil"
 	"net/http"
 
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/middleware"
 	"github.com/SpectoLabs/hoverfly/core/models"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
 )
 
 type HoverflyModify interface {
-	ApplyMiddleware(models.RequestResponsePair) (models.RequestResponsePair, error)
+	ApplyMiddleware(context.Context, string, models.RequestResponsePair) (models.RequestResponsePair, error)
 	DoRequest(*http.Request) (*http.Response, error)
 }
 
@@ -26,7 +29,7 @@ func (this *ModifyMode) View() v2.ModeView {
 func (this *ModifyMode) SetArguments(arguments ModeArguments) {}
 
 func (this ModifyMode) Process(request *http.Request, details models.RequestDetails) (*http.Response, error) {
-	pair, err := this.Hoverfly.ApplyMiddleware(models.RequestResponsePair{Request: details})
+	pair, err := this.Hoverfly.ApplyMiddleware(requestContext(request), middleware.RequestPhase, models.RequestResponsePair{Request: details})
 	if err != nil {
 		return ReturnErrorAndLog(request, err, &pair, "There was an error when executing middleware", Modify)
 	}
@@ -36,6 +39,12 @@ func (this ModifyMode) Process(request *http.Request, details models.RequestDeta
 		return ReturnErrorAndLog(request, err, &pair, "There was an error when rebuilding the modified http request", Modify)
 	}
 
+	if err := forwardOriginalBody(request, details, pair, modifiedRequest); err != nil {
+		return ReturnErrorAndLog(request, err, &pair, "There was an error when reading the http request body", Modify)
+	}
+
+	modifiedRequest = modifiedRequest.WithContext(requestContext(request))
+
 	resp, err := this.Hoverfly.DoRequest(modifiedRequest)
 	if err != nil {
 		return ReturnErrorAndLog(request, err, &pair, "There was an error when forwarding the request to the intended desintation", Modify)
@@ -46,13 +55,19 @@ func (this ModifyMode) Process(request *http.Request, details models.RequestDeta
 		return ReturnErrorAndLog(request, err, &pair, "There was an error when reading the http response body", Modify)
 	}
 
+	body, headers, err := models.DecodeBody(string(bodyBytes), resp.Header)
+	if err != nil {
+		log.WithField("error", err.Error()).Warn("Failed to decode response body, keeping it as it is")
+	}
+
 	pair.Response = models.ResponseDetails{
-		Status:  resp.StatusCode,
-		Body:    string(bodyBytes),
-		Headers: resp.Header,
+		Status:          resp.StatusCode,
+		Body:            body,
+		Headers:         headers,
+		ContentEncoding: models.DecodedContentEncoding(resp.Header, headers),
 	}
 
-	pair, err = this.Hoverfly.ApplyMiddleware(pair)
+	pair, err = this.Hoverfly.ApplyMiddleware(requestContext(request), middleware.ResponsePhase, pair)
 	if err != nil {
 		return ReturnErrorAndLog(request, err, &pair, "There was an error when executing middleware", Modify)
 	}
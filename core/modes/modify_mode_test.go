@@ -2,6 +2,7 @@ package modes_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -28,7 +29,7 @@ func (this hoverflyModifyStub) DoRequest(request *http.Request) (*http.Response,
 	return response, nil
 }
 
-func (this hoverflyModifyStub) ApplyMiddleware(pair models.RequestResponsePair) (models.RequestResponsePair, error) {
+func (this hoverflyModifyStub) ApplyMiddleware(ctx context.Context, phase string, pair models.RequestResponsePair) (models.RequestResponsePair, error) {
 	if pair.Request.Path == "/middleware-error" {
 		return pair, errors.New("middleware-error")
 	}
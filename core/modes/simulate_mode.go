@@ -1,16 +1,18 @@
 package modes
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/SpectoLabs/hoverfly/core/matching"
+	"github.com/SpectoLabs/hoverfly/core/middleware"
 	"github.com/SpectoLabs/hoverfly/core/models"
 	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
 )
 
 type HoverflySimulate interface {
-	GetResponse(models.RequestDetails) (*models.ResponseDetails, *matching.MatchingError)
-	ApplyMiddleware(models.RequestResponsePair) (models.RequestResponsePair, error)
+	GetResponse(context.Context, models.RequestDetails) (*models.ResponseDetails, *matching.MatchingError)
+	ApplyMiddleware(context.Context, string, models.RequestResponsePair) (models.RequestResponsePair, error)
 }
 
 type SimulateMode struct {
@@ -40,14 +42,14 @@ func (this SimulateMode) Process(request *http.Request, details models.RequestDe
 		Request: details,
 	}
 
-	response, matchingErr := this.Hoverfly.GetResponse(details)
+	response, matchingErr := this.Hoverfly.GetResponse(requestContext(request), details)
 	if matchingErr != nil {
 		return ReturnErrorAndLog(request, matchingErr, &pair, "There was an error when matching", Simulate)
 	}
 
 	pair.Response = *response
 
-	pair, err := this.Hoverfly.ApplyMiddleware(pair)
+	pair, err := this.Hoverfly.ApplyMiddleware(requestContext(request), middleware.ResponsePhase, pair)
 	if err != nil {
 		return ReturnErrorAndLog(request, err, &pair, "There was an error when executing middleware", Simulate)
 	}
@@ -1,6 +1,7 @@
 package modes_test
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -14,7 +15,7 @@ import (
 
 type hoverflySimulateStub struct{}
 
-func (this hoverflySimulateStub) GetResponse(request models.RequestDetails) (*models.ResponseDetails, *matching.MatchingError) {
+func (this hoverflySimulateStub) GetResponse(ctx context.Context, request models.RequestDetails) (*models.ResponseDetails, *matching.MatchingError) {
 	if request.Destination == "positive-match.com" {
 		return &models.ResponseDetails{
 			Status: 200,
@@ -27,7 +28,7 @@ func (this hoverflySimulateStub) GetResponse(request models.RequestDetails) (*mo
 	}
 }
 
-func (this hoverflySimulateStub) ApplyMiddleware(pair models.RequestResponsePair) (models.RequestResponsePair, error) {
+func (this hoverflySimulateStub) ApplyMiddleware(ctx context.Context, phase string, pair models.RequestResponsePair) (models.RequestResponsePair, error) {
 	if pair.Request.Path == "middleware-error" {
 		return pair, errors.New("middleware-error")
 	}
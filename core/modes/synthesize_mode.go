@@ -1,9 +1,11 @@
 package modes
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
+	"github.com/SpectoLabs/hoverfly/core/middleware"
 	"github.com/SpectoLabs/hoverfly/core/models"
 
 	log "github.com/Sirupsen/logrus"
@@ -11,7 +13,7 @@ import (
 )
 
 type HoverflySynthesize interface {
-	ApplyMiddleware(models.RequestResponsePair) (models.RequestResponsePair, error)
+	ApplyMiddleware(context.Context, string, models.RequestResponsePair) (models.RequestResponsePair, error)
 	IsMiddlewareSet() bool
 }
 
@@ -41,7 +43,7 @@ func (this SynthesizeMode) Process(request *http.Request, details models.Request
 		return ReturnErrorAndLog(request, err, &pair, "There was an error when creating a synthetic response", Synthesize)
 	}
 
-	pair, err := this.Hoverfly.ApplyMiddleware(pair)
+	pair, err := this.Hoverfly.ApplyMiddleware(requestContext(request), middleware.ResponsePhase, pair)
 	if err != nil {
 		return ReturnErrorAndLog(request, err, &pair, "There was an error when executing middleware", Synthesize)
 	}
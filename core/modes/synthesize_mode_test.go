@@ -1,6 +1,7 @@
 package modes_test
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -15,7 +16,7 @@ type hoverflySynthesizeStub struct {
 	MiddlewareSet bool
 }
 
-func (this hoverflySynthesizeStub) ApplyMiddleware(pair models.RequestResponsePair) (models.RequestResponsePair, error) {
+func (this hoverflySynthesizeStub) ApplyMiddleware(ctx context.Context, phase string, pair models.RequestResponsePair) (models.RequestResponsePair, error) {
 	if pair.Request.Destination == "error.com" {
 		return pair, errors.New("Middleware failed")
 	}
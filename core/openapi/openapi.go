@@ -0,0 +1,584 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/util"
+	"gopkg.in/yaml.v2"
+)
+
+// the order in which the operations of a path become request response pairs
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+var pathParameter = regexp.MustCompile(`\{[^/}]+\}`)
+
+// Document - an OpenAPI 2 (Swagger) or OpenAPI 3 document, kept as the generic
+// structure it was decoded into so that references can be resolved by JSON pointer
+type Document struct {
+	Version     int
+	Destination string
+	BasePath    string
+	Operations  []Operation
+
+	spec map[string]interface{}
+}
+
+// Contract - a document which requests and responses are validated against. When
+// RejectInvalidRequests is set, simulate mode responds to invalid requests with a 400
+type Contract struct {
+	Document              *Document
+	Source                string
+	RejectInvalidRequests bool
+}
+
+// Operation - a method of a path in the document
+type Operation struct {
+	Method string
+	Path   string
+
+	// PathRegex matches the request paths of the operation, including the base path of the API
+	PathRegex string
+
+	spec           map[string]interface{}
+	pathSpec       map[string]interface{}
+	pathMatcher    *regexp.Regexp
+	parameterNames []string
+}
+
+// IsDocument - whether data is an OpenAPI or Swagger document rather than a simulation
+func IsDocument(data []byte) bool {
+	spec, err := decode(data)
+	if err != nil {
+		return false
+	}
+
+	_, isSwagger := spec["swagger"]
+	_, isOpenApi := spec["openapi"]
+
+	return isSwagger || isOpenApi
+}
+
+// Parse - reads an OpenAPI 2 or 3 document in either JSON or YAML
+func Parse(data []byte) (*Document, error) {
+	spec, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid OpenAPI document: %s", err.Error())
+	}
+
+	document := &Document{spec: spec}
+
+	if version, ok := spec["swagger"]; ok && strings.HasPrefix(fmt.Sprint(version), "2") {
+		document.Version = 2
+		document.Destination = stringValue(spec["host"])
+		document.BasePath = stringValue(spec["basePath"])
+	} else if version, ok := spec["openapi"]; ok && strings.HasPrefix(fmt.Sprint(version), "3") {
+		document.Version = 3
+		document.Destination, document.BasePath = serverLocation(spec["servers"])
+	} else {
+		return nil, fmt.Errorf("Invalid OpenAPI document: only Swagger 2.0 and OpenAPI 3 documents are supported")
+	}
+
+	document.BasePath = strings.TrimSuffix(document.BasePath, "/")
+
+	paths := mapValue(spec["paths"])
+	for _, path := range sortedPaths(paths) {
+		pathItem := document.resolve(paths[path])
+		for _, method := range methods {
+			operation := mapValue(pathItem[method])
+			if operation == nil {
+				continue
+			}
+
+			document.Operations = append(document.Operations, Operation{
+				Method:         strings.ToUpper(method),
+				Path:           path,
+				PathRegex:      pathRegex(document.BasePath + path),
+				spec:           operation,
+				pathSpec:       pathItem,
+				pathMatcher:    regexp.MustCompile(pathCaptureRegex(document.BasePath + path)),
+				parameterNames: pathParameterNames(path),
+			})
+		}
+	}
+
+	return document, nil
+}
+
+// NewSimulationView - builds a simulation with a request response pair for each operation of
+// the document. Responses use the examples in the document, or sample data generated from
+// the response schema when there are none
+func NewSimulationView(document *Document) v2.SimulationViewV2 {
+	pairs := []v2.RequestMatcherResponsePairViewV2{}
+
+	for _, operation := range document.Operations {
+		requestMatcher := v2.RequestMatcherViewV2{
+			Method: &v2.RequestFieldMatchersView{
+				ExactMatch: util.StringToPointer(operation.Method),
+			},
+			Path: &v2.RequestFieldMatchersView{
+				RegexMatch: util.StringToPointer(operation.PathRegex),
+			},
+		}
+
+		if document.Destination != "" {
+			requestMatcher.Destination = &v2.RequestFieldMatchersView{
+				ExactMatch: util.StringToPointer(document.Destination),
+			}
+		}
+
+		pairs = append(pairs, v2.RequestMatcherResponsePairViewV2{
+			RequestMatcher: requestMatcher,
+			Response:       document.response(operation),
+		})
+	}
+
+	return v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: pairs,
+		},
+		MetaView: v2.MetaView{
+			SchemaVersion: "v2",
+		},
+	}
+}
+
+func (this *Document) response(operation Operation) v2.ResponseDetailsView {
+	responses := mapValue(operation.spec["responses"])
+
+	status, key := responseStatus(responses)
+	response := this.resolve(responses[key])
+
+	var mediaType string
+	var example interface{}
+	if this.Version == 2 {
+		mediaType, example = this.swaggerExample(operation, response)
+	} else {
+		mediaType, example = this.openApiExample(response)
+	}
+
+	view := v2.ResponseDetailsView{
+		Status: status,
+	}
+
+	if example != nil {
+		if text, ok := example.(string); ok && !isJSONMediaType(mediaType) {
+			view.Body = text
+		} else {
+			body, _ := json.Marshal(example)
+			view.Body = string(body)
+		}
+
+		view.Headers = map[string][]string{
+			"Content-Type": []string{mediaType},
+		}
+	}
+
+	return view
+}
+
+func (this *Document) swaggerExample(operation Operation, response map[string]interface{}) (string, interface{}) {
+	produces := stringValues(operation.spec["produces"])
+	if len(produces) == 0 {
+		produces = stringValues(this.spec["produces"])
+	}
+
+	examples := mapValue(response["examples"])
+	if len(examples) > 0 {
+		mediaType := preferredMediaType(examples, produces)
+		return mediaType, examples[mediaType]
+	}
+
+	if response["schema"] == nil {
+		return "", nil
+	}
+
+	mediaType := "application/json"
+	for _, produced := range produces {
+		if isJSONMediaType(produced) {
+			mediaType = produced
+			break
+		}
+	}
+
+	return mediaType, this.Sample(response["schema"])
+}
+
+func (this *Document) openApiExample(response map[string]interface{}) (string, interface{}) {
+	content := mapValue(response["content"])
+	if len(content) == 0 {
+		return "", nil
+	}
+
+	mediaType := preferredMediaType(content, nil)
+	media := mapValue(content[mediaType])
+
+	if example, ok := media["example"]; ok {
+		return mediaType, example
+	}
+
+	examples := mapValue(media["examples"])
+	if len(examples) > 0 {
+		example := this.resolve(examples[sortedKeys(examples)[0]])
+		return mediaType, example["value"]
+	}
+
+	if media["schema"] == nil {
+		return mediaType, nil
+	}
+
+	return mediaType, this.Sample(media["schema"])
+}
+
+// Sample - generates data which conforms to a schema, using the examples,
+// defaults and enums in the schema where it has them
+func (this *Document) Sample(schema interface{}) interface{} {
+	return this.sample(schema, map[string]bool{})
+}
+
+func (this *Document) sample(schemaValue interface{}, references map[string]bool) interface{} {
+	schema := mapValue(schemaValue)
+	if schema == nil {
+		return nil
+	}
+
+	if reference, ok := schema["$ref"].(string); ok {
+		// recursive schemas end where they refer back to themselves
+		if references[reference] {
+			return nil
+		}
+
+		references[reference] = true
+		defer delete(references, reference)
+
+		return this.sample(this.lookup(reference), references)
+	}
+
+	if example, ok := schema["example"]; ok {
+		return example
+	}
+
+	if value, ok := schema["default"]; ok {
+		return value
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		merged := map[string]interface{}{}
+		for _, subSchema := range allOf {
+			for key, value := range mapValue(this.sample(subSchema, references)) {
+				merged[key] = value
+			}
+		}
+
+		return merged
+	}
+
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if subSchemas, ok := schema[keyword].([]interface{}); ok && len(subSchemas) > 0 {
+			return this.sample(subSchemas[0], references)
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		object := map[string]interface{}{}
+		properties := mapValue(schema["properties"])
+		for name, property := range properties {
+			object[name] = this.sample(property, references)
+		}
+
+		return object
+	case "array":
+		item := this.sample(schema["items"], references)
+		if item == nil {
+			return []interface{}{}
+		}
+
+		return []interface{}{item}
+	case "string":
+		return sampleString(stringValue(schema["format"]))
+	case "integer", "number":
+		if minimum, ok := schema["minimum"]; ok {
+			return minimum
+		}
+
+		return 0
+	case "boolean":
+		return true
+	}
+
+	return nil
+}
+
+// resolve - follows a reference to another part of the document, if value is one
+func (this *Document) resolve(value interface{}) map[string]interface{} {
+	object := mapValue(value)
+	if reference, ok := object["$ref"].(string); ok {
+		return mapValue(this.lookup(reference))
+	}
+
+	return object
+}
+
+// lookup - finds the part of the document a local JSON pointer such as #/definitions/Pet refers to
+func (this *Document) lookup(reference string) interface{} {
+	if !strings.HasPrefix(reference, "#/") {
+		return nil
+	}
+
+	var current interface{} = this.spec
+	for _, token := range strings.Split(strings.TrimPrefix(reference, "#/"), "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+
+		switch value := current.(type) {
+		case map[string]interface{}:
+			current = value[token]
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(value) {
+				return nil
+			}
+			current = value[index]
+		default:
+			return nil
+		}
+	}
+
+	return current
+}
+
+func schemaType(schema map[string]interface{}) string {
+	switch value := schema["type"].(type) {
+	case string:
+		return value
+	case []interface{}:
+		// OpenAPI 3.1 allows a list of types, such as ["string", "null"]
+		for _, schemaType := range value {
+			if schemaType != "null" {
+				return stringValue(schemaType)
+			}
+		}
+	}
+
+	if schema["properties"] != nil {
+		return "object"
+	}
+
+	if schema["items"] != nil {
+		return "array"
+	}
+
+	return ""
+}
+
+func sampleString(format string) string {
+	switch format {
+	case "date-time":
+		return "2017-01-01T00:00:00Z"
+	case "date":
+		return "2017-01-01"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "uri", "url":
+		return "http://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "127.0.0.1"
+	case "ipv6":
+		return "::1"
+	case "byte":
+		return "c3RyaW5n"
+	}
+
+	return "string"
+}
+
+// responseStatus - picks the response to simulate, preferring the first success response
+func responseStatus(responses map[string]interface{}) (int, string) {
+	keys := sortedKeys(responses)
+
+	for _, key := range keys {
+		if strings.HasPrefix(key, "2") {
+			if status, err := strconv.Atoi(key); err == nil {
+				return status, key
+			}
+
+			// OpenAPI 3 ranges such as 2XX
+			return 200, key
+		}
+	}
+
+	if _, ok := responses["default"]; ok {
+		return 200, "default"
+	}
+
+	for _, key := range keys {
+		if status, err := strconv.Atoi(key); err == nil {
+			return status, key
+		}
+	}
+
+	return 200, ""
+}
+
+func preferredMediaType(values map[string]interface{}, produces []string) string {
+	for _, mediaType := range produces {
+		if _, ok := values[mediaType]; ok && isJSONMediaType(mediaType) {
+			return mediaType
+		}
+	}
+
+	keys := sortedKeys(values)
+	for _, mediaType := range keys {
+		if isJSONMediaType(mediaType) {
+			return mediaType
+		}
+	}
+
+	return keys[0]
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return strings.Contains(mediaType, "json")
+}
+
+// serverLocation - the host and base path of the first server of an OpenAPI 3 document
+func serverLocation(value interface{}) (string, string) {
+	servers, ok := value.([]interface{})
+	if !ok || len(servers) == 0 {
+		return "", ""
+	}
+
+	server := mapValue(servers[0])
+	serverURL := stringValue(server["url"])
+
+	variables := mapValue(server["variables"])
+	for name, variable := range variables {
+		serverURL = strings.Replace(serverURL, "{"+name+"}", stringValue(mapValue(variable)["default"]), -1)
+	}
+
+	parsed, err := url.Parse(serverURL)
+	if err != nil {
+		return "", ""
+	}
+
+	return parsed.Host, parsed.Path
+}
+
+func pathRegex(path string) string {
+	regex := []string{"^"}
+	last := 0
+	for _, location := range pathParameter.FindAllStringIndex(path, -1) {
+		regex = append(regex, regexp.QuoteMeta(path[last:location[0]]), "[^/]+")
+		last = location[1]
+	}
+	regex = append(regex, regexp.QuoteMeta(path[last:]), "$")
+
+	return strings.Join(regex, "")
+}
+
+// pathCaptureRegex - the same as pathRegex, capturing the value of each path parameter
+func pathCaptureRegex(path string) string {
+	return strings.Replace(pathRegex(path), "[^/]+", "([^/]+)", -1)
+}
+
+func pathParameterNames(path string) []string {
+	names := []string{}
+	for _, parameter := range pathParameter.FindAllString(path, -1) {
+		names = append(names, strings.Trim(parameter, "{}"))
+	}
+
+	return names
+}
+
+// sortedPaths - orders paths so that paths with fewer parameters come first, as
+// /pets/mine has to be tried before /pets/{id} for it to ever be matched
+func sortedPaths(paths map[string]interface{}) []string {
+	keys := sortedKeys(paths)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return len(pathParameter.FindAllString(keys[i], -1)) < len(pathParameter.FindAllString(keys[j], -1))
+	})
+
+	return keys
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// decode - unmarshals JSON or YAML into maps with string keys
+func decode(data []byte) (map[string]interface{}, error) {
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err == nil {
+		return spec, nil
+	}
+
+	var yamlSpec interface{}
+	if err := yaml.Unmarshal(data, &yamlSpec); err != nil {
+		return nil, err
+	}
+
+	spec = mapValue(fromYAML(yamlSpec))
+	if spec == nil {
+		return nil, fmt.Errorf("document is not an object")
+	}
+
+	return spec, nil
+}
+
+func fromYAML(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		object := map[string]interface{}{}
+		for key, item := range typed {
+			object[fmt.Sprint(key)] = fromYAML(item)
+		}
+		return object
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = fromYAML(item)
+		}
+		return typed
+	}
+
+	return value
+}
+
+func mapValue(value interface{}) map[string]interface{} {
+	object, _ := value.(map[string]interface{})
+	return object
+}
+
+func stringValue(value interface{}) string {
+	text, _ := value.(string)
+	return text
+}
+
+func stringValues(value interface{}) []string {
+	values := []string{}
+	list, _ := value.([]interface{})
+	for _, item := range list {
+		values = append(values, stringValue(item))
+	}
+
+	return values
+}
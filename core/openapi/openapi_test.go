@@ -0,0 +1,240 @@
+package openapi_test
+
+import (
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/openapi"
+	. "github.com/onsi/gomega"
+)
+
+const swaggerDocument = `{
+	"swagger": "2.0",
+	"host": "petstore.swagger.io",
+	"basePath": "/v2/",
+	"produces": ["application/json"],
+	"paths": {
+		"/pets/{petId}": {
+			"get": {
+				"responses": {
+					"404": {"description": "not found"},
+					"200": {
+						"description": "a pet",
+						"schema": {"$ref": "#/definitions/Pet"}
+					}
+				}
+			},
+			"delete": {
+				"responses": {
+					"204": {"description": "deleted"}
+				}
+			}
+		},
+		"/pets/mine": {
+			"get": {
+				"responses": {
+					"200": {
+						"description": "my pets",
+						"examples": {
+							"application/json": [{"id": 1, "name": "Rex"}]
+						}
+					}
+				}
+			}
+		}
+	},
+	"definitions": {
+		"Pet": {
+			"type": "object",
+			"required": ["id", "name"],
+			"properties": {
+				"id": {"type": "integer", "format": "int64"},
+				"name": {"type": "string", "example": "Rex"},
+				"status": {"type": "string", "enum": ["available", "sold"]},
+				"tags": {"type": "array", "items": {"type": "string"}},
+				"parent": {"$ref": "#/definitions/Pet"}
+			}
+		}
+	}
+}`
+
+const openApiDocument = `
+openapi: 3.0.0
+servers:
+  - url: "{scheme}://api.example.com/{version}"
+    variables:
+      scheme:
+        default: https
+      version:
+        default: v1
+paths:
+  /users/{id}:
+    get:
+      responses:
+        default:
+          description: a user
+          content:
+            text/plain:
+              schema:
+                type: string
+            application/json:
+              examples:
+                bob:
+                  $ref: "#/components/examples/Bob"
+  /users:
+    post:
+      responses:
+        201:
+          description: created
+          content:
+            application/json:
+              schema:
+                allOf:
+                  - $ref: "#/components/schemas/User"
+                  - type: object
+                    properties:
+                      created:
+                        type: string
+                        format: date-time
+components:
+  examples:
+    Bob:
+      value:
+        name: Bob
+  schemas:
+    User:
+      type: object
+      properties:
+        name:
+          type: string
+        admin:
+          type: boolean
+`
+
+func Test_IsDocument_DetectsOpenApiAndSwaggerDocuments(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(openapi.IsDocument([]byte(swaggerDocument))).To(BeTrue())
+	Expect(openapi.IsDocument([]byte(openApiDocument))).To(BeTrue())
+	Expect(openapi.IsDocument([]byte(`{"data": {"pairs": []}, "meta": {"schemaVersion": "v2"}}`))).To(BeFalse())
+	Expect(openapi.IsDocument([]byte(`not a document`))).To(BeFalse())
+}
+
+func Test_Parse_ErrorsOnUnsupportedVersion(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := openapi.Parse([]byte(`{"swagger": "1.2", "paths": {}}`))
+
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Invalid OpenAPI document: only Swagger 2.0 and OpenAPI 3 documents are supported"))
+}
+
+func Test_Parse_ReadsSwaggerOperations(t *testing.T) {
+	RegisterTestingT(t)
+
+	document, err := openapi.Parse([]byte(swaggerDocument))
+	Expect(err).To(BeNil())
+
+	Expect(document.Version).To(Equal(2))
+	Expect(document.Destination).To(Equal("petstore.swagger.io"))
+	Expect(document.BasePath).To(Equal("/v2"))
+
+	Expect(document.Operations).To(HaveLen(3))
+
+	Expect(document.Operations[0].Method).To(Equal("GET"))
+	Expect(document.Operations[0].Path).To(Equal("/pets/mine"))
+	Expect(document.Operations[0].PathRegex).To(Equal("^/v2/pets/mine$"))
+
+	Expect(document.Operations[1].Method).To(Equal("GET"))
+	Expect(document.Operations[1].PathRegex).To(Equal("^/v2/pets/[^/]+$"))
+
+	Expect(document.Operations[2].Method).To(Equal("DELETE"))
+}
+
+func Test_NewSimulationView_UsesSwaggerExamplesAndSchemas(t *testing.T) {
+	RegisterTestingT(t)
+
+	document, err := openapi.Parse([]byte(swaggerDocument))
+	Expect(err).To(BeNil())
+
+	simulation := openapi.NewSimulationView(document)
+
+	Expect(simulation.SchemaVersion).To(Equal("v2"))
+
+	pairs := simulation.RequestResponsePairs
+	Expect(pairs).To(HaveLen(3))
+
+	Expect(*pairs[0].RequestMatcher.Method.ExactMatch).To(Equal("GET"))
+	Expect(*pairs[0].RequestMatcher.Path.RegexMatch).To(Equal("^/v2/pets/mine$"))
+	Expect(*pairs[0].RequestMatcher.Destination.ExactMatch).To(Equal("petstore.swagger.io"))
+	Expect(pairs[0].Response.Status).To(Equal(200))
+	Expect(pairs[0].Response.Body).To(MatchJSON(`[{"id": 1, "name": "Rex"}]`))
+	Expect(pairs[0].Response.Headers["Content-Type"]).To(Equal([]string{"application/json"}))
+
+	Expect(pairs[1].Response.Status).To(Equal(200))
+	Expect(pairs[1].Response.Body).To(MatchJSON(`{
+		"id": 0,
+		"name": "Rex",
+		"status": "available",
+		"tags": ["string"],
+		"parent": null
+	}`))
+
+	Expect(*pairs[2].RequestMatcher.Method.ExactMatch).To(Equal("DELETE"))
+	Expect(pairs[2].Response.Status).To(Equal(204))
+	Expect(pairs[2].Response.Body).To(Equal(""))
+	Expect(pairs[2].Response.Headers).To(BeNil())
+}
+
+func Test_NewSimulationView_UsesOpenApiExamplesAndSchemas(t *testing.T) {
+	RegisterTestingT(t)
+
+	document, err := openapi.Parse([]byte(openApiDocument))
+	Expect(err).To(BeNil())
+
+	Expect(document.Version).To(Equal(3))
+	Expect(document.Destination).To(Equal("api.example.com"))
+	Expect(document.BasePath).To(Equal("/v1"))
+
+	pairs := openapi.NewSimulationView(document).RequestResponsePairs
+	Expect(pairs).To(HaveLen(2))
+
+	Expect(*pairs[0].RequestMatcher.Method.ExactMatch).To(Equal("POST"))
+	Expect(*pairs[0].RequestMatcher.Path.RegexMatch).To(Equal("^/v1/users$"))
+	Expect(pairs[0].Response.Status).To(Equal(201))
+	Expect(pairs[0].Response.Body).To(MatchJSON(`{
+		"name": "string",
+		"admin": true,
+		"created": "2017-01-01T00:00:00Z"
+	}`))
+
+	Expect(*pairs[1].RequestMatcher.Path.RegexMatch).To(Equal("^/v1/users/[^/]+$"))
+	Expect(pairs[1].Response.Status).To(Equal(200))
+	Expect(pairs[1].Response.Body).To(MatchJSON(`{"name": "Bob"}`))
+	Expect(pairs[1].Response.Headers["Content-Type"]).To(Equal([]string{"application/json"}))
+}
+
+func Test_NewSimulationView_UsesTextExamplesAsTheBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	document, err := openapi.Parse([]byte(`
+openapi: 3.0.1
+paths:
+  /health:
+    get:
+      responses:
+        200:
+          description: healthy
+          content:
+            text/plain:
+              example: OK
+`))
+	Expect(err).To(BeNil())
+
+	pairs := openapi.NewSimulationView(document).RequestResponsePairs
+	Expect(pairs).To(HaveLen(1))
+
+	Expect(pairs[0].RequestMatcher.Destination).To(BeNil())
+	Expect(*pairs[0].RequestMatcher.Path.RegexMatch).To(Equal("^/health$"))
+	Expect(pairs[0].Response.Body).To(Equal("OK"))
+	Expect(pairs[0].Response.Headers["Content-Type"]).To(Equal([]string{"text/plain"}))
+}
@@ -0,0 +1,469 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+)
+
+// ValidateRequest - checks that a request is for an operation in the document, and that its
+// parameters and body are valid. Requests to other destinations than the one in the document
+// are not part of the contract, so are always valid
+func (this *Document) ValidateRequest(request models.RequestDetails) []string {
+	if !this.coversDestination(request.Destination) {
+		return nil
+	}
+
+	operation, errors := this.findOperation(request)
+	if operation == nil {
+		return errors
+	}
+
+	parameters := this.parameters(*operation)
+
+	pathValues := map[string]string{}
+	matches := operation.pathMatcher.FindStringSubmatch(request.Path)
+	for i, name := range operation.parameterNames {
+		if i+1 < len(matches) {
+			pathValues[name], _ = url.PathUnescape(matches[i+1])
+		}
+	}
+
+	query, _ := url.ParseQuery(request.Query)
+	headers := http.Header(request.Headers)
+
+	for _, parameter := range parameters {
+		name := stringValue(parameter["name"])
+		location := stringValue(parameter["in"])
+
+		var values []string
+		switch location {
+		case "path":
+			if value, ok := pathValues[name]; ok {
+				values = []string{value}
+			}
+		case "query":
+			values = query[name]
+		case "header":
+			values = headers[http.CanonicalHeaderKey(name)]
+		case "body":
+			errors = append(errors, this.validateBody("request body", request.Body, headers.Get("Content-Type"), parameter["schema"], parameter["required"] == true)...)
+			continue
+		default:
+			continue
+		}
+
+		description := fmt.Sprintf("%s parameter %s", location, name)
+
+		if len(values) == 0 {
+			if parameter["required"] == true {
+				errors = append(errors, description+" is required")
+			}
+			continue
+		}
+
+		// OpenAPI 3 describes parameters with a schema, Swagger 2 puts the schema on the parameter
+		schema := parameter["schema"]
+		if schema == nil {
+			schema = parameter
+		}
+
+		for _, value := range values {
+			errors = append(errors, this.validateValue(parseParameter(value, this.resolve(schema)), schema, description)...)
+		}
+	}
+
+	if requestBody := this.resolve(operation.spec["requestBody"]); requestBody != nil {
+		schema := this.contentSchema(mapValue(requestBody["content"]), headers.Get("Content-Type"))
+		errors = append(errors, this.validateBody("request body", request.Body, headers.Get("Content-Type"), schema, requestBody["required"] == true)...)
+	}
+
+	return errors
+}
+
+// ValidateResponse - checks that the status of a response is one the operation can respond
+// with, and that its body conforms to the schema for the status
+func (this *Document) ValidateResponse(request models.RequestDetails, response models.ResponseDetails) []string {
+	if !this.coversDestination(request.Destination) {
+		return nil
+	}
+
+	operation, _ := this.findOperation(request)
+	if operation == nil {
+		return nil
+	}
+
+	responses := mapValue(operation.spec["responses"])
+
+	status := strconv.Itoa(response.Status)
+	responseSpec, ok := responses[status]
+	if !ok {
+		responseSpec, ok = responses[status[:1]+"XX"]
+	}
+	if !ok {
+		responseSpec, ok = responses["default"]
+	}
+	if !ok {
+		return []string{fmt.Sprintf("response status %v is not one of the responses of %s %s", response.Status, operation.Method, operation.Path)}
+	}
+
+	resolved := this.resolve(responseSpec)
+	contentType := http.Header(response.Headers).Get("Content-Type")
+
+	schema := resolved["schema"]
+	if this.Version == 3 {
+		schema = this.contentSchema(mapValue(resolved["content"]), contentType)
+	}
+
+	body := response.Body + models.JoinResponseChunks(response.Chunks)
+
+	return this.validateBody("response body", body, contentType, schema, false)
+}
+
+func (this *Document) coversDestination(destination string) bool {
+	return this.Destination == "" || strings.EqualFold(this.Destination, destination)
+}
+
+func (this *Document) findOperation(request models.RequestDetails) (*Operation, []string) {
+	pathFound := false
+	for i, operation := range this.Operations {
+		if !operation.pathMatcher.MatchString(request.Path) {
+			continue
+		}
+
+		pathFound = true
+		if operation.Method == strings.ToUpper(request.Method) {
+			return &this.Operations[i], nil
+		}
+	}
+
+	if pathFound {
+		return nil, []string{fmt.Sprintf("method %s is not allowed for path %s", request.Method, request.Path)}
+	}
+
+	return nil, []string{fmt.Sprintf("path %s is not in the contract", request.Path)}
+}
+
+// parameters - the parameters of an operation, including those shared by every operation
+// of the path unless the operation overrides them
+func (this *Document) parameters(operation Operation) []map[string]interface{} {
+	parameters := []map[string]interface{}{}
+	overridden := map[string]bool{}
+
+	for _, value := range listValue(operation.spec["parameters"]) {
+		parameter := this.resolve(value)
+		overridden[stringValue(parameter["in"])+":"+stringValue(parameter["name"])] = true
+		parameters = append(parameters, parameter)
+	}
+
+	for _, value := range listValue(operation.pathSpec["parameters"]) {
+		parameter := this.resolve(value)
+		if !overridden[stringValue(parameter["in"])+":"+stringValue(parameter["name"])] {
+			parameters = append(parameters, parameter)
+		}
+	}
+
+	return parameters
+}
+
+// contentSchema - the schema for the media type of a body, or for the first JSON media type
+func (this *Document) contentSchema(content map[string]interface{}, contentType string) interface{} {
+	if len(content) == 0 {
+		return nil
+	}
+
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	if media, ok := content[mediaType]; ok {
+		return mapValue(media)["schema"]
+	}
+
+	return mapValue(content[preferredMediaType(content, nil)])["schema"]
+}
+
+func (this *Document) validateBody(description, body, contentType string, schema interface{}, required bool) []string {
+	if body == "" {
+		if required {
+			return []string{description + " is required"}
+		}
+		return nil
+	}
+
+	// only JSON bodies can be checked against a schema
+	if schema == nil || (contentType != "" && !isJSONMediaType(contentType)) {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(body), &value); err != nil {
+		return []string{description + " is not valid JSON"}
+	}
+
+	return this.validateValue(value, schema, description)
+}
+
+// validateValue - checks a value decoded from JSON against a schema, describing
+// each way in which it does not conform
+func (this *Document) validateValue(value interface{}, schemaValue interface{}, description string) []string {
+	schema := this.resolve(schemaValue)
+	if schema == nil {
+		return nil
+	}
+
+	errors := []string{}
+
+	for _, subSchema := range listValue(schema["allOf"]) {
+		errors = append(errors, this.validateValue(value, subSchema, description)...)
+	}
+
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		subSchemas := listValue(schema[keyword])
+		if len(subSchemas) == 0 {
+			continue
+		}
+
+		matched := false
+		for _, subSchema := range subSchemas {
+			if len(this.validateValue(value, subSchema, description)) == 0 {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			errors = append(errors, description+" does not match any of the schemas it could be")
+		}
+	}
+
+	if value == nil {
+		if schema["nullable"] == true || allowsNull(schema) || schemaType(schema) == "" {
+			return errors
+		}
+
+		return append(errors, fmt.Sprintf("%s should be %s but is null", description, article(schemaType(schema))))
+	}
+
+	if enum := listValue(schema["enum"]); len(enum) > 0 && !containsValue(enum, value) {
+		errors = append(errors, fmt.Sprintf("%s should be one of %s", description, describeValues(enum)))
+	}
+
+	expectedType := schemaType(schema)
+	if expectedType != "" && !isType(value, expectedType) {
+		return append(errors, fmt.Sprintf("%s should be %s but is %s", description, article(expectedType), article(typeOf(value))))
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		properties := mapValue(schema["properties"])
+
+		for _, name := range stringValues(schema["required"]) {
+			if _, ok := typed[name]; !ok {
+				errors = append(errors, fmt.Sprintf("%s.%s is required", description, name))
+			}
+		}
+
+		for _, name := range sortedKeys(typed) {
+			if property, ok := properties[name]; ok {
+				errors = append(errors, this.validateValue(typed[name], property, description+"."+name)...)
+			} else if schema["additionalProperties"] == false {
+				errors = append(errors, fmt.Sprintf("%s.%s is not allowed", description, name))
+			} else if additional := mapValue(schema["additionalProperties"]); additional != nil {
+				errors = append(errors, this.validateValue(typed[name], additional, description+"."+name)...)
+			}
+		}
+	case []interface{}:
+		if minimum, ok := numberValue(schema["minItems"]); ok && float64(len(typed)) < minimum {
+			errors = append(errors, fmt.Sprintf("%s should have at least %v items", description, minimum))
+		}
+
+		if maximum, ok := numberValue(schema["maxItems"]); ok && float64(len(typed)) > maximum {
+			errors = append(errors, fmt.Sprintf("%s should have at most %v items", description, maximum))
+		}
+
+		for i, item := range typed {
+			errors = append(errors, this.validateValue(item, schema["items"], fmt.Sprintf("%s[%v]", description, i))...)
+		}
+	case string:
+		errors = append(errors, validateString(typed, schema, description)...)
+	case float64:
+		errors = append(errors, validateNumber(typed, schema, description)...)
+	}
+
+	return errors
+}
+
+func validateString(value string, schema map[string]interface{}, description string) []string {
+	errors := []string{}
+
+	if minimum, ok := numberValue(schema["minLength"]); ok && float64(len([]rune(value))) < minimum {
+		errors = append(errors, fmt.Sprintf("%s should be at least %v characters", description, minimum))
+	}
+
+	if maximum, ok := numberValue(schema["maxLength"]); ok && float64(len([]rune(value))) > maximum {
+		errors = append(errors, fmt.Sprintf("%s should be at most %v characters", description, maximum))
+	}
+
+	if pattern := stringValue(schema["pattern"]); pattern != "" {
+		if matched, err := regexp.MatchString(pattern, value); err == nil && !matched {
+			errors = append(errors, fmt.Sprintf("%s should match %s", description, pattern))
+		}
+	}
+
+	switch stringValue(schema["format"]) {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			errors = append(errors, description+" should be a date-time")
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			errors = append(errors, description+" should be a date")
+		}
+	}
+
+	return errors
+}
+
+func validateNumber(value float64, schema map[string]interface{}, description string) []string {
+	errors := []string{}
+
+	if minimum, ok := numberValue(schema["minimum"]); ok {
+		if schema["exclusiveMinimum"] == true && value <= minimum {
+			errors = append(errors, fmt.Sprintf("%s should be greater than %v", description, minimum))
+		} else if value < minimum {
+			errors = append(errors, fmt.Sprintf("%s should be at least %v", description, minimum))
+		}
+	}
+
+	if maximum, ok := numberValue(schema["maximum"]); ok {
+		if schema["exclusiveMaximum"] == true && value >= maximum {
+			errors = append(errors, fmt.Sprintf("%s should be less than %v", description, maximum))
+		} else if value > maximum {
+			errors = append(errors, fmt.Sprintf("%s should be at most %v", description, maximum))
+		}
+	}
+
+	return errors
+}
+
+// parseParameter - parameters are always strings, so they are converted to the type
+// in their schema before being validated, leaving them as they are if they do not parse
+func parseParameter(value string, schema map[string]interface{}) interface{} {
+	switch schemaType(schema) {
+	case "integer", "number":
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return number
+		}
+	case "boolean":
+		if boolean, err := strconv.ParseBool(value); err == nil {
+			return boolean
+		}
+	case "array":
+		items := []interface{}{}
+		for _, item := range strings.Split(value, ",") {
+			items = append(items, parseParameter(item, mapValue(schema["items"])))
+		}
+		return items
+	}
+
+	return value
+}
+
+func isType(value interface{}, expectedType string) bool {
+	switch expectedType {
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == float64(int64(number))
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	}
+
+	return typeOf(value) == expectedType
+}
+
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+
+	return "null"
+}
+
+func article(typeName string) string {
+	if strings.IndexAny(typeName, "aeiou") == 0 {
+		return "an " + typeName
+	}
+
+	return "a " + typeName
+}
+
+func allowsNull(schema map[string]interface{}) bool {
+	for _, schemaType := range listValue(schema["type"]) {
+		if schemaType == "null" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if number, ok := numberValue(candidate); ok {
+			candidate = number
+		}
+
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func describeValues(values []interface{}) string {
+	descriptions := []string{}
+	for _, value := range values {
+		description, _ := json.Marshal(value)
+		descriptions = append(descriptions, string(description))
+	}
+
+	return strings.Join(descriptions, ", ")
+}
+
+// numberValue - numbers decoded from YAML are ints, while those decoded from JSON are float64s
+func numberValue(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case int:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case uint64:
+		return float64(number), true
+	}
+
+	return 0, false
+}
+
+func listValue(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
@@ -0,0 +1,291 @@
+package openapi_test
+
+import (
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/openapi"
+	. "github.com/onsi/gomega"
+)
+
+const contractDocument = `
+openapi: 3.0.0
+servers:
+  - url: http://api.example.com
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            maximum: 100
+        - name: X-Request-Id
+          in: header
+          required: true
+          schema:
+            type: string
+      responses:
+        200:
+          description: pets
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/Pet"
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/Pet"
+      responses:
+        201:
+          description: created
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema:
+          type: integer
+    get:
+      responses:
+        200:
+          description: a pet
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Pet"
+        4XX:
+          description: not found
+components:
+  schemas:
+    Pet:
+      type: object
+      required:
+        - id
+        - name
+      additionalProperties: false
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+          minLength: 1
+        status:
+          type: string
+          enum:
+            - available
+            - sold
+        tag:
+          type: string
+          nullable: true
+`
+
+func parseContract() *openapi.Document {
+	document, err := openapi.Parse([]byte(contractDocument))
+	Expect(err).To(BeNil())
+
+	return document
+}
+
+func Test_Document_ValidateRequest_AcceptsValidRequests(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := parseContract()
+
+	Expect(unit.ValidateRequest(models.RequestDetails{
+		Method:      "GET",
+		Destination: "api.example.com",
+		Path:        "/pets",
+		Query:       "limit=10",
+		Headers: map[string][]string{
+			"X-Request-Id": []string{"abc"},
+		},
+	})).To(BeEmpty())
+
+	Expect(unit.ValidateRequest(models.RequestDetails{
+		Method:      "POST",
+		Destination: "api.example.com",
+		Path:        "/pets",
+		Body:        `{"id": 1, "name": "Rex", "tag": null}`,
+		Headers: map[string][]string{
+			"Content-Type": []string{"application/json"},
+		},
+	})).To(BeEmpty())
+
+	Expect(unit.ValidateRequest(models.RequestDetails{
+		Method:      "GET",
+		Destination: "api.example.com",
+		Path:        "/pets/1",
+	})).To(BeEmpty())
+}
+
+func Test_Document_ValidateRequest_IgnoresOtherDestinations(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := parseContract()
+
+	Expect(unit.ValidateRequest(models.RequestDetails{
+		Method:      "GET",
+		Destination: "other.com",
+		Path:        "/unknown",
+	})).To(BeEmpty())
+}
+
+func Test_Document_ValidateRequest_RejectsUnknownPathsAndMethods(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := parseContract()
+
+	Expect(unit.ValidateRequest(models.RequestDetails{
+		Method:      "GET",
+		Destination: "api.example.com",
+		Path:        "/owners",
+	})).To(Equal([]string{"path /owners is not in the contract"}))
+
+	Expect(unit.ValidateRequest(models.RequestDetails{
+		Method:      "DELETE",
+		Destination: "api.example.com",
+		Path:        "/pets/1",
+	})).To(Equal([]string{"method DELETE is not allowed for path /pets/1"}))
+}
+
+func Test_Document_ValidateRequest_RejectsInvalidParameters(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := parseContract()
+
+	Expect(unit.ValidateRequest(models.RequestDetails{
+		Method:      "GET",
+		Destination: "api.example.com",
+		Path:        "/pets",
+		Query:       "limit=500",
+	})).To(Equal([]string{
+		"query parameter limit should be at most 100",
+		"header parameter X-Request-Id is required",
+	}))
+
+	Expect(unit.ValidateRequest(models.RequestDetails{
+		Method:      "GET",
+		Destination: "api.example.com",
+		Path:        "/pets/rex",
+	})).To(Equal([]string{
+		"path parameter petId should be an integer but is a string",
+	}))
+}
+
+func Test_Document_ValidateRequest_RejectsInvalidBodies(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := parseContract()
+
+	Expect(unit.ValidateRequest(models.RequestDetails{
+		Method:      "POST",
+		Destination: "api.example.com",
+		Path:        "/pets",
+	})).To(Equal([]string{"request body is required"}))
+
+	Expect(unit.ValidateRequest(models.RequestDetails{
+		Method:      "POST",
+		Destination: "api.example.com",
+		Path:        "/pets",
+		Body:        `{"name": "", "status": "lost", "colour": "black"}`,
+	})).To(Equal([]string{
+		"request body.id is required",
+		"request body.colour is not allowed",
+		"request body.name should be at least 1 characters",
+		`request body.status should be one of "available", "sold"`,
+	}))
+
+	Expect(unit.ValidateRequest(models.RequestDetails{
+		Method:      "POST",
+		Destination: "api.example.com",
+		Path:        "/pets",
+		Body:        `{"id": `,
+	})).To(Equal([]string{"request body is not valid JSON"}))
+}
+
+func Test_Document_ValidateResponse_ChecksStatusAndBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := parseContract()
+
+	request := models.RequestDetails{
+		Method:      "GET",
+		Destination: "api.example.com",
+		Path:        "/pets",
+	}
+
+	Expect(unit.ValidateResponse(request, models.ResponseDetails{
+		Status: 200,
+		Body:   `[{"id": 1, "name": "Rex"}]`,
+	})).To(BeEmpty())
+
+	Expect(unit.ValidateResponse(request, models.ResponseDetails{
+		Status: 200,
+		Body:   `[{"id": 1.5, "name": "Rex"}, {"id": 2}]`,
+	})).To(Equal([]string{
+		"response body[0].id should be an integer but is a number",
+		"response body[1].name is required",
+	}))
+
+	Expect(unit.ValidateResponse(request, models.ResponseDetails{
+		Status: 500,
+	})).To(Equal([]string{"response status 500 is not one of the responses of GET /pets"}))
+
+	Expect(unit.ValidateResponse(models.RequestDetails{
+		Method:      "GET",
+		Destination: "api.example.com",
+		Path:        "/pets/1",
+	}, models.ResponseDetails{
+		Status: 404,
+		Body:   "Not found",
+		Headers: map[string][]string{
+			"Content-Type": []string{"text/plain"},
+		},
+	})).To(BeEmpty())
+}
+
+func Test_Document_ValidateRequest_UsesSwaggerParametersAndBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit, err := openapi.Parse([]byte(`{
+		"swagger": "2.0",
+		"paths": {
+			"/orders": {
+				"post": {
+					"parameters": [
+						{"name": "dryRun", "in": "query", "type": "boolean"},
+						{
+							"name": "order",
+							"in": "body",
+							"required": true,
+							"schema": {
+								"type": "object",
+								"required": ["quantity"],
+								"properties": {"quantity": {"type": "integer", "minimum": 1}}
+							}
+						}
+					],
+					"responses": {"201": {"description": "created"}}
+				}
+			}
+		}
+	}`))
+	Expect(err).To(BeNil())
+
+	Expect(unit.ValidateRequest(models.RequestDetails{
+		Method: "POST",
+		Path:   "/orders",
+		Query:  "dryRun=maybe",
+		Body:   `{"quantity": 0}`,
+	})).To(Equal([]string{
+		"query parameter dryRun should be a boolean but is a string",
+		"request body.quantity should be at least 1",
+	}))
+}
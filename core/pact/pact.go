@@ -0,0 +1,412 @@
+package pact
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/models"
+)
+
+// volatileHeaders describe a single exchange rather than the API, so a provider
+// could not be verified against them
+var volatileHeaders = []string{
+	"Connection", "Content-Length", "Date", "Hoverfly", "Keep-Alive", "Server", "Transfer-Encoding",
+}
+
+// Pact - a consumer contract built up one interaction at a time. Interactions
+// which are the same as one already added are left out
+type Pact struct {
+	options      v2.PactOptionsView
+	interactions []v2.PactInteractionView
+	added        map[string]bool
+	descriptions map[string]int
+}
+
+// matchingRule - where in the request a rule applies, and the rule itself
+type matchingRule struct {
+	category string
+	name     string
+	matcher  map[string]interface{}
+}
+
+func NewPact(options v2.PactOptionsView) *Pact {
+	return &Pact{
+		options:      options,
+		interactions: []v2.PactInteractionView{},
+		added:        map[string]bool{},
+		descriptions: map[string]int{},
+	}
+}
+
+// AddInteraction - adds a request and the response to it. When the request matcher
+// it was matched by is given, matching rules are derived from its matchers so that
+// the provider is only held to what the consumer relied on
+func (this *Pact) AddInteraction(request models.RequestDetails, response models.ResponseDetails, requestMatcher *models.RequestMatcher) {
+	requestView, rules := this.newRequestView(request, requestMatcher)
+	if len(rules) > 0 {
+		requestView.MatchingRules = this.newMatchingRules(rules)
+	}
+
+	interaction := v2.PactInteractionView{
+		Request:  requestView,
+		Response: newResponseView(response),
+	}
+
+	key, _ := json.Marshal(interaction)
+	if this.added[string(key)] {
+		return
+	}
+	this.added[string(key)] = true
+
+	description := request.Method + " " + request.Path
+	if request.Query != "" {
+		description += "?" + request.Query
+	}
+
+	// descriptions have to be unique, so that the provider can tell interactions apart
+	this.descriptions[description]++
+	if count := this.descriptions[description]; count > 1 {
+		description = fmt.Sprintf("%s (%d)", description, count)
+	}
+	interaction.Description = description
+
+	this.interactions = append(this.interactions, interaction)
+}
+
+// AddPair - adds a request response pair of a simulation, using the values of its
+// matchers as the example request
+func (this *Pact) AddPair(pair models.RequestMatcherResponsePair) {
+	requestMatcher := pair.RequestMatcher
+
+	request := models.RequestDetails{
+		Method:  exampleValue(requestMatcher.Method, "GET"),
+		Path:    exampleValue(requestMatcher.Path, "/"),
+		Query:   exampleValue(requestMatcher.Query, ""),
+		Body:    exampleValue(requestMatcher.Body, ""),
+		Headers: map[string][]string{},
+	}
+
+	for name, values := range requestMatcher.Headers {
+		for _, value := range values {
+			request.Headers[name] = append(request.Headers[name], strings.Replace(value, "*", "", -1))
+		}
+	}
+
+	// a rule the example does not satisfy would fail the provider however it responds
+	if !satisfiesRegex(requestMatcher.Path, request.Path) {
+		requestMatcher.Path = nil
+	}
+	if !satisfiesRegex(requestMatcher.Body, request.Body) {
+		requestMatcher.Body = nil
+	}
+
+	this.AddInteraction(request, pair.Response, &requestMatcher)
+}
+
+func (this *Pact) View() v2.PactView {
+	return v2.PactView{
+		Consumer:     v2.PactParticipantView{Name: this.options.Consumer},
+		Provider:     v2.PactParticipantView{Name: this.options.Provider},
+		Interactions: this.interactions,
+		Metadata: v2.PactMetadataView{
+			PactSpecification: v2.PactSpecificationView{
+				Version: fmt.Sprintf("%d.0.0", this.options.Specification),
+			},
+		},
+	}
+}
+
+func (this *Pact) newRequestView(request models.RequestDetails, requestMatcher *models.RequestMatcher) (v2.PactRequestView, []matchingRule) {
+	view := v2.PactRequestView{
+		Method: request.Method,
+		Path:   request.Path,
+		Body:   newBody(request.Body, headerValue(request.Headers, "Content-Type")),
+	}
+
+	if request.Query != "" {
+		if this.options.Specification == 2 {
+			view.Query = request.Query
+		} else {
+			query, _ := url.ParseQuery(request.Query)
+			view.Query = query
+		}
+	}
+
+	headers := map[string]string{}
+	if view.Body != nil {
+		if contentType := headerValue(request.Headers, "Content-Type"); contentType != "" {
+			headers["Content-Type"] = contentType
+		}
+	}
+
+	rules := []matchingRule{}
+
+	if requestMatcher != nil {
+		if rule := fieldRule(requestMatcher.Path); rule != nil {
+			rules = append(rules, matchingRule{category: "path", matcher: rule})
+		}
+
+		for _, name := range sortedKeys(requestMatcher.Headers) {
+			if value := headerValue(request.Headers, name); value != "" {
+				headers[name] = value
+			}
+
+			for _, value := range requestMatcher.Headers[name] {
+				if strings.Contains(value, "*") {
+					rules = append(rules, matchingRule{category: "header", name: name, matcher: regexRule(globRegex(value))})
+				}
+			}
+		}
+
+		rules = append(rules, bodyRules(requestMatcher.Body)...)
+	}
+
+	if len(headers) > 0 {
+		view.Headers = headers
+	}
+
+	return view, rules
+}
+
+// newMatchingRules - lays out the rules as version 2 or version 3 of the specification expects
+func (this *Pact) newMatchingRules(rules []matchingRule) map[string]interface{} {
+	matchingRules := map[string]interface{}{}
+
+	if this.options.Specification == 2 {
+		for _, rule := range rules {
+			switch rule.category {
+			case "path":
+				matchingRules["$.path"] = rule.matcher
+			case "header":
+				matchingRules["$.headers."+rule.name] = rule.matcher
+			case "body":
+				matchingRules["$.body"+strings.TrimPrefix(rule.name, "$")] = rule.matcher
+			}
+		}
+
+		return matchingRules
+	}
+
+	for _, rule := range rules {
+		matchers := map[string]interface{}{
+			"matchers": []interface{}{rule.matcher},
+		}
+
+		if rule.category == "path" {
+			matchingRules["path"] = matchers
+			continue
+		}
+
+		if _, ok := matchingRules[rule.category]; !ok {
+			matchingRules[rule.category] = map[string]interface{}{}
+		}
+		matchingRules[rule.category].(map[string]interface{})[rule.name] = matchers
+	}
+
+	return matchingRules
+}
+
+func newResponseView(response models.ResponseDetails) v2.PactResponseView {
+	view := v2.PactResponseView{
+		Status: response.Status,
+		Body:   newBody(response.Body+models.JoinResponseChunks(response.Chunks), headerValue(response.Headers, "Content-Type")),
+	}
+
+	for _, name := range sortedKeys(response.Headers) {
+		if isVolatile(name) {
+			continue
+		}
+
+		if view.Headers == nil {
+			view.Headers = map[string]string{}
+		}
+		view.Headers[name] = strings.Join(response.Headers[name], ", ")
+	}
+
+	return view
+}
+
+// fieldRule - a regular expression rule for a field matched by pattern rather than exactly
+func fieldRule(matchers *models.RequestFieldMatchers) map[string]interface{} {
+	if matchers == nil || matchers.ExactMatch != nil {
+		return nil
+	}
+
+	if matchers.RegexMatch != nil {
+		return regexRule(*matchers.RegexMatch)
+	}
+
+	if matchers.GlobMatch != nil {
+		return regexRule(globRegex(*matchers.GlobMatch))
+	}
+
+	return nil
+}
+
+// bodyRules - a body matched by JSONPath only needs the matched values to be there, which
+// Pact expresses as type rules. Bodies matched by pattern become regular expression rules
+func bodyRules(matchers *models.RequestFieldMatchers) []matchingRule {
+	if matchers == nil {
+		return nil
+	}
+
+	rules := []matchingRule{}
+
+	if matchers.JsonPathMatch != nil && !strings.Contains(*matchers.JsonPathMatch, "?(") {
+		rules = append(rules, matchingRule{
+			category: "body",
+			name:     *matchers.JsonPathMatch,
+			matcher:  map[string]interface{}{"match": "type"},
+		})
+	}
+
+	if rule := fieldRule(matchers); rule != nil {
+		rules = append(rules, matchingRule{category: "body", name: "$", matcher: rule})
+	}
+
+	return rules
+}
+
+func regexRule(regex string) map[string]interface{} {
+	return map[string]interface{}{
+		"match": "regex",
+		"regex": regex,
+	}
+}
+
+func globRegex(glob string) string {
+	parts := strings.Split(glob, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+
+	return "^" + strings.Join(parts, ".*") + "$"
+}
+
+// newBody - JSON bodies are embedded in the contract so that matching rules can
+// refer into them, other bodies are kept as strings
+func newBody(body, contentType string) interface{} {
+	if body == "" {
+		return nil
+	}
+
+	trimmed := strings.TrimSpace(body)
+	if strings.Contains(contentType, "json") || strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var value interface{}
+		if json.Unmarshal([]byte(trimmed), &value) == nil {
+			return value
+		}
+	}
+
+	return body
+}
+
+func exampleValue(matchers *models.RequestFieldMatchers, defaultValue string) string {
+	if matchers == nil {
+		return defaultValue
+	}
+
+	for _, value := range []*string{matchers.ExactMatch, matchers.JsonMatch, matchers.XmlMatch} {
+		if value != nil {
+			return *value
+		}
+	}
+
+	if matchers.GlobMatch != nil {
+		return strings.Replace(*matchers.GlobMatch, "*", "", -1)
+	}
+
+	if matchers.RegexMatch != nil {
+		if example, err := regexExample(*matchers.RegexMatch); err == nil {
+			return example
+		}
+	}
+
+	return defaultValue
+}
+
+// regexExample - a value which the regular expression matches, taking the first alternative
+// and the fewest repetitions it allows. Assertions such as word boundaries are not taken into
+// account, so the value still has to be checked against the regular expression
+func regexExample(regex string) (string, error) {
+	parsed, err := syntax.Parse(regex, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+
+	var example func(node *syntax.Regexp) string
+	example = func(node *syntax.Regexp) string {
+		switch node.Op {
+		case syntax.OpLiteral:
+			return string(node.Rune)
+		case syntax.OpCharClass:
+			if len(node.Rune) > 0 {
+				return string(node.Rune[0])
+			}
+		case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+			return "a"
+		case syntax.OpCapture, syntax.OpPlus:
+			return example(node.Sub[0])
+		case syntax.OpRepeat:
+			return strings.Repeat(example(node.Sub[0]), node.Min)
+		case syntax.OpConcat:
+			values := []string{}
+			for _, sub := range node.Sub {
+				values = append(values, example(sub))
+			}
+			return strings.Join(values, "")
+		case syntax.OpAlternate:
+			return example(node.Sub[0])
+		}
+
+		return ""
+	}
+
+	return example(parsed.Simplify()), nil
+}
+
+// satisfiesRegex - whether the value is matched by the field's regular expression, if it has one
+func satisfiesRegex(matchers *models.RequestFieldMatchers, value string) bool {
+	if matchers == nil || matchers.RegexMatch == nil {
+		return true
+	}
+
+	matched, err := regexp.MatchString(*matchers.RegexMatch, value)
+	return err == nil && matched
+}
+
+func headerValue(headers map[string][]string, name string) string {
+	for key, values := range headers {
+		if strings.EqualFold(key, name) {
+			return strings.Join(values, ", ")
+		}
+	}
+
+	return ""
+}
+
+func isVolatile(name string) bool {
+	for _, volatile := range volatileHeaders {
+		if strings.EqualFold(volatile, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys(values map[string][]string) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
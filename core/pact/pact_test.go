@@ -0,0 +1,235 @@
+package pact_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/pact"
+	"github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+)
+
+var request = models.RequestDetails{
+	Method:      "POST",
+	Scheme:      "http",
+	Destination: "api.example.com",
+	Path:        "/pets/1",
+	Query:       "expand=owner",
+	Body:        `{"name": "Rex", "tag": "dog"}`,
+	Headers: map[string][]string{
+		"Content-Type":  []string{"application/json"},
+		"Authorization": []string{"Bearer abc"},
+		"User-Agent":    []string{"curl"},
+	},
+}
+
+var response = models.ResponseDetails{
+	Status: 201,
+	Body:   `{"id": 1}`,
+	Headers: map[string][]string{
+		"Content-Type": []string{"application/json"},
+		"Date":         []string{"Mon, 01 May 2017 10:00:00 GMT"},
+		"Hoverfly":     []string{"Was-Here"},
+	},
+}
+
+var requestMatcher = models.RequestMatcher{
+	Method: &models.RequestFieldMatchers{
+		ExactMatch: util.StringToPointer("POST"),
+	},
+	Path: &models.RequestFieldMatchers{
+		RegexMatch: util.StringToPointer("^/pets/[0-9]+$"),
+	},
+	Body: &models.RequestFieldMatchers{
+		JsonPathMatch: util.StringToPointer("$.name"),
+	},
+	Headers: map[string][]string{
+		"Authorization": []string{"Bearer *"},
+	},
+}
+
+func Test_Pact_AddInteraction_WithoutRequestMatcher(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := pact.NewPact(v2.PactOptionsView{Consumer: "web", Provider: "pets", Specification: 3})
+	unit.AddInteraction(request, response, nil)
+
+	bytes, _ := json.Marshal(unit.View())
+	Expect(bytes).To(MatchJSON(`{
+		"consumer": {"name": "web"},
+		"provider": {"name": "pets"},
+		"interactions": [
+			{
+				"description": "POST /pets/1?expand=owner",
+				"request": {
+					"method": "POST",
+					"path": "/pets/1",
+					"query": {"expand": ["owner"]},
+					"headers": {"Content-Type": "application/json"},
+					"body": {"name": "Rex", "tag": "dog"}
+				},
+				"response": {
+					"status": 201,
+					"headers": {"Content-Type": "application/json"},
+					"body": {"id": 1}
+				}
+			}
+		],
+		"metadata": {"pactSpecification": {"version": "3.0.0"}}
+	}`))
+}
+
+func Test_Pact_AddInteraction_DerivesVersion3MatchingRules(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := pact.NewPact(v2.PactOptionsView{Consumer: "web", Provider: "pets", Specification: 3})
+	unit.AddInteraction(request, response, &requestMatcher)
+
+	interaction := unit.View().Interactions[0]
+	Expect(interaction.Request.Headers).To(Equal(map[string]string{
+		"Authorization": "Bearer abc",
+		"Content-Type":  "application/json",
+	}))
+
+	bytes, _ := json.Marshal(interaction.Request.MatchingRules)
+	Expect(bytes).To(MatchJSON(`{
+		"path": {"matchers": [{"match": "regex", "regex": "^/pets/[0-9]+$"}]},
+		"header": {"Authorization": {"matchers": [{"match": "regex", "regex": "^Bearer .*$"}]}},
+		"body": {"$.name": {"matchers": [{"match": "type"}]}}
+	}`))
+}
+
+func Test_Pact_AddInteraction_DerivesVersion2MatchingRules(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := pact.NewPact(v2.PactOptionsView{Consumer: "web", Provider: "pets", Specification: 2})
+	unit.AddInteraction(request, response, &requestMatcher)
+
+	view := unit.View()
+	Expect(view.Metadata.PactSpecification.Version).To(Equal("2.0.0"))
+
+	interaction := view.Interactions[0]
+	Expect(interaction.Request.Query).To(Equal("expand=owner"))
+
+	bytes, _ := json.Marshal(interaction.Request.MatchingRules)
+	Expect(bytes).To(MatchJSON(`{
+		"$.path": {"match": "regex", "regex": "^/pets/[0-9]+$"},
+		"$.headers.Authorization": {"match": "regex", "regex": "^Bearer .*$"},
+		"$.body.name": {"match": "type"}
+	}`))
+}
+
+func Test_Pact_AddInteraction_LeavesOutRepeatedInteractionsAndNumbersDescriptions(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := pact.NewPact(v2.PactOptionsView{Consumer: "web", Provider: "pets", Specification: 3})
+
+	unit.AddInteraction(request, response, nil)
+	unit.AddInteraction(request, response, nil)
+
+	notFound := response
+	notFound.Status = 404
+	unit.AddInteraction(request, notFound, nil)
+
+	interactions := unit.View().Interactions
+	Expect(interactions).To(HaveLen(2))
+	Expect(interactions[0].Description).To(Equal("POST /pets/1?expand=owner"))
+	Expect(interactions[1].Description).To(Equal("POST /pets/1?expand=owner (2)"))
+	Expect(interactions[1].Response.Status).To(Equal(404))
+}
+
+func Test_Pact_AddPair_UsesMatcherValuesAsExample(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := pact.NewPact(v2.PactOptionsView{Consumer: "web", Provider: "pets", Specification: 3})
+	unit.AddPair(models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Method: &models.RequestFieldMatchers{
+				ExactMatch: util.StringToPointer("GET"),
+			},
+			Path: &models.RequestFieldMatchers{
+				GlobMatch: util.StringToPointer("/pets/*"),
+			},
+		},
+		Response: models.ResponseDetails{
+			Status: 200,
+			Body:   "Rex",
+		},
+	})
+
+	interaction := unit.View().Interactions[0]
+	Expect(interaction.Request.Method).To(Equal("GET"))
+	Expect(interaction.Request.Path).To(Equal("/pets/"))
+	Expect(interaction.Request.MatchingRules).To(Equal(map[string]interface{}{
+		"path": map[string]interface{}{
+			"matchers": []interface{}{
+				map[string]interface{}{"match": "regex", "regex": `^/pets/.*$`},
+			},
+		},
+	}))
+	Expect(interaction.Response.Body).To(Equal("Rex"))
+}
+
+func Test_Pact_AddPair_DerivesAnExampleWhichMatchesARegexMatcher(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := pact.NewPact(v2.PactOptionsView{Consumer: "web", Provider: "pets", Specification: 3})
+	unit.AddPair(models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Path: &models.RequestFieldMatchers{
+				RegexMatch: util.StringToPointer(`^/(pets|owners)/[0-9]+/?$`),
+			},
+		},
+		Response: models.ResponseDetails{
+			Status: 200,
+		},
+	})
+
+	interaction := unit.View().Interactions[0]
+	Expect(interaction.Request.Path).To(Equal("/pets/0"))
+	Expect(interaction.Request.MatchingRules).To(Equal(map[string]interface{}{
+		"path": map[string]interface{}{
+			"matchers": []interface{}{
+				map[string]interface{}{"match": "regex", "regex": `^/(pets|owners)/[0-9]+/?$`},
+			},
+		},
+	}))
+}
+
+func Test_Pact_AddPair_LeavesOutARegexRuleTheExampleDoesNotMatch(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := pact.NewPact(v2.PactOptionsView{Consumer: "web", Provider: "pets", Specification: 3})
+	unit.AddPair(models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Path: &models.RequestFieldMatchers{
+				RegexMatch: util.StringToPointer(`^/pets\bx`),
+			},
+		},
+		Response: models.ResponseDetails{
+			Status: 200,
+		},
+	})
+
+	Expect(unit.View().Interactions[0].Request.MatchingRules).To(BeNil())
+}
+
+func Test_Pact_AddInteraction_IncludesTheChunksOfAStreamedResponseInTheBody(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := pact.NewPact(v2.PactOptionsView{Consumer: "web", Provider: "pets", Specification: 3})
+	unit.AddInteraction(request, models.ResponseDetails{
+		Status: 200,
+		Headers: map[string][]string{
+			"Content-Type": []string{"text/event-stream"},
+		},
+		Chunks: []models.ResponseChunk{
+			{Data: "data: one\n\n"},
+			{Data: "data: two\n\n", Delay: 100},
+		},
+	}, nil)
+
+	Expect(unit.View().Interactions[0].Response.Body).To(Equal("data: one\n\ndata: two\n\n"))
+}
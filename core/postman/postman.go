@@ -0,0 +1,512 @@
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/util"
+)
+
+var variable = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+type collection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []item          `json:"item"`
+	Variable []keyValue      `json:"variable"`
+	Auth     json.RawMessage `json:"auth"`
+	Event    []interface{}   `json:"event"`
+}
+
+// item - either a folder of items or a request with its example responses
+type item struct {
+	Name     string          `json:"name"`
+	Item     []item          `json:"item"`
+	Request  json.RawMessage `json:"request"`
+	Response []response      `json:"response"`
+	Auth     json.RawMessage `json:"auth"`
+	Event    []interface{}   `json:"event"`
+}
+
+type request struct {
+	Method string          `json:"method"`
+	Header []keyValue      `json:"header"`
+	Url    json.RawMessage `json:"url"`
+	Body   *body           `json:"body"`
+	Auth   json.RawMessage `json:"auth"`
+}
+
+type body struct {
+	Mode       string     `json:"mode"`
+	Raw        string     `json:"raw"`
+	Urlencoded []keyValue `json:"urlencoded"`
+	Options    struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type response struct {
+	Name            string          `json:"name"`
+	OriginalRequest json.RawMessage `json:"originalRequest"`
+	Code            int             `json:"code"`
+	Header          []keyValue      `json:"header"`
+	Body            string          `json:"body"`
+}
+
+type keyValue struct {
+	Key      string      `json:"key"`
+	Value    interface{} `json:"value"`
+	Disabled bool        `json:"disabled"`
+}
+
+type urlView struct {
+	Raw      string          `json:"raw"`
+	Protocol string          `json:"protocol"`
+	Host     json.RawMessage `json:"host"`
+	Port     string          `json:"port"`
+	Path     json.RawMessage `json:"path"`
+	Query    []keyValue      `json:"query"`
+	Variable []keyValue      `json:"variable"`
+}
+
+// IsCollection - whether data is a Postman collection rather than a simulation
+func IsCollection(data []byte) bool {
+	var collection collection
+	if json.Unmarshal(data, &collection) != nil {
+		return false
+	}
+
+	return strings.Contains(collection.Info.Schema, "schema.getpostman.com")
+}
+
+// NewSimulationView - converts a Postman collection into a simulation with a request
+// response pair for each example response saved in it. The constructs of the collection
+// which Hoverfly has no equivalent for are left out and listed in the report
+func NewSimulationView(data []byte) (v2.SimulationViewV2, []string, error) {
+	var collection collection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return v2.SimulationViewV2{}, nil, fmt.Errorf("Invalid Postman collection: %s", err.Error())
+	}
+
+	if !strings.Contains(collection.Info.Schema, "/v2.") {
+		return v2.SimulationViewV2{}, nil, fmt.Errorf("Invalid Postman collection: only v2.0 and v2.1 collections are supported")
+	}
+
+	converter := &converter{
+		variables: map[string]string{},
+		report:    []string{},
+		pairs:     []v2.RequestMatcherResponsePairViewV2{},
+	}
+
+	for _, variable := range collection.Variable {
+		if !variable.Disabled {
+			converter.variables[variable.Key] = variable.value()
+		}
+	}
+
+	name := fmt.Sprintf("collection %q", collection.Info.Name)
+	converter.untranslatedScripts(name, collection.Event)
+	converter.untranslatedAuth(name, collection.Auth)
+
+	converter.items(collection.Item, "")
+
+	return v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: converter.pairs,
+		},
+		MetaView: v2.MetaView{
+			SchemaVersion: "v2",
+		},
+	}, converter.report, nil
+}
+
+type converter struct {
+	variables map[string]string
+	report    []string
+	pairs     []v2.RequestMatcherResponsePairViewV2
+}
+
+func (this *converter) untranslated(name, construct string) {
+	this.report = append(this.report, fmt.Sprintf("%s: %s is not translated", name, construct))
+}
+
+func (this *converter) untranslatedScripts(name string, events []interface{}) {
+	if len(events) > 0 {
+		this.untranslated(name, "scripts")
+	}
+}
+
+func (this *converter) untranslatedAuth(name string, auth json.RawMessage) {
+	if len(auth) > 0 && string(auth) != "null" {
+		this.untranslated(name, "auth")
+	}
+}
+
+func (this *converter) items(items []item, folder string) {
+	for _, item := range items {
+		path := item.Name
+		if folder != "" {
+			path = folder + " / " + item.Name
+		}
+		name := fmt.Sprintf("item %q", path)
+
+		this.untranslatedScripts(name, item.Event)
+		this.untranslatedAuth(name, item.Auth)
+
+		if item.Request == nil {
+			this.items(item.Item, path)
+			continue
+		}
+
+		if len(item.Response) == 0 {
+			this.untranslated(name, "request without example responses")
+			continue
+		}
+
+		request, err := parseRequest(item.Request)
+		if err != nil {
+			this.untranslated(name, "request")
+			continue
+		}
+		this.untranslatedAuth(name, request.Auth)
+
+		for _, response := range item.Response {
+			example := fmt.Sprintf("item %q example %q", path, response.Name)
+
+			exampleRequest := request
+			if response.OriginalRequest != nil {
+				if originalRequest, err := parseRequest(response.OriginalRequest); err == nil {
+					exampleRequest = originalRequest
+				}
+			}
+
+			this.pairs = append(this.pairs, v2.RequestMatcherResponsePairViewV2{
+				RequestMatcher: this.requestMatcher(example, exampleRequest),
+				Response:       this.response(response),
+			})
+		}
+	}
+}
+
+// parseRequest - reads a request, which a collection may also give as just its URL
+func parseRequest(data json.RawMessage) (request, error) {
+	var rawUrl string
+	if json.Unmarshal(data, &rawUrl) == nil {
+		urlData, _ := json.Marshal(rawUrl)
+		return request{Method: "GET", Url: urlData}, nil
+	}
+
+	var parsed request
+	err := json.Unmarshal(data, &parsed)
+	if parsed.Method == "" {
+		parsed.Method = "GET"
+	}
+
+	return parsed, err
+}
+
+func (this *converter) requestMatcher(name string, request request) v2.RequestMatcherViewV2 {
+	requestMatcher := v2.RequestMatcherViewV2{
+		Method: &v2.RequestFieldMatchersView{
+			ExactMatch: util.StringToPointer(strings.ToUpper(request.Method)),
+		},
+	}
+
+	scheme, host, path, query := splitUrl(this.rawUrl(request.Url))
+
+	if scheme != "" && !this.hasVariables(name, "url scheme", scheme) {
+		requestMatcher.Scheme = &v2.RequestFieldMatchersView{
+			ExactMatch: util.StringToPointer(scheme),
+		}
+	}
+
+	if host != "" && !this.hasVariables(name, "url host", host) {
+		requestMatcher.Destination = &v2.RequestFieldMatchersView{
+			ExactMatch: util.StringToPointer(host),
+		}
+	}
+
+	requestMatcher.Path = this.pathMatcher(name, path)
+
+	if query != "" && !this.hasVariables(name, "url query", query) {
+		requestMatcher.Query = &v2.RequestFieldMatchersView{
+			ExactMatch: util.StringToPointer(query),
+		}
+	}
+
+	if request.Body != nil {
+		requestMatcher.Body = this.bodyMatcher(name, request)
+	}
+
+	return requestMatcher
+}
+
+// rawUrl - the URL of a request with the collection's variables, and the values
+// given for its path variables, substituted
+func (this *converter) rawUrl(data json.RawMessage) string {
+	var rawUrl string
+	if json.Unmarshal(data, &rawUrl) != nil {
+		var view urlView
+		json.Unmarshal(data, &view)
+		rawUrl = view.withPathVariables(view.raw())
+	}
+
+	return this.substitute(rawUrl)
+}
+
+func (this *converter) substitute(value string) string {
+	return variable.ReplaceAllStringFunc(value, func(match string) string {
+		if value, ok := this.variables[strings.TrimSpace(match[2:len(match)-2])]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// hasVariables - reports a part of the URL which still refers to variables
+// once the collection's variables have been substituted
+func (this *converter) hasVariables(name, construct, value string) bool {
+	matches := variable.FindAllString(value, -1)
+	for _, match := range matches {
+		this.untranslated(name, fmt.Sprintf("variable %s in the %s", match, construct))
+	}
+
+	return len(matches) > 0
+}
+
+// pathMatcher - matches the path exactly unless it has path variables (:name) or
+// unresolved variables, which match any single segment
+func (this *converter) pathMatcher(name, path string) *v2.RequestFieldMatchersView {
+	if path == "" {
+		path = "/"
+	}
+
+	segments := strings.Split(path, "/")
+	expressions := make([]string, len(segments))
+	isRegex := false
+
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			expressions[i] = "[^/]+"
+			isRegex = true
+			continue
+		}
+
+		literals := variable.Split(segment, -1)
+		for j := range literals {
+			literals[j] = regexp.QuoteMeta(literals[j])
+		}
+		expressions[i] = strings.Join(literals, "[^/]+")
+
+		if len(literals) > 1 {
+			isRegex = true
+		}
+	}
+
+	if !isRegex {
+		return &v2.RequestFieldMatchersView{ExactMatch: util.StringToPointer(path)}
+	}
+
+	for _, match := range variable.FindAllString(path, -1) {
+		this.untranslated(name, fmt.Sprintf("variable %s in the url path", match))
+	}
+
+	return &v2.RequestFieldMatchersView{
+		RegexMatch: util.StringToPointer("^" + strings.Join(expressions, "/") + "$"),
+	}
+}
+
+func (this *converter) bodyMatcher(name string, request request) *v2.RequestFieldMatchersView {
+	switch request.Body.Mode {
+	case "raw":
+		raw := this.substitute(request.Body.Raw)
+		if raw == "" {
+			return nil
+		}
+
+		if this.hasVariables(name, "body", raw) {
+			return nil
+		}
+
+		if isJSON(request, raw) {
+			return &v2.RequestFieldMatchersView{JsonMatch: util.StringToPointer(raw)}
+		}
+
+		return &v2.RequestFieldMatchersView{ExactMatch: util.StringToPointer(raw)}
+	case "urlencoded":
+		values := []string{}
+		for _, parameter := range request.Body.Urlencoded {
+			if !parameter.Disabled {
+				values = append(values, url.QueryEscape(parameter.Key)+"="+url.QueryEscape(this.substitute(parameter.value())))
+			}
+		}
+
+		form := strings.Join(values, "&")
+		if this.hasVariables(name, "body", form) {
+			return nil
+		}
+
+		return &v2.RequestFieldMatchersView{ExactMatch: util.StringToPointer(form)}
+	case "":
+		return nil
+	}
+
+	this.untranslated(name, request.Body.Mode+" body")
+	return nil
+}
+
+func (this *converter) response(response response) v2.ResponseDetailsView {
+	view := v2.ResponseDetailsView{
+		Status: response.Code,
+		Body:   response.Body,
+	}
+
+	if view.Status == 0 {
+		view.Status = 200
+	}
+
+	for _, header := range response.Header {
+		if header.Disabled {
+			continue
+		}
+
+		if view.Headers == nil {
+			view.Headers = map[string][]string{}
+		}
+		view.Headers[header.Key] = append(view.Headers[header.Key], header.value())
+	}
+
+	return view
+}
+
+func (this keyValue) value() string {
+	if this.Value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(this.Value)
+}
+
+// raw - the URL as it was entered, or put back together from its parts
+func (this urlView) raw() string {
+	if this.Raw != "" {
+		return this.Raw
+	}
+
+	rawUrl := ""
+	if this.Protocol != "" {
+		rawUrl = this.Protocol + "://"
+	}
+
+	rawUrl += joinParts(this.Host, ".")
+	if this.Port != "" {
+		rawUrl += ":" + this.Port
+	}
+
+	if path := joinParts(this.Path, "/"); path != "" {
+		rawUrl += "/" + strings.TrimPrefix(path, "/")
+	}
+
+	query := []string{}
+	for _, parameter := range this.Query {
+		if !parameter.Disabled {
+			query = append(query, parameter.Key+"="+parameter.value())
+		}
+	}
+	if len(query) > 0 {
+		rawUrl += "?" + strings.Join(query, "&")
+	}
+
+	return rawUrl
+}
+
+func (this urlView) withPathVariables(rawUrl string) string {
+	query := ""
+	if i := strings.Index(rawUrl, "?"); i >= 0 {
+		rawUrl, query = rawUrl[:i], rawUrl[i:]
+	}
+
+	segments := strings.Split(rawUrl, "/")
+	for i, segment := range segments {
+		for _, variable := range this.Variable {
+			if segment == ":"+variable.Key && variable.value() != "" {
+				segments[i] = variable.value()
+			}
+		}
+	}
+
+	return strings.Join(segments, "/") + query
+}
+
+// joinParts - the host and path of a URL are either a string or a list of parts
+func joinParts(data json.RawMessage, separator string) string {
+	var value string
+	if json.Unmarshal(data, &value) == nil {
+		return value
+	}
+
+	var parts []interface{}
+	json.Unmarshal(data, &parts)
+
+	values := []string{}
+	for _, part := range parts {
+		switch typed := part.(type) {
+		case string:
+			values = append(values, typed)
+		case map[string]interface{}:
+			values = append(values, fmt.Sprint(typed["value"]))
+		}
+	}
+
+	return strings.Join(values, separator)
+}
+
+// splitUrl - splits a URL which may not have a scheme, and may still have variables
+// in it, so that it cannot be parsed as a URL
+func splitUrl(rawUrl string) (scheme, host, path, query string) {
+	rest := rawUrl
+	if i := strings.Index(rest, "#"); i >= 0 {
+		rest = rest[:i]
+	}
+
+	if i := strings.Index(rest, "://"); i >= 0 {
+		scheme, rest = rest[:i], rest[i+3:]
+	}
+
+	if i := strings.Index(rest, "?"); i >= 0 {
+		rest, query = rest[:i], util.SortQueryString(rest[i+1:])
+	}
+
+	host = rest
+	if i := strings.Index(rest, "/"); i >= 0 {
+		host, path = rest[:i], rest[i:]
+	}
+
+	return scheme, host, path, query
+}
+
+func isJSON(request request, body string) bool {
+	if request.Body.Options.Raw.Language == "json" {
+		return true
+	}
+
+	for _, header := range request.Header {
+		if strings.EqualFold(header.Key, "Content-Type") && strings.Contains(header.value(), "json") {
+			return true
+		}
+	}
+
+	trimmed := strings.TrimSpace(body)
+	isStructure := strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")
+
+	var value interface{}
+	return isStructure && json.Unmarshal([]byte(trimmed), &value) == nil
+}
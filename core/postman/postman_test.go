@@ -0,0 +1,200 @@
+package postman_test
+
+import (
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/postman"
+	. "github.com/onsi/gomega"
+)
+
+const collection = `{
+	"info": {
+		"name": "Pets",
+		"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+	},
+	"variable": [
+		{"key": "baseUrl", "value": "https://api.example.com"}
+	],
+	"item": [
+		{
+			"name": "Pets",
+			"item": [
+				{
+					"name": "List pets",
+					"request": {
+						"method": "GET",
+						"url": {
+							"raw": "{{baseUrl}}/pets?sort=name&limit=10",
+							"host": ["{{baseUrl}}"],
+							"path": ["pets"]
+						}
+					},
+					"response": [
+						{
+							"name": "Some pets",
+							"code": 200,
+							"header": [
+								{"key": "Content-Type", "value": "application/json"}
+							],
+							"body": "[{\"name\": \"Rex\"}]"
+						}
+					]
+				},
+				{
+					"name": "Get pet",
+					"request": {
+						"method": "GET",
+						"url": "{{baseUrl}}/pets/:petId"
+					},
+					"response": [
+						{
+							"name": "Found",
+							"originalRequest": {
+								"method": "GET",
+								"url": {
+									"raw": "{{baseUrl}}/pets/:petId",
+									"variable": [{"key": "petId", "value": "1"}]
+								}
+							},
+							"code": 200,
+							"body": "{\"name\": \"Rex\"}"
+						},
+						{
+							"name": "Any pet",
+							"code": 404,
+							"body": "Not found"
+						}
+					]
+				}
+			]
+		},
+		{
+			"name": "Create pet",
+			"event": [{"listen": "test", "script": {"exec": ["pm.test()"]}}],
+			"request": {
+				"method": "POST",
+				"url": "{{baseUrl}}/pets",
+				"body": {
+					"mode": "raw",
+					"raw": "{\"name\": \"Rex\"}",
+					"options": {"raw": {"language": "json"}}
+				}
+			},
+			"response": [
+				{"name": "Created", "code": 201}
+			]
+		},
+		{
+			"name": "Upload photo",
+			"request": {
+				"method": "POST",
+				"url": "{{photosUrl}}/photos",
+				"body": {"mode": "formdata", "formdata": []}
+			},
+			"response": [
+				{"name": "Uploaded", "code": 204}
+			]
+		},
+		{
+			"name": "Delete pet",
+			"request": {"method": "DELETE", "url": "{{baseUrl}}/pets/1"},
+			"response": []
+		}
+	]
+}`
+
+func Test_IsCollection_DetectsPostmanCollections(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(postman.IsCollection([]byte(collection))).To(BeTrue())
+	Expect(postman.IsCollection([]byte(`{"data": {"pairs": []}, "meta": {}}`))).To(BeFalse())
+	Expect(postman.IsCollection([]byte(`not json`))).To(BeFalse())
+}
+
+func Test_NewSimulationView_CreatesPairForEachExampleResponse(t *testing.T) {
+	RegisterTestingT(t)
+
+	simulation, _, err := postman.NewSimulationView([]byte(collection))
+	Expect(err).To(BeNil())
+
+	pairs := simulation.RequestResponsePairs
+	Expect(pairs).To(HaveLen(5))
+
+	Expect(*pairs[0].RequestMatcher.Method.ExactMatch).To(Equal("GET"))
+	Expect(*pairs[0].RequestMatcher.Scheme.ExactMatch).To(Equal("https"))
+	Expect(*pairs[0].RequestMatcher.Destination.ExactMatch).To(Equal("api.example.com"))
+	Expect(*pairs[0].RequestMatcher.Path.ExactMatch).To(Equal("/pets"))
+	Expect(*pairs[0].RequestMatcher.Query.ExactMatch).To(Equal("limit=10&sort=name"))
+	Expect(pairs[0].Response.Status).To(Equal(200))
+	Expect(pairs[0].Response.Body).To(Equal(`[{"name": "Rex"}]`))
+	Expect(pairs[0].Response.Headers).To(Equal(map[string][]string{
+		"Content-Type": []string{"application/json"},
+	}))
+
+	Expect(*pairs[1].RequestMatcher.Path.ExactMatch).To(Equal("/pets/1"))
+	Expect(pairs[1].Response.Body).To(Equal(`{"name": "Rex"}`))
+
+	Expect(*pairs[2].RequestMatcher.Path.RegexMatch).To(Equal("^/pets/[^/]+$"))
+	Expect(pairs[2].Response.Status).To(Equal(404))
+
+	Expect(*pairs[3].RequestMatcher.Method.ExactMatch).To(Equal("POST"))
+	Expect(*pairs[3].RequestMatcher.Body.JsonMatch).To(Equal(`{"name": "Rex"}`))
+	Expect(pairs[3].Response.Status).To(Equal(201))
+
+	Expect(pairs[4].RequestMatcher.Destination).To(BeNil())
+	Expect(pairs[4].RequestMatcher.Body).To(BeNil())
+	Expect(*pairs[4].RequestMatcher.Path.ExactMatch).To(Equal("/photos"))
+}
+
+func Test_NewSimulationView_ReportsUntranslatedConstructs(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, untranslated, err := postman.NewSimulationView([]byte(collection))
+	Expect(err).To(BeNil())
+
+	Expect(untranslated).To(Equal([]string{
+		`item "Create pet": scripts is not translated`,
+		`item "Upload photo" example "Uploaded": variable {{photosUrl}} in the url host is not translated`,
+		`item "Upload photo" example "Uploaded": formdata body is not translated`,
+		`item "Delete pet": request without example responses is not translated`,
+	}))
+}
+
+func Test_NewSimulationView_ConvertsUrlencodedBodies(t *testing.T) {
+	RegisterTestingT(t)
+
+	simulation, untranslated, err := postman.NewSimulationView([]byte(`{
+		"info": {"schema": "https://schema.getpostman.com/json/collection/v2.0.0/collection.json"},
+		"item": [{
+			"name": "Login",
+			"request": {
+				"method": "post",
+				"url": "http://example.com/login",
+				"body": {
+					"mode": "urlencoded",
+					"urlencoded": [
+						{"key": "user", "value": "jo bloggs"},
+						{"key": "debug", "value": "true", "disabled": true}
+					]
+				}
+			},
+			"response": [{"name": "Logged in", "code": 200}]
+		}]
+	}`))
+	Expect(err).To(BeNil())
+	Expect(untranslated).To(BeEmpty())
+
+	requestMatcher := simulation.RequestResponsePairs[0].RequestMatcher
+	Expect(*requestMatcher.Method.ExactMatch).To(Equal("POST"))
+	Expect(*requestMatcher.Body.ExactMatch).To(Equal("user=jo+bloggs"))
+}
+
+func Test_NewSimulationView_RejectsOtherCollectionVersions(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, _, err := postman.NewSimulationView([]byte(`{
+		"info": {"schema": "https://schema.getpostman.com/json/collection/v1.0.0/collection.json"}
+	}`))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Invalid Postman collection: only v2.0 and v2.1 collections are supported"))
+}
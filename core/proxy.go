@@ -3,11 +3,14 @@ package hoverfly
 import (
 	"bufio"
 	"bytes"
+	"crypto/tls"
 	"encoding/base64"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,6 +21,8 @@ import (
 	"github.com/SpectoLabs/goproxy/ext/auth"
 	"github.com/SpectoLabs/hoverfly/core/authentication"
 	"github.com/SpectoLabs/hoverfly/core/authentication/backends"
+	"github.com/SpectoLabs/hoverfly/core/middleware"
+	"github.com/SpectoLabs/hoverfly/core/models"
 	"github.com/SpectoLabs/hoverfly/core/util"
 )
 
@@ -31,29 +36,26 @@ func NewProxy(hoverfly *Hoverfly) *goproxy.ProxyHttpServer {
 	// creating proxy
 	proxy := goproxy.NewProxyHttpServer()
 
+	mitmConnect := newMitmConnectAction(hoverfly, proxy)
+
 	proxy.OnRequest(goproxy.UrlMatches(regexp.MustCompile(hoverfly.Cfg.Destination))).
-		HandleConnect(goproxy.AlwaysMitm)
+		HandleConnect(goproxy.FuncHttpsHandler(func(host string, ctx *goproxy.ProxyCtx) (*goproxy.ConnectAction, string) {
+			return mitmConnect, host
+		}))
 
 	if hoverfly.Cfg.HttpsOnly {
 		log.Info("Disabling HTTP")
-		proxy.DisableNonTls(true)
+		// goproxy.DisableNonTls can't be used as MITM'd requests are passed back through the proxy
+		proxy.OnRequest(goproxy.ReqConditionFunc(func(r *http.Request, ctx *goproxy.ProxyCtx) bool {
+			return r.URL.Scheme != "https"
+		})).DoFunc(func(r *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
+			return r, goproxy.NewResponse(r, goproxy.ContentTypeText, http.StatusBadGateway, "This proxy requires TLS (HTTPS)")
+		})
 	}
 
 	if hoverfly.Cfg.AuthEnabled {
 		log.Info("Enabling proxy authentication")
-		proxyBasicAndBearer(proxy, "hoverfly", func(user, password string) bool {
-
-			proxyUser := &backends.User{
-				Username: user,
-				Password: password,
-			}
-
-			responseStatus, _ := authentication.Login(proxyUser, hoverfly.Authentication, nil, 0)
-
-			return responseStatus == http.StatusOK
-		}, func(headerToken string) bool {
-			return authentication.IsJwtTokenValid(headerToken, hoverfly.Authentication, hoverfly.Cfg.SecretKey, hoverfly.Cfg.JWTExpirationDelta)
-		})
+		proxyBasicAndBearer(proxy, "hoverfly", mitmConnect, hoverfly.proxyBasicAuth, hoverfly.proxyBearerAuth)
 	}
 
 	// enable curl -p for all hosts on port 80
@@ -87,8 +89,10 @@ func NewProxy(hoverfly *Hoverfly) *goproxy.ProxyHttpServer {
 	proxy.OnRequest(goproxy.UrlMatches(regexp.MustCompile(hoverfly.Cfg.Destination))).DoFunc(
 		func(r *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
 			startTime := time.Now()
+			r = r.WithContext(middleware.ContextWithDiagnostics(r.Context()))
 			resp := hoverfly.processRequest(r)
 			hoverfly.Journal.NewEntry(r, resp, hoverfly.Cfg.Mode, startTime)
+			hoverfly.encodeGrpcResponse(r, resp)
 			return r, resp
 		})
 
@@ -132,16 +136,28 @@ func NewWebserverProxy(hoverfly *Hoverfly) *goproxy.ProxyHttpServer {
 	proxy.NonproxyHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.Warn("NonproxyHandler")
 		resp := hoverfly.processRequest(r)
-		body, err := util.GetResponseBody(resp)
+		hoverfly.encodeGrpcResponse(r, resp)
 
-		if err != nil {
-			log.Error("Error reading response body")
-			w.WriteHeader(500)
-			return
+		streamedBody, streamed := resp.Body.(*models.StreamedBody)
+		fileBody, fromFile := resp.Body.(*models.FileBody)
+
+		var body string
+		if !streamed && !fromFile {
+			var err error
+			body, err = util.GetResponseBody(resp)
+
+			if err != nil {
+				log.Error("Error reading response body")
+				w.WriteHeader(500)
+				return
+			}
 		}
 
 		for name, values := range resp.Header {
-			name = strings.ToLower(name)
+			// trailer keys are matched case sensitively by net/http
+			if !strings.HasPrefix(name, http.TrailerPrefix) {
+				name = strings.ToLower(name)
+			}
 
 			for _, value := range values {
 				w.Header().Add(name, value)
@@ -150,8 +166,25 @@ func NewWebserverProxy(hoverfly *Hoverfly) *goproxy.ProxyHttpServer {
 
 		w.Header().Set("Req", r.RequestURI)
 		w.Header().Set("Resp", resp.Header.Get("Content-Length"))
+		if fromFile {
+			w.Header().Set("Content-Length", strconv.FormatInt(fileBody.Length, 10))
+		}
 
 		w.WriteHeader(resp.StatusCode)
+
+		if streamed {
+			streamedBody.WriteTo(w)
+			streamedBody.Close()
+			return
+		}
+
+		// body files are copied to the client as they are read, rather than read into memory first
+		if fromFile {
+			io.Copy(w, fileBody)
+			fileBody.Close()
+			return
+		}
+
 		w.Write([]byte(body))
 	})
 
@@ -178,6 +211,81 @@ func NewWebserverProxy(hoverfly *Hoverfly) *goproxy.ProxyHttpServer {
 	return proxy
 }
 
+// Creates a MITM connect action which terminates TLS using leaf certificates issued by the
+// Hoverfly certificate manager. HTTP/2 is offered via ALPN, requests read from the connection
+// are passed back through the proxy so they are processed like any other proxied request
+func newMitmConnectAction(hoverfly *Hoverfly, proxy *goproxy.ProxyHttpServer) *goproxy.ConnectAction {
+	handler := hoverfly.webSocketHandler(proxy)
+
+	return &goproxy.ConnectAction{
+		Action: goproxy.ConnectHijack,
+		Hijack: func(req *http.Request, client net.Conn, ctx *goproxy.ProxyCtx) {
+			host := req.URL.Host
+
+			tlsConfig, err := hoverfly.CertificateManager.TLSConfigForHost(host)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"error": err.Error(),
+					"host":  host,
+				}).Error("Failed to issue certificate for host")
+				client.Close()
+				return
+			}
+			tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+
+			// served in a separate goroutine, same as goproxy does for MITM'd connections, so the
+			// CONNECT request is not considered to be in flight for the lifetime of the tunnel
+			go serveMitmConnection(tls.Server(client, tlsConfig), host, req.RemoteAddr, handler)
+		},
+	}
+}
+
+func serveMitmConnection(conn *tls.Conn, host, remoteAddr string, handler http.Handler) {
+	if err := conn.Handshake(); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+			"host":  host,
+		}).Warn("Cannot handshake client")
+		conn.Close()
+		return
+	}
+
+	listener := newConnListener(conn)
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.URL.Scheme = "https"
+			r.URL.Host = host
+			// since we're converting the request, need to carry over the original connecting IP as well
+			r.RemoteAddr = remoteAddr
+
+			handler.ServeHTTP(w, r)
+		}),
+		ConnState: func(c net.Conn, state http.ConnState) {
+			if state == http.StateClosed || state == http.StateHijacked {
+				listener.Close()
+			}
+		},
+	}
+
+	server.Serve(listener)
+}
+
+func (hf *Hoverfly) proxyBasicAuth(user, password string) bool {
+	proxyUser := &backends.User{
+		Username: user,
+		Password: password,
+	}
+
+	responseStatus, _ := authentication.Login(proxyUser, hf.Authentication, nil, 0)
+
+	return responseStatus == http.StatusOK
+}
+
+func (hf *Hoverfly) proxyBearerAuth(headerToken string) bool {
+	return authentication.IsJwtTokenValid(headerToken, hf.Authentication, hf.Cfg.SecretKey, hf.Cfg.JWTExpirationDelta)
+}
+
 func unauthorizedError(request *http.Request, realm, message string) *http.Response {
 	response := auth.BasicUnauthorized(request, realm)
 	response.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(message)))
@@ -186,7 +294,7 @@ func unauthorizedError(request *http.Request, realm, message string) *http.Respo
 	return response
 }
 
-func proxyBasicAndBearer(proxy *goproxy.ProxyHttpServer, realm string, basicFunc func(user, passwd string) bool, bearerFunc func(token string) bool) {
+func proxyBasicAndBearer(proxy *goproxy.ProxyHttpServer, realm string, mitmConnect *goproxy.ConnectAction, basicFunc func(user, passwd string) bool, bearerFunc func(token string) bool) {
 
 	proxy.OnRequest().Do(goproxy.FuncReqHandler(func(req *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
 		err := authFromHeader(req, basicFunc, bearerFunc)
@@ -202,7 +310,7 @@ func proxyBasicAndBearer(proxy *goproxy.ProxyHttpServer, realm string, basicFunc
 			ctx.Resp = unauthorizedError(ctx.Req, realm, err.Error())
 			return goproxy.RejectConnect, host
 		}
-		return goproxy.MitmConnect, host
+		return mitmConnect, host
 	}))
 }
 
@@ -1,9 +1,18 @@
 package hoverfly
 
 import (
+	"bufio"
+	"crypto/tls"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/util"
 	. "github.com/onsi/gomega"
 )
 
@@ -83,3 +92,282 @@ func Test_authFromHeader_Bearer_ShouldPassJwtTokenOntoFunction(t *testing.T) {
 
 	Expect(bearerToken).To(Equal("gregg.EEewGREQ.GDSG"))
 }
+
+func Test_NewProxy_MitmConnectionsNegotiateHTTP2(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	unit.Cfg.ProxyPort = "9781"
+	unit.Cfg.SetMode("simulate")
+	unit.Simulation.AddRequestMatcherResponsePair(&models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Protocol: &models.RequestFieldMatchers{
+				ExactMatch: util.StringToPointer("HTTP/2.0"),
+			},
+		},
+		Response: models.ResponseDetails{
+			Status: 200,
+			Body:   "h2",
+		},
+	})
+
+	Expect(unit.StartProxy()).To(BeNil())
+	defer unit.StopProxy()
+
+	proxyURL, _ := url.Parse("http://localhost:9781")
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:             http.ProxyURL(proxyURL),
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+
+	response, err := client.Get("https://hoverfly.io/")
+	Expect(err).To(BeNil())
+	Expect(response.Proto).To(Equal("HTTP/2.0"))
+
+	body, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+	Expect(string(body)).To(Equal("h2"))
+
+	entries, err := unit.Journal.GetEntries()
+	Expect(err).To(BeNil())
+	Expect(entries).To(HaveLen(1))
+	Expect(*entries[0].Request.Protocol).To(Equal("HTTP/2.0"))
+}
+
+func Test_NewProxy_MitmConnectionsStillServeHTTP1(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	unit.Cfg.ProxyPort = "9782"
+	unit.Cfg.SetMode("simulate")
+	unit.Simulation.AddRequestMatcherResponsePair(&models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Protocol: &models.RequestFieldMatchers{
+				ExactMatch: util.StringToPointer("HTTP/1.1"),
+			},
+		},
+		Response: models.ResponseDetails{
+			Status: 200,
+			Body:   "h1",
+		},
+	})
+
+	Expect(unit.StartProxy()).To(BeNil())
+	defer unit.StopProxy()
+
+	proxyURL, _ := url.Parse("http://localhost:9782")
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+
+	response, err := client.Get("https://hoverfly.io/")
+	Expect(err).To(BeNil())
+	Expect(response.Proto).To(Equal("HTTP/1.1"))
+
+	body, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+	Expect(string(body)).To(Equal("h1"))
+}
+
+func Test_NewProxy_StreamsSimulatedChunksAsTheyAreSent(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	unit.Cfg.ProxyPort = "9783"
+	unit.Cfg.SetMode("simulate")
+	unit.Simulation.AddRequestMatcherResponsePair(&models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Path: &models.RequestFieldMatchers{
+				ExactMatch: util.StringToPointer("/events"),
+			},
+		},
+		Response: models.ResponseDetails{
+			Status: 200,
+			Headers: map[string][]string{
+				"Content-Type": []string{"text/event-stream"},
+			},
+			Chunks: []models.ResponseChunk{
+				models.ResponseChunk{Data: "data: one\n\n"},
+				models.ResponseChunk{Data: "data: two\n\n", Delay: 500},
+			},
+		},
+	})
+
+	Expect(unit.StartProxy()).To(BeNil())
+	defer unit.StopProxy()
+
+	proxyURL, _ := url.Parse("http://localhost:9783")
+	client := &http.Client{Transport: &http.Transport{
+		Proxy: http.ProxyURL(proxyURL),
+	}}
+
+	started := time.Now()
+
+	response, err := client.Get("http://hoverfly.io/events")
+	Expect(err).To(BeNil())
+
+	reader := bufio.NewReader(response.Body)
+
+	event, err := reader.ReadString('\n')
+	Expect(err).To(BeNil())
+	Expect(event).To(Equal("data: one\n"))
+	Expect(time.Since(started)).To(BeNumerically("<", 500*time.Millisecond))
+
+	rest, err := ioutil.ReadAll(reader)
+	Expect(err).To(BeNil())
+	Expect(string(rest)).To(Equal("\ndata: two\n\n"))
+	Expect(time.Since(started)).To(BeNumerically(">=", 500*time.Millisecond))
+
+	Eventually(func() int {
+		entries, _ := unit.Journal.GetEntries()
+		return len(entries)
+	}).Should(Equal(1))
+}
+
+func Test_NewProxy_CapturesStreamedResponseAsChunks(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		w.Write([]byte("data: one\n\n"))
+		w.(http.Flusher).Flush()
+
+		time.Sleep(100 * time.Millisecond)
+
+		w.Write([]byte("data: two\n\n"))
+	}))
+	defer upstream.Close()
+
+	unit.HTTP = &http.Client{}
+	unit.Cfg.ProxyPort = "9784"
+	unit.Cfg.SetMode("capture")
+
+	Expect(unit.StartProxy()).To(BeNil())
+	defer unit.StopProxy()
+
+	proxyURL, _ := url.Parse("http://localhost:9784")
+	client := &http.Client{Transport: &http.Transport{
+		Proxy: http.ProxyURL(proxyURL),
+	}}
+
+	response, err := client.Get(upstream.URL + "/events")
+	Expect(err).To(BeNil())
+
+	body, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+	Expect(string(body)).To(Equal("data: one\n\ndata: two\n\n"))
+
+	Eventually(func() int {
+		simulation, _ := unit.GetSimulation()
+		return len(simulation.RequestResponsePairs)
+	}).Should(Equal(1))
+
+	simulation, _ := unit.GetSimulation()
+	chunks := simulation.RequestResponsePairs[0].Response.Chunks
+	Expect(chunks).To(HaveLen(2))
+	Expect(chunks[0].Data).To(Equal("data: one\n\n"))
+	Expect(chunks[1].Data).To(Equal("data: two\n\n"))
+	Expect(chunks[1].Delay).To(BeNumerically(">=", 100))
+}
+
+func Test_NewProxy_CapturesChunkedResponseAsChunks(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		w.Write([]byte(`{"first": true}`))
+		w.(http.Flusher).Flush()
+
+		time.Sleep(100 * time.Millisecond)
+
+		w.Write([]byte(`{"second": true}`))
+	}))
+	defer upstream.Close()
+
+	unit.HTTP = &http.Client{}
+	unit.Cfg.ProxyPort = "9786"
+	unit.Cfg.SetMode("capture")
+
+	Expect(unit.StartProxy()).To(BeNil())
+	defer unit.StopProxy()
+
+	proxyURL, _ := url.Parse("http://localhost:9786")
+	client := &http.Client{Transport: &http.Transport{
+		Proxy: http.ProxyURL(proxyURL),
+	}}
+
+	response, err := client.Get(upstream.URL + "/poll")
+	Expect(err).To(BeNil())
+
+	body, err := ioutil.ReadAll(response.Body)
+	Expect(err).To(BeNil())
+	Expect(string(body)).To(Equal(`{"first": true}{"second": true}`))
+
+	Eventually(func() int {
+		simulation, _ := unit.GetSimulation()
+		return len(simulation.RequestResponsePairs)
+	}).Should(Equal(1))
+
+	simulation, _ := unit.GetSimulation()
+	chunks := simulation.RequestResponsePairs[0].Response.Chunks
+	Expect(chunks).To(HaveLen(2))
+	Expect(chunks[0].Data).To(Equal(`{"first": true}`))
+	Expect(chunks[1].Data).To(Equal(`{"second": true}`))
+	Expect(chunks[1].Delay).To(BeNumerically(">=", 100))
+}
+
+func Test_NewProxy_RecordsWhatMiddlewareDidInTheJournal(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(201, `{'message': 'here'}`)
+	defer server.Close()
+
+	unit.Cfg.ProxyPort = "9785"
+	unit.Cfg.SetMode("simulate")
+	unit.Simulation.AddRequestMatcherResponsePair(&models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{},
+		Response: models.ResponseDetails{
+			Status: 200,
+			Body:   "simulated",
+		},
+	})
+	Expect(unit.SetMiddlewareWithOptions(v2.MiddlewareView{
+		Engine: "lua",
+		Script: `print("debugging " .. pair.request.path)`,
+	})).To(BeNil())
+
+	Expect(unit.StartProxy()).To(BeNil())
+	defer unit.StopProxy()
+
+	proxyURL, _ := url.Parse("http://localhost:9785")
+	client := &http.Client{Transport: &http.Transport{
+		Proxy: http.ProxyURL(proxyURL),
+	}}
+
+	response, err := client.Get("http://hoverfly.io/journal")
+	Expect(err).To(BeNil())
+	Expect(response.StatusCode).To(Equal(200))
+
+	entries, err := unit.Journal.GetEntries()
+	Expect(err).To(BeNil())
+	Expect(entries).To(HaveLen(1))
+	Expect(entries[0].Middleware).To(HaveLen(1))
+	Expect(entries[0].Middleware[0].Stderr).To(Equal("debugging /journal\n"))
+	Expect(entries[0].Middleware[0].Error).To(Equal(""))
+}
@@ -9,6 +9,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/SpectoLabs/hoverfly/core/middleware"
+	"github.com/SpectoLabs/hoverfly/core/models"
 )
 
 // Configuration - initial structure of configuration
@@ -21,6 +22,8 @@ type Configuration struct {
 	DatabasePath string
 	Webserver    bool
 
+	MiddlewareChain []middleware.ChainedMiddleware
+
 	TLSVerification bool
 
 	UpstreamProxy string
@@ -29,6 +32,9 @@ type Configuration struct {
 
 	DisableCache bool
 
+	SimulationDirectory string
+	BodyFileCaptureSize int
+
 	SecretKey          []byte
 	JWTExpirationDelta int
 	AuthEnabled        bool
@@ -42,6 +48,31 @@ type Configuration struct {
 	mu sync.Mutex
 }
 
+// BodyFiles - where body files are read from and captured bodies are written to
+func (c *Configuration) BodyFiles() models.BodyFiles {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return models.BodyFiles{
+		Directory:   c.SimulationDirectory,
+		CaptureSize: c.BodyFileCaptureSize,
+	}
+}
+
+// SetSimulationDirectory - provides safe way to set where body files are read from and written to
+func (c *Configuration) SetSimulationDirectory(simulationDirectory string) {
+	c.mu.Lock()
+	c.SimulationDirectory = simulationDirectory
+	c.mu.Unlock()
+}
+
+// SetBodyFileCaptureSize - provides safe way to set how large a captured body has to be to be written to a body file
+func (c *Configuration) SetBodyFileCaptureSize(bodyFileCaptureSize int) {
+	c.mu.Lock()
+	c.BodyFileCaptureSize = bodyFileCaptureSize
+	c.mu.Unlock()
+}
+
 // SetMode - provides safe way to set new mode
 func (c *Configuration) SetMode(mode string) {
 	c.mu.Lock()
@@ -49,11 +80,54 @@ func (c *Configuration) SetMode(mode string) {
 	c.mu.Unlock()
 }
 
+// SetUpstreamProxy - provides safe way to set the proxy requests are made upstream through, which
+// is not used when it is empty
 func (c *Configuration) SetUpstreamProxy(upstreamProxy string) {
-	if !strings.HasPrefix(upstreamProxy, "http://") && !strings.HasPrefix(upstreamProxy, "https://") {
+	if upstreamProxy != "" && !strings.HasPrefix(upstreamProxy, "http://") && !strings.HasPrefix(upstreamProxy, "https://") {
 		upstreamProxy = "http://" + upstreamProxy
 	}
+
+	c.mu.Lock()
 	c.UpstreamProxy = upstreamProxy
+	c.mu.Unlock()
+}
+
+// GetUpstreamProxy - provides safe way to get the upstream proxy
+func (c *Configuration) GetUpstreamProxy() string {
+	c.mu.Lock()
+	upstreamProxy := c.UpstreamProxy
+	c.mu.Unlock()
+	return upstreamProxy
+}
+
+// SetTLSVerification - provides safe way to set whether upstream certificates are verified
+func (c *Configuration) SetTLSVerification(tlsVerification bool) {
+	c.mu.Lock()
+	c.TLSVerification = tlsVerification
+	c.mu.Unlock()
+}
+
+// GetTLSVerification - provides safe way to get whether upstream certificates are verified
+func (c *Configuration) GetTLSVerification() bool {
+	c.mu.Lock()
+	tlsVerification := c.TLSVerification
+	c.mu.Unlock()
+	return tlsVerification
+}
+
+// SetVerbose - provides safe way to set verbose logging
+func (c *Configuration) SetVerbose(verbose bool) {
+	c.mu.Lock()
+	c.Verbose = verbose
+	c.mu.Unlock()
+}
+
+// GetVerbose - provides safe way to get whether logging is verbose
+func (c *Configuration) GetVerbose() bool {
+	c.mu.Lock()
+	verbose := c.Verbose
+	c.mu.Unlock()
+	return verbose
 }
 
 // GetMode - provides safe way to get current mode
@@ -102,39 +176,52 @@ const (
 // InitSettings gets and returns initial configuration from env
 // variables or sets defaults
 func InitSettings() *Configuration {
+	appConfig, _ := InitSettingsWithConfigFile(&ConfigFile{})
+	return appConfig
+}
+
+// InitSettingsWithConfigFile gets and returns initial configuration from
+// the defaults, overridden by the configuration file and then by env variables
+func InitSettingsWithConfigFile(configFile *ConfigFile) (*Configuration, error) {
+	appConfig := Configuration{
+		AdminPort:                DefaultAdminPort,
+		ProxyPort:                DefaultPort,
+		DatabasePath:             DefaultDatabasePath,
+		JWTExpirationDelta:       DefaultJWTExpirationDelta,
+		TLSVerification:          true,
+		Mode:                     "simulate",
+		ProxyAuthorizationHeader: "Proxy-Authorization",
+	}
+
+	// settings env variables are set for are left out of the file, so
+	// that middleware in the file is not set up only to be overridden
+	withoutEnvironment := configFile.withoutEnvironmentSettings()
+	if err := withoutEnvironment.apply(&appConfig); err != nil {
+		return nil, err
+	}
 
-	var appConfig Configuration
-	// getting default admin interface port
+	// getting admin interface port
 	if os.Getenv(HoverflyAdminPortEV) != "" {
 		appConfig.AdminPort = os.Getenv(HoverflyAdminPortEV)
-	} else {
-		appConfig.AdminPort = DefaultAdminPort
 	}
 
 	// getting proxy port
 	if os.Getenv(HoverflyProxyPortEV) != "" {
 		appConfig.ProxyPort = os.Getenv(HoverflyProxyPortEV)
-	} else {
-		appConfig.ProxyPort = DefaultPort
 	}
 
 	// getting external proxy
 	if os.Getenv(HoverflyUpstreamProxyPortEV) != "" {
 		appConfig.UpstreamProxy = os.Getenv(HoverflyUpstreamProxyPortEV)
-	} else {
-		appConfig.UpstreamProxy = ""
 	}
 
-	databasePath := os.Getenv(HoverflyDBEV)
-	if databasePath == "" {
-		appConfig.DatabasePath = DefaultDatabasePath
+	if os.Getenv(HoverflyDBEV) != "" {
+		appConfig.DatabasePath = os.Getenv(HoverflyDBEV)
 	}
 
-	appConfig.Webserver = false
-
 	if os.Getenv(HoverflySecretEV) != "" {
 		appConfig.SecretKey = []byte(os.Getenv(HoverflySecretEV))
-	} else {
+	} else if appConfig.SecretKey == nil {
 		appConfig.SecretKey = GetRandomName(10)
 	}
 
@@ -149,31 +236,22 @@ func InitSettings() *Configuration {
 			exp = DefaultJWTExpirationDelta
 		}
 		appConfig.JWTExpirationDelta = exp
-
-	} else {
-		appConfig.JWTExpirationDelta = DefaultJWTExpirationDelta
 	}
 
-	if os.Getenv(HoverflyAuthEnabledEV) == "true" {
-		appConfig.AuthEnabled = true
-	} else {
-		appConfig.AuthEnabled = false
+	if os.Getenv(HoverflyAuthEnabledEV) != "" {
+		appConfig.AuthEnabled = os.Getenv(HoverflyAuthEnabledEV) == "true"
 	}
 
 	// middleware configuration
-	newMiddleware, _ := middleware.ConvertToNewMiddleware(os.Getenv(HoverflyMiddlewareEV))
+	if os.Getenv(HoverflyMiddlewareEV) != "" || configFile.Middleware == nil {
+		newMiddleware, _ := middleware.ConvertToNewMiddleware(os.Getenv(HoverflyMiddlewareEV))
 
-	appConfig.Middleware = *newMiddleware
-
-	if os.Getenv(HoverflyTLSVerification) == "false" {
-		appConfig.TLSVerification = false
-	} else {
-		appConfig.TLSVerification = true
+		appConfig.Middleware = *newMiddleware
 	}
 
-	appConfig.Mode = "simulate"
-
-	appConfig.ProxyAuthorizationHeader = "Proxy-Authorization"
+	if os.Getenv(HoverflyTLSVerification) != "" {
+		appConfig.TLSVerification = os.Getenv(HoverflyTLSVerification) != "false"
+	}
 
-	return &appConfig
+	return &appConfig, nil
 }
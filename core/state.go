@@ -0,0 +1,159 @@
+package hoverfly
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+)
+
+// StateBucketName - name of the BoltDB bucket the state of Hoverfly is kept in
+const StateBucketName = "stateBucket"
+
+var stateKey = []byte("state")
+
+// stateSaveDelay - how long a captured pair waits to be saved, so that pairs captured
+// together are saved together
+const stateSaveDelay = time.Second
+
+// stateView - what is kept of Hoverfly so that it can carry on where it left off after a restart
+type stateView struct {
+	Simulation      v2.SimulationViewV2    `json:"simulation"`
+	Mode            v2.ModeView            `json:"mode"`
+	Destination     string                 `json:"destination"`
+	Middleware      v2.MiddlewareView      `json:"middleware"`
+	MiddlewareChain v2.MiddlewareChainView `json:"middlewareChain"`
+}
+
+// SaveState - snapshots the simulation and runtime configuration when a state cache has been set.
+// Failing to save is logged rather than returned, the change itself has already been made
+func (this *Hoverfly) SaveState() {
+	if this.StateCache == nil {
+		return
+	}
+
+	this.mu.Lock()
+	if this.restoring {
+		this.mu.Unlock()
+		return
+	}
+	state, err := this.snapshotState()
+	this.mu.Unlock()
+
+	var bytes []byte
+	if err == nil {
+		bytes, err = json.Marshal(state)
+	}
+	if err == nil {
+		err = this.StateCache.Set(stateKey, bytes)
+	}
+
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Failed to save state")
+	}
+}
+
+// snapshotState - what is to be saved of Hoverfly, which the caller has to hold the lock for
+// so that it is all taken at the same point
+func (this *Hoverfly) snapshotState() (stateView, error) {
+	simulation, err := this.GetSimulation()
+	if err != nil {
+		return stateView{}, err
+	}
+
+	mode := v2.ModeView{Mode: this.Cfg.GetMode()}
+	if _, ok := this.modeMap[mode.Mode]; ok {
+		mode = this.GetMode()
+	}
+
+	return stateView{
+		Simulation:      simulation,
+		Mode:            mode,
+		Destination:     this.Cfg.Destination,
+		Middleware:      newMiddlewareView(this.Cfg.Middleware),
+		MiddlewareChain: newMiddlewareChainView(this.Cfg.MiddlewareChain),
+	}, nil
+}
+
+// saveStateLater - saves the state after stateSaveDelay, unless a save is already waiting. Saving
+// snapshots the whole simulation, so doing it for every captured pair would slow capturing down
+// the more that has been captured. Changing mode out of capture saves straight away
+func (this *Hoverfly) saveStateLater() {
+	if this.StateCache == nil {
+		return
+	}
+
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if this.statePending {
+		return
+	}
+	this.statePending = true
+
+	time.AfterFunc(stateSaveDelay, func() {
+		this.mu.Lock()
+		this.statePending = false
+		this.mu.Unlock()
+
+		this.SaveState()
+	})
+}
+
+// RestoreState - restores the simulation and runtime configuration from the state cache,
+// returning false when no state has been saved yet. It is meant to be called before the
+// proxy is started
+func (this *Hoverfly) RestoreState() (bool, error) {
+	if this.StateCache == nil {
+		return false, nil
+	}
+
+	bytes, err := this.StateCache.Get(stateKey)
+	if err != nil || len(bytes) == 0 {
+		return false, nil
+	}
+
+	var state stateView
+	if err := json.Unmarshal(bytes, &state); err != nil {
+		return false, err
+	}
+
+	// the state is saved as it is restored, so saving is held off until all of it is back
+	this.setRestoring(true)
+	defer this.setRestoring(false)
+
+	this.DeleteSimulation()
+	if err := this.PutSimulation(state.Simulation); err != nil {
+		return false, err
+	}
+
+	// middleware may rely on something which is no longer there, which should not stop the rest being restored
+	if err := this.SetMiddlewareWithOptions(state.Middleware); err != nil {
+		log.WithField("error", err.Error()).Warn("Failed to restore middleware")
+	}
+
+	if err := this.SetMiddlewareChain(state.MiddlewareChain); err != nil {
+		log.WithField("error", err.Error()).Warn("Failed to restore middleware chain")
+	}
+
+	if state.Destination != "" {
+		this.mu.Lock()
+		this.Cfg.Destination = state.Destination
+		this.mu.Unlock()
+	}
+
+	if state.Mode.Mode != "" {
+		if err := this.SetModeWithArguments(state.Mode); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (this *Hoverfly) setRestoring(restoring bool) {
+	this.mu.Lock()
+	this.restoring = restoring
+	this.mu.Unlock()
+}
@@ -0,0 +1,203 @@
+package hoverfly
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/cache"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/util"
+	. "github.com/onsi/gomega"
+)
+
+func Test_Hoverfly_SaveState_DoesNothingWithoutAStateCache(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+
+	Expect(unit.SetMode("capture")).To(BeNil())
+
+	restored, err := unit.RestoreState()
+	Expect(err).To(BeNil())
+	Expect(restored).To(BeFalse())
+}
+
+func Test_Hoverfly_RestoreState_ReturnsFalseWhenNothingHasBeenSaved(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.StateCache = cache.NewBoltDBCache(TestDB, GetRandomName(10))
+
+	restored, err := unit.RestoreState()
+	Expect(err).To(BeNil())
+	Expect(restored).To(BeFalse())
+}
+
+func Test_Hoverfly_RestoreState_RestoresWhatWasSavedOnChange(t *testing.T) {
+	RegisterTestingT(t)
+
+	stateCache := cache.NewBoltDBCache(TestDB, GetRandomName(10))
+
+	previous := NewHoverflyWithConfiguration(&Configuration{})
+	previous.StateCache = stateCache
+
+	Expect(previous.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{pairOne},
+			GlobalActions: v2.GlobalActionsView{
+				Delays: []v1.ResponseDelayView{delayOne},
+			},
+		},
+	})).To(BeNil())
+	Expect(previous.SetModeWithArguments(v2.ModeView{
+		Mode: "capture",
+		Arguments: v2.ModeArgumentsView{
+			Headers: []string{"Content-Type"},
+		},
+	})).To(BeNil())
+	previous.Cfg.Destination = "test.com"
+	Expect(previous.SetMiddleware("python", "", "")).To(BeNil())
+	Expect(previous.SetMiddlewareChain(v2.MiddlewareChainView{
+		Middlewares: []v2.ChainedMiddlewareView{
+			{Name: "lua", Phase: "response", MiddlewareView: v2.MiddlewareView{Engine: "lua", Script: `pair.response.body = "lua"`}},
+		},
+	})).To(BeNil())
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.StateCache = stateCache
+
+	restored, err := unit.RestoreState()
+	Expect(err).To(BeNil())
+	Expect(restored).To(BeTrue())
+
+	simulation, _ := unit.GetSimulation()
+	Expect(simulation.RequestResponsePairs).To(HaveLen(1))
+	Expect(simulation.RequestResponsePairs[0].Response.Body).To(Equal("test-body"))
+	Expect(simulation.GlobalActions.Delays).To(ConsistOf(delayOne))
+
+	Expect(unit.GetMode().Mode).To(Equal("capture"))
+	Expect(unit.GetMode().Arguments.Headers).To(Equal([]string{"Content-Type"}))
+	Expect(unit.GetDestination()).To(Equal("test.com"))
+
+	binary, _, _ := unit.GetMiddleware()
+	Expect(binary).To(Equal("python"))
+
+	Expect(unit.GetMiddlewareChain()).To(Equal(previous.GetMiddlewareChain()))
+}
+
+func Test_Hoverfly_RestoreState_ReplacesTheSimulation(t *testing.T) {
+	RegisterTestingT(t)
+
+	stateCache := cache.NewBoltDBCache(TestDB, GetRandomName(10))
+
+	previous := NewHoverflyWithConfiguration(&Configuration{})
+	previous.StateCache = stateCache
+	previous.SetMode("simulate")
+	previous.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{pairTwo},
+		},
+	})
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{pairOne},
+		},
+	})
+	unit.StateCache = stateCache
+
+	_, err := unit.RestoreState()
+	Expect(err).To(BeNil())
+
+	Expect(unit.Simulation.MatchingPairs).To(HaveLen(1))
+	Expect(*unit.Simulation.MatchingPairs[0].RequestMatcher.Path.ExactMatch).To(Equal("/path"))
+}
+
+func Test_Hoverfly_Save_SavesStateOfCapturedPairsWhenCaptureEnds(t *testing.T) {
+	RegisterTestingT(t)
+
+	stateCache := cache.NewBoltDBCache(TestDB, GetRandomName(10))
+
+	previous := NewHoverflyWithConfiguration(&Configuration{})
+	previous.StateCache = stateCache
+	previous.SetMode("capture")
+
+	request := models.RequestDetails{
+		Method:      "GET",
+		Scheme:      "http",
+		Destination: "test.com",
+		Path:        "/captured",
+	}
+	Expect(previous.Save(&request, &models.ResponseDetails{Status: 200, Body: "captured"}, nil)).To(BeNil())
+	Expect(previous.SetMode("simulate")).To(BeNil())
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.StateCache = stateCache
+	unit.RestoreState()
+
+	Expect(unit.Simulation.MatchingPairs).To(HaveLen(1))
+	Expect(unit.Simulation.MatchingPairs[0].Response.Body).To(Equal("captured"))
+	Expect(unit.Simulation.MatchingPairs[0].RequestMatcher.Path.ExactMatch).To(Equal(util.StringToPointer("/captured")))
+}
+
+func Test_Hoverfly_Save_SavesStateOfPairsCapturedTogetherOnce(t *testing.T) {
+	RegisterTestingT(t)
+
+	stateCache := cache.NewBoltDBCache(TestDB, GetRandomName(10))
+
+	previous := NewHoverflyWithConfiguration(&Configuration{})
+	previous.StateCache = stateCache
+	previous.SetMode("capture")
+
+	for _, path := range []string{"/one", "/two"} {
+		request := models.RequestDetails{
+			Method:      "GET",
+			Scheme:      "http",
+			Destination: "test.com",
+			Path:        path,
+		}
+		Expect(previous.Save(&request, &models.ResponseDetails{Status: 200, Body: "captured"}, nil)).To(BeNil())
+	}
+
+	restoredPairs := func() int {
+		unit := NewHoverflyWithConfiguration(&Configuration{})
+		unit.StateCache = stateCache
+		unit.RestoreState()
+
+		return len(unit.Simulation.MatchingPairs)
+	}
+
+	Expect(restoredPairs()).To(Equal(0))
+	Eventually(restoredPairs, 2*stateSaveDelay).Should(Equal(2))
+}
+
+func Test_Hoverfly_RestoreState_DoesNotSaveStateWhileRestoring(t *testing.T) {
+	RegisterTestingT(t)
+
+	stateCache := cache.NewBoltDBCache(TestDB, GetRandomName(10))
+
+	saved, err := json.Marshal(stateView{
+		Simulation: v2.SimulationViewV2{
+			DataViewV2: v2.DataViewV2{
+				RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{pairOne},
+			},
+			MetaView: v2.MetaView{SchemaVersion: "v3"},
+		},
+		Mode: v2.ModeView{Mode: "unknown"},
+	})
+	Expect(err).To(BeNil())
+	Expect(stateCache.Set(stateKey, saved)).To(BeNil())
+
+	unit := NewHoverflyWithConfiguration(&Configuration{})
+	unit.StateCache = stateCache
+
+	_, err = unit.RestoreState()
+	Expect(err).ToNot(BeNil())
+
+	stored, err := stateCache.Get(stateKey)
+	Expect(err).To(BeNil())
+	Expect(stored).To(Equal(saved))
+}
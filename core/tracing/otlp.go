@@ -0,0 +1,210 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// OTLPExporter - sends spans in batches to an OpenTelemetry collector, using OTLP over HTTP
+// with JSON encoding. Spans are dropped rather than holding up requests when the collector
+// cannot keep up
+type OTLPExporter struct {
+	Endpoint    string
+	ServiceName string
+	HTTP        *http.Client
+
+	spans     chan *Span
+	batchSize int
+	interval  time.Duration
+}
+
+// NewOTLPExporter - endpoint is the collector's traces endpoint, eg. http://localhost:4318/v1/traces
+func NewOTLPExporter(endpoint, serviceName string) *OTLPExporter {
+	exporter := &OTLPExporter{
+		Endpoint:    endpoint,
+		ServiceName: serviceName,
+		HTTP:        &http.Client{Timeout: 10 * time.Second},
+		spans:       make(chan *Span, 2048),
+		batchSize:   512,
+		interval:    time.Second,
+	}
+
+	go exporter.run()
+
+	return exporter
+}
+
+func (this *OTLPExporter) ExportSpan(span *Span) {
+	select {
+	case this.spans <- span:
+	default:
+		log.Warn("Dropped span as the trace collector is not keeping up")
+	}
+}
+
+func (this *OTLPExporter) run() {
+	ticker := time.NewTicker(this.interval)
+	batch := []*Span{}
+
+	for {
+		select {
+		case span := <-this.spans:
+			batch = append(batch, span)
+			if len(batch) < this.batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		if err := this.Send(batch); err != nil {
+			log.WithFields(log.Fields{
+				"error":    err.Error(),
+				"endpoint": this.Endpoint,
+			}).Warn("Failed to export spans")
+		}
+		batch = []*Span{}
+	}
+}
+
+// Send - posts the spans to the collector
+func (this *OTLPExporter) Send(spans []*Span) error {
+	body, err := json.Marshal(NewOTLPRequest(this.ServiceName, spans))
+	if err != nil {
+		return err
+	}
+
+	response, err := this.HTTP.Post(this.Endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("collector responded with %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// NewOTLPRequest - the body of an OTLP export request holding the spans
+func NewOTLPRequest(serviceName string, spans []*Span) interface{} {
+	otlpSpans := []otlpSpan{}
+
+	for _, span := range spans {
+		span.mutex.Lock()
+
+		otlp := otlpSpan{
+			TraceID:           span.Context.TraceID.String(),
+			SpanID:            span.Context.SpanID.String(),
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        newOTLPAttributes(span.Attributes),
+		}
+
+		if span.ParentSpanID != (SpanID{}) {
+			otlp.ParentSpanID = span.ParentSpanID.String()
+		}
+
+		if span.StatusMessage != "" {
+			otlp.Status = &otlpStatus{Code: 2, Message: span.StatusMessage}
+		}
+
+		span.mutex.Unlock()
+
+		otlpSpans = append(otlpSpans, otlp)
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: newOTLPAttributes(map[string]interface{}{"service.name": serviceName}),
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "hoverfly"},
+				Spans: otlpSpans,
+			}},
+		}},
+	}
+}
+
+func newOTLPAttributes(attributes map[string]interface{}) []otlpAttribute {
+	keys := []string{}
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	otlpAttributes := []otlpAttribute{}
+	for _, key := range keys {
+		var value map[string]interface{}
+
+		switch typed := attributes[key].(type) {
+		case int:
+			value = map[string]interface{}{"intValue": strconv.Itoa(typed)}
+		case bool:
+			value = map[string]interface{}{"boolValue": typed}
+		default:
+			value = map[string]interface{}{"stringValue": fmt.Sprint(typed)}
+		}
+
+		otlpAttributes = append(otlpAttributes, otlpAttribute{Key: key, Value: value})
+	}
+
+	return otlpAttributes
+}
@@ -0,0 +1,80 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/tracing"
+	. "github.com/onsi/gomega"
+)
+
+func Test_OTLPExporter_Send_PostsSpansAsOTLPJSON(t *testing.T) {
+	RegisterTestingT(t)
+
+	var body []byte
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	tracer := tracing.NewTracer(&recordingExporter{})
+	ctx := tracing.ContextWithRemoteParent(context.Background(), traceparent)
+	_, span := tracer.Start(ctx, "hoverfly.request", tracing.SpanKindServer)
+	span.SetAttribute("http.method", "GET")
+	span.SetAttribute("http.status_code", 502)
+	span.SetError(errors.New("upstream failed"))
+	span.Finish()
+
+	unit := tracing.NewOTLPExporter(server.URL, "staging-hoverfly")
+	Expect(unit.Send([]*tracing.Span{span})).To(Succeed())
+
+	Expect(contentType).To(Equal("application/json"))
+	Expect(body).To(MatchJSON(`{
+		"resourceSpans": [{
+			"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "staging-hoverfly"}}]},
+			"scopeSpans": [{
+				"scope": {"name": "hoverfly"},
+				"spans": [{
+					"traceId": "4bf92f3577b34da6a3ce929d0e0e4736",
+					"spanId": "` + span.Context.SpanID.String() + `",
+					"parentSpanId": "00f067aa0ba902b7",
+					"name": "hoverfly.request",
+					"kind": 2,
+					"startTimeUnixNano": "` + formatNano(span.Start.UnixNano()) + `",
+					"endTimeUnixNano": "` + formatNano(span.End.UnixNano()) + `",
+					"attributes": [
+						{"key": "http.method", "value": {"stringValue": "GET"}},
+						{"key": "http.status_code", "value": {"intValue": "502"}}
+					],
+					"status": {"code": 2, "message": "upstream failed"}
+				}]
+			}]
+		}]
+	}`))
+}
+
+func Test_OTLPExporter_Send_ReturnsErrorWhenCollectorRejectsSpans(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	unit := tracing.NewOTLPExporter(server.URL, "hoverfly")
+
+	err := unit.Send([]*tracing.Span{})
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("collector responded with 400"))
+}
+
+func formatNano(nanos int64) string {
+	return strconv.FormatInt(nanos, 10)
+}
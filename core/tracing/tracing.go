@@ -0,0 +1,203 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader - the W3C Trace Context header carrying the trace a request is part of
+const TraceparentHeader = "Traceparent"
+
+const (
+	SpanKindInternal = 1
+	SpanKindServer   = 2
+	SpanKindClient   = 3
+)
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+func (this TraceID) String() string {
+	return hex.EncodeToString(this[:])
+}
+
+func (this SpanID) String() string {
+	return hex.EncodeToString(this[:])
+}
+
+// SpanContext - identifies a span within a trace, as carried by a traceparent header
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// ParseTraceparent - reads a traceparent header, returning false when it is not valid
+func ParseTraceparent(traceparent string) (SpanContext, bool) {
+	var spanContext SpanContext
+
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return spanContext, false
+	}
+
+	version, err := hex.DecodeString(parts[0])
+	traceID, traceErr := hex.DecodeString(parts[1])
+	spanID, spanErr := hex.DecodeString(parts[2])
+	flags, flagsErr := hex.DecodeString(parts[3])
+	if err != nil || traceErr != nil || spanErr != nil || flagsErr != nil ||
+		len(version) != 1 || len(traceID) != 16 || len(spanID) != 8 || len(flags) != 1 {
+		return spanContext, false
+	}
+
+	copy(spanContext.TraceID[:], traceID)
+	copy(spanContext.SpanID[:], spanID)
+	spanContext.Sampled = flags[0]&1 == 1
+
+	if spanContext.TraceID == (TraceID{}) || spanContext.SpanID == (SpanID{}) {
+		return spanContext, false
+	}
+
+	return spanContext, true
+}
+
+// Traceparent - the traceparent header which makes a request a child of this span
+func (this SpanContext) Traceparent() string {
+	flags := "00"
+	if this.Sampled {
+		flags = "01"
+	}
+
+	return fmt.Sprintf("00-%s-%s-%s", this.TraceID, this.SpanID, flags)
+}
+
+// Span - a timed operation within a trace
+type Span struct {
+	Name          string
+	Kind          int
+	Context       SpanContext
+	ParentSpanID  SpanID
+	Start         time.Time
+	End           time.Time
+	Attributes    map[string]interface{}
+	StatusMessage string
+
+	mutex  sync.Mutex
+	tracer *Tracer
+}
+
+// SetAttribute - adds an attribute to the span. It is safe to call on a nil span,
+// which is what a tracer gives out when tracing is off
+func (this *Span) SetAttribute(key string, value interface{}) {
+	if this == nil {
+		return
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.Attributes[key] = value
+}
+
+// SetError - marks the span as having failed
+func (this *Span) SetError(err error) {
+	if this == nil || err == nil {
+		return
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.StatusMessage = err.Error()
+}
+
+// Finish - ends the span, exporting it when the trace is sampled
+func (this *Span) Finish() {
+	if this == nil {
+		return
+	}
+
+	this.mutex.Lock()
+	this.End = time.Now()
+	this.mutex.Unlock()
+
+	if this.Context.Sampled {
+		this.tracer.Exporter.ExportSpan(this)
+	}
+}
+
+// Exporter - sends finished spans on to wherever traces are collected
+type Exporter interface {
+	ExportSpan(span *Span)
+}
+
+// Tracer - starts spans, which are exported once they are finished. A nil tracer
+// starts nil spans, so that code can be traced whether or not tracing is on
+type Tracer struct {
+	Exporter Exporter
+}
+
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{
+		Exporter: exporter,
+	}
+}
+
+type spanContextKey struct{}
+
+type remoteParentKey struct{}
+
+// ContextWithRemoteParent - makes the span given by a traceparent header the parent of
+// the next span started, so that a trace carries on from the caller
+func ContextWithRemoteParent(ctx context.Context, traceparent string) context.Context {
+	if spanContext, ok := ParseTraceparent(traceparent); ok {
+		return context.WithValue(ctx, remoteParentKey{}, spanContext)
+	}
+
+	return ctx
+}
+
+// SpanFromContext - the span started last in this context, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// Start - starts a span as a child of the span in the context, or of the remote parent
+// in the context, or otherwise as the root of a new trace
+func (this *Tracer) Start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	if this == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		Name:       name,
+		Kind:       kind,
+		Start:      time.Now(),
+		Attributes: map[string]interface{}{},
+		tracer:     this,
+	}
+
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.Context.TraceID = parent.Context.TraceID
+		span.Context.Sampled = parent.Context.Sampled
+		span.ParentSpanID = parent.Context.SpanID
+	} else if remote, ok := ctx.Value(remoteParentKey{}).(SpanContext); ok {
+		span.Context.TraceID = remote.TraceID
+		span.Context.Sampled = remote.Sampled
+		span.ParentSpanID = remote.SpanID
+	} else {
+		rand.Read(span.Context.TraceID[:])
+		span.Context.Sampled = true
+	}
+
+	rand.Read(span.Context.SpanID[:])
+
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
@@ -0,0 +1,127 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/tracing"
+	. "github.com/onsi/gomega"
+)
+
+type recordingExporter struct {
+	mutex sync.Mutex
+	spans []*tracing.Span
+}
+
+func (this *recordingExporter) ExportSpan(span *tracing.Span) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.spans = append(this.spans, span)
+}
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func Test_ParseTraceparent_ReadsValidHeader(t *testing.T) {
+	RegisterTestingT(t)
+
+	spanContext, ok := tracing.ParseTraceparent(traceparent)
+	Expect(ok).To(BeTrue())
+
+	Expect(spanContext.TraceID.String()).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+	Expect(spanContext.SpanID.String()).To(Equal("00f067aa0ba902b7"))
+	Expect(spanContext.Sampled).To(BeTrue())
+	Expect(spanContext.Traceparent()).To(Equal(traceparent))
+}
+
+func Test_ParseTraceparent_RejectsInvalidHeaders(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		_, ok := tracing.ParseTraceparent(header)
+		Expect(ok).To(BeFalse(), header)
+	}
+}
+
+func Test_ParseTraceparent_AcceptsLaterVersionsWithMoreFields(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, ok := tracing.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+	Expect(ok).To(BeTrue())
+}
+
+func Test_Tracer_Start_CarriesOnFromRemoteParent(t *testing.T) {
+	RegisterTestingT(t)
+
+	exporter := &recordingExporter{}
+	unit := tracing.NewTracer(exporter)
+
+	ctx := tracing.ContextWithRemoteParent(context.Background(), traceparent)
+	ctx, parent := unit.Start(ctx, "parent", tracing.SpanKindServer)
+	_, child := unit.Start(ctx, "child", tracing.SpanKindInternal)
+
+	Expect(parent.Context.TraceID.String()).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+	Expect(parent.ParentSpanID.String()).To(Equal("00f067aa0ba902b7"))
+
+	Expect(child.Context.TraceID).To(Equal(parent.Context.TraceID))
+	Expect(child.ParentSpanID).To(Equal(parent.Context.SpanID))
+	Expect(child.Context.SpanID).ToNot(Equal(parent.Context.SpanID))
+
+	child.Finish()
+	parent.Finish()
+
+	Expect(exporter.spans).To(HaveLen(2))
+	Expect(exporter.spans[0].Name).To(Equal("child"))
+	Expect(exporter.spans[1].End).ToNot(BeZero())
+}
+
+func Test_Tracer_Start_StartsNewTraceWithoutParent(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := tracing.NewTracer(&recordingExporter{})
+
+	_, span := unit.Start(context.Background(), "root", tracing.SpanKindServer)
+
+	Expect(span.Context.TraceID).ToNot(Equal(tracing.TraceID{}))
+	Expect(span.ParentSpanID).To(Equal(tracing.SpanID{}))
+	Expect(span.Context.Sampled).To(BeTrue())
+}
+
+func Test_Span_Finish_DoesNotExportUnsampledTraces(t *testing.T) {
+	RegisterTestingT(t)
+
+	exporter := &recordingExporter{}
+	unit := tracing.NewTracer(exporter)
+
+	ctx := tracing.ContextWithRemoteParent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	_, span := unit.Start(ctx, "unsampled", tracing.SpanKindServer)
+	span.Finish()
+
+	Expect(exporter.spans).To(BeEmpty())
+	Expect(span.Context.Traceparent()).To(HaveSuffix("-00"))
+}
+
+func Test_Tracer_DoesNothingWhenNil(t *testing.T) {
+	RegisterTestingT(t)
+
+	var unit *tracing.Tracer
+
+	ctx, span := unit.Start(context.Background(), "nothing", tracing.SpanKindServer)
+	Expect(span).To(BeNil())
+	Expect(ctx).To(Equal(context.Background()))
+
+	span.SetAttribute("key", "value")
+	span.SetError(errors.New("error"))
+	span.Finish()
+}
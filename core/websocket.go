@@ -0,0 +1,483 @@
+package hoverfly
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/goproxy"
+	"github.com/SpectoLabs/hoverfly/core/matching"
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/modes"
+	"github.com/gorilla/websocket"
+)
+
+// headers which belong to a single WebSocket handshake and can't be passed on
+var webSocketHandshakeHeaders = map[string]bool{
+	"Upgrade":                  true,
+	"Connection":               true,
+	"Sec-Websocket-Key":        true,
+	"Sec-Websocket-Version":    true,
+	"Sec-Websocket-Extensions": true,
+	"Sec-Websocket-Accept":     true,
+	"Proxy-Connection":         true,
+	"Proxy-Authorization":      true,
+	"Content-Length":           true,
+}
+
+var webSocketUpgrader = websocket.Upgrader{
+	// Hoverfly stands in for the real server, so it accepts any origin the real server would
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// webSocketHandler - takes over WebSocket upgrade requests, which goproxy is unable to proxy,
+// and passes every other request on to the next handler. The destination is compiled once for
+// the handler, which is made again when the proxy is restarted with a new destination
+func (hf *Hoverfly) webSocketHandler(next http.Handler) http.Handler {
+	destination := regexp.MustCompile(hf.Cfg.Destination)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// requests made directly to the proxy are answered by goproxy's non proxy handler
+		if !websocket.IsWebSocketUpgrade(r) || (!hf.Cfg.Webserver && !r.URL.IsAbs()) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		hf.serveWebSocket(w, r, destination)
+	})
+}
+
+func (hf *Hoverfly) serveWebSocket(w http.ResponseWriter, r *http.Request, destination *regexp.Regexp) {
+	startTime := time.Now()
+
+	if !hf.Cfg.Webserver {
+		if hf.Cfg.HttpsOnly && r.URL.Scheme != "https" {
+			writeResponse(w, goproxy.NewResponse(r, goproxy.ContentTypeText, http.StatusBadGateway, "This proxy requires TLS (HTTPS)"))
+			return
+		}
+
+		// MITM'd connections have already been authenticated by their CONNECT request
+		if hf.Cfg.AuthEnabled && r.URL.Scheme != "https" {
+			if err := authFromHeader(r, hf.proxyBasicAuth, hf.proxyBearerAuth); err != nil {
+				writeResponse(w, unauthorizedError(r, "hoverfly", err.Error()))
+				return
+			}
+		}
+	}
+
+	requestDetails, err := models.NewRequestDetailsFromHttpRequest(r)
+	if err != nil {
+		writeResponse(w, modes.ErrorResponse(r, err, "Could not interpret HTTP request"))
+		return
+	}
+
+	mode := hf.Cfg.GetMode()
+	if hf.Cfg.Webserver {
+		// there is nothing to proxy to when running as a webserver
+		mode = modes.Simulate
+	} else if !destination.MatchString(r.URL.Path) && !destination.MatchString(r.URL.Host+r.URL.Path) {
+		hf.proxyWebSocket(w, r, requestDetails, nil, nil)
+		return
+	}
+
+	if mode == modes.Simulate {
+		hf.simulateWebSocket(w, r, requestDetails, startTime)
+		return
+	}
+
+	// synthesize mode has no real server to make the connection to
+	if mode == modes.Synthesize {
+		resp := modes.ErrorResponse(r, errors.New("WebSocket connections cannot be synthesized"), "There was an error when synthesizing the response")
+		hf.Journal.NewEntry(r, resp, mode, startTime)
+		writeResponse(w, resp)
+		return
+	}
+
+	var recorder *webSocketRecorder
+	if mode == modes.Capture {
+		recorder = newWebSocketRecorder()
+	}
+
+	hf.proxyWebSocket(w, r, requestDetails, recorder, func(resp *http.Response) {
+		hf.Journal.NewEntry(r, resp, mode, startTime)
+	})
+}
+
+// simulateWebSocket - upgrades the connection when the matching response has a WebSocket
+// script and plays it, any other response is returned as it is
+func (hf *Hoverfly) simulateWebSocket(w http.ResponseWriter, r *http.Request, requestDetails models.RequestDetails, startTime time.Time) {
+	r = r.WithContext(models.ContextWithBodyFiles(r.Context(), hf.Cfg.BodyFiles()))
+
+	response, matchingErr := hf.GetResponse(r.Context(), requestDetails)
+	if matchingErr != nil {
+		resp := modes.ErrorResponse(r, matchingErr, "There was an error when matching")
+		hf.Journal.NewEntry(r, resp, modes.Simulate, startTime)
+		writeResponse(w, resp)
+		return
+	}
+
+	pair := models.RequestResponsePair{Request: requestDetails, Response: *response}
+
+	if response.WebSocket == nil {
+		resp := modes.ReconstructResponse(r, pair)
+		hf.Journal.NewEntry(r, resp, modes.Simulate, startTime)
+		writeResponse(w, resp)
+		return
+	}
+
+	conn, err := webSocketUpgrader.Upgrade(w, r, webSocketResponseHeader(response.Headers))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+			"path":  r.URL.Path,
+		}).Warn("Could not upgrade WebSocket connection")
+		return
+	}
+
+	pair.Response.Status = http.StatusSwitchingProtocols
+	hf.Journal.NewEntry(r, modes.ReconstructResponse(r, pair), modes.Simulate, startTime)
+
+	playWebSocketScript(conn, response.WebSocket)
+}
+
+// proxyWebSocket - connects to the real server and passes messages both ways until either side
+// closes the connection. When a recorder is given, the conversation is saved to the simulation
+func (hf *Hoverfly) proxyWebSocket(w http.ResponseWriter, r *http.Request, requestDetails models.RequestDetails, recorder *webSocketRecorder, journal func(*http.Response)) {
+	upstream, upstreamResponse, err := hf.dialWebSocket(r)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":       err.Error(),
+			"destination": r.URL.Host,
+			"path":        r.URL.Path,
+		}).Warn("Could not connect to WebSocket server")
+
+		resp := upstreamResponse
+		if resp == nil {
+			resp = modes.ErrorResponse(r, err, "There was an error when forwarding the request to the intended destination")
+		}
+
+		if journal != nil {
+			journal(resp)
+		}
+		writeResponse(w, resp)
+		return
+	}
+	defer upstream.Close()
+
+	conn, err := webSocketUpgrader.Upgrade(w, r, webSocketResponseHeader(upstreamResponse.Header))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+			"path":  r.URL.Path,
+		}).Warn("Could not upgrade WebSocket connection")
+		return
+	}
+	defer conn.Close()
+
+	if journal != nil {
+		journal(upstreamResponse)
+	}
+
+	var fromClient, fromServer func([]byte, bool)
+	if recorder != nil {
+		fromClient = recorder.clientMessage
+		fromServer = recorder.serverMessage
+	}
+
+	done := make(chan bool, 2)
+	go func() {
+		pipeWebSocket(conn, upstream, fromClient)
+		done <- true
+	}()
+	go func() {
+		pipeWebSocket(upstream, conn, fromServer)
+		done <- true
+	}()
+
+	<-done
+	// give the other side a chance to acknowledge the close before the connections are dropped
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+	}
+
+	if recorder == nil {
+		return
+	}
+
+	response := &models.ResponseDetails{
+		Status:    http.StatusSwitchingProtocols,
+		Headers:   upstreamResponse.Header,
+		WebSocket: recorder.script(),
+	}
+
+	// handshake headers, such as the random key, would stop the request from ever matching again
+	requestHeaders := make(map[string][]string)
+	for name, values := range requestDetails.Headers {
+		if !webSocketHandshakeHeaders[http.CanonicalHeaderKey(name)] {
+			requestHeaders[name] = values
+		}
+	}
+	requestDetails.Headers = requestHeaders
+
+	captureMode := hf.modeMap[modes.Capture].(*modes.CaptureMode)
+	if err := hf.Save(&requestDetails, response, captureMode.Arguments.Headers); err != nil {
+		log.WithFields(log.Fields{
+			"error": err.Error(),
+			"path":  r.URL.Path,
+		}).Error("Could not save WebSocket conversation")
+	}
+}
+
+func (hf *Hoverfly) dialWebSocket(r *http.Request) (*websocket.Conn, *http.Response, error) {
+	target := *r.URL
+	if r.URL.Scheme == "https" {
+		target.Scheme = "wss"
+	} else {
+		target.Scheme = "ws"
+	}
+
+	header := make(http.Header)
+	for name, values := range r.Header {
+		if !webSocketHandshakeHeaders[http.CanonicalHeaderKey(name)] {
+			header[name] = values
+		}
+	}
+
+	dialer := &websocket.Dialer{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: !hf.Cfg.GetTLSVerification()},
+	}
+
+	if upstreamProxy := hf.Cfg.GetUpstreamProxy(); upstreamProxy != "" {
+		proxyURL, err := url.Parse(upstreamProxy)
+		if err != nil {
+			return nil, nil, err
+		}
+		dialer.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return dialer.Dial(target.String(), header)
+}
+
+// pipeWebSocket - copies messages from one connection to another, passing on the close
+// message once the connection being read from is closed
+func pipeWebSocket(from, to *websocket.Conn, record func([]byte, bool)) {
+	for {
+		messageType, data, err := from.ReadMessage()
+		if err != nil {
+			code, text := websocket.CloseNormalClosure, ""
+			if closeErr, ok := err.(*websocket.CloseError); ok && closeErr.Code != websocket.CloseNoStatusReceived && closeErr.Code != websocket.CloseAbnormalClosure {
+				code, text = closeErr.Code, closeErr.Text
+			}
+
+			to.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(time.Second))
+			return
+		}
+
+		if record != nil {
+			record(data, messageType == websocket.BinaryMessage)
+		}
+
+		if err := to.WriteMessage(messageType, data); err != nil {
+			return
+		}
+	}
+}
+
+// playWebSocketScript - sends the scripted messages to the client until it closes the connection
+func playWebSocketScript(conn *websocket.Conn, script *models.WebSocket) {
+	var mu sync.Mutex
+	done := make(chan struct{})
+
+	write := func(data string, binary bool) bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		messageType := websocket.TextMessage
+		if binary {
+			messageType = websocket.BinaryMessage
+		}
+
+		return conn.WriteMessage(messageType, []byte(data)) == nil
+	}
+
+	send := func(frames []models.WebSocketFrame) {
+		for _, frame := range frames {
+			select {
+			case <-time.After(time.Duration(frame.Delay) * time.Millisecond):
+			case <-done:
+				return
+			}
+
+			if !write(frame.Data, frame.Binary) {
+				return
+			}
+		}
+	}
+
+	go send(script.OnConnect)
+
+	for _, periodic := range script.Periodic {
+		if periodic.Interval <= 0 {
+			continue
+		}
+
+		go func(periodic models.WebSocketPeriodicFrame) {
+			ticker := time.NewTicker(time.Duration(periodic.Interval) * time.Millisecond)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					if !write(periodic.Data, periodic.Binary) {
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		}(periodic)
+	}
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			close(done)
+			conn.Close()
+			return
+		}
+
+		message := webSocketMessageToMatch(data, messageType == websocket.BinaryMessage)
+
+		for _, handler := range script.OnMessage {
+			if matching.UnscoredFieldMatcher(handler.Match, message).Matched {
+				go send(handler.Reply)
+				break
+			}
+		}
+	}
+}
+
+// webSocketMessageToMatch - binary messages are matched against their Base64 encoding
+func webSocketMessageToMatch(data []byte, binary bool) string {
+	if binary {
+		return base64.StdEncoding.EncodeToString(data)
+	}
+
+	return string(data)
+}
+
+func webSocketResponseHeader(headers map[string][]string) http.Header {
+	responseHeader := make(http.Header)
+	for name, values := range headers {
+		if webSocketHandshakeHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+
+		for _, value := range values {
+			responseHeader.Add(name, value)
+		}
+	}
+
+	return responseHeader
+}
+
+// writeResponse - writes a response to the client of a connection which hasn't been upgraded
+func writeResponse(w http.ResponseWriter, resp *http.Response) {
+	for name, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+
+	w.WriteHeader(resp.StatusCode)
+
+	if resp.Body != nil {
+		io.Copy(w, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// webSocketRecorder - turns the messages passed through a WebSocket connection into a script.
+// Messages the server sends before the client sends any are sent on connect, messages it sends
+// after are replies to the last client message
+type webSocketRecorder struct {
+	mu        sync.Mutex
+	last      time.Time
+	recorded  models.WebSocket
+	current   int
+	repeated  bool
+	responses map[string]bool
+}
+
+func newWebSocketRecorder() *webSocketRecorder {
+	return &webSocketRecorder{
+		last:      time.Now(),
+		current:   -1,
+		responses: make(map[string]bool),
+	}
+}
+
+func (this *webSocketRecorder) clientMessage(data []byte, binary bool) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	this.last = time.Now()
+
+	message := webSocketMessageToMatch(data, binary)
+
+	// only the replies to the first of any repeated messages are kept
+	this.repeated = this.responses[message]
+	if this.repeated {
+		return
+	}
+	this.responses[message] = true
+
+	this.recorded.OnMessage = append(this.recorded.OnMessage, models.WebSocketMessageHandler{
+		Match: &models.RequestFieldMatchers{
+			ExactMatch: &message,
+		},
+	})
+	this.current = len(this.recorded.OnMessage) - 1
+}
+
+func (this *webSocketRecorder) serverMessage(data []byte, binary bool) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	now := time.Now()
+	frame := models.WebSocketFrame{
+		Data:   string(data),
+		Binary: binary,
+		Delay:  int(now.Sub(this.last) / time.Millisecond),
+	}
+	this.last = now
+
+	if this.repeated {
+		return
+	}
+
+	if this.current < 0 {
+		this.recorded.OnConnect = append(this.recorded.OnConnect, frame)
+	} else {
+		handler := &this.recorded.OnMessage[this.current]
+		handler.Reply = append(handler.Reply, frame)
+	}
+}
+
+func (this *webSocketRecorder) script() *models.WebSocket {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	script := this.recorded
+	return &script
+}
@@ -0,0 +1,246 @@
+package hoverfly
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SpectoLabs/hoverfly/core/models"
+	"github.com/SpectoLabs/hoverfly/core/util"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/gomega"
+)
+
+func webSocketURL(server *httptest.Server, path string) string {
+	return strings.Replace(server.URL, "http://", "ws://", 1) + path
+}
+
+func readWebSocketMessage(conn *websocket.Conn) string {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	Expect(err).To(BeNil())
+
+	return string(data)
+}
+
+func Test_Hoverfly_WebSocket_SimulatesScriptedConversation(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(200, "")
+	defer server.Close()
+
+	unit.Cfg.Webserver = true
+	unit.Cfg.SetMode("simulate")
+
+	unit.Simulation.AddRequestMatcherResponsePair(&models.RequestMatcherResponsePair{
+		RequestMatcher: models.RequestMatcher{
+			Path: &models.RequestFieldMatchers{
+				ExactMatch: util.StringToPointer("/chat"),
+			},
+		},
+		Response: models.ResponseDetails{
+			Status: 101,
+			WebSocket: &models.WebSocket{
+				OnConnect: []models.WebSocketFrame{
+					models.WebSocketFrame{Data: "welcome"},
+				},
+				OnMessage: []models.WebSocketMessageHandler{
+					models.WebSocketMessageHandler{
+						Match: &models.RequestFieldMatchers{
+							JsonMatch: util.StringToPointer(`{"type": "ping"}`),
+						},
+						Reply: []models.WebSocketFrame{
+							models.WebSocketFrame{Data: "pong", Delay: 10},
+						},
+					},
+				},
+				Periodic: []models.WebSocketPeriodicFrame{
+					models.WebSocketPeriodicFrame{Data: "tick", Interval: 100},
+				},
+			},
+		},
+	})
+
+	hoverflyServer := httptest.NewServer(unit.webSocketHandler(http.NotFoundHandler()))
+	defer hoverflyServer.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(webSocketURL(hoverflyServer, "/chat"), nil)
+	Expect(err).To(BeNil())
+	defer conn.Close()
+
+	Expect(readWebSocketMessage(conn)).To(Equal("welcome"))
+
+	Expect(conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "ping"}`))).To(Succeed())
+	Expect(readWebSocketMessage(conn)).To(Equal("pong"))
+
+	Expect(readWebSocketMessage(conn)).To(Equal("tick"))
+}
+
+func Test_Hoverfly_WebSocket_ReturnsErrorWhenSimulationDoesNotMatch(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(200, "")
+	defer server.Close()
+
+	unit.Cfg.Webserver = true
+	unit.Cfg.SetMode("simulate")
+
+	hoverflyServer := httptest.NewServer(unit.webSocketHandler(http.NotFoundHandler()))
+	defer hoverflyServer.Close()
+
+	_, response, err := websocket.DefaultDialer.Dial(webSocketURL(hoverflyServer, "/chat"), nil)
+	Expect(err).To(Equal(websocket.ErrBadHandshake))
+	Expect(response.StatusCode).To(Equal(http.StatusBadGateway))
+}
+
+func Test_Hoverfly_WebSocket_RejectsUpgradeInSynthesizeMode(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(200, "")
+	defer server.Close()
+
+	upstreamDialed := false
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamDialed = true
+	}))
+	defer upstream.Close()
+
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	unit.Cfg.SetMode("synthesize")
+
+	// requests are given an absolute URL the same way MITM'd requests are
+	handler := unit.webSocketHandler(http.NotFoundHandler())
+	hoverflyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Scheme = "http"
+		r.URL.Host = upstreamURL.Host
+		handler.ServeHTTP(w, r)
+	}))
+	defer hoverflyServer.Close()
+
+	_, response, err := websocket.DefaultDialer.Dial(webSocketURL(hoverflyServer, "/chat"), nil)
+	Expect(err).To(Equal(websocket.ErrBadHandshake))
+	Expect(response.StatusCode).To(Equal(http.StatusBadGateway))
+	Expect(upstreamDialed).To(BeFalse())
+}
+
+func Test_Hoverfly_WebSocket_PassesNonUpgradeRequestsToNextHandler(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(200, "")
+	defer server.Close()
+
+	hoverflyServer := httptest.NewServer(unit.webSocketHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})))
+	defer hoverflyServer.Close()
+
+	response, err := http.Get(hoverflyServer.URL + "/chat")
+	Expect(err).To(BeNil())
+	Expect(response.StatusCode).To(Equal(http.StatusTeapot))
+}
+
+func Test_Hoverfly_WebSocket_CapturesConversation(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, unit := testTools(200, "")
+	defer server.Close()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := webSocketUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte("hello"))
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(messageType, append([]byte("echo: "), data...))
+		}
+	}))
+	defer upstream.Close()
+
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	unit.Cfg.SetMode("capture")
+
+	// requests are given an absolute URL the same way MITM'd requests are
+	handler := unit.webSocketHandler(http.NotFoundHandler())
+	served := make(chan bool)
+	hoverflyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Scheme = "http"
+		r.URL.Host = upstreamURL.Host
+		handler.ServeHTTP(w, r)
+		close(served)
+	}))
+	defer hoverflyServer.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(webSocketURL(hoverflyServer, "/echo"), nil)
+	Expect(err).To(BeNil())
+
+	Expect(readWebSocketMessage(conn)).To(Equal("hello"))
+
+	Expect(conn.WriteMessage(websocket.TextMessage, []byte("one"))).To(Succeed())
+	Expect(readWebSocketMessage(conn)).To(Equal("echo: one"))
+
+	Expect(conn.WriteMessage(websocket.BinaryMessage, []byte{0xff, 0x00})).To(Succeed())
+	Expect(readWebSocketMessage(conn)).To(Equal("echo: \xff\x00"))
+
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	conn.Close()
+
+	Eventually(served).Should(BeClosed())
+
+	Expect(unit.Simulation.MatchingPairs).To(HaveLen(1))
+
+	pair := unit.Simulation.MatchingPairs[0]
+	Expect(*pair.RequestMatcher.Path.ExactMatch).To(Equal("/echo"))
+	Expect(pair.Response.Status).To(Equal(http.StatusSwitchingProtocols))
+
+	script := pair.Response.WebSocket
+	Expect(script).ToNot(BeNil())
+	Expect(script.OnConnect).To(HaveLen(1))
+	Expect(script.OnConnect[0].Data).To(Equal("hello"))
+
+	Expect(script.OnMessage).To(HaveLen(2))
+	Expect(*script.OnMessage[0].Match.ExactMatch).To(Equal("one"))
+	Expect(script.OnMessage[0].Reply).To(HaveLen(1))
+	Expect(script.OnMessage[0].Reply[0].Data).To(Equal("echo: one"))
+
+	Expect(*script.OnMessage[1].Match.ExactMatch).To(Equal("/wA="))
+	Expect(script.OnMessage[1].Reply[0].Binary).To(BeTrue())
+}
+
+func Test_webSocketRecorder_KeepsRepliesToFirstOfRepeatedMessages(t *testing.T) {
+	RegisterTestingT(t)
+
+	unit := newWebSocketRecorder()
+
+	unit.serverMessage([]byte("welcome"), false)
+	unit.clientMessage([]byte("ping"), false)
+	unit.serverMessage([]byte("pong 1"), false)
+	unit.clientMessage([]byte("ping"), false)
+	unit.serverMessage([]byte("pong 2"), false)
+	unit.clientMessage([]byte("bye"), false)
+
+	script := unit.script()
+
+	Expect(script.OnConnect).To(Equal([]models.WebSocketFrame{
+		models.WebSocketFrame{Data: "welcome", Delay: script.OnConnect[0].Delay},
+	}))
+
+	Expect(script.OnMessage).To(HaveLen(2))
+	Expect(*script.OnMessage[0].Match.ExactMatch).To(Equal("ping"))
+	Expect(script.OnMessage[0].Reply).To(HaveLen(1))
+	Expect(script.OnMessage[0].Reply[0].Data).To(Equal("pong 1"))
+
+	Expect(*script.OnMessage[1].Match.ExactMatch).To(Equal("bye"))
+	Expect(script.OnMessage[1].Reply).To(BeEmpty())
+}
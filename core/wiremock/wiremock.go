@@ -0,0 +1,561 @@
+package wiremock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/SpectoLabs/hoverfly/core/handlers/v1"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
+	"github.com/SpectoLabs/hoverfly/core/util"
+)
+
+// the priority WireMock gives to mappings which do not set one
+const defaultPriority = 5
+
+var knownRequestFields = []string{
+	"method", "url", "urlPath", "urlPattern", "urlPathPattern",
+	"queryParameters", "headers", "bodyPatterns",
+}
+
+var knownResponseFields = []string{
+	"status", "statusMessage", "body", "jsonBody", "base64Body", "headers", "fixedDelayMilliseconds",
+}
+
+// ignoredMappingFields are the fields of a mapping which only identify or describe it
+var ignoredMappingFields = []string{"id", "uuid", "name", "priority", "request", "response", "metadata", "persistent"}
+
+type mapping struct {
+	Id       string       `json:"id"`
+	Uuid     string       `json:"uuid"`
+	Name     string       `json:"name"`
+	Priority *int         `json:"priority"`
+	Request  requestView  `json:"request"`
+	Response responseView `json:"response"`
+
+	fields map[string]json.RawMessage
+}
+
+type requestView struct {
+	Method          string                            `json:"method"`
+	Url             string                            `json:"url"`
+	UrlPath         string                            `json:"urlPath"`
+	UrlPattern      string                            `json:"urlPattern"`
+	UrlPathPattern  string                            `json:"urlPathPattern"`
+	QueryParameters map[string]map[string]interface{} `json:"queryParameters"`
+	Headers         map[string]map[string]interface{} `json:"headers"`
+	BodyPatterns    []map[string]interface{}          `json:"bodyPatterns"`
+
+	fields map[string]json.RawMessage
+}
+
+type responseView struct {
+	Status                 int                    `json:"status"`
+	Body                   string                 `json:"body"`
+	JsonBody               interface{}            `json:"jsonBody"`
+	Base64Body             string                 `json:"base64Body"`
+	Headers                map[string]interface{} `json:"headers"`
+	FixedDelayMilliseconds int                    `json:"fixedDelayMilliseconds"`
+
+	fields map[string]json.RawMessage
+}
+
+// IsMapping - whether data is a WireMock stub mapping, or a file of them, rather than a simulation
+func IsMapping(data []byte) bool {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return false
+	}
+
+	if _, ok := fields["mappings"]; ok {
+		return true
+	}
+
+	_, hasRequest := fields["request"]
+	_, hasResponse := fields["response"]
+
+	return hasRequest && hasResponse
+}
+
+// NewSimulationView - converts a WireMock stub mapping, or a file with a list of
+// mappings, into a simulation. The constructs of the mappings which Hoverfly has
+// no equivalent for are left out of the simulation and listed in the report
+func NewSimulationView(data []byte) (v2.SimulationViewV2, []string, error) {
+	mappings, err := parse(data)
+	if err != nil {
+		return v2.SimulationViewV2{}, nil, err
+	}
+
+	// WireMock prefers the mapping with the lowest priority, which is the first
+	// pair Hoverfly considers when more than one matches equally well
+	sort.SliceStable(mappings, func(i, j int) bool {
+		return mappings[i].priority() < mappings[j].priority()
+	})
+
+	report := []string{}
+	pairs := []v2.RequestMatcherResponsePairViewV2{}
+	delays := []v1.ResponseDelayView{}
+
+	for i, mapping := range mappings {
+		converter := &converter{name: mapping.describe(i)}
+
+		converter.unknownFields("", mapping.fields, ignoredMappingFields)
+		converter.unknownFields("request.", mapping.Request.fields, knownRequestFields)
+		converter.unknownFields("response.", mapping.Response.fields, knownResponseFields)
+
+		pair := v2.RequestMatcherResponsePairViewV2{
+			RequestMatcher: converter.requestMatcher(mapping.Request),
+			Response:       converter.response(mapping.Response),
+		}
+		pairs = append(pairs, pair)
+
+		if mapping.Response.FixedDelayMilliseconds > 0 {
+			if delay := converter.delay(mapping); delay != nil {
+				delays = append(delays, *delay)
+			}
+		}
+
+		report = append(report, converter.report...)
+	}
+
+	return v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: pairs,
+			GlobalActions: v2.GlobalActionsView{
+				Delays: delays,
+			},
+		},
+		MetaView: v2.MetaView{
+			SchemaVersion: "v2",
+		},
+	}, report, nil
+}
+
+func parse(data []byte) ([]mapping, error) {
+	var file struct {
+		Mappings []json.RawMessage `json:"mappings"`
+	}
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("Invalid WireMock mapping: %s", err.Error())
+	}
+
+	if file.Mappings == nil {
+		file.Mappings = []json.RawMessage{data}
+	}
+
+	mappings := []mapping{}
+	for _, raw := range file.Mappings {
+		var mapping mapping
+		if err := json.Unmarshal(raw, &mapping); err != nil {
+			return nil, fmt.Errorf("Invalid WireMock mapping: %s", err.Error())
+		}
+
+		json.Unmarshal(raw, &mapping.fields)
+
+		var fields struct {
+			Request  map[string]json.RawMessage `json:"request"`
+			Response map[string]json.RawMessage `json:"response"`
+		}
+		json.Unmarshal(raw, &fields)
+
+		mapping.Request.fields = fields.Request
+		mapping.Response.fields = fields.Response
+
+		mappings = append(mappings, mapping)
+	}
+
+	return mappings, nil
+}
+
+func (this mapping) priority() int {
+	if this.Priority == nil {
+		return defaultPriority
+	}
+
+	return *this.Priority
+}
+
+func (this mapping) describe(index int) string {
+	for _, name := range []string{this.Name, this.Id, this.Uuid} {
+		if name != "" {
+			return fmt.Sprintf("mapping %q", name)
+		}
+	}
+
+	return fmt.Sprintf("mapping %d", index+1)
+}
+
+// converter - converts a single mapping, keeping track of what it could not translate
+type converter struct {
+	name   string
+	report []string
+}
+
+func (this *converter) untranslated(construct string) {
+	this.report = append(this.report, fmt.Sprintf("%s: %s is not translated", this.name, construct))
+}
+
+func (this *converter) unknownFields(prefix string, fields map[string]json.RawMessage, known []string) {
+	names := []string{}
+	for name := range fields {
+		if !contains(known, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		this.untranslated(prefix + name)
+	}
+}
+
+func (this *converter) requestMatcher(request requestView) v2.RequestMatcherViewV2 {
+	requestMatcher := v2.RequestMatcherViewV2{}
+
+	if request.Method != "" && request.Method != "ANY" {
+		requestMatcher.Method = &v2.RequestFieldMatchersView{
+			ExactMatch: util.StringToPointer(request.Method),
+		}
+	}
+
+	switch {
+	case request.Url != "":
+		path, query := splitUrl(request.Url)
+		requestMatcher.Path = &v2.RequestFieldMatchersView{ExactMatch: util.StringToPointer(path)}
+		requestMatcher.Query = &v2.RequestFieldMatchersView{ExactMatch: util.StringToPointer(query)}
+	case request.UrlPath != "":
+		requestMatcher.Path = &v2.RequestFieldMatchersView{ExactMatch: util.StringToPointer(request.UrlPath)}
+	case request.UrlPattern != "":
+		// Hoverfly matches the path and the query separately, so a pattern for
+		// the whole URL is split where it matches the start of the query
+		path, query := splitUrlPattern(request.UrlPattern)
+		if this.validPattern("request.urlPattern", path) && this.validPattern("request.urlPattern", query) {
+			requestMatcher.Path = &v2.RequestFieldMatchersView{RegexMatch: util.StringToPointer(anchor(path))}
+			if query != "" {
+				requestMatcher.Query = &v2.RequestFieldMatchersView{RegexMatch: util.StringToPointer(anchor(query))}
+			}
+		}
+	case request.UrlPathPattern != "":
+		if this.validPattern("request.urlPathPattern", request.UrlPathPattern) {
+			requestMatcher.Path = &v2.RequestFieldMatchersView{RegexMatch: util.StringToPointer(anchor(request.UrlPathPattern))}
+		}
+	}
+
+	if queryRegex := this.queryParameters(request.QueryParameters); queryRegex != "" {
+		if requestMatcher.Query == nil {
+			requestMatcher.Query = &v2.RequestFieldMatchersView{}
+		}
+		requestMatcher.Query.RegexMatch = util.StringToPointer(queryRegex)
+	}
+
+	requestMatcher.Headers = this.headers(request.Headers)
+	requestMatcher.Body = this.bodyPatterns(request.BodyPatterns)
+
+	return requestMatcher
+}
+
+// queryParameters - builds a single regular expression for the query, relying on
+// Hoverfly sorting query parameters by name before they are matched
+func (this *converter) queryParameters(parameters map[string]map[string]interface{}) string {
+	names := []string{}
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i]+"=" < names[j]+"="
+	})
+
+	expressions := []string{}
+	for _, name := range names {
+		construct := "request.queryParameters." + name
+		operator, value, ok := this.singleOperator(construct, parameters[name])
+		if !ok {
+			continue
+		}
+
+		var valueExpression string
+		switch operator {
+		case "equalTo":
+			valueExpression = regexp.QuoteMeta(url.QueryEscape(value))
+		case "contains":
+			valueExpression = "[^&]*" + regexp.QuoteMeta(url.QueryEscape(value)) + "[^&]*"
+		case "matches":
+			if !this.validPattern(construct+".matches", value) {
+				continue
+			}
+			valueExpression = "(?:" + value + ")"
+		default:
+			this.untranslated(construct + "." + operator)
+			continue
+		}
+
+		expressions = append(expressions, regexp.QuoteMeta(url.QueryEscape(name))+"="+valueExpression)
+	}
+
+	if len(expressions) == 0 {
+		return ""
+	}
+
+	return "(^|&)" + strings.Join(expressions, "(&.*)?&") + "(&|$)"
+}
+
+func (this *converter) headers(headers map[string]map[string]interface{}) map[string][]string {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	names := []string{}
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matchers := map[string][]string{}
+	for _, name := range names {
+		construct := "request.headers." + name
+		operator, value, ok := this.singleOperator(construct, headers[name])
+		if !ok {
+			continue
+		}
+
+		// Hoverfly compares header values as globs
+		switch operator {
+		case "equalTo":
+			matchers[name] = []string{value}
+		case "contains":
+			matchers[name] = []string{"*" + value + "*"}
+		default:
+			this.untranslated(construct + "." + operator)
+		}
+	}
+
+	if len(matchers) == 0 {
+		return nil
+	}
+
+	return matchers
+}
+
+func (this *converter) bodyPatterns(patterns []map[string]interface{}) *v2.RequestFieldMatchersView {
+	matcher := &v2.RequestFieldMatchersView{}
+	translated := false
+
+	for i, pattern := range patterns {
+		construct := fmt.Sprintf("request.bodyPatterns[%d]", i)
+
+		operator, value, ok := this.singleOperator(construct, pattern)
+		if !ok {
+			continue
+		}
+
+		var field **string
+		switch operator {
+		case "equalTo":
+			field = &matcher.ExactMatch
+		case "equalToJson":
+			field = &matcher.JsonMatch
+		case "matchesJsonPath":
+			field = &matcher.JsonPathMatch
+		case "equalToXml":
+			field = &matcher.XmlMatch
+		case "matchesXPath":
+			field = &matcher.XpathMatch
+		case "matches":
+			if !this.validPattern(construct+".matches", value) {
+				continue
+			}
+			field = &matcher.RegexMatch
+			value = anchor(value)
+		case "contains":
+			field = &matcher.GlobMatch
+			value = "*" + value + "*"
+		default:
+			this.untranslated(construct + "." + operator)
+			continue
+		}
+
+		// a field of a Hoverfly matcher holds a single matcher of each kind
+		if *field != nil {
+			this.untranslated(construct + "." + operator)
+			continue
+		}
+
+		*field = util.StringToPointer(value)
+		translated = true
+	}
+
+	if !translated {
+		return nil
+	}
+
+	return matcher
+}
+
+// singleOperator - reads a WireMock content pattern such as {"equalTo": "value"},
+// reporting the options Hoverfly has no equivalent for
+func (this *converter) singleOperator(construct string, pattern map[string]interface{}) (string, string, bool) {
+	operator := ""
+	var value interface{}
+
+	options := []string{}
+	for key, patternValue := range pattern {
+		switch key {
+		case "equalTo", "equalToJson", "matchesJsonPath", "equalToXml", "matchesXPath",
+			"matches", "doesNotMatch", "contains", "absent", "binaryEqualTo", "before", "after", "equalToDateTime":
+			operator = key
+			value = patternValue
+		case "caseInsensitive":
+			// Hoverfly compares header values without regard to case already
+			if !strings.HasPrefix(construct, "request.headers.") && patternValue == true {
+				options = append(options, key)
+			}
+		default:
+			if patternValue != false {
+				options = append(options, key)
+			}
+		}
+	}
+
+	if operator == "" {
+		this.untranslated(construct)
+		return "", "", false
+	}
+
+	sort.Strings(options)
+	for _, option := range options {
+		this.untranslated(construct + "." + option)
+	}
+
+	switch typed := value.(type) {
+	case string:
+		return operator, typed, true
+	case map[string]interface{}:
+		if operator == "equalToJson" {
+			bytes, _ := json.Marshal(typed)
+			return operator, string(bytes), true
+		}
+	case []interface{}:
+		if operator == "equalToJson" {
+			bytes, _ := json.Marshal(typed)
+			return operator, string(bytes), true
+		}
+	case bool:
+		return operator, "", true
+	}
+
+	this.untranslated(construct + "." + operator)
+	return "", "", false
+}
+
+func (this *converter) validPattern(construct, pattern string) bool {
+	if _, err := regexp.Compile(pattern); err != nil {
+		this.untranslated(construct + " " + pattern)
+		return false
+	}
+
+	return true
+}
+
+func (this *converter) response(response responseView) v2.ResponseDetailsView {
+	view := v2.ResponseDetailsView{
+		Status: response.Status,
+	}
+
+	if view.Status == 0 {
+		view.Status = 200
+	}
+
+	switch {
+	case response.Base64Body != "":
+		view.Body = response.Base64Body
+		view.EncodedBody = true
+	case response.JsonBody != nil:
+		bytes, _ := json.Marshal(response.JsonBody)
+		view.Body = string(bytes)
+	default:
+		view.Body = response.Body
+	}
+
+	if len(response.Headers) > 0 {
+		view.Headers = map[string][]string{}
+	}
+
+	for name, value := range response.Headers {
+		switch typed := value.(type) {
+		case string:
+			view.Headers[name] = []string{typed}
+		case []interface{}:
+			for _, item := range typed {
+				view.Headers[name] = append(view.Headers[name], fmt.Sprint(item))
+			}
+		default:
+			view.Headers[name] = []string{fmt.Sprint(typed)}
+		}
+	}
+
+	return view
+}
+
+// delay - Hoverfly delays responses by a pattern for the destination and path of
+// the request, so only mappings for a fixed path can keep their delay
+func (this *converter) delay(mapping mapping) *v1.ResponseDelayView {
+	var path string
+	switch {
+	case mapping.Request.Url != "":
+		path, _ = splitUrl(mapping.Request.Url)
+	case mapping.Request.UrlPath != "":
+		path = mapping.Request.UrlPath
+	default:
+		this.untranslated("response.fixedDelayMilliseconds")
+		return nil
+	}
+
+	delay := &v1.ResponseDelayView{
+		UrlPattern: regexp.QuoteMeta(path) + "$",
+		Delay:      mapping.Response.FixedDelayMilliseconds,
+	}
+
+	if mapping.Request.Method != "ANY" {
+		delay.HttpMethod = mapping.Request.Method
+	}
+
+	return delay
+}
+
+func splitUrl(rawUrl string) (string, string) {
+	path := rawUrl
+	query := ""
+	if i := strings.Index(rawUrl, "?"); i >= 0 {
+		path, query = rawUrl[:i], rawUrl[i+1:]
+	}
+
+	return path, util.SortQueryString(query)
+}
+
+func splitUrlPattern(pattern string) (string, string) {
+	if i := strings.Index(pattern, `\?`); i >= 0 {
+		return pattern[:i], pattern[i+2:]
+	}
+
+	return pattern, ""
+}
+
+// anchor - WireMock patterns have to match the whole value, where Hoverfly's regular
+// expressions only have to match part of it
+func anchor(pattern string) string {
+	return "^(?:" + pattern + ")$"
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,177 @@
+package wiremock_test
+
+import (
+	"testing"
+
+	"github.com/SpectoLabs/hoverfly/core/wiremock"
+	. "github.com/onsi/gomega"
+)
+
+func Test_IsMapping_DetectsMappingsAndMappingFiles(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(wiremock.IsMapping([]byte(`{"request": {"url": "/"}, "response": {"status": 200}}`))).To(BeTrue())
+	Expect(wiremock.IsMapping([]byte(`{"mappings": []}`))).To(BeTrue())
+	Expect(wiremock.IsMapping([]byte(`{"data": {"pairs": []}, "meta": {}}`))).To(BeFalse())
+	Expect(wiremock.IsMapping([]byte(`not json`))).To(BeFalse())
+}
+
+func Test_NewSimulationView_ConvertsUrlsMethodsAndResponses(t *testing.T) {
+	RegisterTestingT(t)
+
+	simulation, untranslated, err := wiremock.NewSimulationView([]byte(`{
+		"mappings": [
+			{
+				"request": {"method": "GET", "url": "/pets?sort=name&limit=10"},
+				"response": {
+					"status": 200,
+					"jsonBody": {"name": "Rex"},
+					"headers": {"Content-Type": "application/json", "Vary": ["Accept", "Origin"]}
+				}
+			},
+			{
+				"request": {"method": "ANY", "urlPathPattern": "/pets/[0-9]+"},
+				"response": {"status": 404, "body": "Not found"}
+			},
+			{
+				"request": {"urlPattern": "/owners/.*\\?page=[0-9]+"},
+				"response": {"base64Body": "aGVsbG8="}
+			}
+		]
+	}`))
+	Expect(err).To(BeNil())
+	Expect(untranslated).To(BeEmpty())
+
+	pairs := simulation.RequestResponsePairs
+	Expect(pairs).To(HaveLen(3))
+
+	Expect(*pairs[0].RequestMatcher.Method.ExactMatch).To(Equal("GET"))
+	Expect(*pairs[0].RequestMatcher.Path.ExactMatch).To(Equal("/pets"))
+	Expect(*pairs[0].RequestMatcher.Query.ExactMatch).To(Equal("limit=10&sort=name"))
+	Expect(pairs[0].Response.Status).To(Equal(200))
+	Expect(pairs[0].Response.Body).To(Equal(`{"name":"Rex"}`))
+	Expect(pairs[0].Response.Headers).To(Equal(map[string][]string{
+		"Content-Type": []string{"application/json"},
+		"Vary":         []string{"Accept", "Origin"},
+	}))
+
+	Expect(pairs[1].RequestMatcher.Method).To(BeNil())
+	Expect(*pairs[1].RequestMatcher.Path.RegexMatch).To(Equal("^(?:/pets/[0-9]+)$"))
+	Expect(pairs[1].Response.Status).To(Equal(404))
+	Expect(pairs[1].Response.Body).To(Equal("Not found"))
+
+	Expect(*pairs[2].RequestMatcher.Path.RegexMatch).To(Equal("^(?:/owners/.*)$"))
+	Expect(*pairs[2].RequestMatcher.Query.RegexMatch).To(Equal("^(?:page=[0-9]+)$"))
+	Expect(pairs[2].Response.Status).To(Equal(200))
+	Expect(pairs[2].Response.Body).To(Equal("aGVsbG8="))
+	Expect(pairs[2].Response.EncodedBody).To(BeTrue())
+}
+
+func Test_NewSimulationView_ConvertsBodyPatterns(t *testing.T) {
+	RegisterTestingT(t)
+
+	simulation, untranslated, err := wiremock.NewSimulationView([]byte(`{
+		"request": {
+			"method": "POST",
+			"urlPath": "/pets",
+			"bodyPatterns": [
+				{"equalToJson": {"name": "Rex"}, "ignoreExtraElements": true},
+				{"matchesJsonPath": "$.name"},
+				{"matchesJsonPath": "$.tag"}
+			]
+		},
+		"response": {"status": 201}
+	}`))
+	Expect(err).To(BeNil())
+
+	body := simulation.RequestResponsePairs[0].RequestMatcher.Body
+	Expect(*body.JsonMatch).To(Equal(`{"name":"Rex"}`))
+	Expect(*body.JsonPathMatch).To(Equal("$.name"))
+	Expect(body.ExactMatch).To(BeNil())
+
+	Expect(untranslated).To(Equal([]string{
+		"mapping 1: request.bodyPatterns[0].ignoreExtraElements is not translated",
+		"mapping 1: request.bodyPatterns[2].matchesJsonPath is not translated",
+	}))
+}
+
+func Test_NewSimulationView_ConvertsHeaderAndQueryParameterMatchers(t *testing.T) {
+	RegisterTestingT(t)
+
+	simulation, untranslated, err := wiremock.NewSimulationView([]byte(`{
+		"name": "search",
+		"request": {
+			"urlPath": "/search",
+			"headers": {
+				"Accept": {"equalTo": "application/json"},
+				"Authorization": {"contains": "Bearer"},
+				"X-Trace": {"absent": true}
+			},
+			"queryParameters": {
+				"q": {"equalTo": "red dog"},
+				"page": {"matches": "[0-9]+"}
+			}
+		},
+		"response": {"status": 200}
+	}`))
+	Expect(err).To(BeNil())
+
+	requestMatcher := simulation.RequestResponsePairs[0].RequestMatcher
+	Expect(requestMatcher.Headers).To(Equal(map[string][]string{
+		"Accept":        []string{"application/json"},
+		"Authorization": []string{"*Bearer*"},
+	}))
+	Expect(*requestMatcher.Query.RegexMatch).To(Equal(`(^|&)page=(?:[0-9]+)(&.*)?&q=red\+dog(&|$)`))
+
+	Expect(untranslated).To(Equal([]string{
+		`mapping "search": request.headers.X-Trace.absent is not translated`,
+	}))
+}
+
+func Test_NewSimulationView_OrdersByPriorityAndKeepsFixedDelays(t *testing.T) {
+	RegisterTestingT(t)
+
+	simulation, _, err := wiremock.NewSimulationView([]byte(`{
+		"mappings": [
+			{"request": {"urlPathPattern": "/.*"}, "response": {"status": 404}, "priority": 10},
+			{"request": {"method": "GET", "urlPath": "/slow"}, "response": {"status": 200, "fixedDelayMilliseconds": 500}}
+		]
+	}`))
+	Expect(err).To(BeNil())
+
+	Expect(simulation.RequestResponsePairs[0].Response.Status).To(Equal(200))
+	Expect(simulation.RequestResponsePairs[1].Response.Status).To(Equal(404))
+
+	Expect(simulation.GlobalActions.Delays).To(HaveLen(1))
+	Expect(simulation.GlobalActions.Delays[0].UrlPattern).To(Equal("/slow$"))
+	Expect(simulation.GlobalActions.Delays[0].HttpMethod).To(Equal("GET"))
+	Expect(simulation.GlobalActions.Delays[0].Delay).To(Equal(500))
+}
+
+func Test_NewSimulationView_ReportsUntranslatedConstructs(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, untranslated, err := wiremock.NewSimulationView([]byte(`{
+		"id": "8c5db8b0",
+		"scenarioName": "checkout",
+		"request": {"urlPathPattern": "/items/(", "cookies": {"session": {"equalTo": "1"}}},
+		"response": {"status": 200, "fault": "CONNECTION_RESET_BY_PEER", "transformers": ["response-template"]}
+	}`))
+	Expect(err).To(BeNil())
+
+	Expect(untranslated).To(Equal([]string{
+		`mapping "8c5db8b0": scenarioName is not translated`,
+		`mapping "8c5db8b0": request.cookies is not translated`,
+		`mapping "8c5db8b0": response.fault is not translated`,
+		`mapping "8c5db8b0": response.transformers is not translated`,
+		`mapping "8c5db8b0": request.urlPathPattern /items/( is not translated`,
+	}))
+}
+
+func Test_NewSimulationView_ReturnsErrorForInvalidJson(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, _, err := wiremock.NewSimulationView([]byte(`{"mappings": [`))
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(ContainSubstring("Invalid WireMock mapping"))
+}
@@ -14,6 +14,9 @@ var exportCmd = &cobra.Command{
 	Long: `
 Exports a simulation from Hoverfly. The simulation JSON
 will be written to the file path provided.
+
+With --format har, the simulation is written as an
+HTTP Archive (HAR 1.2) instead.
 	`,
 
 	Run: func(cmd *cobra.Command, args []string) {
@@ -21,7 +24,19 @@ will be written to the file path provided.
 
 		checkArgAndExit(args, "You have not provided a path to simulation", "export")
 
-		simulationData, err := wrapper.ExportSimulation(*target)
+		format, _ := cmd.Flags().GetString("format")
+
+		var simulationData []byte
+		var err error
+
+		switch format {
+		case "json":
+			simulationData, err = wrapper.ExportSimulation(*target)
+		case "har":
+			simulationData, err = wrapper.ExportSimulationHar(*target)
+		default:
+			err = fmt.Errorf("%s is not a valid format, use json or har", format)
+		}
 		handleIfError(err)
 
 		err = configuration.WriteFile(args[0], simulationData)
@@ -33,4 +48,6 @@ will be written to the file path provided.
 
 func init() {
 	RootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().String("format", "json", "Format to export the simulation in, json or har")
 }
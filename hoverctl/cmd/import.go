@@ -9,6 +9,7 @@ import (
 )
 
 var importV1 bool
+var importOpenApi bool
 
 // importCmd represents the import command
 var importCmd = &cobra.Command{
@@ -18,6 +19,25 @@ var importCmd = &cobra.Command{
 Imports a simulation into Hoverfly. An absolute or
 relative path to a Hoverfly simulation JSON file
 must be provided.
+
+With --format har, the file is read as an HTTP Archive
+(HAR 1.2) and each of its entries becomes a request
+response pair, replacing the current simulation.
+
+With --openapi, the file is read as an OpenAPI 2 or 3
+document in JSON or YAML. Each operation becomes a
+request response pair, responding with the examples in
+the document or with data generated from its schemas.
+
+With --format postman, the file is read as a Postman
+collection (v2.0 or v2.1) and each example response
+saved in it becomes a request response pair.
+
+With --format wiremock, the file is read as a WireMock
+stub mapping, or a file with a list of mappings.
+
+Postman and WireMock constructs which Hoverfly has no
+equivalent for are left out and listed after importing.
 	`,
 
 	Run: func(cmd *cobra.Command, args []string) {
@@ -27,13 +47,43 @@ must be provided.
 		simulationData, err := configuration.ReadFile(args[0])
 		handleIfError(err)
 
-		err = wrapper.ImportSimulation(*target, string(simulationData))
+		var untranslated []string
+
+		format, _ := cmd.Flags().GetString("format")
+		if importOpenApi {
+			format = "openapi"
+		}
+
+		switch format {
+		case "json":
+			err = wrapper.ImportSimulation(*target, string(simulationData))
+		case "har":
+			err = wrapper.ImportSimulationHar(*target, string(simulationData))
+		case "openapi":
+			err = wrapper.ImportSimulationOpenApi(*target, string(simulationData))
+		case "postman":
+			untranslated, err = wrapper.ImportSimulationPostman(*target, string(simulationData))
+		case "wiremock":
+			untranslated, err = wrapper.ImportSimulationWireMock(*target, string(simulationData))
+		default:
+			err = fmt.Errorf("%s is not a valid format, use json, har, openapi, postman or wiremock", format)
+		}
 		handleIfError(err)
 
 		fmt.Println("Successfully imported simulation from", args[0])
+
+		if len(untranslated) > 0 {
+			fmt.Println("\nThe following could not be translated:")
+			for _, construct := range untranslated {
+				fmt.Println(" -", construct)
+			}
+		}
 	},
 }
 
 func init() {
 	RootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().String("format", "json", "Format of the simulation being imported, json, har, openapi, postman or wiremock")
+	importCmd.Flags().BoolVar(&importOpenApi, "openapi", false, "Generate the simulation from an OpenAPI 2 or 3 document, the same as --format openapi")
 }
@@ -10,7 +10,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var middlewareBinary, middlewareScript, middlewareRemote string
+var middlewareBinary, middlewareScript, middlewareRemote, middlewareEngine string
 
 var middlewareCmd = &cobra.Command{
 	Use:   "middleware",
@@ -21,6 +21,7 @@ combinations of flags:
 
 	--binary
 	--binary --script
+	--engine --script
 	--remote
 
 If flags are not used, the current Hoverfly middleware
@@ -34,7 +35,7 @@ configuration will be shown.
 		var middleware v2.MiddlewareView
 		var err error
 
-		if middlewareBinary == "" && middlewareScript == "" && middlewareRemote == "" {
+		if middlewareBinary == "" && middlewareScript == "" && middlewareRemote == "" && middlewareEngine == "" {
 			middleware, err = wrapper.GetMiddleware(*target)
 			handleIfError(err)
 			fmt.Println("Hoverfly middleware configuration is currently set to")
@@ -47,7 +48,11 @@ configuration will be shown.
 				script, err := configuration.ReadFile(middlewareScript)
 				handleIfError(err)
 
-				middleware, err = wrapper.SetMiddleware(*target, middlewareBinary, string(script), "")
+				middleware, err = wrapper.SetMiddlewareWithOptions(*target, v2.MiddlewareView{
+					Binary: middlewareBinary,
+					Script: string(script),
+					Engine: middlewareEngine,
+				})
 				handleIfError(err)
 				fmt.Println("Hoverfly middleware configuration has been set to")
 			}
@@ -57,6 +62,10 @@ configuration will be shown.
 			fmt.Println("Binary: " + middleware.Binary)
 		}
 
+		if middleware.Engine != "" {
+			fmt.Println("Engine: " + middleware.Engine)
+		}
+
 		if middleware.Script != "" {
 			middlewareScript := strings.Split(middleware.Script, "\n")
 			if verbose || len(middlewareScript) < 5 {
@@ -82,6 +91,8 @@ func init() {
 		"An absolute or relative path to a binary that Hoverfly will execute as middleware")
 	middlewareCmd.PersistentFlags().StringVar(&middlewareScript, "script", "",
 		"An absolute or relative path to a script that will be executed by the middleware binary")
+	middlewareCmd.PersistentFlags().StringVar(&middlewareEngine, "engine", "",
+		"A scripting engine within Hoverfly that will execute the script, rather than a binary - 'lua' is supported")
 	middlewareCmd.PersistentFlags().StringVar(&middlewareRemote, "remote", "",
 		"A URL to a remote address that will be called by Hoverfly as middleware")
 }
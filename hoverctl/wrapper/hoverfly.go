@@ -25,12 +25,16 @@ const (
 	v1ApiDelays     = "/api/delays"
 	v1ApiSimulation = "/api/records"
 
-	v2ApiSimulation  = "/api/v2/simulation"
-	v2ApiMode        = "/api/v2/hoverfly/mode"
-	v2ApiDestination = "/api/v2/hoverfly/destination"
-	v2ApiMiddleware  = "/api/v2/hoverfly/middleware"
-	v2ApiCache       = "/api/v2/cache"
-	v2ApiLogs        = "/api/v2/logs"
+	v2ApiSimulation         = "/api/v2/simulation"
+	v2ApiSimulationHar      = "/api/v2/simulation/har"
+	v2ApiSimulationOpenApi  = "/api/v2/simulation/openapi"
+	v2ApiSimulationPostman  = "/api/v2/simulation/postman"
+	v2ApiSimulationWireMock = "/api/v2/simulation/wiremock"
+	v2ApiMode               = "/api/v2/hoverfly/mode"
+	v2ApiDestination        = "/api/v2/hoverfly/destination"
+	v2ApiMiddleware         = "/api/v2/hoverfly/middleware"
+	v2ApiCache              = "/api/v2/cache"
+	v2ApiLogs               = "/api/v2/logs"
 
 	v2ApiShutdown = "/api/v2/shutdown"
 	v2ApiHealth   = "/api/health"
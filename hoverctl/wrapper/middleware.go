@@ -32,12 +32,15 @@ func GetMiddleware(target configuration.Target) (v2.MiddlewareView, error) {
 }
 
 func SetMiddleware(target configuration.Target, binary, script, remote string) (v2.MiddlewareView, error) {
-	middlewareRequest := &v2.MiddlewareView{
+	return SetMiddlewareWithOptions(target, v2.MiddlewareView{
 		Binary: binary,
 		Script: script,
 		Remote: remote,
-	}
+	})
+}
 
+// SetMiddlewareWithOptions - sets middleware which runs with an engine, or persistently
+func SetMiddlewareWithOptions(target configuration.Target, middlewareRequest v2.MiddlewareView) (v2.MiddlewareView, error) {
 	marshalledMiddleware, err := json.Marshal(middlewareRequest)
 	if err != nil {
 		return v2.MiddlewareView{}, err
@@ -7,11 +7,21 @@ import (
 	"io/ioutil"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/SpectoLabs/hoverfly/core/handlers/v2"
 	"github.com/SpectoLabs/hoverfly/hoverctl/configuration"
 )
 
 func ExportSimulation(target configuration.Target) ([]byte, error) {
-	response, err := doRequest(target, "GET", v2ApiSimulation, "", nil)
+	return exportSimulation(target, v2ApiSimulation)
+}
+
+// ExportSimulationHar - exports the simulation as an HTTP Archive (HAR 1.2)
+func ExportSimulationHar(target configuration.Target) ([]byte, error) {
+	return exportSimulation(target, v2ApiSimulationHar)
+}
+
+func exportSimulation(target configuration.Target, path string) ([]byte, error) {
+	response, err := doRequest(target, "GET", path, "", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +50,56 @@ func ExportSimulation(target configuration.Target) ([]byte, error) {
 }
 
 func ImportSimulation(target configuration.Target, simulationData string) error {
-	response, err := doRequest(target, "PUT", v2ApiSimulation, simulationData, nil)
+	return importSimulation(target, v2ApiSimulation, simulationData)
+}
+
+// ImportSimulationHar - replaces the simulation with the entries of an HTTP Archive (HAR 1.2)
+func ImportSimulationHar(target configuration.Target, harData string) error {
+	return importSimulation(target, v2ApiSimulationHar, harData)
+}
+
+// ImportSimulationOpenApi - replaces the simulation with one generated from an OpenAPI 2 or 3 document
+func ImportSimulationOpenApi(target configuration.Target, document string) error {
+	return importSimulation(target, v2ApiSimulationOpenApi, document)
+}
+
+// ImportSimulationPostman - replaces the simulation with one converted from a Postman
+// collection, returning the constructs of the collection which could not be translated
+func ImportSimulationPostman(target configuration.Target, collection string) ([]string, error) {
+	return importConvertedSimulation(target, v2ApiSimulationPostman, collection)
+}
+
+// ImportSimulationWireMock - replaces the simulation with one converted from WireMock
+// stub mappings, returning the constructs of the mappings which could not be translated
+func ImportSimulationWireMock(target configuration.Target, mappings string) ([]string, error) {
+	return importConvertedSimulation(target, v2ApiSimulationWireMock, mappings)
+}
+
+func importConvertedSimulation(target configuration.Target, path, data string) ([]string, error) {
+	response, err := doRequest(target, "PUT", path, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	err = handleResponseError(response, "Could not import simulation")
+	if err != nil {
+		return nil, err
+	}
+
+	var importView v2.SimulationImportView
+	err = UnmarshalToInterface(response, &importView)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, errors.New("Could not import simulation")
+	}
+
+	return importView.Untranslated, nil
+}
+
+func importSimulation(target configuration.Target, path, simulationData string) error {
+	response, err := doRequest(target, "PUT", path, simulationData, nil)
 	if err != nil {
 		return err
 	}
@@ -84,6 +84,40 @@ func Test_ExportSimulation_ErrorsWhen_HoverflyReturnsNon200(t *testing.T) {
 	Expect(err.Error()).To(Equal("Could not retrieve simulation\n\ntest error"))
 }
 
+func Test_ExportSimulationHar_GetsHarFromHoverfly(t *testing.T) {
+	RegisterTestingT(t)
+
+	hoverfly.DeleteSimulation()
+	hoverfly.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{
+				v2.RequestMatcherResponsePairViewV2{
+					RequestMatcher: v2.RequestMatcherViewV2{
+						Method: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("GET"),
+						},
+						Path: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("/api/v2/simulation/har"),
+						},
+					},
+					Response: v2.ResponseDetailsView{
+						Status: 200,
+						Body:   `{"log": {"version": "1.2"}}`,
+					},
+				},
+			},
+		},
+		MetaView: v2.MetaView{
+			SchemaVersion: "v2",
+		},
+	})
+
+	simulation, err := ExportSimulationHar(target)
+	Expect(err).To(BeNil())
+
+	Expect(string(simulation)).To(Equal("{\n\t\"log\": {\n\t\t\"version\": \"1.2\"\n\t}\n}"))
+}
+
 func Test_ImportSimulation_SendsCorrectHTTPRequest(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -234,3 +268,208 @@ func Test_DeleteSimulations_ErrorsWhen_HoverflyReturnsNon200(t *testing.T) {
 	Expect(err).ToNot(BeNil())
 	Expect(err.Error()).To(Equal("Could not delete simulation\n\ntest error"))
 }
+
+func Test_ImportSimulationHar_SendsCorrectHTTPRequest(t *testing.T) {
+	RegisterTestingT(t)
+
+	hoverfly.DeleteSimulation()
+	hoverfly.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{
+				v2.RequestMatcherResponsePairViewV2{
+					RequestMatcher: v2.RequestMatcherViewV2{
+						Method: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("PUT"),
+						},
+						Path: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("/api/v2/simulation/har"),
+						},
+						Body: &v2.RequestFieldMatchersView{
+							JsonMatch: util.StringToPointer(`{"log": {"entries": []}}`),
+						},
+					},
+					Response: v2.ResponseDetailsView{
+						Status: 200,
+						Body:   `{"log": {"entries": []}}`,
+					},
+				},
+			},
+		},
+		MetaView: v2.MetaView{
+			SchemaVersion: "v2",
+		},
+	})
+
+	err := ImportSimulationHar(target, `{"log": {"entries": []}}`)
+	Expect(err).To(BeNil())
+}
+
+func Test_ImportSimulationHar_ErrorsWhen_HoverflyReturnsNon200(t *testing.T) {
+	RegisterTestingT(t)
+
+	hoverfly.DeleteSimulation()
+	hoverfly.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{
+				v2.RequestMatcherResponsePairViewV2{
+					RequestMatcher: v2.RequestMatcherViewV2{
+						Method: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("PUT"),
+						},
+						Path: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("/api/v2/simulation/har"),
+						},
+					},
+					Response: v2.ResponseDetailsView{
+						Status: 422,
+						Body:   "{\"error\":\"Invalid request URL /path\"}",
+					},
+				},
+			},
+		},
+		MetaView: v2.MetaView{
+			SchemaVersion: "v2",
+		},
+	})
+
+	err := ImportSimulationHar(target, `{"log": {"entries": []}}`)
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Could not import simulation\n\nInvalid request URL /path"))
+}
+
+func Test_ImportSimulationOpenApi_SendsCorrectHTTPRequest(t *testing.T) {
+	RegisterTestingT(t)
+
+	hoverfly.DeleteSimulation()
+	hoverfly.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{
+				v2.RequestMatcherResponsePairViewV2{
+					RequestMatcher: v2.RequestMatcherViewV2{
+						Method: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("PUT"),
+						},
+						Path: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("/api/v2/simulation/openapi"),
+						},
+						Body: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("openapi: 3.0.0\npaths: {}\n"),
+						},
+					},
+					Response: v2.ResponseDetailsView{
+						Status: 200,
+						Body:   `{"data": {"pairs": []}}`,
+					},
+				},
+			},
+		},
+		MetaView: v2.MetaView{
+			SchemaVersion: "v2",
+		},
+	})
+
+	err := ImportSimulationOpenApi(target, "openapi: 3.0.0\npaths: {}\n")
+	Expect(err).To(BeNil())
+}
+
+func Test_ImportSimulationOpenApi_ErrorsWhen_HoverflyReturnsNon200(t *testing.T) {
+	RegisterTestingT(t)
+
+	hoverfly.DeleteSimulation()
+	hoverfly.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{
+				v2.RequestMatcherResponsePairViewV2{
+					RequestMatcher: v2.RequestMatcherViewV2{
+						Method: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("PUT"),
+						},
+						Path: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("/api/v2/simulation/openapi"),
+						},
+					},
+					Response: v2.ResponseDetailsView{
+						Status: 400,
+						Body:   "{\"error\":\"Invalid OpenAPI document: only Swagger 2.0 and OpenAPI 3 documents are supported\"}",
+					},
+				},
+			},
+		},
+		MetaView: v2.MetaView{
+			SchemaVersion: "v2",
+		},
+	})
+
+	err := ImportSimulationOpenApi(target, "swagger: 1.2")
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Could not import simulation\n\nInvalid OpenAPI document: only Swagger 2.0 and OpenAPI 3 documents are supported"))
+}
+
+func Test_ImportSimulationWireMock_ReturnsUntranslatedConstructs(t *testing.T) {
+	RegisterTestingT(t)
+
+	hoverfly.DeleteSimulation()
+	hoverfly.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{
+				v2.RequestMatcherResponsePairViewV2{
+					RequestMatcher: v2.RequestMatcherViewV2{
+						Method: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("PUT"),
+						},
+						Path: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("/api/v2/simulation/wiremock"),
+						},
+						Body: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer(`{"mappings": []}`),
+						},
+					},
+					Response: v2.ResponseDetailsView{
+						Status: 200,
+						Body:   `{"simulation": {"data": {"pairs": []}}, "untranslated": ["mapping 1: response.fault is not translated"]}`,
+					},
+				},
+			},
+		},
+		MetaView: v2.MetaView{
+			SchemaVersion: "v2",
+		},
+	})
+
+	untranslated, err := ImportSimulationWireMock(target, `{"mappings": []}`)
+	Expect(err).To(BeNil())
+	Expect(untranslated).To(Equal([]string{"mapping 1: response.fault is not translated"}))
+}
+
+func Test_ImportSimulationPostman_ErrorsWhen_HoverflyReturnsNon200(t *testing.T) {
+	RegisterTestingT(t)
+
+	hoverfly.DeleteSimulation()
+	hoverfly.PutSimulation(v2.SimulationViewV2{
+		DataViewV2: v2.DataViewV2{
+			RequestResponsePairs: []v2.RequestMatcherResponsePairViewV2{
+				v2.RequestMatcherResponsePairViewV2{
+					RequestMatcher: v2.RequestMatcherViewV2{
+						Method: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("PUT"),
+						},
+						Path: &v2.RequestFieldMatchersView{
+							ExactMatch: util.StringToPointer("/api/v2/simulation/postman"),
+						},
+					},
+					Response: v2.ResponseDetailsView{
+						Status: 400,
+						Body:   "{\"error\":\"Invalid Postman collection: only v2.0 and v2.1 collections are supported\"}",
+					},
+				},
+			},
+		},
+		MetaView: v2.MetaView{
+			SchemaVersion: "v2",
+		},
+	})
+
+	_, err := ImportSimulationPostman(target, `{"info": {}}`)
+	Expect(err).ToNot(BeNil())
+	Expect(err.Error()).To(Equal("Could not import simulation\n\nInvalid Postman collection: only v2.0 and v2.1 collections are supported"))
+}